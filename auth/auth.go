@@ -0,0 +1,54 @@
+package auth
+
+import (
+	"context"
+	"slices"
+
+	"github.com/google/uuid"
+)
+
+const (
+	RoleAdmin     = "admin"
+	RoleOrganizer = "organizer"
+	RoleBoxOffice = "box_office"
+	RoleCustomer  = "customer"
+)
+
+// Principal is the authenticated caller of a request
+type Principal struct {
+	Subject uuid.UUID
+	Email   string
+	Roles   []string
+
+	// Token is the raw bearer token, kept so the identity can be forwarded
+	// to downstream services
+	Token string
+}
+
+func (p *Principal) HasRole(roles ...string) bool {
+	for _, role := range roles {
+		if slices.Contains(p.Roles, role) {
+			return true
+		}
+	}
+	return false
+}
+
+// SystemPrincipal acts for internal jobs, such as seeding, that run outside
+// of any request. It has no subject, so events it creates have no organizer.
+func SystemPrincipal() *Principal {
+	return &Principal{Roles: []string{RoleAdmin}}
+}
+
+type principalKey struct{}
+
+func WithPrincipal(ctx context.Context, principal *Principal) context.Context {
+	return context.WithValue(ctx, principalKey{}, principal)
+}
+
+// PrincipalFromContext returns the caller of the request, if it was
+// authenticated
+func PrincipalFromContext(ctx context.Context) (*Principal, bool) {
+	principal, ok := ctx.Value(principalKey{}).(*Principal)
+	return principal, ok && principal != nil
+}
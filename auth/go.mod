@@ -0,0 +1,8 @@
+module github.com/ignisrex/tix/auth
+
+go 1.25.4
+
+require (
+	github.com/golang-jwt/jwt/v5 v5.3.0
+	github.com/google/uuid v1.6.0
+)
@@ -0,0 +1,87 @@
+package auth
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strings"
+)
+
+const bearerPrefix = "Bearer "
+
+// writeError writes err in the services' JSON error shape
+func writeError(w http.ResponseWriter, status int, err error) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(map[string]string{"error": err.Error()})
+}
+
+// Authenticate verifies the bearer token, if one is sent, and stores its
+// principal in the request context. Requests without a token pass through
+// anonymously; routes that need a caller add RequireAuth or RequireRole.
+func (v *Verifier) Authenticate(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		header := r.Header.Get("Authorization")
+		if header == "" {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		if !strings.HasPrefix(header, bearerPrefix) {
+			writeError(w, http.StatusUnauthorized, errors.New("authorization header must be a bearer token"))
+			return
+		}
+
+		principal, err := v.Verify(strings.TrimPrefix(header, bearerPrefix))
+		if err != nil {
+			writeError(w, http.StatusUnauthorized, err)
+			return
+		}
+
+		next.ServeHTTP(w, r.WithContext(WithPrincipal(r.Context(), principal)))
+	})
+}
+
+// RequireAuth rejects anonymous requests
+func RequireAuth(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if _, ok := PrincipalFromContext(r.Context()); !ok {
+			writeError(w, http.StatusUnauthorized, ErrUnauthenticated)
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+// RequireRole rejects requests whose principal has none of the given roles
+func RequireRole(roles ...string) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			principal, ok := PrincipalFromContext(r.Context())
+			if !ok {
+				writeError(w, http.StatusUnauthorized, ErrUnauthenticated)
+				return
+			}
+			if !principal.HasRole(roles...) {
+				writeError(w, http.StatusForbidden, ErrForbidden)
+				return
+			}
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+// RequirePermission rejects requests whose principal lacks perm. Services
+// check permissions again, so this only saves work on routes that need the
+// permission unconditionally.
+func RequirePermission(perm Permission) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if _, err := Authorize(r.Context(), perm); err != nil {
+				writeError(w, ErrorStatus(err, http.StatusForbidden), err)
+				return
+			}
+			next.ServeHTTP(w, r)
+		})
+	}
+}
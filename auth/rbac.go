@@ -0,0 +1,109 @@
+package auth
+
+import (
+	"context"
+	"errors"
+	"net/http"
+
+	"github.com/google/uuid"
+)
+
+type Permission string
+
+const (
+	PermManageVenues Permission = "venues:manage"
+
+	// PermManageOwnEvents allows managing events the principal organizes;
+	// PermManageAllEvents allows managing any event
+	PermManageOwnEvents Permission = "events:manage_own"
+	PermManageAllEvents Permission = "events:manage_all"
+
+	// PermSellTickets allows buying tickets on behalf of a customer;
+	// PermCompTickets allows issuing them free of charge
+	PermSellTickets Permission = "tickets:sell"
+	PermCompTickets Permission = "tickets:comp"
+
+	PermRefundPurchases  Permission = "purchases:refund"
+	PermViewAllPurchases Permission = "purchases:view_all"
+	PermManageCustomers  Permission = "customers:manage"
+)
+
+var rolePermissions = map[string][]Permission{
+	RoleAdmin: {
+		PermManageVenues,
+		PermManageOwnEvents,
+		PermManageAllEvents,
+		PermSellTickets,
+		PermCompTickets,
+		PermRefundPurchases,
+		PermViewAllPurchases,
+		PermManageCustomers,
+	},
+	RoleOrganizer: {
+		PermManageOwnEvents,
+	},
+	RoleBoxOffice: {
+		PermSellTickets,
+		PermCompTickets,
+		PermViewAllPurchases,
+		PermManageCustomers,
+	},
+	// Customers have no permissions beyond their own purchases and account,
+	// which are checked by ownership
+	RoleCustomer: {},
+}
+
+var (
+	ErrUnauthenticated = errors.New("authentication required")
+	ErrForbidden       = errors.New("insufficient permissions")
+)
+
+// Can reports whether any of the principal's roles grants perm
+func (p *Principal) Can(perm Permission) bool {
+	for _, role := range p.Roles {
+		for _, granted := range rolePermissions[role] {
+			if granted == perm {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// Authorize returns the caller if they hold perm, or ErrUnauthenticated or
+// ErrForbidden otherwise
+func Authorize(ctx context.Context, perm Permission) (*Principal, error) {
+	principal, ok := PrincipalFromContext(ctx)
+	if !ok {
+		return nil, ErrUnauthenticated
+	}
+	if !principal.Can(perm) {
+		return nil, ErrForbidden
+	}
+	return principal, nil
+}
+
+// AuthorizeOwner allows the caller if they are ownerID, or if they hold perm
+// and may therefore act on anyone's behalf
+func AuthorizeOwner(ctx context.Context, ownerID uuid.UUID, perm Permission) (*Principal, error) {
+	principal, ok := PrincipalFromContext(ctx)
+	if !ok {
+		return nil, ErrUnauthenticated
+	}
+	if principal.Subject != ownerID && !principal.Can(perm) {
+		return nil, ErrForbidden
+	}
+	return principal, nil
+}
+
+// ErrorStatus maps authorization errors to their HTTP status, falling back to
+// fallback for any other error
+func ErrorStatus(err error, fallback int) int {
+	switch {
+	case errors.Is(err, ErrUnauthenticated):
+		return http.StatusUnauthorized
+	case errors.Is(err, ErrForbidden):
+		return http.StatusForbidden
+	}
+	return fallback
+}
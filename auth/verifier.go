@@ -0,0 +1,128 @@
+package auth
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/google/uuid"
+)
+
+const (
+	AlgorithmHS256 = "HS256"
+	AlgorithmRS256 = "RS256"
+)
+
+// DevSecret is the placeholder HS256 secret of the example configuration.
+// Anyone can mint tokens with it, so NewVerifier refuses it unless
+// AllowDevSecret is set.
+const DevSecret = "dev-secret-change-me"
+
+var (
+	ErrInvalidToken = errors.New("invalid token")
+	ErrDevSecret    = errors.New("jwt secret is the development placeholder")
+)
+
+// KeyConfig describes how tokens are signed. HS256 uses a shared secret,
+// given inline or in a file; RS256 uses the issuer's PEM encoded public key.
+// Issuer and Audience are only checked when set. AllowDevSecret permits
+// DevSecret and should only be set in development.
+type KeyConfig struct {
+	Algorithm      string
+	Secret         string
+	SecretFile     string
+	PublicKeyFile  string
+	Issuer         string
+	Audience       string
+	AllowDevSecret bool
+}
+
+type Claims struct {
+	jwt.RegisteredClaims
+	Email string   `json:"email,omitempty"`
+	Roles []string `json:"roles,omitempty"`
+}
+
+// Verifier validates bearer tokens locally, without calling the issuer
+type Verifier struct {
+	key    any
+	parser *jwt.Parser
+}
+
+func NewVerifier(cfg KeyConfig) (*Verifier, error) {
+	var key any
+	switch cfg.Algorithm {
+	case AlgorithmHS256:
+		secret := cfg.Secret
+		if cfg.SecretFile != "" {
+			data, err := os.ReadFile(cfg.SecretFile)
+			if err != nil {
+				return nil, fmt.Errorf("failed to read jwt secret file: %w", err)
+			}
+			secret = strings.TrimSpace(string(data))
+		}
+		if secret == "" {
+			return nil, errors.New("jwt secret is not configured")
+		}
+		if secret == DevSecret && !cfg.AllowDevSecret {
+			return nil, fmt.Errorf("%w: set JWT_SECRET, or APP_ENV=development to use it locally", ErrDevSecret)
+		}
+		key = []byte(secret)
+	case AlgorithmRS256:
+		if cfg.PublicKeyFile == "" {
+			return nil, errors.New("jwt public key file is not configured")
+		}
+		data, err := os.ReadFile(cfg.PublicKeyFile)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read jwt public key file: %w", err)
+		}
+		publicKey, err := jwt.ParseRSAPublicKeyFromPEM(data)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse jwt public key: %w", err)
+		}
+		key = publicKey
+	default:
+		return nil, fmt.Errorf("unsupported jwt algorithm %q", cfg.Algorithm)
+	}
+
+	opts := []jwt.ParserOption{
+		jwt.WithValidMethods([]string{cfg.Algorithm}),
+		jwt.WithExpirationRequired(),
+	}
+	if cfg.Issuer != "" {
+		opts = append(opts, jwt.WithIssuer(cfg.Issuer))
+	}
+	if cfg.Audience != "" {
+		opts = append(opts, jwt.WithAudience(cfg.Audience))
+	}
+
+	return &Verifier{
+		key:    key,
+		parser: jwt.NewParser(opts...),
+	}, nil
+}
+
+// Verify checks the token's signature and claims and returns its principal.
+// The subject must be a UUID.
+func (v *Verifier) Verify(token string) (*Principal, error) {
+	var claims Claims
+	if _, err := v.parser.ParseWithClaims(token, &claims, func(*jwt.Token) (any, error) {
+		return v.key, nil
+	}); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidToken, err)
+	}
+
+	subject, err := uuid.Parse(claims.Subject)
+	if err != nil {
+		return nil, fmt.Errorf("%w: subject is not a valid id", ErrInvalidToken)
+	}
+
+	return &Principal{
+		Subject: subject,
+		Email:   claims.Email,
+		Roles:   claims.Roles,
+		Token:   token,
+	}, nil
+}
@@ -0,0 +1,68 @@
+package auth
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/google/uuid"
+)
+
+func TestNewVerifierRejectsDevSecret(t *testing.T) {
+	_, err := NewVerifier(KeyConfig{Algorithm: AlgorithmHS256, Secret: DevSecret})
+	if !errors.Is(err, ErrDevSecret) {
+		t.Fatalf("err = %v, want ErrDevSecret", err)
+	}
+
+	if _, err := NewVerifier(KeyConfig{Algorithm: AlgorithmHS256, Secret: DevSecret, AllowDevSecret: true}); err != nil {
+		t.Fatalf("development: %v", err)
+	}
+}
+
+func TestVerify(t *testing.T) {
+	const secret = "test-secret"
+	verifier, err := NewVerifier(KeyConfig{Algorithm: AlgorithmHS256, Secret: secret})
+	if err != nil {
+		t.Fatalf("NewVerifier: %v", err)
+	}
+
+	subject := uuid.New()
+	sign := func(key string, claims Claims) string {
+		token, err := jwt.NewWithClaims(jwt.SigningMethodHS256, claims).SignedString([]byte(key))
+		if err != nil {
+			t.Fatalf("failed to sign token: %v", err)
+		}
+		return token
+	}
+	valid := Claims{
+		RegisteredClaims: jwt.RegisteredClaims{
+			Subject:   subject.String(),
+			ExpiresAt: jwt.NewNumericDate(time.Now().Add(time.Hour)),
+		},
+		Roles: []string{RoleCustomer},
+	}
+
+	principal, err := verifier.Verify(sign(secret, valid))
+	if err != nil {
+		t.Fatalf("Verify: %v", err)
+	}
+	if principal.Subject != subject || !principal.HasRole(RoleCustomer) {
+		t.Errorf("principal = %+v, want customer %s", principal, subject)
+	}
+
+	expired := valid
+	expired.ExpiresAt = jwt.NewNumericDate(time.Now().Add(-time.Minute))
+	noSubject := valid
+	noSubject.Subject = "alice"
+
+	for name, token := range map[string]string{
+		"wrong key":   sign("other-secret", valid),
+		"expired":     sign(secret, expired),
+		"bad subject": sign(secret, noSubject),
+	} {
+		if _, err := verifier.Verify(token); !errors.Is(err, ErrInvalidToken) {
+			t.Errorf("%s: err = %v, want ErrInvalidToken", name, err)
+		}
+	}
+}
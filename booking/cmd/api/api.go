@@ -1,15 +1,20 @@
 package api
 
 import (
+	"context"
 	"database/sql"
 	"net/http"
+	"time"
 
 	"github.com/go-chi/chi/v5"
 	"github.com/go-chi/chi/v5/middleware"
 	"github.com/go-chi/cors"
 
+	"github.com/ignisrex/tix/auth"
 	"github.com/ignisrex/tix/booking/internal/config"
 	"github.com/ignisrex/tix/booking/internal/database"
+	"github.com/ignisrex/tix/booking/internal/payment"
+	"github.com/ignisrex/tix/booking/internal/queue"
 	"github.com/ignisrex/tix/booking/internal/redis"
 	"github.com/ignisrex/tix/booking/service/booking"
 )
@@ -19,15 +24,21 @@ type APIServer struct {
 	db *sql.DB
 	queries *database.Queries
 	redisClient *redis.Client
+	paymentProvider payment.PaymentProvider
+	verifier *auth.Verifier
+	queueTokens *queue.Signer
 }
 
-func NewAPIServer(addr string, db *sql.DB, redisClient *redis.Client) *APIServer {
+func NewAPIServer(addr string, db *sql.DB, redisClient *redis.Client, paymentProvider payment.PaymentProvider, verifier *auth.Verifier, queueTokens *queue.Signer) *APIServer {
 	queries := database.New(db)
 	return &APIServer{
 		addr:    addr,
 		db: db,
 		queries: queries,
 		redisClient: redisClient,
+		paymentProvider: paymentProvider,
+		verifier: verifier,
+		queueTokens: queueTokens,
 	}
 }
 
@@ -37,20 +48,33 @@ func (s *APIServer) Run() error {
 	r.Use(cors.Handler(cors.Options{
 		AllowedOrigins:   []string{"https://*", "http://*"},
 		AllowedMethods:   []string{"GET", "POST", "PUT", "DELETE", "OPTIONS"},
-		AllowedHeaders:   []string{"Accept", "Authorization", "Content-Type", "X-CSRF-Token"},
-		ExposedHeaders:   []string{"Link"},
+		AllowedHeaders:   []string{"Accept", "Authorization", "Content-Type", "Idempotency-Key", "X-Admission-Token", "X-CSRF-Token", "X-Queue-Token"},
+		ExposedHeaders:   []string{"Idempotent-Replayed", "Link"},
 		AllowCredentials: false,
 		MaxAge:           300,
 	}))
 
+	// Booking is only reachable through core, which passes on the client's
+	// address in X-Real-IP; the waiting room limits joins per client on it
+	r.Use(middleware.RealIP)
 	r.Use(middleware.Logger)
+	r.Use(s.verifier.Authenticate)
 
 	r.Get("/", func(w http.ResponseWriter, r *http.Request) {
 		w.Write([]byte("booking service"))
 	})
 
+	// Settle payments left between authorization and capture, including any
+	// abandoned by a previous run of this service
+	reconciler := booking.NewService(booking.NewRepo(s.db, s.queries), s.redisClient, s.paymentProvider, s.queueTokens)
+	go reconciler.RunPaymentReconciler(context.Background(), time.Minute)
+
+	// Admit waiting room visitors in batches; each waiting room keeps its
+	// own interval, shared by all booking instances
+	go reconciler.RunQueueAdmitter(context.Background(), time.Second)
+
 	v1 := chi.NewRouter()
-	bookingHandler := booking.NewHandler(s.queries, s.redisClient)
+	bookingHandler := booking.NewHandler(s.db, s.queries, s.redisClient, s.paymentProvider, s.queueTokens)
 	bookingHandler.RegisterRoutes(v1)
 	r.Mount("/api/v1", v1)
 
@@ -5,8 +5,11 @@ import (
 	"log"
 	
 
+	"github.com/ignisrex/tix/auth"
 	"github.com/ignisrex/tix/booking/cmd/api"
 	"github.com/ignisrex/tix/booking/internal/config"
+	"github.com/ignisrex/tix/booking/internal/payment"
+	"github.com/ignisrex/tix/booking/internal/queue"
 	"github.com/ignisrex/tix/booking/internal/redis"
 	_ "github.com/lib/pq"
 )
@@ -28,7 +31,41 @@ func main() {
 	}
 	log.Printf("Successfully connected to Redis at %s", redisAddr)
 
-	server := api.NewAPIServer(addr, db, redisClient)
+	paymentProvider, err := payment.NewProvider(config.Envs.PaymentProvider)
+	if err != nil {
+		log.Fatal("failed to create payment provider: ", err)
+	}
+	log.Printf("Using %s payment provider", config.Envs.PaymentProvider)
+
+	verifier, err := auth.NewVerifier(auth.KeyConfig{
+		Algorithm:      config.Envs.JWTAlgorithm,
+		Secret:         config.Envs.JWTSecret,
+		SecretFile:     config.Envs.JWTSecretFile,
+		PublicKeyFile:  config.Envs.JWTPublicKeyFile,
+		Issuer:         config.Envs.JWTIssuer,
+		Audience:       config.Envs.JWTAudience,
+		AllowDevSecret: config.Envs.AppEnv == "development",
+	})
+	if err != nil {
+		log.Fatal("failed to create jwt verifier: ", err)
+	}
+	log.Printf("Verifying %s tokens", config.Envs.JWTAlgorithm)
+
+	// Waiting room tokens fall back to the jwt secret so instances that
+	// already share it agree on them too
+	queueSecret := config.Envs.QueueTokenSecret
+	if queueSecret == "" && config.Envs.JWTAlgorithm == auth.AlgorithmHS256 {
+		queueSecret = config.Envs.JWTSecret
+	}
+	if queueSecret == "" {
+		log.Printf("QUEUE_TOKEN_SECRET is not set; waiting room tokens only work with a single booking instance")
+	}
+	queueTokens, err := queue.NewSigner(queueSecret)
+	if err != nil {
+		log.Fatal("failed to create queue token signer: ", err)
+	}
+
+	server := api.NewAPIServer(addr, db, redisClient, paymentProvider, verifier, queueTokens)
 	if err := server.Run(); err != nil {
 		log.Fatal("booking service failed: ", err)
 	}
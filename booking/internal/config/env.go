@@ -20,6 +20,27 @@ type Config struct {
 	RedisPort string
 
 	ReservationTTLSeconds int
+	MaxHoldExtensions     int
+	BestAvailableAttempts int
+
+	QueueTokenSecret      string
+	QueueBatchSize        int
+	QueueIntervalSeconds  int
+	QueueAdmissionSeconds int
+	QueueJoinLimit        int
+
+	PaymentProvider string
+
+	// AppEnv is "development" for local setups, which may use the
+	// placeholder JWT secret
+	AppEnv string
+
+	JWTAlgorithm     string
+	JWTSecret        string
+	JWTSecretFile    string
+	JWTPublicKeyFile string
+	JWTIssuer        string
+	JWTAudience      string
 }
 
 var Envs Config = initConfig()
@@ -37,6 +58,21 @@ func initConfig() Config {
 		RedisHost: getEnv("REDIS_HOST", "ticket-lock"),
 		RedisPort: getEnv("REDIS_PORT", "6379"),
 		ReservationTTLSeconds: getEnvInt("RESERVATION_TTL_SECONDS", 180),
+		MaxHoldExtensions:     getEnvInt("MAX_HOLD_EXTENSIONS", 2),
+		BestAvailableAttempts: getEnvInt("BEST_AVAILABLE_ATTEMPTS", 5),
+		QueueTokenSecret:      getEnv("QUEUE_TOKEN_SECRET", ""),
+		QueueBatchSize:        getEnvInt("QUEUE_BATCH_SIZE", 100),
+		QueueIntervalSeconds:  getEnvInt("QUEUE_INTERVAL_SECONDS", 10),
+		QueueAdmissionSeconds: getEnvInt("QUEUE_ADMISSION_SECONDS", 600),
+		QueueJoinLimit:        getEnvInt("QUEUE_JOIN_LIMIT", 10),
+		PaymentProvider:       getEnv("PAYMENT_PROVIDER", "mock"),
+		AppEnv:           getEnv("APP_ENV", "production"),
+		JWTAlgorithm:     getEnv("JWT_ALGORITHM", "HS256"),
+		JWTSecret:        getEnv("JWT_SECRET", ""),
+		JWTSecretFile:    getEnv("JWT_SECRET_FILE", ""),
+		JWTPublicKeyFile: getEnv("JWT_PUBLIC_KEY_FILE", ""),
+		JWTIssuer:        getEnv("JWT_ISSUER", ""),
+		JWTAudience:      getEnv("JWT_AUDIENCE", ""),
 	}
 }
 
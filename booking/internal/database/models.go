@@ -5,6 +5,7 @@
 package database
 
 import (
+	"database/sql"
 	"database/sql/driver"
 	"fmt"
 	"time"
@@ -12,11 +13,146 @@ import (
 	"github.com/google/uuid"
 )
 
+type PaymentAttemptStatus string
+
+const (
+	PaymentAttemptStatusPending    PaymentAttemptStatus = "pending"
+	PaymentAttemptStatusAuthorized PaymentAttemptStatus = "authorized"
+	PaymentAttemptStatusCaptured   PaymentAttemptStatus = "captured"
+	PaymentAttemptStatusVoided     PaymentAttemptStatus = "voided"
+	PaymentAttemptStatusFailed     PaymentAttemptStatus = "failed"
+)
+
+func (e *PaymentAttemptStatus) Scan(src interface{}) error {
+	switch s := src.(type) {
+	case []byte:
+		*e = PaymentAttemptStatus(s)
+	case string:
+		*e = PaymentAttemptStatus(s)
+	default:
+		return fmt.Errorf("unsupported scan type for PaymentAttemptStatus: %T", src)
+	}
+	return nil
+}
+
+type NullPaymentAttemptStatus struct {
+	PaymentAttemptStatus PaymentAttemptStatus
+	Valid                bool // Valid is true if PaymentAttemptStatus is not NULL
+}
+
+// Scan implements the Scanner interface.
+func (ns *NullPaymentAttemptStatus) Scan(value interface{}) error {
+	if value == nil {
+		ns.PaymentAttemptStatus, ns.Valid = "", false
+		return nil
+	}
+	ns.Valid = true
+	return ns.PaymentAttemptStatus.Scan(value)
+}
+
+// Value implements the driver Valuer interface.
+func (ns NullPaymentAttemptStatus) Value() (driver.Value, error) {
+	if !ns.Valid {
+		return nil, nil
+	}
+	return string(ns.PaymentAttemptStatus), nil
+}
+
+type PurchaseStatus string
+
+const (
+	PurchaseStatusPendingPayment    PurchaseStatus = "pending_payment"
+	PurchaseStatusPaid              PurchaseStatus = "paid"
+	PurchaseStatusFailed            PurchaseStatus = "failed"
+	PurchaseStatusRefunded          PurchaseStatus = "refunded"
+	PurchaseStatusPartiallyRefunded PurchaseStatus = "partially_refunded"
+	PurchaseStatusCancelled         PurchaseStatus = "cancelled"
+)
+
+func (e *PurchaseStatus) Scan(src interface{}) error {
+	switch s := src.(type) {
+	case []byte:
+		*e = PurchaseStatus(s)
+	case string:
+		*e = PurchaseStatus(s)
+	default:
+		return fmt.Errorf("unsupported scan type for PurchaseStatus: %T", src)
+	}
+	return nil
+}
+
+type NullPurchaseStatus struct {
+	PurchaseStatus PurchaseStatus
+	Valid          bool // Valid is true if PurchaseStatus is not NULL
+}
+
+// Scan implements the Scanner interface.
+func (ns *NullPurchaseStatus) Scan(value interface{}) error {
+	if value == nil {
+		ns.PurchaseStatus, ns.Valid = "", false
+		return nil
+	}
+	ns.Valid = true
+	return ns.PurchaseStatus.Scan(value)
+}
+
+// Value implements the driver Valuer interface.
+func (ns NullPurchaseStatus) Value() (driver.Value, error) {
+	if !ns.Valid {
+		return nil, nil
+	}
+	return string(ns.PurchaseStatus), nil
+}
+
+type RefundStatus string
+
+const (
+	RefundStatusPending   RefundStatus = "pending"
+	RefundStatusSucceeded RefundStatus = "succeeded"
+	RefundStatusFailed    RefundStatus = "failed"
+)
+
+func (e *RefundStatus) Scan(src interface{}) error {
+	switch s := src.(type) {
+	case []byte:
+		*e = RefundStatus(s)
+	case string:
+		*e = RefundStatus(s)
+	default:
+		return fmt.Errorf("unsupported scan type for RefundStatus: %T", src)
+	}
+	return nil
+}
+
+type NullRefundStatus struct {
+	RefundStatus RefundStatus
+	Valid        bool // Valid is true if RefundStatus is not NULL
+}
+
+// Scan implements the Scanner interface.
+func (ns *NullRefundStatus) Scan(value interface{}) error {
+	if value == nil {
+		ns.RefundStatus, ns.Valid = "", false
+		return nil
+	}
+	ns.Valid = true
+	return ns.RefundStatus.Scan(value)
+}
+
+// Value implements the driver Valuer interface.
+func (ns NullRefundStatus) Value() (driver.Value, error) {
+	if !ns.Valid {
+		return nil, nil
+	}
+	return string(ns.RefundStatus), nil
+}
+
 type TicketStatus string
 
 const (
 	TicketStatusAvailable TicketStatus = "available"
 	TicketStatusSold      TicketStatus = "sold"
+	TicketStatusRefunded  TicketStatus = "refunded"
 )
 
 func (e *TicketStatus) Scan(src interface{}) error {
@@ -54,6 +190,14 @@ func (ns NullTicketStatus) Value() (driver.Value, error) {
 	return string(ns.TicketStatus), nil
 }
 
+type Customer struct {
+	ID        uuid.UUID
+	Email     string
+	Name      string
+	CreatedAt time.Time
+	UpdatedAt time.Time
+}
+
 type EnrichedTicket struct {
 	ID                    uuid.UUID
 	EventID               uuid.UUID
@@ -64,6 +208,11 @@ type EnrichedTicket struct {
 	TicketTypeName        string
 	TicketTypeDisplayName string
 	TicketTypePriceCents  int32
+	SeatID                uuid.NullUUID
+	SectionName           sql.NullString
+	RowLabel              sql.NullString
+	SeatNumber            sql.NullInt32
+	SeatAccessible        sql.NullBool
 }
 
 type Event struct {
@@ -74,6 +223,29 @@ type Event struct {
 	VenueID     uuid.UUID
 	CreatedAt   time.Time
 	UpdatedAt   time.Time
+	OrganizerID uuid.NullUUID
+}
+
+type IdempotencyKey struct {
+	Key            string
+	RequestPath    string
+	RequestHash    string
+	ResponseStatus sql.NullInt32
+	ResponseBody   []byte
+	CreatedAt      time.Time
+	UpdatedAt      time.Time
+}
+
+type PaymentAttempt struct {
+	ID              uuid.UUID
+	HoldID          uuid.UUID
+	AmountCents     int32
+	Status          PaymentAttemptStatus
+	AuthorizationID sql.NullString
+	PurchaseID      uuid.NullUUID
+	FailureReason   sql.NullString
+	CreatedAt       time.Time
+	UpdatedAt       time.Time
 }
 
 type Purchase struct {
@@ -81,6 +253,36 @@ type Purchase struct {
 	TotalCents int32
 	CreatedAt  time.Time
 	UpdatedAt  time.Time
+	Status     PurchaseStatus
+	CustomerID uuid.NullUUID
+}
+
+type PurchaseStatusTransition struct {
+	ID         uuid.UUID
+	PurchaseID uuid.UUID
+	FromStatus NullPurchaseStatus
+	ToStatus   PurchaseStatus
+	Reason     sql.NullString
+	CreatedAt  time.Time
+}
+
+type Refund struct {
+	ID                  uuid.UUID
+	PurchaseID          uuid.UUID
+	AmountCents         int32
+	ReturnedToInventory bool
+	ProviderRefundID    sql.NullString
+	Reason              sql.NullString
+	CreatedAt           time.Time
+	Status              RefundStatus
+	FailureReason       sql.NullString
+	UpdatedAt           time.Time
+}
+
+type RefundTicket struct {
+	RefundID    uuid.UUID
+	TicketID    uuid.UUID
+	AmountCents int32
 }
 
 type Ticket struct {
@@ -91,6 +293,7 @@ type Ticket struct {
 	CreatedAt    time.Time
 	UpdatedAt    time.Time
 	PurchaseID   uuid.NullUUID
+	SeatID       uuid.NullUUID
 }
 
 type TicketType struct {
@@ -98,6 +301,11 @@ type TicketType struct {
 	Name        string
 	DisplayName string
 	PriceCents  int32
+	EventID     uuid.UUID
+	Quantity    int32
+	MaxPerOrder sql.NullInt32
+	CreatedAt   time.Time
+	UpdatedAt   time.Time
 }
 
 type Venue struct {
@@ -105,3 +313,22 @@ type Venue struct {
 	Name     string
 	Location string
 }
+
+type VenueSeat struct {
+	ID         uuid.UUID
+	SectionID  uuid.UUID
+	RowLabel   string
+	RowIndex   int32
+	SeatNumber int32
+	X          float64
+	Y          float64
+	Accessible bool
+	Companion  bool
+}
+
+type VenueSection struct {
+	ID       uuid.UUID
+	VenueID  uuid.UUID
+	Name     string
+	Position int32
+}
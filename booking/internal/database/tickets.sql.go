@@ -7,6 +7,7 @@ package database
 
 import (
 	"context"
+	"database/sql"
 	"encoding/json"
 	"time"
 
@@ -18,63 +19,103 @@ const getPurchaseDetails = `-- name: GetPurchaseDetails :one
 SELECT 
     p.id as purchase_id,
     p.total_cents,
+    p.status,
+    p.customer_id,
     p.created_at as purchase_created_at,
-    json_agg(
+    COALESCE(json_agg(
         json_build_object(
             'id', t.id,
             'event_id', t.event_id,
             'ticket_type_id', t.ticket_type_id,
-            'status', t.status,
+            'status', CASE WHEN t.purchase_id = p.id THEN t.status ELSE 'refunded' END,
             'ticket_type_name', tt.name,
             'ticket_type_display_name', tt.display_name,
             'ticket_type_price_cents', tt.price_cents
         )
-    ) as tickets
+    ) FILTER (WHERE t.id IS NOT NULL), '[]') as tickets
 FROM purchases p
-JOIN tickets t ON t.purchase_id = p.id
-JOIN ticket_types tt ON t.ticket_type_id = tt.id
+LEFT JOIN tickets t ON t.purchase_id = p.id OR t.id IN (
+    SELECT rt.ticket_id FROM refund_tickets rt
+    JOIN refunds r ON r.id = rt.refund_id
+    WHERE r.purchase_id = p.id AND r.status = 'succeeded' AND r.returned_to_inventory
+)
+LEFT JOIN ticket_types tt ON t.ticket_type_id = tt.id
 WHERE p.id = $1
-GROUP BY p.id, p.total_cents, p.created_at
+GROUP BY p.id, p.total_cents, p.status, p.customer_id, p.created_at
 `
 
 type GetPurchaseDetailsRow struct {
 	PurchaseID        uuid.UUID
 	TotalCents        int32
+	Status            PurchaseStatus
+	CustomerID        uuid.NullUUID
 	PurchaseCreatedAt time.Time
 	Tickets           json.RawMessage
 }
 
 // need to test this query performance; can converted to view?
+// Tickets returned to inventory by a refund no longer point at the purchase,
+// so they are found through the refund and listed as refunded.
 func (q *Queries) GetPurchaseDetails(ctx context.Context, id uuid.UUID) (GetPurchaseDetailsRow, error) {
 	row := q.db.QueryRowContext(ctx, getPurchaseDetails, id)
 	var i GetPurchaseDetailsRow
 	err := row.Scan(
 		&i.PurchaseID,
 		&i.TotalCents,
+		&i.Status,
+		&i.CustomerID,
 		&i.PurchaseCreatedAt,
 		&i.Tickets,
 	)
 	return i, err
 }
 
+const getTicketTypeForEvent = `-- name: GetTicketTypeForEvent :one
+SELECT id, name, max_per_order
+FROM ticket_types
+WHERE event_id = $1 AND id = $2
+`
+
+type GetTicketTypeForEventParams struct {
+	EventID uuid.UUID
+	ID      uuid.UUID
+}
+
+type GetTicketTypeForEventRow struct {
+	ID          uuid.UUID
+	Name        string
+	MaxPerOrder sql.NullInt32
+}
+
+func (q *Queries) GetTicketTypeForEvent(ctx context.Context, arg GetTicketTypeForEventParams) (GetTicketTypeForEventRow, error) {
+	row := q.db.QueryRowContext(ctx, getTicketTypeForEvent, arg.EventID, arg.ID)
+	var i GetTicketTypeForEventRow
+	err := row.Scan(&i.ID, &i.Name, &i.MaxPerOrder)
+	return i, err
+}
+
 const getTicketsWithPrice = `-- name: GetTicketsWithPrice :many
 SELECT 
     t.id,
     t.event_id,
     t.ticket_type_id,
     t.status,
-    tt.price_cents
+    tt.price_cents,
+    tt.name AS ticket_type_name,
+    tt.max_per_order
 FROM tickets t
 JOIN ticket_types tt ON t.ticket_type_id = tt.id
 WHERE t.id = ANY($1::uuid[])
 `
 
 type GetTicketsWithPriceRow struct {
-	ID           uuid.UUID
-	EventID      uuid.UUID
-	TicketTypeID uuid.UUID
-	Status       TicketStatus
-	PriceCents   int32
+	ID             uuid.UUID
+	EventID        uuid.UUID
+	TicketTypeID   uuid.UUID
+	Status         TicketStatus
+	PriceCents     int32
+	TicketTypeName string
+	MaxPerOrder    sql.NullInt32
 }
 
 func (q *Queries) GetTicketsWithPrice(ctx context.Context, dollar_1 []uuid.UUID) ([]GetTicketsWithPriceRow, error) {
@@ -92,6 +133,8 @@ func (q *Queries) GetTicketsWithPrice(ctx context.Context, dollar_1 []uuid.UUID)
 			&i.TicketTypeID,
 			&i.Status,
 			&i.PriceCents,
+			&i.TicketTypeName,
+			&i.MaxPerOrder,
 		); err != nil {
 			return nil, err
 		}
@@ -106,30 +149,82 @@ func (q *Queries) GetTicketsWithPrice(ctx context.Context, dollar_1 []uuid.UUID)
 	return items, nil
 }
 
-const purchaseTickets = `-- name: PurchaseTickets :one
-WITH purchase_insert AS (
-    INSERT INTO purchases (total_cents)
-    VALUES ($1)
-    RETURNING id
-),
-updated_tickets AS (
+const listAvailableTicketsForType = `-- name: ListAvailableTicketsForType :many
+SELECT
+    t.id,
+    s.section_id,
+    s.row_index,
+    s.seat_number
+FROM tickets t
+LEFT JOIN venue_seats s ON t.seat_id = s.id
+LEFT JOIN venue_sections vs ON s.section_id = vs.id
+WHERE t.event_id = $1 AND t.ticket_type_id = $2 AND t.status = 'available'
+ORDER BY vs.position, s.row_index, s.seat_number, t.created_at, t.id
+`
+
+type ListAvailableTicketsForTypeParams struct {
+	EventID      uuid.UUID
+	TicketTypeID uuid.UUID
+}
+
+type ListAvailableTicketsForTypeRow struct {
+	ID         uuid.UUID
+	SectionID  uuid.NullUUID
+	RowIndex   sql.NullInt32
+	SeatNumber sql.NullInt32
+}
+
+// Seated tickets come first in seat map order (section, then row front to
+// back, then seat number) so adjacent seats are next to each other.
+func (q *Queries) ListAvailableTicketsForType(ctx context.Context, arg ListAvailableTicketsForTypeParams) ([]ListAvailableTicketsForTypeRow, error) {
+	rows, err := q.db.QueryContext(ctx, listAvailableTicketsForType, arg.EventID, arg.TicketTypeID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []ListAvailableTicketsForTypeRow
+	for rows.Next() {
+		var i ListAvailableTicketsForTypeRow
+		if err := rows.Scan(
+			&i.ID,
+			&i.SectionID,
+			&i.RowIndex,
+			&i.SeatNumber,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const sellTickets = `-- name: SellTickets :one
+WITH updated_tickets AS (
     UPDATE tickets
-    SET status = 'sold', purchase_id = (SELECT id FROM purchase_insert)
+    SET status = 'sold', purchase_id = $1
     WHERE id = ANY($2::uuid[]) AND status = 'available'
-    RETURNING purchase_id
+    RETURNING id
 )
-SELECT id FROM purchase_insert
+SELECT ARRAY(SELECT id FROM updated_tickets)::uuid[] AS sold_ticket_ids
 `
 
-type PurchaseTicketsParams struct {
-	TotalCents int32
+type SellTicketsParams struct {
+	PurchaseID uuid.NullUUID
 	Column2    []uuid.UUID
 }
 
-// This query creates a purchase record and updates all tickets atomically
-func (q *Queries) PurchaseTickets(ctx context.Context, arg PurchaseTicketsParams) (uuid.UUID, error) {
-	row := q.db.QueryRowContext(ctx, purchaseTickets, arg.TotalCents, pq.Array(arg.Column2))
-	var id uuid.UUID
-	err := row.Scan(&id)
-	return id, err
+// This query assigns all tickets to the purchase and marks them sold.
+// Tickets that are no longer available are skipped, so callers must compare
+// sold_ticket_ids with the requested IDs and roll back on a mismatch.
+func (q *Queries) SellTickets(ctx context.Context, arg SellTicketsParams) ([]uuid.UUID, error) {
+	row := q.db.QueryRowContext(ctx, sellTickets, arg.PurchaseID, pq.Array(arg.Column2))
+	var sold_ticket_ids []uuid.UUID
+	err := row.Scan(pq.Array(&sold_ticket_ids))
+	return sold_ticket_ids, err
 }
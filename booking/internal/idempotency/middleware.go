@@ -0,0 +1,188 @@
+package idempotency
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"database/sql"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/go-chi/chi/v5/middleware"
+
+	"github.com/ignisrex/tix/booking/internal/database"
+	"github.com/ignisrex/tix/booking/internal/utils"
+)
+
+const (
+	HeaderKey = "Idempotency-Key"
+
+	// ReplayedHeader is set on responses served from a stored result.
+	ReplayedHeader = "Idempotent-Replayed"
+
+	maxKeyLength = 255
+
+	// A request that has not recorded a response after this long is assumed
+	// to have died with its process and may be retried.
+	staleAfter = 2 * time.Minute
+
+	// retryAfterSeconds is how long a client should wait before resending a
+	// request whose key is still in progress
+	retryAfterSeconds = 1
+)
+
+var (
+	ErrKeyReused  = errors.New(HeaderKey + " was already used with a different request body")
+	ErrInProgress = errors.New("a request with this " + HeaderKey + " is still being processed")
+)
+
+type Store struct {
+	queries *database.Queries
+}
+
+func NewStore(queries *database.Queries) *Store {
+	return &Store{queries: queries}
+}
+
+// Middleware makes a handler safe to retry. The first response for an
+// Idempotency-Key is stored and replayed for later requests carrying the same
+// key and body; reusing a key with a different body is rejected with 422.
+// Requests without the header pass through unchanged.
+// Server errors are not stored so that the request can be retried.
+func (s *Store) Middleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		key := r.Header.Get(HeaderKey)
+		if key == "" {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		if len(key) > maxKeyLength {
+			utils.WriteError(w, http.StatusBadRequest, fmt.Errorf("%s must be at most %d characters", HeaderKey, maxKeyLength))
+			return
+		}
+
+		body, err := io.ReadAll(r.Body)
+		if err != nil {
+			utils.WriteError(w, http.StatusBadRequest, fmt.Errorf("failed to read request body: %w", err))
+			return
+		}
+		r.Body = io.NopCloser(bytes.NewReader(body))
+
+		hash := sha256.Sum256(body)
+		requestHash := hex.EncodeToString(hash[:])
+		ctx := r.Context()
+
+		stored, err := s.acquire(ctx, key, r.URL.Path, requestHash)
+		switch {
+		case errors.Is(err, ErrKeyReused):
+			utils.WriteError(w, http.StatusUnprocessableEntity, err)
+			return
+		case errors.Is(err, ErrInProgress):
+			// Retry-After tells clients this conflict clears on its own and
+			// the request can be resent with the same key
+			w.Header().Set("Retry-After", strconv.Itoa(retryAfterSeconds))
+			utils.WriteError(w, http.StatusConflict, err)
+			return
+		case err != nil:
+			log.Printf("idempotency: failed to acquire key %q: %v", key, err)
+			utils.WriteError(w, http.StatusInternalServerError, fmt.Errorf("failed to process idempotency key"))
+			return
+		}
+
+		if stored != nil {
+			w.Header().Set("Content-Type", "application/json")
+			w.Header().Set(ReplayedHeader, "true")
+			w.WriteHeader(int(stored.ResponseStatus.Int32))
+			_, _ = w.Write(stored.ResponseBody)
+			return
+		}
+
+		ww := middleware.NewWrapResponseWriter(w, r.ProtoMajor)
+		var recorded bytes.Buffer
+		ww.Tee(&recorded)
+
+		next.ServeHTTP(ww, r)
+
+		// Use a fresh context: the stored result must be written even if the
+		// client already gave up on this request.
+		saveCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+
+		status := ww.Status()
+		if status >= http.StatusInternalServerError {
+			err = s.queries.DeleteIdempotencyKey(saveCtx, database.DeleteIdempotencyKeyParams{
+				Key:         key,
+				RequestPath: r.URL.Path,
+			})
+		} else {
+			err = s.queries.SaveIdempotencyResponse(saveCtx, database.SaveIdempotencyResponseParams{
+				Key:            key,
+				RequestPath:    r.URL.Path,
+				ResponseStatus: sql.NullInt32{Int32: int32(status), Valid: true},
+				ResponseBody:   recorded.Bytes(),
+			})
+		}
+		if err != nil {
+			log.Printf("idempotency: failed to record response for key %q: %v", key, err)
+		}
+	})
+}
+
+// acquire claims the key for the current request. It returns a nil record when
+// the caller owns the key and must execute the request, or the stored record
+// when a response can be replayed.
+func (s *Store) acquire(ctx context.Context, key, path, requestHash string) (*database.IdempotencyKey, error) {
+	inserted, err := s.queries.CreateIdempotencyKey(ctx, database.CreateIdempotencyKeyParams{
+		Key:         key,
+		RequestPath: path,
+		RequestHash: requestHash,
+	})
+	if err != nil {
+		return nil, err
+	}
+	if inserted == 1 {
+		return nil, nil
+	}
+
+	stored, err := s.queries.GetIdempotencyKey(ctx, database.GetIdempotencyKeyParams{
+		Key:         key,
+		RequestPath: path,
+	})
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			// The first request failed and released the key after our insert
+			return nil, ErrInProgress
+		}
+		return nil, err
+	}
+
+	if stored.RequestHash != requestHash {
+		return nil, ErrKeyReused
+	}
+
+	if stored.ResponseStatus.Valid {
+		return &stored, nil
+	}
+
+	// No response yet: either the first request is still running or its
+	// process died before recording one.
+	reclaimed, err := s.queries.ReclaimIdempotencyKey(ctx, database.ReclaimIdempotencyKeyParams{
+		Key:         key,
+		RequestPath: path,
+		UpdatedAt:   time.Now().Add(-staleAfter),
+	})
+	if err != nil {
+		return nil, err
+	}
+	if reclaimed == 0 {
+		return nil, ErrInProgress
+	}
+	return nil, nil
+}
@@ -0,0 +1,222 @@
+package idempotency
+
+import (
+	"crypto/sha256"
+	"database/sql"
+	"encoding/hex"
+	"net/http"
+	"net/http/httptest"
+	"regexp"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+
+	"github.com/ignisrex/tix/booking/internal/database"
+)
+
+const (
+	testKey  = "checkout-1"
+	testPath = "/api/v1/booking/purchase"
+	testBody = `{"hold_id":"h1"}`
+)
+
+var idempotencyColumns = []string{"key", "request_path", "request_hash", "response_status", "response_body", "created_at", "updated_at"}
+
+func newTestStore(t *testing.T) (*Store, sqlmock.Sqlmock) {
+	t.Helper()
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("failed to open sqlmock: %v", err)
+	}
+	t.Cleanup(func() { db.Close() })
+	return NewStore(database.New(db)), mock
+}
+
+func hashOf(body string) string {
+	hash := sha256.Sum256([]byte(body))
+	return hex.EncodeToString(hash[:])
+}
+
+// countingHandler answers 201 and counts how often it ran
+func countingHandler(calls *int) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		*calls++
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusCreated)
+		_, _ = w.Write([]byte(`{"success":true}`))
+	})
+}
+
+func send(store *Store, next http.Handler, body string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(http.MethodPost, testPath, strings.NewReader(body))
+	req.Header.Set(HeaderKey, testKey)
+	rec := httptest.NewRecorder()
+	store.Middleware(next).ServeHTTP(rec, req)
+	return rec
+}
+
+func TestMiddlewareStoresFirstResponse(t *testing.T) {
+	store, mock := newTestStore(t)
+	mock.ExpectExec(regexp.QuoteMeta("INSERT INTO idempotency_keys")).
+		WithArgs(testKey, testPath, hashOf(testBody)).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec(regexp.QuoteMeta("UPDATE idempotency_keys\nSET response_status")).
+		WithArgs(testKey, testPath, sql.NullInt32{Int32: http.StatusCreated, Valid: true}, []byte(`{"success":true}`)).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+
+	calls := 0
+	rec := send(store, countingHandler(&calls), testBody)
+
+	if calls != 1 {
+		t.Fatalf("handler ran %d times, want 1", calls)
+	}
+	if rec.Code != http.StatusCreated {
+		t.Errorf("status = %d, want %d", rec.Code, http.StatusCreated)
+	}
+	if rec.Header().Get(ReplayedHeader) != "" {
+		t.Errorf("first response marked as replayed")
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestMiddlewareReplaysStoredResponse(t *testing.T) {
+	store, mock := newTestStore(t)
+	mock.ExpectExec(regexp.QuoteMeta("INSERT INTO idempotency_keys")).
+		WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectQuery(regexp.QuoteMeta("FROM idempotency_keys")).
+		WithArgs(testKey, testPath).
+		WillReturnRows(sqlmock.NewRows(idempotencyColumns).
+			AddRow(testKey, testPath, hashOf(testBody), http.StatusCreated, []byte(`{"success":true,"purchase_id":"p1"}`), time.Now(), time.Now()))
+
+	calls := 0
+	rec := send(store, countingHandler(&calls), testBody)
+
+	if calls != 0 {
+		t.Fatalf("handler ran %d times for a replayed key, want 0", calls)
+	}
+	if rec.Code != http.StatusCreated {
+		t.Errorf("status = %d, want %d", rec.Code, http.StatusCreated)
+	}
+	if rec.Header().Get(ReplayedHeader) != "true" {
+		t.Errorf("%s = %q, want true", ReplayedHeader, rec.Header().Get(ReplayedHeader))
+	}
+	if got := rec.Body.String(); got != `{"success":true,"purchase_id":"p1"}` {
+		t.Errorf("body = %s, want the stored response", got)
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestMiddlewareRejectsReusedKeyWithDifferentBody(t *testing.T) {
+	store, mock := newTestStore(t)
+	mock.ExpectExec(regexp.QuoteMeta("INSERT INTO idempotency_keys")).
+		WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectQuery(regexp.QuoteMeta("FROM idempotency_keys")).
+		WillReturnRows(sqlmock.NewRows(idempotencyColumns).
+			AddRow(testKey, testPath, hashOf(`{"hold_id":"other"}`), http.StatusCreated, []byte(`{}`), time.Now(), time.Now()))
+
+	calls := 0
+	rec := send(store, countingHandler(&calls), testBody)
+
+	if calls != 0 {
+		t.Fatalf("handler ran %d times, want 0", calls)
+	}
+	if rec.Code != http.StatusUnprocessableEntity {
+		t.Errorf("status = %d, want %d", rec.Code, http.StatusUnprocessableEntity)
+	}
+}
+
+func TestMiddlewareInProgressAsksToRetry(t *testing.T) {
+	store, mock := newTestStore(t)
+	mock.ExpectExec(regexp.QuoteMeta("INSERT INTO idempotency_keys")).
+		WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectQuery(regexp.QuoteMeta("FROM idempotency_keys")).
+		WillReturnRows(sqlmock.NewRows(idempotencyColumns).
+			AddRow(testKey, testPath, hashOf(testBody), nil, nil, time.Now(), time.Now()))
+	mock.ExpectExec(regexp.QuoteMeta("SET updated_at = CURRENT_TIMESTAMP")).
+		WillReturnResult(sqlmock.NewResult(0, 0))
+
+	calls := 0
+	rec := send(store, countingHandler(&calls), testBody)
+
+	if calls != 0 {
+		t.Fatalf("handler ran %d times while the key was in progress, want 0", calls)
+	}
+	if rec.Code != http.StatusConflict {
+		t.Errorf("status = %d, want %d", rec.Code, http.StatusConflict)
+	}
+	if rec.Header().Get("Retry-After") == "" {
+		t.Errorf("in-progress conflict has no Retry-After")
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestMiddlewareReclaimsStaleKey(t *testing.T) {
+	store, mock := newTestStore(t)
+	mock.ExpectExec(regexp.QuoteMeta("INSERT INTO idempotency_keys")).
+		WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectQuery(regexp.QuoteMeta("FROM idempotency_keys")).
+		WillReturnRows(sqlmock.NewRows(idempotencyColumns).
+			AddRow(testKey, testPath, hashOf(testBody), nil, nil, time.Now(), time.Now()))
+	mock.ExpectExec(regexp.QuoteMeta("SET updated_at = CURRENT_TIMESTAMP")).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec(regexp.QuoteMeta("SET response_status")).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+
+	calls := 0
+	rec := send(store, countingHandler(&calls), testBody)
+
+	if calls != 1 {
+		t.Fatalf("handler ran %d times for a reclaimed key, want 1", calls)
+	}
+	if rec.Code != http.StatusCreated {
+		t.Errorf("status = %d, want %d", rec.Code, http.StatusCreated)
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestMiddlewareReleasesKeyOnServerError(t *testing.T) {
+	store, mock := newTestStore(t)
+	mock.ExpectExec(regexp.QuoteMeta("INSERT INTO idempotency_keys")).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec(regexp.QuoteMeta("DELETE FROM idempotency_keys")).
+		WithArgs(testKey, testPath).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+
+	failing := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusInternalServerError)
+	})
+	rec := send(store, failing, testBody)
+
+	if rec.Code != http.StatusInternalServerError {
+		t.Errorf("status = %d, want %d", rec.Code, http.StatusInternalServerError)
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestMiddlewarePassesThroughWithoutKey(t *testing.T) {
+	store, mock := newTestStore(t)
+
+	calls := 0
+	req := httptest.NewRequest(http.MethodPost, testPath, strings.NewReader(testBody))
+	rec := httptest.NewRecorder()
+	store.Middleware(countingHandler(&calls)).ServeHTTP(rec, req)
+
+	if calls != 1 {
+		t.Fatalf("handler ran %d times, want 1", calls)
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Error(err)
+	}
+}
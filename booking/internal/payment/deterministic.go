@@ -0,0 +1,63 @@
+package payment
+
+import (
+	"context"
+	"fmt"
+	"time"
+)
+
+// Magic payment method tokens understood by the deterministic provider.
+const (
+	TokenSuccess           = "tok_success"
+	TokenDecline           = "tok_decline"
+	TokenInsufficientFunds = "tok_insufficient_funds"
+	TokenTimeout           = "tok_timeout"
+)
+
+// Deterministic is a test provider whose outcome depends only on the payment
+// method token, so purchase flows can be exercised without randomness.
+type Deterministic struct {
+	// timeout is how long TokenTimeout blocks before giving up. It stays below
+	// the core service's client timeout so the failure reaches the caller.
+	timeout time.Duration
+}
+
+func NewDeterministic() *Deterministic {
+	return &Deterministic{
+		timeout: 3 * time.Second,
+	}
+}
+
+func (d *Deterministic) Authorize(ctx context.Context, reference string, paymentToken string, amountCents int32) (string, error) {
+	switch paymentToken {
+	case TokenSuccess:
+		return AuthorizationID(reference), nil
+	case TokenDecline:
+		return "", ErrCardDeclined
+	case TokenInsufficientFunds:
+		return "", fmt.Errorf("%w for amount %d cents", ErrInsufficientFunds, amountCents)
+	case TokenTimeout:
+		select {
+		case <-ctx.Done():
+			return "", ctx.Err()
+		case <-time.After(d.timeout):
+			return "", ErrTimeout
+		}
+	default:
+		return "", fmt.Errorf("%w: %q", ErrInvalidToken, paymentToken)
+	}
+}
+
+// Capture, Void and Refund always succeed; only authorization outcomes are
+// scripted.
+func (d *Deterministic) Capture(ctx context.Context, authorizationID string) error {
+	return nil
+}
+
+func (d *Deterministic) Void(ctx context.Context, authorizationID string) error {
+	return nil
+}
+
+func (d *Deterministic) Refund(ctx context.Context, reference string, authorizationID string, amountCents int32) (string, error) {
+	return refundID(reference), nil
+}
@@ -5,35 +5,56 @@ import (
 	"fmt"
 	"math/rand/v2"
 	"time"
-
-	"github.com/ignisrex/tix/booking/types"
 )
 
-// ProcessPayment simulates a payment processing with mockStripe
-// Takes ticket info including price and processes payment
-// Returns success 90% of the time, failure 10% of the time
-// Can process single or multiple tickets
-func ProcessPayment(ctx context.Context, tickets ...types.Ticket) (int32, error) {
+// MockStripe simulates a payment processor that fails at random.
+type MockStripe struct{}
 
-	// Simulate payment processing delay
-	select {
-	case <-ctx.Done():
-		return 0, ctx.Err()
-	case <-time.After(50 * time.Millisecond):
+func NewMockStripe() *MockStripe {
+	return &MockStripe{}
+}
+
+// Authorize simulates a payment authorization with mockStripe
+// Returns success 90% of the time, failure 10% of the time
+func (m *MockStripe) Authorize(ctx context.Context, reference string, paymentToken string, amountCents int32) (string, error) {
+	if paymentToken == "" {
+		return "", ErrInvalidToken
 	}
 
-	// Calculate total
-	totalCents := int32(0)
-	for _, ticket := range tickets {
-		totalCents += ticket.PriceCents
+	if err := simulateLatency(ctx); err != nil {
+		return "", err
 	}
 
 	// 90% success rate: if random value < 0.1 (10%), it fails
 	// Using math/rand/v2 which doesn't require seeding
 	if rand.Float32() < 0.1 {
-		return 0, fmt.Errorf("payment processing failed: insufficient funds for amount %d cents", totalCents)
+		return "", fmt.Errorf("%w for amount %d cents", ErrInsufficientFunds, amountCents)
 	}
 
-	return totalCents, nil
+	return AuthorizationID(reference), nil
+}
+
+func (m *MockStripe) Capture(ctx context.Context, authorizationID string) error {
+	return simulateLatency(ctx)
 }
 
+func (m *MockStripe) Void(ctx context.Context, authorizationID string) error {
+	return simulateLatency(ctx)
+}
+
+func (m *MockStripe) Refund(ctx context.Context, reference string, authorizationID string, amountCents int32) (string, error) {
+	if err := simulateLatency(ctx); err != nil {
+		return "", err
+	}
+	return refundID(reference), nil
+}
+
+// simulateLatency simulates the payment processing delay
+func simulateLatency(ctx context.Context) error {
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-time.After(50 * time.Millisecond):
+		return nil
+	}
+}
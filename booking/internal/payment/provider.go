@@ -0,0 +1,64 @@
+package payment
+
+import (
+	"context"
+	"errors"
+	"fmt"
+)
+
+// Provider names accepted by NewProvider (PAYMENT_PROVIDER env var).
+const (
+	ProviderMock          = "mock"
+	ProviderDeterministic = "deterministic"
+)
+
+var (
+	ErrInvalidToken      = errors.New("invalid payment method token")
+	ErrCardDeclined      = errors.New("card declined")
+	ErrInsufficientFunds = errors.New("insufficient funds")
+	ErrTimeout           = errors.New("payment provider timed out")
+)
+
+// PaymentProvider charges a payment method in two phases: Authorize places a
+// hold on the funds, then Capture collects them or Void releases them.
+type PaymentProvider interface {
+	// Authorize reserves amountCents on the payment method and returns the
+	// authorization ID. reference identifies the purchase attempt so that a
+	// retried authorization is not placed twice.
+	Authorize(ctx context.Context, reference string, paymentToken string, amountCents int32) (string, error)
+	Capture(ctx context.Context, authorizationID string) error
+	// Void releases an authorization. Voiding one that was never placed, or
+	// was already voided, succeeds, so an authorization whose result was
+	// lost can be voided by the ID AuthorizationID derives for it.
+	Void(ctx context.Context, authorizationID string) error
+	// Refund returns amountCents of a captured payment and returns the
+	// provider's refund ID. It may be called several times for partial refunds.
+	// reference identifies the refund so that a retried refund is not paid
+	// out twice.
+	Refund(ctx context.Context, reference string, authorizationID string, amountCents int32) (string, error)
+}
+
+// NewProvider returns the provider registered under name.
+func NewProvider(name string) (PaymentProvider, error) {
+	switch name {
+	case ProviderMock:
+		return NewMockStripe(), nil
+	case ProviderDeterministic:
+		return NewDeterministic(), nil
+	default:
+		return nil, fmt.Errorf("unknown payment provider %q", name)
+	}
+}
+
+// AuthorizationID is the ID of the authorization placed for reference.
+// Authorizations are keyed by reference, so the ID is known even when the
+// result of Authorize was never recorded.
+func AuthorizationID(reference string) string {
+	return "auth_" + reference
+}
+
+// refundID is the ID of the refund made for reference. Like authorizations,
+// refunds are keyed by reference.
+func refundID(reference string) string {
+	return "re_" + reference
+}
@@ -0,0 +1,86 @@
+package queue
+
+import (
+	"crypto/rand"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/google/uuid"
+)
+
+// Token kinds. A queue token identifies a visitor waiting for an event; an
+// admission token lets them reserve tickets for it until it expires.
+const (
+	KindQueue     = "queue"
+	KindAdmission = "admission"
+)
+
+var ErrInvalidToken = errors.New("invalid waiting room token")
+
+type Claims struct {
+	jwt.RegisteredClaims
+	EventID uuid.UUID `json:"event_id"`
+	Kind    string    `json:"kind"`
+}
+
+// Signer issues and verifies waiting room tokens, signed with HS256. Every
+// booking instance must share the secret.
+type Signer struct {
+	secret []byte
+	parser *jwt.Parser
+}
+
+// NewSigner returns a signer for secret. With an empty secret a random one is
+// generated, which only works while a single booking instance is running.
+func NewSigner(secret string) (*Signer, error) {
+	key := []byte(secret)
+	if secret == "" {
+		key = make([]byte, 32)
+		if _, err := rand.Read(key); err != nil {
+			return nil, fmt.Errorf("failed to generate queue token secret: %w", err)
+		}
+	}
+	return &Signer{
+		secret: key,
+		parser: jwt.NewParser(
+			jwt.WithValidMethods([]string{jwt.SigningMethodHS256.Alg()}),
+			jwt.WithExpirationRequired(),
+		),
+	}, nil
+}
+
+// Issue signs a token of the given kind for a visitor of an event
+func (s *Signer) Issue(kind string, eventID uuid.UUID, visitorID uuid.UUID, expiresAt time.Time) (string, error) {
+	token := jwt.NewWithClaims(jwt.SigningMethodHS256, Claims{
+		RegisteredClaims: jwt.RegisteredClaims{
+			Subject:   visitorID.String(),
+			IssuedAt:  jwt.NewNumericDate(time.Now()),
+			ExpiresAt: jwt.NewNumericDate(expiresAt),
+		},
+		EventID: eventID,
+		Kind:    kind,
+	})
+	return token.SignedString(s.secret)
+}
+
+// Verify checks that token is an unexpired token of the given kind for the
+// event and returns the visitor it was issued to
+func (s *Signer) Verify(token string, kind string, eventID uuid.UUID) (uuid.UUID, error) {
+	var claims Claims
+	if _, err := s.parser.ParseWithClaims(token, &claims, func(*jwt.Token) (any, error) {
+		return s.secret, nil
+	}); err != nil {
+		return uuid.Nil, fmt.Errorf("%w: %v", ErrInvalidToken, err)
+	}
+	if claims.Kind != kind || claims.EventID != eventID {
+		return uuid.Nil, fmt.Errorf("%w: not a %s token for event %s", ErrInvalidToken, kind, eventID)
+	}
+
+	visitorID, err := uuid.Parse(claims.Subject)
+	if err != nil {
+		return uuid.Nil, fmt.Errorf("%w: subject is not a valid id", ErrInvalidToken)
+	}
+	return visitorID, nil
+}
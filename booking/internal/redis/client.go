@@ -12,7 +12,8 @@ import (
 )
 
 const (
-	keyPrefix = "ticket:"
+	keyPrefix     = "ticket:"
+	holdKeyPrefix = "hold:"
 )
 
 type Client struct {
@@ -20,15 +21,105 @@ type Client struct {
 	ttl time.Duration
 }
 
+// Errors returned when a hold cannot be created or acted on by the caller.
+var (
+	ErrAlreadyReserved = errors.New("at least one ticket is already reserved")
+	ErrHoldNotFound    = errors.New("reservation not found or expired")
+	ErrHoldMismatch    = errors.New("reservation is held by another owner")
+	ErrExtensionLimit  = errors.New("reservation extension limit reached")
+)
+
+// All hold scripts take the same key layout:
+//   KEYS[1]     hold:<id>             set of ticket IDs in the hold
+//   KEYS[2]     hold:<id>:extensions  number of times the hold was extended
+//   KEYS[3]     hold:<id>:owner       subject of the caller who created the hold
+//   KEYS[4..n]  ticket:<id>           one key per ticket, valued with the hold ID
+
+// reserveScript stores the hold ID as the value of every ticket key so later
+// calls can verify ownership. Keys already held under the same hold ID are
+// treated as free, which lets a caller add tickets to an existing hold, as
+// long as it is the caller who created the hold. It returns 0 when a ticket
+// is held by another hold and -1 when the hold belongs to another caller.
+// ARGV: ttl, hold ID, owner ("" for anonymous callers), ticket IDs...
 var reserveScript = redis.NewScript(`
-for i = 1, #KEYS do
-  if redis.call("EXISTS", KEYS[i]) == 1 then
+if redis.call("EXISTS", KEYS[1]) == 1 and (redis.call("GET", KEYS[3]) or "") ~= ARGV[3] then
+  return -1
+end
+for i = 4, #KEYS do
+  local owner = redis.call("GET", KEYS[i])
+  if owner and owner ~= ARGV[2] then
+    return 0
+  end
+end
+for i = 4, #KEYS do
+  redis.call("SET", KEYS[i], ARGV[2], "EX", ARGV[1])
+end
+redis.call("SADD", KEYS[1], unpack(ARGV, 4))
+if redis.call("TTL", KEYS[1]) < tonumber(ARGV[1]) then
+  redis.call("EXPIRE", KEYS[1], ARGV[1])
+  redis.call("EXPIRE", KEYS[2], ARGV[1])
+end
+if ARGV[3] ~= "" then
+  redis.call("SET", KEYS[3], ARGV[3], "EX", redis.call("TTL", KEYS[1]))
+end
+return 1
+`)
+
+// verifyHoldLua is prepended to scripts that act on an existing hold. It
+// returns 0 when a key has expired and -1 when a key is held by someone else,
+// otherwise execution falls through to the action. ARGV[1] is the hold ID.
+const verifyHoldLua = `
+for i = 4, #KEYS do
+  local owner = redis.call("GET", KEYS[i])
+  if not owner then
     return 0
   end
+  if owner ~= ARGV[1] then
+    return -1
+  end
+end
+`
+
+// ARGV: hold ID, ttl
+var refreshScript = redis.NewScript(verifyHoldLua + `
+for i = 4, #KEYS do
+  redis.call("EXPIRE", KEYS[i], ARGV[2])
+end
+if redis.call("TTL", KEYS[1]) < tonumber(ARGV[2]) then
+  redis.call("EXPIRE", KEYS[1], ARGV[2])
+  redis.call("EXPIRE", KEYS[2], ARGV[2])
+  redis.call("EXPIRE", KEYS[3], ARGV[2])
+end
+return 1
+`)
+
+// ARGV: hold ID, ticket IDs...
+var releaseScript = redis.NewScript(verifyHoldLua + `
+for i = 4, #KEYS do
+  redis.call("DEL", KEYS[i])
+end
+redis.call("SREM", KEYS[1], unpack(ARGV, 2))
+if redis.call("SCARD", KEYS[1]) == 0 then
+  redis.call("DEL", KEYS[1], KEYS[2], KEYS[3])
+end
+return 1
+`)
+
+// extendScript resets every ticket in the hold to a full TTL, at most
+// ARGV[3] times per hold. It returns -2 once the limit is reached.
+// ARGV: hold ID, ttl, max extensions
+var extendScript = redis.NewScript(verifyHoldLua + `
+local extensions = tonumber(redis.call("GET", KEYS[2]) or "0")
+if extensions >= tonumber(ARGV[3]) then
+  return -2
 end
-for i = 1, #KEYS do
-  redis.call("SET", KEYS[i], "true", "EX", ARGV[1], "NX")
+redis.call("INCR", KEYS[2])
+for i = 4, #KEYS do
+  redis.call("EXPIRE", KEYS[i], ARGV[2])
 end
+redis.call("EXPIRE", KEYS[1], ARGV[2])
+redis.call("EXPIRE", KEYS[2], ARGV[2])
+redis.call("EXPIRE", KEYS[3], ARGV[2])
 return 1
 `)
 
@@ -60,68 +151,85 @@ func (c *Client) IsReserved(ctx context.Context, ticketID uuid.UUID) (bool, erro
 	return exists > 0, nil
 }
 
-/*ReserveTickets attempts to reserve multiple tickets atomically during the operation*/
-func (c *Client) ReserveTickets(ctx context.Context, ticketIDs []uuid.UUID) error {
-	keys := make([]string, len(ticketIDs))
-	for i, id := range ticketIDs {
-		keys[i] = keyPrefix + id.String()
+/*ReserveTickets attempts to reserve multiple tickets atomically under the given hold ID.
+A new hold belongs to ownerID, or to nobody when ownerID is uuid.Nil; only its owner
+can add tickets to it afterwards (ErrHoldMismatch otherwise).*/
+func (c *Client) ReserveTickets(ctx context.Context, ticketIDs []uuid.UUID, holdID uuid.UUID, ownerID uuid.UUID) error {
+	owner := ""
+	if ownerID != uuid.Nil {
+		owner = ownerID.String()
 	}
+	args := append([]interface{}{int(c.ttl.Seconds()), holdID.String(), owner}, ticketArgs(ticketIDs)...)
 
-	res, err := reserveScript.Run(ctx, c.rdb, keys, int(c.ttl.Seconds())).Int()
+	res, err := reserveScript.Run(ctx, c.rdb, holdKeys(holdID, ticketIDs), args...).Int()
 	if err != nil {
 		return fmt.Errorf("failed to reserve tickets: %w", err)
 	}
 
-	if res == 0 {
-		return errors.New("failed to reserve tickets. At least one ticket is already reserved")
+	switch res {
+	case 0:
+		return ErrAlreadyReserved
+	case -1:
+		return ErrHoldMismatch
 	}
 
 	return nil
 }
 
-func (c *Client) RefreshTickets(ctx context.Context, ticketIDs []uuid.UUID, ttl time.Duration) (bool, error) {
-	pipe := c.rdb.Pipeline()
-	for _, ticketID := range ticketIDs {
-		key := keyPrefix + ticketID.String()
-		pipe.Expire(ctx, key, ttl)
-	}
-
-	cmds, err := pipe.Exec(ctx)
+// RefreshTickets extends the TTL of tickets held under holdID.
+// It fails with ErrHoldNotFound or ErrHoldMismatch without touching any key
+// if one of the tickets is not held by the caller.
+func (c *Client) RefreshTickets(ctx context.Context, ticketIDs []uuid.UUID, holdID uuid.UUID, ttl time.Duration) error {
+	res, err := refreshScript.Run(ctx, c.rdb, holdKeys(holdID, ticketIDs), holdID.String(), int(ttl.Seconds())).Int()
 	if err != nil {
-		return false, fmt.Errorf("failed to refresh tickets: %w", err)
+		return fmt.Errorf("failed to refresh tickets: %w", err)
 	}
+	return holdResult(res)
+}
 
-	for _, cmd := range cmds {
-		if cmd.Err() != nil {
-			return false, fmt.Errorf("failed to refresh ticket: %w", cmd.Err())
-		}
-
-		expireCmd, ok := cmd.(*redis.BoolCmd)
-		if !ok {
-			return false, fmt.Errorf("failed to refresh ticket: %w", cmd.Err())
-		}
+// ReleaseTickets deletes the reservations for tickets held under holdID and
+// removes them from the hold. Like RefreshTickets, nothing is released unless
+// the caller owns every ticket.
+func (c *Client) ReleaseTickets(ctx context.Context, ticketIDs []uuid.UUID, holdID uuid.UUID) error {
+	args := append([]interface{}{holdID.String()}, ticketArgs(ticketIDs)...)
 
-		if !expireCmd.Val() {
-			return false, fmt.Errorf("failed to refresh ticket: %w", expireCmd.Err())
-		}
+	res, err := releaseScript.Run(ctx, c.rdb, holdKeys(holdID, ticketIDs), args...).Int()
+	if err != nil {
+		return fmt.Errorf("failed to release tickets: %w", err)
 	}
-	return true, nil
+	return holdResult(res)
 }
 
-func (c *Client) ReleaseTickets(ctx context.Context, ticketIDs []uuid.UUID) error {
-	pipe := c.rdb.Pipeline()
-	for _, ticketID := range ticketIDs {
-		key := keyPrefix + ticketID.String()
-		pipe.Del(ctx, key)
+func holdResult(res int) error {
+	switch res {
+	case 0:
+		return ErrHoldNotFound
+	case -1:
+		return ErrHoldMismatch
+	case -2:
+		return ErrExtensionLimit
 	}
+	return nil
+}
 
-	_, err := pipe.Exec(ctx)
-	if err != nil {
-		return fmt.Errorf("failed to release tickets: %w", err)
+// holdKeys builds the KEYS layout shared by the hold scripts.
+func holdKeys(holdID uuid.UUID, ticketIDs []uuid.UUID) []string {
+	holdKey := holdKeyPrefix + holdID.String()
+	keys := make([]string, 0, len(ticketIDs)+3)
+	keys = append(keys, holdKey, holdKey+":extensions", holdKey+":owner")
+	for _, id := range ticketIDs {
+		keys = append(keys, keyPrefix+id.String())
 	}
-	return nil
+	return keys
 }
 
+func ticketArgs(ticketIDs []uuid.UUID) []interface{} {
+	args := make([]interface{}, len(ticketIDs))
+	for i, id := range ticketIDs {
+		args[i] = id.String()
+	}
+	return args
+}
 
 // Returns a map of ticketID -> is_reserved (true if reserved, false if available).
 func (c *Client) AreReserved(ctx context.Context, ticketIDs []uuid.UUID) (map[uuid.UUID]bool, error) {
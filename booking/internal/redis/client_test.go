@@ -0,0 +1,209 @@
+package redis
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/google/uuid"
+	"github.com/redis/go-redis/v9"
+)
+
+// newTestClient runs the hold scripts against an in-memory Redis. Time only
+// passes there through FastForward.
+func newTestClient(t *testing.T) (*Client, *miniredis.Miniredis) {
+	t.Helper()
+	mr := miniredis.RunT(t)
+	rdb := redis.NewClient(&redis.Options{Addr: mr.Addr()})
+	t.Cleanup(func() { rdb.Close() })
+	return &Client{rdb: rdb, ttl: time.Minute}, mr
+}
+
+func newTicketIDs(n int) []uuid.UUID {
+	ids := make([]uuid.UUID, n)
+	for i := range ids {
+		ids[i] = uuid.New()
+	}
+	return ids
+}
+
+func TestReserveTicketsIsAllOrNothing(t *testing.T) {
+	c, mr := newTestClient(t)
+	ctx := context.Background()
+	tickets := newTicketIDs(3)
+	first, second := uuid.New(), uuid.New()
+
+	if err := c.ReserveTickets(ctx, tickets[:2], first, uuid.Nil); err != nil {
+		t.Fatalf("ReserveTickets: %v", err)
+	}
+	if err := c.ReserveTickets(ctx, tickets[1:], second, uuid.Nil); !errors.Is(err, ErrAlreadyReserved) {
+		t.Fatalf("reserving a held ticket = %v, want ErrAlreadyReserved", err)
+	}
+	if mr.Exists(keyPrefix + tickets[2].String()) {
+		t.Error("the free ticket of a failed reservation was reserved")
+	}
+	if got, _ := mr.Get(keyPrefix + tickets[1].String()); got != first.String() {
+		t.Errorf("held ticket belongs to %q, want the first hold", got)
+	}
+
+	// Once the first hold expires its tickets are free again
+	mr.FastForward(time.Minute + time.Second)
+	if err := c.ReserveTickets(ctx, tickets[1:], second, uuid.Nil); err != nil {
+		t.Fatalf("reserving expired tickets: %v", err)
+	}
+}
+
+func TestReserveTicketsOnlyLetsTheOwnerAddToAHold(t *testing.T) {
+	c, _ := newTestClient(t)
+	ctx := context.Background()
+	tickets := newTicketIDs(4)
+	owner := uuid.New()
+	holdID, anonymousHoldID := uuid.New(), uuid.New()
+
+	if err := c.ReserveTickets(ctx, tickets[:1], holdID, owner); err != nil {
+		t.Fatalf("ReserveTickets: %v", err)
+	}
+	if err := c.ReserveTickets(ctx, tickets[1:2], holdID, owner); err != nil {
+		t.Fatalf("owner adding to their hold: %v", err)
+	}
+	if err := c.ReserveTickets(ctx, tickets[2:3], holdID, uuid.New()); !errors.Is(err, ErrHoldMismatch) {
+		t.Errorf("another caller adding to the hold = %v, want ErrHoldMismatch", err)
+	}
+	if err := c.ReserveTickets(ctx, tickets[2:3], holdID, uuid.Nil); !errors.Is(err, ErrHoldMismatch) {
+		t.Errorf("an anonymous caller adding to the hold = %v, want ErrHoldMismatch", err)
+	}
+
+	if err := c.ReserveTickets(ctx, tickets[2:3], anonymousHoldID, uuid.Nil); err != nil {
+		t.Fatalf("ReserveTickets: %v", err)
+	}
+	if err := c.ReserveTickets(ctx, tickets[3:], anonymousHoldID, uuid.Nil); err != nil {
+		t.Errorf("adding to an anonymous hold: %v", err)
+	}
+
+	hold, err := c.GetHold(ctx, holdID)
+	if err != nil {
+		t.Fatalf("GetHold: %v", err)
+	}
+	if hold.Owner != owner || len(hold.Tickets) != 2 {
+		t.Errorf("hold has owner %s and %d tickets, want %s and 2", hold.Owner, len(hold.Tickets), owner)
+	}
+}
+
+func TestReleaseTicketsChecksTheHold(t *testing.T) {
+	c, mr := newTestClient(t)
+	ctx := context.Background()
+	tickets := newTicketIDs(2)
+	holdID := uuid.New()
+	holdKey := holdKeyPrefix + holdID.String()
+
+	if err := c.ReserveTickets(ctx, tickets, holdID, uuid.New()); err != nil {
+		t.Fatalf("ReserveTickets: %v", err)
+	}
+	if err := c.ReleaseTickets(ctx, tickets, uuid.New()); !errors.Is(err, ErrHoldMismatch) {
+		t.Fatalf("releasing under another hold = %v, want ErrHoldMismatch", err)
+	}
+	if !mr.Exists(keyPrefix + tickets[0].String()) {
+		t.Fatal("a rejected release removed a ticket")
+	}
+
+	if err := c.ReleaseTickets(ctx, tickets[:1], holdID); err != nil {
+		t.Fatalf("ReleaseTickets: %v", err)
+	}
+	if mr.Exists(keyPrefix+tickets[0].String()) || !mr.Exists(holdKey) {
+		t.Fatal("releasing one ticket should keep the rest of the hold")
+	}
+	if err := c.ReleaseTickets(ctx, tickets[1:], holdID); err != nil {
+		t.Fatalf("ReleaseTickets: %v", err)
+	}
+	for _, key := range []string{holdKey, holdKey + ":owner"} {
+		if mr.Exists(key) {
+			t.Errorf("%s is left after the last ticket was released", key)
+		}
+	}
+
+	if err := c.ReleaseTickets(ctx, tickets[1:], holdID); !errors.Is(err, ErrHoldNotFound) {
+		t.Errorf("releasing again = %v, want ErrHoldNotFound", err)
+	}
+}
+
+func TestRefreshTicketsFailsForExpiredTickets(t *testing.T) {
+	c, mr := newTestClient(t)
+	ctx := context.Background()
+	tickets := newTicketIDs(2)
+	holdID := uuid.New()
+
+	if err := c.ReserveTickets(ctx, tickets[:1], holdID, uuid.Nil); err != nil {
+		t.Fatalf("ReserveTickets: %v", err)
+	}
+	mr.FastForward(40 * time.Second)
+	if err := c.ReserveTickets(ctx, tickets[1:], holdID, uuid.Nil); err != nil {
+		t.Fatalf("ReserveTickets: %v", err)
+	}
+	mr.FastForward(30 * time.Second)
+
+	// The first ticket expired; GetHold leaves it out and refreshing the
+	// whole hold fails without touching the other ticket
+	hold, err := c.GetHold(ctx, holdID)
+	if err != nil {
+		t.Fatalf("GetHold: %v", err)
+	}
+	if _, ok := hold.Tickets[tickets[1]]; !ok || len(hold.Tickets) != 1 {
+		t.Fatalf("hold tickets = %v, want only the unexpired ticket", hold.Tickets)
+	}
+	before := mr.TTL(keyPrefix + tickets[1].String())
+	if err := c.RefreshTickets(ctx, tickets, holdID, time.Minute); !errors.Is(err, ErrHoldNotFound) {
+		t.Fatalf("refreshing an expired ticket = %v, want ErrHoldNotFound", err)
+	}
+	if got := mr.TTL(keyPrefix + tickets[1].String()); got != before {
+		t.Errorf("a failed refresh changed a TTL from %s to %s", before, got)
+	}
+
+	if err := c.RefreshTickets(ctx, tickets[1:], holdID, 2*time.Minute); err != nil {
+		t.Fatalf("RefreshTickets: %v", err)
+	}
+	if got := mr.TTL(keyPrefix + tickets[1].String()); got != 2*time.Minute {
+		t.Errorf("refreshed TTL = %s, want 2m", got)
+	}
+}
+
+func TestExtendHoldStopsAtTheLimit(t *testing.T) {
+	c, mr := newTestClient(t)
+	ctx := context.Background()
+	tickets := newTicketIDs(1)
+	holdID := uuid.New()
+	ticketKey := keyPrefix + tickets[0].String()
+
+	if err := c.ReserveTickets(ctx, tickets, holdID, uuid.Nil); err != nil {
+		t.Fatalf("ReserveTickets: %v", err)
+	}
+	for n := 1; n <= 2; n++ {
+		mr.FastForward(30 * time.Second)
+		if err := c.ExtendHold(ctx, holdID, tickets, 2); err != nil {
+			t.Fatalf("extension %d: %v", n, err)
+		}
+		if got := mr.TTL(ticketKey); got != time.Minute {
+			t.Errorf("TTL after extension %d = %s, want a full minute", n, got)
+		}
+	}
+
+	mr.FastForward(30 * time.Second)
+	if err := c.ExtendHold(ctx, holdID, tickets, 2); !errors.Is(err, ErrExtensionLimit) {
+		t.Fatalf("third extension = %v, want ErrExtensionLimit", err)
+	}
+	if got := mr.TTL(ticketKey); got != 30*time.Second {
+		t.Errorf("TTL after a refused extension = %s, want 30s", got)
+	}
+	if err := c.ExtendHold(ctx, holdID, tickets, 2); !errors.Is(err, ErrExtensionLimit) {
+		t.Errorf("extension after the limit = %v, want ErrExtensionLimit", err)
+	}
+
+	hold, err := c.GetHold(ctx, holdID)
+	if err != nil {
+		t.Fatalf("GetHold: %v", err)
+	}
+	if hold.Extensions != 2 {
+		t.Errorf("hold reports %d extensions, want 2", hold.Extensions)
+	}
+}
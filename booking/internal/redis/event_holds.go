@@ -0,0 +1,55 @@
+package redis
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/redis/go-redis/v9"
+)
+
+// Every event has a sorted set event:<event>:holds of its held ticket IDs,
+// scored by when their hold expires in unix milliseconds, so that held
+// tickets can be counted per event without checking every ticket key. The
+// index is kept next to the ticket keys, which remain the source of truth.
+
+// trackHoldsScript drops expired entries, adds the tickets and keeps the set
+// alive until its last hold expires.
+// KEYS: event:<event>:holds
+// ARGV: now, expiry, ticket IDs...
+var trackHoldsScript = redis.NewScript(`
+redis.call("ZREMRANGEBYSCORE", KEYS[1], "-inf", ARGV[1])
+for i = 3, #ARGV do
+  redis.call("ZADD", KEYS[1], ARGV[2], ARGV[i])
+end
+local last = redis.call("ZRANGE", KEYS[1], -1, -1, "WITHSCORES")
+if last[2] then
+  redis.call("PEXPIREAT", KEYS[1], last[2])
+end
+return 1
+`)
+
+func eventHoldsKey(eventID uuid.UUID) string {
+	return "event:" + eventID.String() + ":holds"
+}
+
+// TrackHolds records that the event's tickets are held for ttl from now.
+// Tickets already in the index get the new expiry.
+func (c *Client) TrackHolds(ctx context.Context, eventID uuid.UUID, ticketIDs []uuid.UUID, ttl time.Duration) error {
+	now := time.Now()
+	args := append([]interface{}{now.UnixMilli(), now.Add(ttl).UnixMilli()}, ticketArgs(ticketIDs)...)
+
+	if err := trackHoldsScript.Run(ctx, c.rdb, []string{eventHoldsKey(eventID)}, args...).Err(); err != nil {
+		return fmt.Errorf("failed to track holds: %w", err)
+	}
+	return nil
+}
+
+// UntrackHolds removes released or sold tickets from the event's hold index
+func (c *Client) UntrackHolds(ctx context.Context, eventID uuid.UUID, ticketIDs []uuid.UUID) error {
+	if err := c.rdb.ZRem(ctx, eventHoldsKey(eventID), ticketArgs(ticketIDs)...).Err(); err != nil {
+		return fmt.Errorf("failed to untrack holds: %w", err)
+	}
+	return nil
+}
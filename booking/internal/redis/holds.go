@@ -0,0 +1,86 @@
+package redis
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/redis/go-redis/v9"
+)
+
+// Hold describes the tickets a hold ID still owns.
+type Hold struct {
+	ID         uuid.UUID
+	Owner      uuid.UUID                   // uuid.Nil for holds made anonymously
+	Tickets    map[uuid.UUID]time.Duration // ticket ID -> remaining TTL
+	Extensions int
+}
+
+// GetHold returns the tickets still reserved under holdID along with their
+// remaining TTL. Tickets that expired or were taken by another hold are left out.
+// It returns ErrHoldNotFound when the hold no longer owns any ticket.
+func (c *Client) GetHold(ctx context.Context, holdID uuid.UUID) (*Hold, error) {
+	holdKey := holdKeyPrefix + holdID.String()
+
+	members, err := c.rdb.SMembers(ctx, holdKey).Result()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get hold: %w", err)
+	}
+	if len(members) == 0 {
+		return nil, ErrHoldNotFound
+	}
+
+	pipe := c.rdb.Pipeline()
+	extensionsCmd := pipe.Get(ctx, holdKey+":extensions")
+	ownerCmd := pipe.Get(ctx, holdKey+":owner")
+	ownerCmds := make([]*redis.StringCmd, len(members))
+	ttlCmds := make([]*redis.DurationCmd, len(members))
+	for i, member := range members {
+		key := keyPrefix + member
+		ownerCmds[i] = pipe.Get(ctx, key)
+		ttlCmds[i] = pipe.TTL(ctx, key)
+	}
+
+	// GET on a missing key reports redis.Nil, which is expected for expired tickets
+	if _, err := pipe.Exec(ctx); err != nil && !errors.Is(err, redis.Nil) {
+		return nil, fmt.Errorf("failed to get hold tickets: %w", err)
+	}
+
+	hold := &Hold{
+		ID:      holdID,
+		Tickets: make(map[uuid.UUID]time.Duration),
+	}
+	hold.Extensions, _ = extensionsCmd.Int()
+	if owner, err := uuid.Parse(ownerCmd.Val()); err == nil {
+		hold.Owner = owner
+	}
+
+	for i, member := range members {
+		if ownerCmds[i].Val() != holdID.String() {
+			continue
+		}
+		ticketID, err := uuid.Parse(member)
+		if err != nil {
+			continue
+		}
+		hold.Tickets[ticketID] = ttlCmds[i].Val()
+	}
+
+	if len(hold.Tickets) == 0 {
+		return nil, ErrHoldNotFound
+	}
+	return hold, nil
+}
+
+// ExtendHold resets the TTL of every ticket in the hold to the reservation TTL.
+// A hold can be extended at most maxExtensions times; after that
+// ErrExtensionLimit is returned and the TTLs are left untouched.
+func (c *Client) ExtendHold(ctx context.Context, holdID uuid.UUID, ticketIDs []uuid.UUID, maxExtensions int) error {
+	res, err := extendScript.Run(ctx, c.rdb, holdKeys(holdID, ticketIDs), holdID.String(), int(c.ttl.Seconds()), maxExtensions).Int()
+	if err != nil {
+		return fmt.Errorf("failed to extend hold: %w", err)
+	}
+	return holdResult(res)
+}
@@ -0,0 +1,236 @@
+package redis
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/redis/go-redis/v9"
+)
+
+const queueKeyPrefix = "queue:"
+
+var queuedEventsKey = queueKeyPrefix + "events"
+
+// Waiting room keys:
+//   queue:events                   set of event IDs with a waiting room
+//   queue:<event>                  hash with the admission batch_size and interval_seconds
+//   queue:<event>:waiting          sorted set of visitor IDs, scored by arrival
+//   queue:<event>:seq              arrival counter used as the waiting score
+//   queue:<event>:tick             set while the current admission interval runs
+//   queue:<event>:admitted:<id>    set while the visitor's admission window is open
+//   queue:<event>:joins:<addr>     anonymous joins from a client address in the current window
+
+var (
+	ErrQueueNotFound         = errors.New("event has no waiting room")
+	ErrQueuePositionNotFound = errors.New("visitor is not waiting or admitted")
+)
+
+// QueueConfig is the admission rate of an event's waiting room
+type QueueConfig struct {
+	BatchSize       int
+	IntervalSeconds int
+}
+
+// QueuePosition is where a visitor stands in a waiting room. Admitted
+// visitors have no position and an admission window of AdmittedFor.
+type QueuePosition struct {
+	Position    int64
+	Admitted    bool
+	AdmittedFor time.Duration
+}
+
+// joinQueueScript adds a visitor to the back of the queue unless they are
+// already waiting or admitted. It returns the visitor's 1-based position, 0
+// when they are admitted and -1 when the event has no waiting room.
+// KEYS: queue:<event>, queue:<event>:waiting, queue:<event>:seq, queue:<event>:admitted:<visitor>
+// ARGV: visitor ID
+var joinQueueScript = redis.NewScript(`
+if redis.call("EXISTS", KEYS[1]) == 0 then
+  return -1
+end
+if redis.call("EXISTS", KEYS[4]) == 1 then
+  return 0
+end
+if not redis.call("ZSCORE", KEYS[2], ARGV[1]) then
+  redis.call("ZADD", KEYS[2], redis.call("INCR", KEYS[3]), ARGV[1])
+end
+return redis.call("ZRANK", KEYS[2], ARGV[1]) + 1
+`)
+
+// admitScript admits the next batch of visitors once per interval. The tick
+// key makes sure several booking instances don't each admit a batch. It
+// returns the number of visitors admitted.
+// KEYS: queue:<event>, queue:<event>:waiting, queue:<event>:tick
+// ARGV: admission window in seconds, admitted key prefix
+var admitScript = redis.NewScript(`
+local batch = tonumber(redis.call("HGET", KEYS[1], "batch_size"))
+local interval = tonumber(redis.call("HGET", KEYS[1], "interval_seconds"))
+if not batch or not interval then
+  return 0
+end
+if not redis.call("SET", KEYS[3], "1", "NX", "EX", interval) then
+  return 0
+end
+local popped = redis.call("ZPOPMIN", KEYS[2], batch)
+for i = 1, #popped, 2 do
+  redis.call("SET", ARGV[2] .. popped[i], "1", "EX", ARGV[1])
+end
+return #popped / 2
+`)
+
+func queueKey(eventID uuid.UUID) string {
+	return queueKeyPrefix + eventID.String()
+}
+
+func admittedKeyPrefix(eventID uuid.UUID) string {
+	return queueKey(eventID) + ":admitted:"
+}
+
+// ConfigureQueue opens a waiting room for the event, or changes the admission
+// rate of an existing one
+func (c *Client) ConfigureQueue(ctx context.Context, eventID uuid.UUID, cfg QueueConfig) error {
+	pipe := c.rdb.TxPipeline()
+	pipe.HSet(ctx, queueKey(eventID), "batch_size", cfg.BatchSize, "interval_seconds", cfg.IntervalSeconds)
+	pipe.SAdd(ctx, queuedEventsKey, eventID.String())
+	if _, err := pipe.Exec(ctx); err != nil {
+		return fmt.Errorf("failed to configure queue: %w", err)
+	}
+	return nil
+}
+
+// RemoveQueue closes the event's waiting room. Visitors still waiting are
+// dropped and admission is no longer required.
+func (c *Client) RemoveQueue(ctx context.Context, eventID uuid.UUID) error {
+	key := queueKey(eventID)
+	removed, err := c.rdb.SRem(ctx, queuedEventsKey, eventID.String()).Result()
+	if err != nil {
+		return fmt.Errorf("failed to remove queue: %w", err)
+	}
+	if removed == 0 {
+		return ErrQueueNotFound
+	}
+	// Admitted keys expire on their own
+	if err := c.rdb.Del(ctx, key, key+":waiting", key+":seq", key+":tick").Err(); err != nil {
+		return fmt.Errorf("failed to remove queue: %w", err)
+	}
+	return nil
+}
+
+// GetQueue returns the event's admission rate and how many visitors are
+// waiting. It returns ErrQueueNotFound for events without a waiting room.
+func (c *Client) GetQueue(ctx context.Context, eventID uuid.UUID) (QueueConfig, int64, error) {
+	key := queueKey(eventID)
+	pipe := c.rdb.Pipeline()
+	configCmd := pipe.HMGet(ctx, key, "batch_size", "interval_seconds")
+	waitingCmd := pipe.ZCard(ctx, key+":waiting")
+	if _, err := pipe.Exec(ctx); err != nil {
+		return QueueConfig{}, 0, fmt.Errorf("failed to get queue: %w", err)
+	}
+
+	var fields struct {
+		BatchSize       int `redis:"batch_size"`
+		IntervalSeconds int `redis:"interval_seconds"`
+	}
+	if err := configCmd.Scan(&fields); err != nil {
+		return QueueConfig{}, 0, fmt.Errorf("failed to read queue config: %w", err)
+	}
+	if fields.BatchSize == 0 || fields.IntervalSeconds == 0 {
+		return QueueConfig{}, 0, ErrQueueNotFound
+	}
+	return QueueConfig{BatchSize: fields.BatchSize, IntervalSeconds: fields.IntervalSeconds}, waitingCmd.Val(), nil
+}
+
+// IsQueued reports whether the event has a waiting room
+func (c *Client) IsQueued(ctx context.Context, eventID uuid.UUID) (bool, error) {
+	queued, err := c.rdb.SIsMember(ctx, queuedEventsKey, eventID.String()).Result()
+	if err != nil {
+		return false, fmt.Errorf("failed to check queue: %w", err)
+	}
+	return queued, nil
+}
+
+// ListQueuedEvents returns the events that have a waiting room
+func (c *Client) ListQueuedEvents(ctx context.Context) ([]uuid.UUID, error) {
+	members, err := c.rdb.SMembers(ctx, queuedEventsKey).Result()
+	if err != nil {
+		return nil, fmt.Errorf("failed to list queues: %w", err)
+	}
+	eventIDs := make([]uuid.UUID, 0, len(members))
+	for _, member := range members {
+		if eventID, err := uuid.Parse(member); err == nil {
+			eventIDs = append(eventIDs, eventID)
+		}
+	}
+	return eventIDs, nil
+}
+
+// JoinQueue puts the visitor at the back of the event's queue, or leaves them
+// where they are if they already joined, and returns their position
+func (c *Client) JoinQueue(ctx context.Context, eventID uuid.UUID, visitorID uuid.UUID) (QueuePosition, error) {
+	key := queueKey(eventID)
+	keys := []string{key, key + ":waiting", key + ":seq", admittedKeyPrefix(eventID) + visitorID.String()}
+
+	res, err := joinQueueScript.Run(ctx, c.rdb, keys, visitorID.String()).Int64()
+	if err != nil {
+		return QueuePosition{}, fmt.Errorf("failed to join queue: %w", err)
+	}
+	switch {
+	case res < 0:
+		return QueuePosition{}, ErrQueueNotFound
+	case res == 0:
+		return c.GetQueuePosition(ctx, eventID, visitorID)
+	}
+	return QueuePosition{Position: res}, nil
+}
+
+// CountQueueJoin counts an anonymous join of the event's queue from the client
+// address and returns how many joins it made within the current window
+func (c *Client) CountQueueJoin(ctx context.Context, eventID uuid.UUID, addr string, window time.Duration) (int64, error) {
+	key := queueKey(eventID) + ":joins:" + addr
+	pipe := c.rdb.TxPipeline()
+	countCmd := pipe.Incr(ctx, key)
+	pipe.ExpireNX(ctx, key, window)
+	if _, err := pipe.Exec(ctx); err != nil {
+		return 0, fmt.Errorf("failed to count queue join: %w", err)
+	}
+	return countCmd.Val(), nil
+}
+
+// GetQueuePosition returns where the visitor stands in the event's queue. It
+// returns ErrQueuePositionNotFound when the visitor is neither waiting nor
+// admitted, e.g. because their admission window has passed.
+func (c *Client) GetQueuePosition(ctx context.Context, eventID uuid.UUID, visitorID uuid.UUID) (QueuePosition, error) {
+	pipe := c.rdb.Pipeline()
+	rankCmd := pipe.ZRank(ctx, queueKey(eventID)+":waiting", visitorID.String())
+	ttlCmd := pipe.TTL(ctx, admittedKeyPrefix(eventID)+visitorID.String())
+
+	// ZRANK reports redis.Nil for visitors that are no longer waiting
+	if _, err := pipe.Exec(ctx); err != nil && !errors.Is(err, redis.Nil) {
+		return QueuePosition{}, fmt.Errorf("failed to get queue position: %w", err)
+	}
+
+	if rank, err := rankCmd.Result(); err == nil {
+		return QueuePosition{Position: rank + 1}, nil
+	}
+	if ttl := ttlCmd.Val(); ttl > 0 {
+		return QueuePosition{Admitted: true, AdmittedFor: ttl}, nil
+	}
+	return QueuePosition{}, ErrQueuePositionNotFound
+}
+
+// AdmitNext admits the next batch of the event's visitors if the current
+// admission interval has passed, opening an admission window of the given
+// length for each. It returns how many visitors were admitted.
+func (c *Client) AdmitNext(ctx context.Context, eventID uuid.UUID, window time.Duration) (int, error) {
+	key := queueKey(eventID)
+	keys := []string{key, key + ":waiting", key + ":tick"}
+
+	admitted, err := admitScript.Run(ctx, c.rdb, keys, int(window.Seconds()), admittedKeyPrefix(eventID)).Int()
+	if err != nil {
+		return 0, fmt.Errorf("failed to admit visitors: %w", err)
+	}
+	return admitted, nil
+}
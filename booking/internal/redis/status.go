@@ -0,0 +1,33 @@
+package redis
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	"github.com/google/uuid"
+)
+
+// TicketStatusChannel carries ticket status changes made in the database,
+// i.e. tickets being sold, refunded or returned to inventory. Holds need no
+// message: subscribers follow them through keyspace notifications on the
+// ticket keys.
+const TicketStatusChannel = "tickets:status"
+
+// TicketStatusMessage is published on TicketStatusChannel
+type TicketStatusMessage struct {
+	TicketIDs []uuid.UUID `json:"ticket_ids"`
+	Status    string      `json:"status"`
+}
+
+// PublishTicketStatus announces that the tickets now have the given status
+func (c *Client) PublishTicketStatus(ctx context.Context, ticketIDs []uuid.UUID, status string) error {
+	payload, err := json.Marshal(TicketStatusMessage{TicketIDs: ticketIDs, Status: status})
+	if err != nil {
+		return fmt.Errorf("failed to marshal ticket status: %w", err)
+	}
+	if err := c.rdb.Publish(ctx, TicketStatusChannel, payload).Err(); err != nil {
+		return fmt.Errorf("failed to publish ticket status: %w", err)
+	}
+	return nil
+}
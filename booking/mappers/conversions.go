@@ -1,13 +1,21 @@
 package mappers
 
 import (
+	"time"
+
 	"github.com/ignisrex/tix/booking/internal/database"
 	"github.com/ignisrex/tix/booking/types"
 )
 
 func ToTicket(dbTicket database.GetTicketsWithPriceRow) types.Ticket {
 	return types.Ticket{
-		ID: dbTicket.ID,
+		ID:             dbTicket.ID,
+		EventID:        dbTicket.EventID,
+		TicketTypeID:   dbTicket.TicketTypeID,
+		Status:         string(dbTicket.Status),
+		PriceCents:     dbTicket.PriceCents,
+		TicketTypeName: dbTicket.TicketTypeName,
+		MaxPerOrder:    dbTicket.MaxPerOrder.Int32,
 	}
 }
 
@@ -17,4 +25,59 @@ func ToTickets(dbTickets []database.GetTicketsWithPriceRow) []types.Ticket {
 		tickets[i] = ToTicket(dbTicket)
 	}
 	return tickets
-}
\ No newline at end of file
+}
+func ToRefund(dbRefund database.ListPurchaseRefundsRow) types.Refund {
+	return types.Refund{
+		ID:                  dbRefund.ID,
+		TicketIDs:           dbRefund.TicketIds,
+		AmountCents:         dbRefund.AmountCents,
+		ReturnedToInventory: dbRefund.ReturnedToInventory,
+		Reason:              dbRefund.Reason.String,
+		Status:              string(dbRefund.Status),
+		CreatedAt:           dbRefund.CreatedAt.Format(time.RFC3339),
+	}
+}
+
+func ToRefunds(dbRefunds []database.ListPurchaseRefundsRow) []types.Refund {
+	refunds := make([]types.Refund, len(dbRefunds))
+	for i, dbRefund := range dbRefunds {
+		refunds[i] = ToRefund(dbRefund)
+	}
+	return refunds
+}
+
+func ToStatusTransition(dbTransition database.PurchaseStatusTransition) types.StatusTransition {
+	return types.StatusTransition{
+		From:   string(dbTransition.FromStatus.PurchaseStatus),
+		To:     string(dbTransition.ToStatus),
+		Reason: dbTransition.Reason.String,
+		At:     dbTransition.CreatedAt.Format(time.RFC3339),
+	}
+}
+
+func ToStatusTransitions(dbTransitions []database.PurchaseStatusTransition) []types.StatusTransition {
+	transitions := make([]types.StatusTransition, len(dbTransitions))
+	for i, dbTransition := range dbTransitions {
+		transitions[i] = ToStatusTransition(dbTransition)
+	}
+	return transitions
+}
+
+func ToPaymentAttempt(dbAttempt database.PaymentAttempt) types.PaymentAttempt {
+	return types.PaymentAttempt{
+		ID:            dbAttempt.ID,
+		Status:        string(dbAttempt.Status),
+		AmountCents:   dbAttempt.AmountCents,
+		FailureReason: dbAttempt.FailureReason.String,
+		CreatedAt:     dbAttempt.CreatedAt.Format(time.RFC3339),
+		UpdatedAt:     dbAttempt.UpdatedAt.Format(time.RFC3339),
+	}
+}
+
+func ToPaymentAttempts(dbAttempts []database.PaymentAttempt) []types.PaymentAttempt {
+	attempts := make([]types.PaymentAttempt, len(dbAttempts))
+	for i, dbAttempt := range dbAttempts {
+		attempts[i] = ToPaymentAttempt(dbAttempt)
+	}
+	return attempts
+}
@@ -0,0 +1,140 @@
+package booking
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"log"
+	"math/rand/v2"
+	"slices"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/ignisrex/tix/booking/internal/database"
+)
+
+// ReserveBestAvailable picks quantity available tickets of a ticket type and
+// reserves them under a hold, like ReserveTickets. Seated tickets are picked
+// as a block of adjacent seats in the frontmost row that fits them, falling
+// back to the fewest separate blocks when no row has room.
+// Tickets taken by other customers between picking and reserving are picked
+// again, up to the configured number of attempts.
+// On failure it returns a domain error (e.g. ErrTicketTypeNotFound,
+// ErrNotEnoughTickets, ErrOrderLimit, ErrAdmissionRequired).
+func (s *Service) ReserveBestAvailable(ctx context.Context, eventID uuid.UUID, ticketTypeID uuid.UUID, quantity int, holdID uuid.UUID, admissionToken string) ([]uuid.UUID, uuid.UUID, error) {
+	if err := s.checkAdmission(ctx, []uuid.UUID{eventID}, admissionToken); err != nil {
+		log.Printf("ReserveBestAvailable: %v", err)
+		return nil, uuid.Nil, err
+	}
+
+	ticketType, err := s.repo.GetTicketTypeForEvent(ctx, eventID, ticketTypeID)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, uuid.Nil, fmt.Errorf("%w: %s", ErrTicketTypeNotFound, ticketTypeID)
+		}
+		log.Printf("ReserveBestAvailable: failed to get ticket type %s: %v", ticketTypeID, err)
+		return nil, uuid.Nil, fmt.Errorf("failed to get ticket type: %w", err)
+	}
+	if ticketType.MaxPerOrder.Valid && quantity > int(ticketType.MaxPerOrder.Int32) {
+		return nil, uuid.Nil, fmt.Errorf("%w: at most %d %s tickets can be bought per order", ErrOrderLimit, ticketType.MaxPerOrder.Int32, ticketType.Name)
+	}
+
+	for attempt := 1; ; attempt++ {
+		tickets, err := s.unreservedTickets(ctx, eventID, ticketTypeID)
+		if err != nil {
+			log.Printf("ReserveBestAvailable: failed to list available tickets: %v", err)
+			return nil, uuid.Nil, fmt.Errorf("failed to list available tickets: %w", err)
+		}
+		if len(tickets) < quantity {
+			return nil, uuid.Nil, fmt.Errorf("%w: %d %s tickets requested, %d available", ErrNotEnoughTickets, quantity, ticketType.Name, len(tickets))
+		}
+
+		reservedIDs, reservedHoldID, err := s.ReserveTickets(ctx, pickBestAvailable(tickets, quantity), holdID, admissionToken)
+		if err == nil {
+			return reservedIDs, reservedHoldID, nil
+		}
+
+		// Another customer got to some of the picked tickets first
+		lostRace := errors.Is(err, ErrTicketReserved) || errors.Is(err, ErrTicketSold)
+		if !lostRace || attempt >= s.bestAvailableAttempts {
+			return nil, uuid.Nil, err
+		}
+		log.Printf("ReserveBestAvailable: attempt %d for ticket type %s lost a race, retrying: %v", attempt, ticketTypeID, err)
+
+		// Back off with jitter so competing callers don't pick in lockstep
+		backoff := time.Duration(attempt)*20*time.Millisecond + rand.N(20*time.Millisecond)
+		select {
+		case <-ctx.Done():
+			return nil, uuid.Nil, ctx.Err()
+		case <-time.After(backoff):
+		}
+	}
+}
+
+// unreservedTickets returns the unsold tickets of a ticket type that are not
+// reserved by anyone, in seat map order
+func (s *Service) unreservedTickets(ctx context.Context, eventID uuid.UUID, ticketTypeID uuid.UUID) ([]database.ListAvailableTicketsForTypeRow, error) {
+	tickets, err := s.repo.ListAvailableTickets(ctx, eventID, ticketTypeID)
+	if err != nil || len(tickets) == 0 {
+		return nil, err
+	}
+
+	ids := make([]uuid.UUID, len(tickets))
+	for i, ticket := range tickets {
+		ids[i] = ticket.ID
+	}
+	locks, err := s.redisClient.AreReserved(ctx, ids)
+	if err != nil {
+		return nil, err
+	}
+
+	return slices.DeleteFunc(tickets, func(ticket database.ListAvailableTicketsForTypeRow) bool {
+		return locks[ticket.ID]
+	}), nil
+}
+
+// pickBestAvailable picks quantity tickets from tickets, which must be in seat
+// map order and hold at least quantity tickets. Tickets are split into blocks
+// of adjacent seats; unseated tickets form a single block. The first block
+// that fits the whole order wins, otherwise the largest blocks are combined.
+func pickBestAvailable(tickets []database.ListAvailableTicketsForTypeRow, quantity int) []uuid.UUID {
+	var blocks [][]uuid.UUID
+	for i, ticket := range tickets {
+		if i == 0 || !adjacentSeats(tickets[i-1], ticket) {
+			blocks = append(blocks, nil)
+		}
+		blocks[len(blocks)-1] = append(blocks[len(blocks)-1], ticket.ID)
+	}
+
+	for _, block := range blocks {
+		if len(block) >= quantity {
+			return block[:quantity]
+		}
+	}
+
+	// Stable so that blocks of the same size keep seat map order
+	slices.SortStableFunc(blocks, func(a, b []uuid.UUID) int {
+		return len(b) - len(a)
+	})
+	picked := make([]uuid.UUID, 0, quantity)
+	for _, block := range blocks {
+		picked = append(picked, block[:min(len(block), quantity-len(picked))]...)
+		if len(picked) == quantity {
+			break
+		}
+	}
+	return picked
+}
+
+// adjacentSeats reports whether b is the seat right after a in the same row.
+// Unseated tickets are all adjacent to each other.
+func adjacentSeats(a, b database.ListAvailableTicketsForTypeRow) bool {
+	if !a.SectionID.Valid || !b.SectionID.Valid {
+		return !a.SectionID.Valid && !b.SectionID.Valid
+	}
+	return a.SectionID.UUID == b.SectionID.UUID &&
+		a.RowIndex.Int32 == b.RowIndex.Int32 &&
+		a.SeatNumber.Int32+1 == b.SeatNumber.Int32
+}
@@ -0,0 +1,750 @@
+package booking
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/ignisrex/tix/booking/internal/database"
+	"github.com/ignisrex/tix/booking/internal/payment"
+	"github.com/ignisrex/tix/booking/internal/redis"
+	"github.com/ignisrex/tix/booking/types"
+)
+
+// fakeRepo keeps tickets, purchases, payment attempts and refunds in memory. Methods
+// a test does not need are left to the embedded nil interface and panic.
+type fakeRepo struct {
+	PurchaseRepo
+
+	mu        sync.Mutex
+	tickets   map[uuid.UUID]types.Ticket
+	purchases map[uuid.UUID]database.Purchase
+	attempts  map[uuid.UUID]database.PaymentAttempt
+	ticketOf  map[uuid.UUID]uuid.UUID // ticket ID -> purchase ID
+	refunds   map[uuid.UUID]database.Refund
+	refunded  map[uuid.UUID][]uuid.UUID // refund ID -> ticket IDs
+}
+
+func newFakeRepo(tickets ...types.Ticket) *fakeRepo {
+	r := &fakeRepo{
+		tickets:   make(map[uuid.UUID]types.Ticket),
+		purchases: make(map[uuid.UUID]database.Purchase),
+		attempts:  make(map[uuid.UUID]database.PaymentAttempt),
+		ticketOf:  make(map[uuid.UUID]uuid.UUID),
+		refunds:   make(map[uuid.UUID]database.Refund),
+		refunded:  make(map[uuid.UUID][]uuid.UUID),
+	}
+	for _, ticket := range tickets {
+		r.tickets[ticket.ID] = ticket
+	}
+	return r
+}
+
+func (r *fakeRepo) GetTicketsWithPrice(ctx context.Context, ticketIDs []uuid.UUID) ([]types.Ticket, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	var tickets []types.Ticket
+	for _, id := range ticketIDs {
+		if ticket, ok := r.tickets[id]; ok {
+			tickets = append(tickets, ticket)
+		}
+	}
+	return tickets, nil
+}
+
+func (r *fakeRepo) CreatePurchase(ctx context.Context, totalCents int32, customerID uuid.UUID) (database.Purchase, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	purchase := database.Purchase{
+		ID:         uuid.New(),
+		TotalCents: totalCents,
+		Status:     database.PurchaseStatusPendingPayment,
+		CustomerID: uuid.NullUUID{UUID: customerID, Valid: customerID != uuid.Nil},
+	}
+	r.purchases[purchase.ID] = purchase
+	return purchase, nil
+}
+
+func (r *fakeRepo) SellTickets(ctx context.Context, purchaseID uuid.UUID, ticketIDs []uuid.UUID, reason string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	var unavailable []uuid.UUID
+	for _, id := range ticketIDs {
+		if r.tickets[id].Status != string(database.TicketStatusAvailable) {
+			unavailable = append(unavailable, id)
+		}
+	}
+	if len(unavailable) > 0 {
+		return &TicketsSoldError{TicketIDs: unavailable}
+	}
+	if err := r.transition(purchaseID, database.PurchaseStatusPendingPayment, database.PurchaseStatusPaid); err != nil {
+		return err
+	}
+	for _, id := range ticketIDs {
+		ticket := r.tickets[id]
+		ticket.Status = string(database.TicketStatusSold)
+		r.tickets[id] = ticket
+		r.ticketOf[id] = purchaseID
+	}
+	return nil
+}
+
+func (r *fakeRepo) TransitionPurchase(ctx context.Context, purchaseID uuid.UUID, from, to database.PurchaseStatus, reason string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.transition(purchaseID, from, to)
+}
+
+func (r *fakeRepo) transition(purchaseID uuid.UUID, from, to database.PurchaseStatus) error {
+	purchase, ok := r.purchases[purchaseID]
+	if !ok || purchase.Status != from {
+		return fmt.Errorf("%w: purchase %s is no longer %s", ErrInvalidTransition, purchaseID, from)
+	}
+	purchase.Status = to
+	r.purchases[purchaseID] = purchase
+	return nil
+}
+
+func (r *fakeRepo) GetPurchase(ctx context.Context, purchaseID uuid.UUID) (database.Purchase, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	purchase, ok := r.purchases[purchaseID]
+	if !ok {
+		return database.Purchase{}, sql.ErrNoRows
+	}
+	return purchase, nil
+}
+
+func (r *fakeRepo) CreatePaymentAttempt(ctx context.Context, holdID uuid.UUID, amountCents int32, purchaseID uuid.UUID) (database.PaymentAttempt, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	attempt := database.PaymentAttempt{
+		ID:          uuid.New(),
+		HoldID:      holdID,
+		AmountCents: amountCents,
+		Status:      database.PaymentAttemptStatusPending,
+		PurchaseID:  uuid.NullUUID{UUID: purchaseID, Valid: true},
+		CreatedAt:   time.Now(),
+		UpdatedAt:   time.Now(),
+	}
+	r.attempts[attempt.ID] = attempt
+	return attempt, nil
+}
+
+// setAttempt moves a payment attempt to status if it is in one of from,
+// like the guarded UPDATEs of the payment queries
+func (r *fakeRepo) setAttempt(id uuid.UUID, status database.PaymentAttemptStatus, from []database.PaymentAttemptStatus, update func(*database.PaymentAttempt)) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	attempt, ok := r.attempts[id]
+	if !ok {
+		return sql.ErrNoRows
+	}
+	allowed := false
+	for _, f := range from {
+		allowed = allowed || attempt.Status == f
+	}
+	if !allowed {
+		return fmt.Errorf("%w: payment attempt %s is %s, not %v", ErrPaymentSettled, id, attempt.Status, from)
+	}
+	attempt.Status = status
+	attempt.UpdatedAt = time.Now()
+	if update != nil {
+		update(&attempt)
+	}
+	r.attempts[id] = attempt
+	return nil
+}
+
+func (r *fakeRepo) MarkPaymentAuthorized(ctx context.Context, id uuid.UUID, authorizationID string) error {
+	return r.setAttempt(id, database.PaymentAttemptStatusAuthorized, []database.PaymentAttemptStatus{database.PaymentAttemptStatusPending}, func(a *database.PaymentAttempt) {
+		a.AuthorizationID = sql.NullString{String: authorizationID, Valid: true}
+	})
+}
+
+func (r *fakeRepo) MarkPaymentCaptured(ctx context.Context, id uuid.UUID) error {
+	return r.setAttempt(id, database.PaymentAttemptStatusCaptured, []database.PaymentAttemptStatus{database.PaymentAttemptStatusAuthorized}, nil)
+}
+
+func (r *fakeRepo) MarkPaymentVoided(ctx context.Context, id uuid.UUID, reason string) error {
+	return r.setAttempt(id, database.PaymentAttemptStatusVoided, []database.PaymentAttemptStatus{database.PaymentAttemptStatusAuthorized}, func(a *database.PaymentAttempt) {
+		a.FailureReason = sql.NullString{String: reason, Valid: true}
+	})
+}
+
+func (r *fakeRepo) MarkPaymentFailed(ctx context.Context, id uuid.UUID, reason string) error {
+	return r.setAttempt(id, database.PaymentAttemptStatusFailed, []database.PaymentAttemptStatus{database.PaymentAttemptStatusPending}, func(a *database.PaymentAttempt) {
+		a.FailureReason = sql.NullString{String: reason, Valid: true}
+	})
+}
+
+func (r *fakeRepo) ClaimUnsettledPaymentAttempts(ctx context.Context, updatedBefore time.Time, batchSize int32) ([]database.ClaimUnsettledPaymentAttemptsRow, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	var claimed []database.ClaimUnsettledPaymentAttemptsRow
+	for id, attempt := range r.attempts {
+		if len(claimed) == int(batchSize) {
+			break
+		}
+		unsettled := attempt.Status == database.PaymentAttemptStatusPending || attempt.Status == database.PaymentAttemptStatusAuthorized
+		if !unsettled || !attempt.UpdatedAt.Before(updatedBefore) {
+			continue
+		}
+		attempt.UpdatedAt = time.Now()
+		r.attempts[id] = attempt
+
+		row := database.ClaimUnsettledPaymentAttemptsRow{
+			ID:              attempt.ID,
+			Status:          attempt.Status,
+			AuthorizationID: attempt.AuthorizationID,
+			PurchaseID:      attempt.PurchaseID,
+		}
+		if purchase, ok := r.purchases[attempt.PurchaseID.UUID]; ok {
+			row.PurchaseStatus = database.NullPurchaseStatus{PurchaseStatus: purchase.Status, Valid: true}
+		}
+		claimed = append(claimed, row)
+	}
+	return claimed, nil
+}
+
+func (r *fakeRepo) GetPurchaseDetails(ctx context.Context, purchaseID uuid.UUID) (database.GetPurchaseDetailsRow, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	purchase, ok := r.purchases[purchaseID]
+	if !ok {
+		return database.GetPurchaseDetailsRow{}, sql.ErrNoRows
+	}
+
+	// Tickets returned to inventory are found through their refund
+	tickets := []types.PurchaseTicketDetail{}
+	for id, owner := range r.ticketOf {
+		if owner == purchaseID {
+			tickets = append(tickets, types.PurchaseTicketDetail{ID: id, Status: r.tickets[id].Status})
+		}
+	}
+	for refundID, ticketIDs := range r.refunded {
+		refund := r.refunds[refundID]
+		if refund.PurchaseID != purchaseID || refund.Status != database.RefundStatusSucceeded || !refund.ReturnedToInventory {
+			continue
+		}
+		for _, id := range ticketIDs {
+			tickets = append(tickets, types.PurchaseTicketDetail{ID: id, Status: string(database.TicketStatusRefunded)})
+		}
+	}
+	ticketsJSON, err := json.Marshal(tickets)
+	if err != nil {
+		return database.GetPurchaseDetailsRow{}, err
+	}
+
+	return database.GetPurchaseDetailsRow{
+		PurchaseID: purchase.ID,
+		TotalCents: purchase.TotalCents,
+		Status:     purchase.Status,
+		CustomerID: purchase.CustomerID,
+		Tickets:    ticketsJSON,
+	}, nil
+}
+
+func (r *fakeRepo) ListPurchaseRefunds(ctx context.Context, purchaseID uuid.UUID) ([]database.ListPurchaseRefundsRow, error) {
+	return nil, nil
+}
+
+func (r *fakeRepo) ListPurchaseStatusTransitions(ctx context.Context, purchaseID uuid.UUID) ([]database.PurchaseStatusTransition, error) {
+	return nil, nil
+}
+
+func (r *fakeRepo) ListPurchasePaymentAttempts(ctx context.Context, purchaseID uuid.UUID) ([]database.PaymentAttempt, error) {
+	return nil, nil
+}
+
+func (r *fakeRepo) GetCapturedPaymentForPurchase(ctx context.Context, purchaseID uuid.UUID) (database.PaymentAttempt, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for _, attempt := range r.attempts {
+		if attempt.PurchaseID.UUID == purchaseID && attempt.Status == database.PaymentAttemptStatusCaptured {
+			return attempt, nil
+		}
+	}
+	return database.PaymentAttempt{}, sql.ErrNoRows
+}
+
+// refundPending reports whether a ticket is part of a pending refund
+func (r *fakeRepo) refundPending(ticketID uuid.UUID) bool {
+	for refundID, ticketIDs := range r.refunded {
+		if r.refunds[refundID].Status == database.RefundStatusPending && slices.Contains(ticketIDs, ticketID) {
+			return true
+		}
+	}
+	return false
+}
+
+func (r *fakeRepo) CreatePendingRefund(ctx context.Context, purchaseID uuid.UUID, capturedCents int32, ticketIDs []uuid.UUID, returnToInventory bool, reason string) (database.Refund, []uuid.UUID, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if len(ticketIDs) == 0 {
+		for id, owner := range r.ticketOf {
+			if owner == purchaseID && r.tickets[id].Status == string(database.TicketStatusSold) && !r.refundPending(id) {
+				ticketIDs = append(ticketIDs, id)
+			}
+		}
+	}
+	if len(ticketIDs) == 0 {
+		return database.Refund{}, nil, fmt.Errorf("%w: no tickets left to refund", ErrTicketAlreadyRefunded)
+	}
+
+	amountCents := int32(0)
+	for _, id := range ticketIDs {
+		switch {
+		case r.ticketOf[id] != purchaseID:
+			return database.Refund{}, nil, fmt.Errorf("%w: %s", ErrTicketNotInPurchase, id)
+		case r.tickets[id].Status != string(database.TicketStatusSold):
+			return database.Refund{}, nil, fmt.Errorf("%w: %s", ErrTicketAlreadyRefunded, id)
+		case r.refundPending(id):
+			return database.Refund{}, nil, fmt.Errorf("%w: %s", ErrRefundInProgress, id)
+		}
+		amountCents += r.tickets[id].PriceCents
+	}
+
+	remaining := capturedCents
+	for _, refund := range r.refunds {
+		if refund.PurchaseID == purchaseID && refund.Status != database.RefundStatusFailed {
+			remaining -= refund.AmountCents
+		}
+	}
+	if remaining <= 0 {
+		return database.Refund{}, nil, fmt.Errorf("%w: purchase %s", ErrPaymentRefunded, purchaseID)
+	}
+	amountCents = min(amountCents, remaining)
+
+	refund := database.Refund{
+		ID:                  uuid.New(),
+		PurchaseID:          purchaseID,
+		AmountCents:         amountCents,
+		ReturnedToInventory: returnToInventory,
+		Reason:              sql.NullString{String: reason, Valid: reason != ""},
+		Status:              database.RefundStatusPending,
+		CreatedAt:           time.Now(),
+		UpdatedAt:           time.Now(),
+	}
+	r.refunds[refund.ID] = refund
+	r.refunded[refund.ID] = ticketIDs
+	return refund, ticketIDs, nil
+}
+
+func (r *fakeRepo) SettleRefund(ctx context.Context, refundID uuid.UUID, providerRefundID string) ([]uuid.UUID, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	refund := r.refunds[refundID]
+	if refund.Status != database.RefundStatusPending {
+		return nil, fmt.Errorf("%w: refund %s is %s", ErrRefundSettled, refundID, refund.Status)
+	}
+
+	status := database.TicketStatusRefunded
+	if refund.ReturnedToInventory {
+		status = database.TicketStatusAvailable
+	}
+	for _, id := range r.refunded[refundID] {
+		ticket := r.tickets[id]
+		ticket.Status = string(status)
+		r.tickets[id] = ticket
+		if refund.ReturnedToInventory {
+			// Like ReturnTicketsToInventory, which clears the ticket's purchase
+			delete(r.ticketOf, id)
+		}
+	}
+
+	next := database.PurchaseStatusRefunded
+	for id, owner := range r.ticketOf {
+		if owner == refund.PurchaseID && r.tickets[id].Status == string(database.TicketStatusSold) {
+			next = database.PurchaseStatusPartiallyRefunded
+		}
+	}
+	if err := r.transition(refund.PurchaseID, r.purchases[refund.PurchaseID].Status, next); err != nil {
+		return nil, err
+	}
+
+	refund.Status = database.RefundStatusSucceeded
+	refund.ProviderRefundID = sql.NullString{String: providerRefundID, Valid: true}
+	r.refunds[refundID] = refund
+	return r.refunded[refundID], nil
+}
+
+func (r *fakeRepo) FailRefund(ctx context.Context, refundID uuid.UUID, reason string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	refund := r.refunds[refundID]
+	if refund.Status != database.RefundStatusPending {
+		return fmt.Errorf("%w: refund %s", ErrRefundSettled, refundID)
+	}
+	refund.Status = database.RefundStatusFailed
+	refund.FailureReason = sql.NullString{String: reason, Valid: true}
+	r.refunds[refundID] = refund
+	return nil
+}
+
+func (r *fakeRepo) ClaimPendingRefunds(ctx context.Context, updatedBefore time.Time, batchSize int32) ([]database.Refund, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	var claimed []database.Refund
+	for id, refund := range r.refunds {
+		if len(claimed) == int(batchSize) {
+			break
+		}
+		if refund.Status != database.RefundStatusPending || !refund.UpdatedAt.Before(updatedBefore) {
+			continue
+		}
+		refund.UpdatedAt = time.Now()
+		r.refunds[id] = refund
+		claimed = append(claimed, refund)
+	}
+	return claimed, nil
+}
+
+// addSoldPurchase records a paid purchase of tickets at prices along with
+// its captured payment
+func (r *fakeRepo) addSoldPurchase(prices ...int32) (uuid.UUID, []uuid.UUID) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	purchase := database.Purchase{ID: uuid.New(), Status: database.PurchaseStatusPaid}
+	var ticketIDs []uuid.UUID
+	for _, price := range prices {
+		ticket := newTestTicket(price)
+		ticket.Status = string(database.TicketStatusSold)
+		r.tickets[ticket.ID] = ticket
+		r.ticketOf[ticket.ID] = purchase.ID
+		ticketIDs = append(ticketIDs, ticket.ID)
+		purchase.TotalCents += price
+	}
+	r.purchases[purchase.ID] = purchase
+
+	attempt := database.PaymentAttempt{
+		ID:          uuid.New(),
+		AmountCents: purchase.TotalCents,
+		Status:      database.PaymentAttemptStatusCaptured,
+		PurchaseID:  uuid.NullUUID{UUID: purchase.ID, Valid: true},
+	}
+	attempt.AuthorizationID = sql.NullString{String: "auth_" + attempt.ID.String(), Valid: true}
+	r.attempts[attempt.ID] = attempt
+	return purchase.ID, ticketIDs
+}
+
+func (r *fakeRepo) refund(id uuid.UUID) database.Refund {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.refunds[id]
+}
+
+// ageRefunds makes every refund look untouched for age
+func (r *fakeRepo) ageRefunds(age time.Duration) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for id, refund := range r.refunds {
+		refund.UpdatedAt = time.Now().Add(-age)
+		r.refunds[id] = refund
+	}
+}
+
+// addAttempt records a purchase in purchaseStatus with a payment attempt in
+// status that last moved age ago
+func (r *fakeRepo) addAttempt(status database.PaymentAttemptStatus, purchaseStatus database.PurchaseStatus, age time.Duration) database.PaymentAttempt {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	purchase := database.Purchase{ID: uuid.New(), TotalCents: 2500, Status: purchaseStatus}
+	r.purchases[purchase.ID] = purchase
+
+	attempt := database.PaymentAttempt{
+		ID:          uuid.New(),
+		HoldID:      uuid.New(),
+		AmountCents: 2500,
+		Status:      status,
+		PurchaseID:  uuid.NullUUID{UUID: purchase.ID, Valid: true},
+		CreatedAt:   time.Now().Add(-age),
+		UpdatedAt:   time.Now().Add(-age),
+	}
+	if status != database.PaymentAttemptStatusPending {
+		attempt.AuthorizationID = sql.NullString{String: "auth_" + attempt.ID.String(), Valid: true}
+	}
+	r.attempts[attempt.ID] = attempt
+	return attempt
+}
+
+func (r *fakeRepo) attempt(id uuid.UUID) database.PaymentAttempt {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.attempts[id]
+}
+
+func (r *fakeRepo) purchase(id uuid.UUID) database.Purchase {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.purchases[id]
+}
+
+func (r *fakeRepo) ticket(id uuid.UUID) types.Ticket {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.tickets[id]
+}
+
+// attemptFor returns the only payment attempt of a purchase
+func (r *fakeRepo) attemptFor(purchaseID uuid.UUID) (database.PaymentAttempt, bool) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for _, attempt := range r.attempts {
+		if attempt.PurchaseID.UUID == purchaseID {
+			return attempt, true
+		}
+	}
+	return database.PaymentAttempt{}, false
+}
+
+// fakeHoldStore keeps holds in memory with the ownership rules of the Redis
+// scripts: a ticket belongs to at most one hold and only its hold can
+// refresh or release it.
+type fakeHoldStore struct {
+	HoldStore
+
+	mu        sync.Mutex
+	holds     map[uuid.UUID]uuid.UUID // ticket ID -> hold ID
+	owners    map[uuid.UUID]uuid.UUID // hold ID -> subject who created it
+	published map[uuid.UUID]string    // ticket ID -> last published status
+
+	queued   map[uuid.UUID][]uuid.UUID // event ID -> waiting visitors
+	admitted map[uuid.UUID]bool        // visitor ID -> admission window open
+	joins    map[string]int64          // client address -> anonymous joins
+}
+
+func newFakeHoldStore() *fakeHoldStore {
+	return &fakeHoldStore{
+		holds:     make(map[uuid.UUID]uuid.UUID),
+		owners:    make(map[uuid.UUID]uuid.UUID),
+		published: make(map[uuid.UUID]string),
+		queued:    make(map[uuid.UUID][]uuid.UUID),
+		admitted:  make(map[uuid.UUID]bool),
+		joins:     make(map[string]int64),
+	}
+}
+
+func (h *fakeHoldStore) ReserveTickets(ctx context.Context, ticketIDs []uuid.UUID, holdID uuid.UUID, ownerID uuid.UUID) error {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if h.holdExists(holdID) && h.owners[holdID] != ownerID {
+		return fmt.Errorf("%w: hold %s", redis.ErrHoldMismatch, holdID)
+	}
+	for _, id := range ticketIDs {
+		if owner, ok := h.holds[id]; ok && owner != holdID {
+			return fmt.Errorf("%w: %s", redis.ErrAlreadyReserved, id)
+		}
+	}
+	for _, id := range ticketIDs {
+		h.holds[id] = holdID
+	}
+	if ownerID != uuid.Nil {
+		h.owners[holdID] = ownerID
+	}
+	return nil
+}
+
+func (h *fakeHoldStore) holdExists(holdID uuid.UUID) bool {
+	for _, held := range h.holds {
+		if held == holdID {
+			return true
+		}
+	}
+	return false
+}
+
+func (h *fakeHoldStore) GetHold(ctx context.Context, holdID uuid.UUID) (*redis.Hold, error) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	hold := &redis.Hold{ID: holdID, Owner: h.owners[holdID], Tickets: make(map[uuid.UUID]time.Duration)}
+	for ticketID, held := range h.holds {
+		if held == holdID {
+			hold.Tickets[ticketID] = time.Minute
+		}
+	}
+	if len(hold.Tickets) == 0 {
+		return nil, redis.ErrHoldNotFound
+	}
+	return hold, nil
+}
+
+func (h *fakeHoldStore) RefreshTickets(ctx context.Context, ticketIDs []uuid.UUID, holdID uuid.UUID, ttl time.Duration) error {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	return h.checkOwner(ticketIDs, holdID)
+}
+
+func (h *fakeHoldStore) ReleaseTickets(ctx context.Context, ticketIDs []uuid.UUID, holdID uuid.UUID) error {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if err := h.checkOwner(ticketIDs, holdID); err != nil {
+		return err
+	}
+	for _, id := range ticketIDs {
+		delete(h.holds, id)
+	}
+	return nil
+}
+
+func (h *fakeHoldStore) checkOwner(ticketIDs []uuid.UUID, holdID uuid.UUID) error {
+	for _, id := range ticketIDs {
+		owner, ok := h.holds[id]
+		if !ok {
+			return fmt.Errorf("%w: %s", redis.ErrHoldNotFound, id)
+		}
+		if owner != holdID {
+			return fmt.Errorf("%w: %s", redis.ErrHoldMismatch, id)
+		}
+	}
+	return nil
+}
+
+// IsQueued reports the events opened with openQueue as having a waiting room
+func (h *fakeHoldStore) IsQueued(ctx context.Context, eventID uuid.UUID) (bool, error) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	_, ok := h.queued[eventID]
+	return ok, nil
+}
+
+func (h *fakeHoldStore) openQueue(eventID uuid.UUID) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.queued[eventID] = []uuid.UUID{}
+}
+
+// admit opens the visitor's admission window without them queueing first
+func (h *fakeHoldStore) admit(visitorID uuid.UUID, open bool) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.admitted[visitorID] = open
+}
+
+func (h *fakeHoldStore) JoinQueue(ctx context.Context, eventID uuid.UUID, visitorID uuid.UUID) (redis.QueuePosition, error) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	waiting, ok := h.queued[eventID]
+	if !ok {
+		return redis.QueuePosition{}, redis.ErrQueueNotFound
+	}
+	for n, id := range waiting {
+		if id == visitorID {
+			return redis.QueuePosition{Position: int64(n + 1)}, nil
+		}
+	}
+	h.queued[eventID] = append(waiting, visitorID)
+	return redis.QueuePosition{Position: int64(len(waiting) + 1)}, nil
+}
+
+func (h *fakeHoldStore) GetQueue(ctx context.Context, eventID uuid.UUID) (redis.QueueConfig, int64, error) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	return redis.QueueConfig{BatchSize: 10, IntervalSeconds: 10}, int64(len(h.queued[eventID])), nil
+}
+
+func (h *fakeHoldStore) GetQueuePosition(ctx context.Context, eventID uuid.UUID, visitorID uuid.UUID) (redis.QueuePosition, error) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if h.admitted[visitorID] {
+		return redis.QueuePosition{Admitted: true, AdmittedFor: time.Minute}, nil
+	}
+	return redis.QueuePosition{}, redis.ErrQueuePositionNotFound
+}
+
+func (h *fakeHoldStore) CountQueueJoin(ctx context.Context, eventID uuid.UUID, addr string, window time.Duration) (int64, error) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.joins[addr]++
+	return h.joins[addr], nil
+}
+
+func (h *fakeHoldStore) TrackHolds(ctx context.Context, eventID uuid.UUID, ticketIDs []uuid.UUID, ttl time.Duration) error {
+	return nil
+}
+
+func (h *fakeHoldStore) UntrackHolds(ctx context.Context, eventID uuid.UUID, ticketIDs []uuid.UUID) error {
+	return nil
+}
+
+func (h *fakeHoldStore) PublishTicketStatus(ctx context.Context, ticketIDs []uuid.UUID, status string) error {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	for _, id := range ticketIDs {
+		h.published[id] = status
+	}
+	return nil
+}
+
+func (h *fakeHoldStore) holder(ticketID uuid.UUID) (uuid.UUID, bool) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	holdID, ok := h.holds[ticketID]
+	return holdID, ok
+}
+
+func newTestTicket(priceCents int32) types.Ticket {
+	return types.Ticket{
+		ID:             uuid.New(),
+		EventID:        uuid.New(),
+		TicketTypeID:   uuid.New(),
+		Status:         string(database.TicketStatusAvailable),
+		PriceCents:     priceCents,
+		TicketTypeName: "general",
+	}
+}
+
+// recordingProvider is the deterministic provider that records the
+// authorizations it captures and voids and the refunds it makes, and fails
+// them on demand
+type recordingProvider struct {
+	*payment.Deterministic
+
+	mu         sync.Mutex
+	captured   []string
+	voided     []string
+	refunded   []string // refund references
+	captureErr error
+	voidErr    error
+	refundErr  error
+}
+
+func newRecordingProvider() *recordingProvider {
+	return &recordingProvider{Deterministic: payment.NewDeterministic()}
+}
+
+func (p *recordingProvider) Capture(ctx context.Context, authorizationID string) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.captureErr != nil {
+		return p.captureErr
+	}
+	p.captured = append(p.captured, authorizationID)
+	return nil
+}
+
+func (p *recordingProvider) Void(ctx context.Context, authorizationID string) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.voidErr != nil {
+		return p.voidErr
+	}
+	p.voided = append(p.voided, authorizationID)
+	return nil
+}
+
+func (p *recordingProvider) Refund(ctx context.Context, reference string, authorizationID string, amountCents int32) (string, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.refundErr != nil {
+		return "", p.refundErr
+	}
+	p.refunded = append(p.refunded, reference)
+	return p.Deterministic.Refund(ctx, reference, authorizationID, amountCents)
+}
@@ -1,37 +1,63 @@
 package booking
 
 import (
+	"database/sql"
 	"errors"
 	"fmt"
+	"net"
 	"net/http"
 
 	"github.com/go-chi/chi/v5"
 	"github.com/google/uuid"
 
+	"github.com/ignisrex/tix/auth"
 	"github.com/ignisrex/tix/booking/internal/database"
+	"github.com/ignisrex/tix/booking/internal/idempotency"
+	"github.com/ignisrex/tix/booking/internal/payment"
+	"github.com/ignisrex/tix/booking/internal/queue"
 	"github.com/ignisrex/tix/booking/internal/redis"
 	"github.com/ignisrex/tix/booking/internal/utils"
 	"github.com/ignisrex/tix/booking/types"
 )
 
+// Waiting room tokens are sent in headers rather than the query string so
+// they don't end up in access logs
+const (
+	QueueTokenHeader     = "X-Queue-Token"
+	AdmissionTokenHeader = "X-Admission-Token"
+)
+
 type Handler struct {
-	service *Service
+	service     *Service
+	idempotency *idempotency.Store
 }
 
-func NewHandler(queries *database.Queries, redisClient *redis.Client) *Handler {
-	repo := NewRepo(queries)
-	service := NewService(repo, redisClient)
+func NewHandler(db *sql.DB, queries *database.Queries, redisClient *redis.Client, paymentProvider payment.PaymentProvider, queueTokens *queue.Signer) *Handler {
+	repo := NewRepo(db, queries)
+	service := NewService(repo, redisClient, paymentProvider, queueTokens)
 	return &Handler{
-		service: service,
+		service:     service,
+		idempotency: idempotency.NewStore(queries),
 	}
 }
 
 func (h *Handler) RegisterRoutes(r chi.Router) {
 	r.Route("/booking", func(r chi.Router) {
-		r.Post("/reserve", h.handleReserve)
-		r.Post("/purchase", h.handlePurchase)
+		r.With(h.idempotency.Middleware).Post("/reserve", h.handleReserve)
+		r.With(h.idempotency.Middleware).Post("/reserve/best-available", h.handleReserveBestAvailable)
+		r.With(h.idempotency.Middleware).Post("/purchase", h.handlePurchase)
+		r.With(auth.RequireRole(auth.RoleAdmin, auth.RoleBoxOffice), h.idempotency.Middleware).Post("/comp", h.handleComp)
 		r.Get("/purchases/{id}", h.handleGetPurchase)
+		r.With(auth.RequireRole(auth.RoleAdmin), h.idempotency.Middleware).Post("/purchases/{id}/refund", h.handleRefundPurchase)
 		r.Post("/locks/check", h.handleCheckLocks)
+		r.Get("/holds/{id}", h.handleGetHold)
+		r.Post("/holds/{id}/extend", h.handleExtendHold)
+		r.Delete("/holds/{id}", h.handleReleaseHold)
+		r.Get("/queue/{event_id}", h.handleGetQueue)
+		r.With(auth.RequireRole(auth.RoleAdmin)).Put("/queue/{event_id}", h.handleConfigureQueue)
+		r.With(auth.RequireRole(auth.RoleAdmin)).Delete("/queue/{event_id}", h.handleRemoveQueue)
+		r.Post("/queue/{event_id}/join", h.handleJoinQueue)
+		r.Get("/queue/{event_id}/status", h.handleGetQueueStatus)
 	})
 }
 
@@ -47,7 +73,7 @@ func (h *Handler) handleReserve(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	reservedIDs, err := h.service.ReserveTickets(r.Context(), req.TicketIDs)
+	reservedIDs, holdID, err := h.service.ReserveTickets(r.Context(), req.TicketIDs, req.HoldID, r.Header.Get(AdmissionTokenHeader))
 	if err != nil {
 		status := http.StatusInternalServerError
 		message := "failed to reserve tickets"
@@ -62,12 +88,84 @@ func (h *Handler) handleReserve(w http.ResponseWriter, r *http.Request) {
 		case errors.Is(err, ErrTicketReserved):
 			status = http.StatusConflict
 			message = err.Error()
+		case errors.Is(err, ErrHoldMismatch):
+			status = http.StatusForbidden
+			message = "the hold belongs to another customer"
+		case errors.Is(err, ErrOrderLimit):
+			status = http.StatusUnprocessableEntity
+			message = err.Error()
+		case errors.Is(err, ErrAdmissionRequired):
+			status = http.StatusForbidden
+			message = err.Error()
+		}
+
+		response := types.ReserveResponse{
+			Success:   false,
+			Message:   message,
+			TicketIDs: []uuid.UUID{},
+			HoldID:    uuid.Nil,
+		}
+		utils.WriteJSON(w, status, response)
+		return
+	}
+
+	resp := types.ReserveResponse{
+		Success:   true,
+		Message:   "tickets reserved successfully",
+		TicketIDs: reservedIDs,
+		HoldID:    holdID,
+	}
+	utils.WriteJSON(w, http.StatusOK, resp)
+}
+
+func (h *Handler) handleReserveBestAvailable(w http.ResponseWriter, r *http.Request) {
+	var req types.BestAvailableRequest
+	if err := utils.ParseJSON(r, &req); err != nil {
+		utils.WriteError(w, http.StatusBadRequest, fmt.Errorf("invalid request body: %w", err))
+		return
+	}
+
+	if req.EventID == uuid.Nil || req.TicketTypeID == uuid.Nil {
+		utils.WriteError(w, http.StatusBadRequest, fmt.Errorf("event_id and ticket_type_id are required"))
+		return
+	}
+
+	if req.Quantity <= 0 {
+		utils.WriteError(w, http.StatusBadRequest, fmt.Errorf("quantity must be positive"))
+		return
+	}
+
+	reservedIDs, holdID, err := h.service.ReserveBestAvailable(r.Context(), req.EventID, req.TicketTypeID, req.Quantity, req.HoldID, r.Header.Get(AdmissionTokenHeader))
+	if err != nil {
+		status := http.StatusInternalServerError
+		message := "failed to reserve tickets"
+
+		switch {
+		case errors.Is(err, ErrTicketTypeNotFound):
+			status = http.StatusNotFound
+			message = "ticket type not found for event"
+		case errors.Is(err, ErrNotEnoughTickets):
+			status = http.StatusConflict
+			message = err.Error()
+		case errors.Is(err, ErrTicketReserved), errors.Is(err, ErrTicketSold):
+			status = http.StatusConflict
+			message = "tickets kept being taken by other customers, please try again"
+		case errors.Is(err, ErrHoldMismatch):
+			status = http.StatusForbidden
+			message = "the hold belongs to another customer"
+		case errors.Is(err, ErrOrderLimit):
+			status = http.StatusUnprocessableEntity
+			message = err.Error()
+		case errors.Is(err, ErrAdmissionRequired):
+			status = http.StatusForbidden
+			message = err.Error()
 		}
 
 		response := types.ReserveResponse{
 			Success:   false,
 			Message:   message,
 			TicketIDs: []uuid.UUID{},
+			HoldID:    uuid.Nil,
 		}
 		utils.WriteJSON(w, status, response)
 		return
@@ -77,6 +175,7 @@ func (h *Handler) handleReserve(w http.ResponseWriter, r *http.Request) {
 		Success:   true,
 		Message:   "tickets reserved successfully",
 		TicketIDs: reservedIDs,
+		HoldID:    holdID,
 	}
 	utils.WriteJSON(w, http.StatusOK, resp)
 }
@@ -93,29 +192,64 @@ func (h *Handler) handlePurchase(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	purchaseID, totalCents, err := h.service.PurchaseTickets(r.Context(), req.TicketIDs)
+	if req.HoldID == uuid.Nil {
+		utils.WriteError(w, http.StatusBadRequest, fmt.Errorf("hold_id is required"))
+		return
+	}
+
+	if req.PaymentToken == "" {
+		utils.WriteError(w, http.StatusBadRequest, fmt.Errorf("payment_token is required"))
+		return
+	}
+
+	customerID, status, err := purchaseCustomerID(r, req.CustomerID)
+	if err != nil {
+		utils.WriteError(w, status, err)
+		return
+	}
+
+	purchaseID, totalCents, err := h.service.PurchaseTickets(r.Context(), req.TicketIDs, req.HoldID, req.PaymentToken, customerID)
 	if err != nil {
 		status := http.StatusInternalServerError
 		message := "failed to purchase tickets"
+		var unavailable []uuid.UUID
 
+		var soldErr *TicketsSoldError
 		switch {
+		case errors.As(err, &soldErr):
+			status = http.StatusGone
+			message = "one or more tickets have already been sold"
+			unavailable = soldErr.TicketIDs
 		case errors.Is(err, ErrTicketNotFound):
 			status = http.StatusNotFound
 			message = "one or more tickets not found"
+		case errors.Is(err, ErrCustomerNotFound):
+			status = http.StatusNotFound
+			message = "customer not found"
 		case errors.Is(err, ErrTicketReserved):
 			status = http.StatusConflict
 			message = "one or more tickets are not reserved"
+		case errors.Is(err, ErrHoldMismatch):
+			status = http.StatusForbidden
+			message = "one or more tickets are held by another customer"
+		case errors.Is(err, ErrOrderLimit):
+			status = http.StatusUnprocessableEntity
+			message = err.Error()
+		case errors.Is(err, ErrPaymentTimeout):
+			status = http.StatusGatewayTimeout
+			message = err.Error()
 		case errors.Is(err, ErrPaymentFailed):
 			status = http.StatusPaymentRequired
 			message = err.Error()
 		}
 
 		response := types.PurchaseResponse{
-			Success:    false,
-			Message:    message,
-			TicketIDs:  []uuid.UUID{},
-			Total:      0,
-			PurchaseID: uuid.Nil,
+			Success:              false,
+			Message:              message,
+			TicketIDs:            []uuid.UUID{},
+			Total:                0,
+			PurchaseID:           purchaseID, // Set when a declined payment left a failed purchase
+			UnavailableTicketIDs: unavailable,
 		}
 		utils.WriteJSON(w, status, response)
 		return
@@ -131,6 +265,94 @@ func (h *Handler) handlePurchase(w http.ResponseWriter, r *http.Request) {
 	utils.WriteJSON(w, http.StatusOK, response)
 }
 
+func (h *Handler) handleComp(w http.ResponseWriter, r *http.Request) {
+	var req types.CompRequest
+	if err := utils.ParseJSON(r, &req); err != nil {
+		utils.WriteError(w, http.StatusBadRequest, fmt.Errorf("invalid request body: %w", err))
+		return
+	}
+
+	if len(req.TicketIDs) == 0 {
+		utils.WriteError(w, http.StatusBadRequest, fmt.Errorf("ticket_ids cannot be empty"))
+		return
+	}
+
+	if req.HoldID == uuid.Nil {
+		utils.WriteError(w, http.StatusBadRequest, fmt.Errorf("hold_id is required"))
+		return
+	}
+
+	purchaseID, err := h.service.CompTickets(r.Context(), req.TicketIDs, req.HoldID, req.CustomerID, req.Reason)
+	if err != nil {
+		status := http.StatusInternalServerError
+		message := "failed to issue complimentary tickets"
+		var unavailable []uuid.UUID
+
+		var soldErr *TicketsSoldError
+		switch {
+		case errors.As(err, &soldErr):
+			status = http.StatusGone
+			message = "one or more tickets have already been sold"
+			unavailable = soldErr.TicketIDs
+		case errors.Is(err, ErrTicketNotFound):
+			status = http.StatusNotFound
+			message = "one or more tickets not found"
+		case errors.Is(err, ErrCustomerNotFound):
+			status = http.StatusNotFound
+			message = "customer not found"
+		case errors.Is(err, ErrTicketReserved):
+			status = http.StatusConflict
+			message = "one or more tickets are not reserved"
+		case errors.Is(err, ErrHoldMismatch):
+			status = http.StatusForbidden
+			message = "one or more tickets are held by another customer"
+		}
+
+		response := types.PurchaseResponse{
+			Success:              false,
+			Message:              message,
+			TicketIDs:            []uuid.UUID{},
+			UnavailableTicketIDs: unavailable,
+		}
+		utils.WriteJSON(w, status, response)
+		return
+	}
+
+	response := types.PurchaseResponse{
+		Success:    true,
+		Message:    "complimentary tickets issued successfully",
+		TicketIDs:  req.TicketIDs,
+		Total:      0,
+		PurchaseID: purchaseID,
+	}
+	utils.WriteJSON(w, http.StatusOK, response)
+}
+
+// purchaseCustomerID decides who a purchase is attributed to. Customers
+// always buy for themselves, admins and box office staff may buy for any
+// customer, and anonymous callers can only make anonymous purchases.
+func purchaseCustomerID(r *http.Request, requested uuid.UUID) (uuid.UUID, int, error) {
+	principal, ok := auth.PrincipalFromContext(r.Context())
+	switch {
+	case !ok:
+		if requested != uuid.Nil {
+			return uuid.Nil, http.StatusUnauthorized, fmt.Errorf("customer_id requires an authenticated caller")
+		}
+		return uuid.Nil, http.StatusOK, nil
+	case principal.HasRole(auth.RoleAdmin, auth.RoleBoxOffice):
+		return requested, http.StatusOK, nil
+	case principal.HasRole(auth.RoleCustomer):
+		if requested != uuid.Nil && requested != principal.Subject {
+			return uuid.Nil, http.StatusForbidden, fmt.Errorf("customers can only purchase for themselves")
+		}
+		return principal.Subject, http.StatusOK, nil
+	}
+	if requested != uuid.Nil {
+		return uuid.Nil, http.StatusForbidden, fmt.Errorf("not allowed to purchase for a customer")
+	}
+	return uuid.Nil, http.StatusOK, nil
+}
+
 func (h *Handler) handleGetPurchase(w http.ResponseWriter, r *http.Request) {
 	idStr := chi.URLParam(r, "id")
 	id, err := uuid.Parse(idStr)
@@ -141,7 +363,7 @@ func (h *Handler) handleGetPurchase(w http.ResponseWriter, r *http.Request) {
 
 	response, err := h.service.GetPurchaseDetails(r.Context(), id)
 	if err != nil {
-		status := http.StatusInternalServerError
+		status := auth.ErrorStatus(err, http.StatusInternalServerError)
 		if errors.Is(err, ErrPurchaseNotFound) {
 			status = http.StatusNotFound
 		}
@@ -152,6 +374,45 @@ func (h *Handler) handleGetPurchase(w http.ResponseWriter, r *http.Request) {
 	utils.WriteJSON(w, http.StatusOK, response)
 }
 
+func (h *Handler) handleRefundPurchase(w http.ResponseWriter, r *http.Request) {
+	idStr := chi.URLParam(r, "id")
+	id, err := uuid.Parse(idStr)
+	if err != nil {
+		utils.WriteError(w, http.StatusBadRequest, fmt.Errorf("invalid purchase id: %w", err))
+		return
+	}
+
+	var req types.RefundRequest
+	if err := utils.ParseJSON(r, &req); err != nil {
+		utils.WriteError(w, http.StatusBadRequest, fmt.Errorf("invalid request body: %w", err))
+		return
+	}
+
+	refund, err := h.service.RefundPurchase(r.Context(), id, req.TicketIDs, req.ReturnToInventory, req.Reason)
+	if err != nil {
+		status := http.StatusInternalServerError
+		switch {
+		case errors.Is(err, ErrPurchaseNotFound):
+			status = http.StatusNotFound
+		case errors.Is(err, ErrTicketNotInPurchase):
+			status = http.StatusBadRequest
+		case errors.Is(err, ErrTicketAlreadyRefunded), errors.Is(err, ErrRefundInProgress), errors.Is(err, ErrPaymentNotCaptured), errors.Is(err, ErrPaymentRefunded), errors.Is(err, ErrInvalidTransition):
+			status = http.StatusConflict
+		case errors.Is(err, ErrRefundFailed):
+			status = http.StatusBadGateway
+		}
+		utils.WriteError(w, status, fmt.Errorf("failed to refund purchase: %w", err))
+		return
+	}
+
+	// The provider's answer was lost; the refund settles in the background
+	if refund.Status == string(database.RefundStatusPending) {
+		utils.WriteJSON(w, http.StatusAccepted, refund)
+		return
+	}
+	utils.WriteJSON(w, http.StatusOK, refund)
+}
+
 func (h *Handler) handleCheckLocks(w http.ResponseWriter, r *http.Request) {
 	var req types.CheckLocksRequest
 	if err := utils.ParseJSON(r, &req); err != nil {
@@ -182,3 +443,171 @@ func (h *Handler) handleCheckLocks(w http.ResponseWriter, r *http.Request) {
 
 	utils.WriteJSON(w, http.StatusOK, response)
 }
+
+func (h *Handler) handleGetHold(w http.ResponseWriter, r *http.Request) {
+	holdID, err := uuid.Parse(chi.URLParam(r, "id"))
+	if err != nil {
+		utils.WriteError(w, http.StatusBadRequest, fmt.Errorf("invalid hold id: %w", err))
+		return
+	}
+
+	response, err := h.service.GetHold(r.Context(), holdID)
+	if err != nil {
+		utils.WriteError(w, holdErrorStatus(err), fmt.Errorf("failed to get hold: %w", err))
+		return
+	}
+
+	utils.WriteJSON(w, http.StatusOK, response)
+}
+
+func (h *Handler) handleExtendHold(w http.ResponseWriter, r *http.Request) {
+	holdID, err := uuid.Parse(chi.URLParam(r, "id"))
+	if err != nil {
+		utils.WriteError(w, http.StatusBadRequest, fmt.Errorf("invalid hold id: %w", err))
+		return
+	}
+
+	response, err := h.service.ExtendHold(r.Context(), holdID)
+	if err != nil {
+		utils.WriteError(w, holdErrorStatus(err), fmt.Errorf("failed to extend hold: %w", err))
+		return
+	}
+
+	utils.WriteJSON(w, http.StatusOK, response)
+}
+
+func (h *Handler) handleReleaseHold(w http.ResponseWriter, r *http.Request) {
+	holdID, err := uuid.Parse(chi.URLParam(r, "id"))
+	if err != nil {
+		utils.WriteError(w, http.StatusBadRequest, fmt.Errorf("invalid hold id: %w", err))
+		return
+	}
+
+	if err := h.service.ReleaseHold(r.Context(), holdID); err != nil {
+		utils.WriteError(w, holdErrorStatus(err), fmt.Errorf("failed to release hold: %w", err))
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func holdErrorStatus(err error) int {
+	switch {
+	case errors.Is(err, ErrHoldNotFound):
+		return http.StatusNotFound
+	case errors.Is(err, ErrExtensionLimit):
+		return http.StatusConflict
+	}
+	return auth.ErrorStatus(err, http.StatusInternalServerError)
+}
+
+func (h *Handler) handleGetQueue(w http.ResponseWriter, r *http.Request) {
+	eventID, err := uuid.Parse(chi.URLParam(r, "event_id"))
+	if err != nil {
+		utils.WriteError(w, http.StatusBadRequest, fmt.Errorf("invalid event id: %w", err))
+		return
+	}
+
+	response, err := h.service.GetQueue(r.Context(), eventID)
+	if err != nil {
+		utils.WriteError(w, queueErrorStatus(err), fmt.Errorf("failed to get queue: %w", err))
+		return
+	}
+
+	utils.WriteJSON(w, http.StatusOK, response)
+}
+
+func (h *Handler) handleConfigureQueue(w http.ResponseWriter, r *http.Request) {
+	eventID, err := uuid.Parse(chi.URLParam(r, "event_id"))
+	if err != nil {
+		utils.WriteError(w, http.StatusBadRequest, fmt.Errorf("invalid event id: %w", err))
+		return
+	}
+
+	var req types.QueueConfigRequest
+	if err := utils.ParseJSON(r, &req); err != nil {
+		utils.WriteError(w, http.StatusBadRequest, fmt.Errorf("invalid request body: %w", err))
+		return
+	}
+
+	response, err := h.service.ConfigureQueue(r.Context(), eventID, req.BatchSize, req.IntervalSeconds)
+	if err != nil {
+		utils.WriteError(w, queueErrorStatus(err), fmt.Errorf("failed to configure queue: %w", err))
+		return
+	}
+
+	utils.WriteJSON(w, http.StatusOK, response)
+}
+
+func (h *Handler) handleRemoveQueue(w http.ResponseWriter, r *http.Request) {
+	eventID, err := uuid.Parse(chi.URLParam(r, "event_id"))
+	if err != nil {
+		utils.WriteError(w, http.StatusBadRequest, fmt.Errorf("invalid event id: %w", err))
+		return
+	}
+
+	if err := h.service.RemoveQueue(r.Context(), eventID); err != nil {
+		utils.WriteError(w, queueErrorStatus(err), fmt.Errorf("failed to remove queue: %w", err))
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// handleJoinQueue queues the caller for an event. Authenticated callers keep
+// a single place in the queue however often they join; anonymous callers keep
+// theirs by sending their queue token in the X-Queue-Token header.
+func (h *Handler) handleJoinQueue(w http.ResponseWriter, r *http.Request) {
+	eventID, err := uuid.Parse(chi.URLParam(r, "event_id"))
+	if err != nil {
+		utils.WriteError(w, http.StatusBadRequest, fmt.Errorf("invalid event id: %w", err))
+		return
+	}
+
+	// middleware.RealIP sets RemoteAddr to the client address core
+	// forwards, without a port
+	clientAddr, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		clientAddr = r.RemoteAddr
+	}
+
+	response, err := h.service.JoinQueue(r.Context(), eventID, r.Header.Get(QueueTokenHeader), clientAddr)
+	if err != nil {
+		utils.WriteError(w, queueErrorStatus(err), fmt.Errorf("failed to join queue: %w", err))
+		return
+	}
+
+	utils.WriteJSON(w, http.StatusOK, response)
+}
+
+func (h *Handler) handleGetQueueStatus(w http.ResponseWriter, r *http.Request) {
+	eventID, err := uuid.Parse(chi.URLParam(r, "event_id"))
+	if err != nil {
+		utils.WriteError(w, http.StatusBadRequest, fmt.Errorf("invalid event id: %w", err))
+		return
+	}
+
+	response, err := h.service.GetQueueStatus(r.Context(), eventID, r.Header.Get(QueueTokenHeader))
+	if err != nil {
+		utils.WriteError(w, queueErrorStatus(err), fmt.Errorf("failed to get queue status: %w", err))
+		return
+	}
+
+	utils.WriteJSON(w, http.StatusOK, response)
+}
+
+func queueErrorStatus(err error) int {
+	switch {
+	case errors.Is(err, ErrQueueNotFound):
+		return http.StatusNotFound
+	case errors.Is(err, ErrInvalidQueueConfig):
+		return http.StatusBadRequest
+	case errors.Is(err, ErrInvalidQueueToken):
+		return http.StatusUnauthorized
+	case errors.Is(err, ErrAdmissionExpired):
+		return http.StatusGone
+	case errors.Is(err, ErrQueueJoinLimited):
+		return http.StatusTooManyRequests
+	}
+	return http.StatusInternalServerError
+}
@@ -0,0 +1,173 @@
+package booking
+
+import (
+	"context"
+	"log"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/ignisrex/tix/booking/internal/database"
+	"github.com/ignisrex/tix/booking/internal/payment"
+)
+
+// Payment attempts that have not moved for this long are considered abandoned
+// by the process that started them.
+const unsettledPaymentAge = 2 * time.Minute
+
+// reconcileBatchSize is how many payment attempts a reconciler claims at once
+const reconcileBatchSize = 100
+
+// capturePayment captures an authorization whose purchase has been committed.
+func (s *Service) capturePayment(ctx context.Context, attemptID uuid.UUID, authorizationID string) {
+	if err := s.paymentProvider.Capture(ctx, authorizationID); err != nil {
+		log.Printf("capturePayment: failed to capture payment attempt %s: %v", attemptID, err)
+		return
+	}
+	if err := s.repo.MarkPaymentCaptured(ctx, attemptID); err != nil {
+		log.Printf("capturePayment: failed to mark payment attempt %s as captured: %v", attemptID, err)
+	}
+}
+
+// voidPayment releases an authorization whose purchase could not be completed.
+// It runs even if the request context is cancelled, since the customer's
+// funds stay on hold otherwise. A failed void leaves the attempt authorized
+// for the reconciler to retry.
+func (s *Service) voidPayment(ctx context.Context, attemptID uuid.UUID, authorizationID string, reason string) {
+	ctx = context.WithoutCancel(ctx)
+	if err := s.paymentProvider.Void(ctx, authorizationID); err != nil {
+		log.Printf("voidPayment: failed to void payment attempt %s: %v", attemptID, err)
+		return
+	}
+	if err := s.repo.MarkPaymentVoided(ctx, attemptID, reason); err != nil {
+		log.Printf("voidPayment: failed to mark payment attempt %s as voided: %v", attemptID, err)
+	}
+}
+
+// cancelPurchase voids the authorization of a purchase that could not be
+// completed and moves the purchase to cancelled.
+func (s *Service) cancelPurchase(ctx context.Context, purchaseID, attemptID uuid.UUID, authorizationID string, reason string) {
+	s.voidPayment(ctx, attemptID, authorizationID, reason)
+	s.transitionPurchase(ctx, purchaseID, database.PurchaseStatusPendingPayment, database.PurchaseStatusCancelled, reason)
+}
+
+// ReconcilePayments settles payment attempts abandoned mid-purchase, e.g. by
+// a crashed process: authorizations whose tickets were sold are captured, the
+// rest are voided and their purchase cancelled. Attempts whose authorization
+// result was never recorded are voided in case the authorization went
+// through, then failed along with their purchase.
+// Attempts are claimed before they are settled, so several instances can
+// reconcile at once without capturing or voiding an attempt twice.
+func (s *Service) ReconcilePayments(ctx context.Context) error {
+	for {
+		attempts, err := s.repo.ClaimUnsettledPaymentAttempts(ctx, time.Now().Add(-unsettledPaymentAge), reconcileBatchSize)
+		if err != nil {
+			return err
+		}
+
+		for _, attempt := range attempts {
+			s.reconcilePayment(ctx, attempt)
+		}
+
+		// Claimed attempts are not claimed again on this run
+		if len(attempts) < reconcileBatchSize {
+			return nil
+		}
+	}
+}
+
+func (s *Service) reconcilePayment(ctx context.Context, attempt database.ClaimUnsettledPaymentAttemptsRow) {
+	purchasePending := attempt.PurchaseStatus.Valid && attempt.PurchaseStatus.PurchaseStatus == database.PurchaseStatusPendingPayment
+
+	switch {
+	case attempt.Status == database.PaymentAttemptStatusPending:
+		// The authorization may have gone through without its result being
+		// recorded. Its ID is derived from the attempt, so void it before
+		// failing the attempt; if the void fails the attempt stays pending
+		// and is retried on a later run.
+		authorizationID := payment.AuthorizationID(attempt.ID.String())
+		if err := s.paymentProvider.Void(ctx, authorizationID); err != nil {
+			log.Printf("ReconcilePayments: failed to void authorization of pending payment attempt %s: %v", attempt.ID, err)
+			return
+		}
+		reason := "abandoned before authorization completed"
+		if err := s.repo.MarkPaymentFailed(ctx, attempt.ID, reason); err != nil {
+			log.Printf("ReconcilePayments: failed to mark payment attempt %s as failed: %v", attempt.ID, err)
+			return
+		}
+		if purchasePending {
+			s.transitionPurchase(ctx, attempt.PurchaseID.UUID, database.PurchaseStatusPendingPayment, database.PurchaseStatusFailed, reason)
+		}
+	case attempt.PurchaseStatus.Valid && purchaseSold(attempt.PurchaseStatus.PurchaseStatus):
+		// The tickets were sold, so the payment is owed
+		log.Printf("ReconcilePayments: capturing payment attempt %s for purchase %s", attempt.ID, attempt.PurchaseID.UUID)
+		s.capturePayment(ctx, attempt.ID, attempt.AuthorizationID.String)
+	default:
+		log.Printf("ReconcilePayments: voiding payment attempt %s with no completed purchase", attempt.ID)
+		reason := "purchase never completed"
+		s.voidPayment(ctx, attempt.ID, attempt.AuthorizationID.String, reason)
+		if purchasePending {
+			s.transitionPurchase(ctx, attempt.PurchaseID.UUID, database.PurchaseStatusPendingPayment, database.PurchaseStatusCancelled, reason)
+		}
+	}
+}
+
+// purchaseSold reports whether a purchase got its tickets, so its payment is
+// owed. Failed and cancelled purchases never did.
+func purchaseSold(status database.PurchaseStatus) bool {
+	switch status {
+	case database.PurchaseStatusPaid, database.PurchaseStatusPartiallyRefunded, database.PurchaseStatusRefunded:
+		return true
+	}
+	return false
+}
+
+// ReconcileRefunds retries refunds left pending, e.g. because the provider
+// timed out or the process crashed mid-refund. The provider keys refunds by
+// reference, so a refund that did go through is not paid out twice.
+func (s *Service) ReconcileRefunds(ctx context.Context) error {
+	for {
+		refunds, err := s.repo.ClaimPendingRefunds(ctx, time.Now().Add(-unsettledPaymentAge), reconcileBatchSize)
+		if err != nil {
+			return err
+		}
+
+		for _, refund := range refunds {
+			attempt, err := s.repo.GetCapturedPaymentForPurchase(ctx, refund.PurchaseID)
+			if err != nil {
+				log.Printf("ReconcileRefunds: failed to get payment for refund %s: %v", refund.ID, err)
+				continue
+			}
+			log.Printf("ReconcileRefunds: retrying pending refund %s for purchase %s", refund.ID, refund.PurchaseID)
+			if _, err := s.processRefund(ctx, refund, nil, attempt.AuthorizationID.String); err != nil {
+				log.Printf("ReconcileRefunds: refund %s: %v", refund.ID, err)
+			}
+		}
+
+		if len(refunds) < reconcileBatchSize {
+			return nil
+		}
+	}
+}
+
+// RunPaymentReconciler reconciles payments and refunds immediately and then
+// every interval until ctx is cancelled.
+func (s *Service) RunPaymentReconciler(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		if err := s.ReconcilePayments(ctx); err != nil {
+			log.Printf("RunPaymentReconciler: failed to reconcile payments: %v", err)
+		}
+		if err := s.ReconcileRefunds(ctx); err != nil {
+			log.Printf("RunPaymentReconciler: failed to reconcile refunds: %v", err)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
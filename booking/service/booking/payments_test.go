@@ -0,0 +1,226 @@
+package booking
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/ignisrex/tix/booking/internal/database"
+	"github.com/ignisrex/tix/booking/internal/payment"
+)
+
+func newReconcileService(repo *fakeRepo, provider *recordingProvider) *Service {
+	return &Service{repo: repo, paymentProvider: provider}
+}
+
+// stale is older than unsettledPaymentAge, so the reconciler picks it up
+const stale = unsettledPaymentAge + time.Minute
+
+func TestReconcileSettlesAbandonedAttempts(t *testing.T) {
+	tests := []struct {
+		name           string
+		status         database.PaymentAttemptStatus
+		purchaseStatus database.PurchaseStatus
+		wantStatus     database.PaymentAttemptStatus
+		wantPurchase   database.PurchaseStatus
+		wantCaptured   bool
+		wantVoided     bool
+	}{
+		{
+			// The authorization may have gone through without being recorded
+			name:           "pending is voided then failed",
+			status:         database.PaymentAttemptStatusPending,
+			purchaseStatus: database.PurchaseStatusPendingPayment,
+			wantStatus:     database.PaymentAttemptStatusFailed,
+			wantPurchase:   database.PurchaseStatusFailed,
+			wantVoided:     true,
+		},
+		{
+			name:           "authorized with tickets sold is captured",
+			status:         database.PaymentAttemptStatusAuthorized,
+			purchaseStatus: database.PurchaseStatusPaid,
+			wantStatus:     database.PaymentAttemptStatusCaptured,
+			wantPurchase:   database.PurchaseStatusPaid,
+			wantCaptured:   true,
+		},
+		{
+			name:           "authorized without a completed purchase is voided",
+			status:         database.PaymentAttemptStatusAuthorized,
+			purchaseStatus: database.PurchaseStatusPendingPayment,
+			wantStatus:     database.PaymentAttemptStatusVoided,
+			wantPurchase:   database.PurchaseStatusCancelled,
+			wantVoided:     true,
+		},
+		{
+			// An earlier void failed after the purchase was cancelled
+			name:           "authorized with a cancelled purchase is voided",
+			status:         database.PaymentAttemptStatusAuthorized,
+			purchaseStatus: database.PurchaseStatusCancelled,
+			wantStatus:     database.PaymentAttemptStatusVoided,
+			wantPurchase:   database.PurchaseStatusCancelled,
+			wantVoided:     true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo := newFakeRepo()
+			provider := newRecordingProvider()
+			s := newReconcileService(repo, provider)
+			attempt := repo.addAttempt(tt.status, tt.purchaseStatus, stale)
+
+			if err := s.ReconcilePayments(context.Background()); err != nil {
+				t.Fatalf("ReconcilePayments: %v", err)
+			}
+
+			if got := repo.attempt(attempt.ID).Status; got != tt.wantStatus {
+				t.Errorf("attempt status = %s, want %s", got, tt.wantStatus)
+			}
+			if got := repo.purchase(attempt.PurchaseID.UUID).Status; got != tt.wantPurchase {
+				t.Errorf("purchase status = %s, want %s", got, tt.wantPurchase)
+			}
+
+			authorizationID := "auth_" + attempt.ID.String()
+			if tt.wantCaptured != (len(provider.captured) == 1 && provider.captured[0] == authorizationID) {
+				t.Errorf("captured = %v, want capture of %s: %v", provider.captured, authorizationID, tt.wantCaptured)
+			}
+			if tt.wantVoided != (len(provider.voided) == 1 && provider.voided[0] == authorizationID) {
+				t.Errorf("voided = %v, want void of %s: %v", provider.voided, authorizationID, tt.wantVoided)
+			}
+		})
+	}
+}
+
+func TestReconcileRetriesPendingAttemptWhenVoidFails(t *testing.T) {
+	repo := newFakeRepo()
+	provider := newRecordingProvider()
+	provider.voidErr = errors.New("provider unavailable")
+	s := newReconcileService(repo, provider)
+	attempt := repo.addAttempt(database.PaymentAttemptStatusPending, database.PurchaseStatusPendingPayment, stale)
+
+	if err := s.ReconcilePayments(context.Background()); err != nil {
+		t.Fatalf("ReconcilePayments: %v", err)
+	}
+
+	// Failing the attempt without the void could leave the funds on hold
+	if got := repo.attempt(attempt.ID).Status; got != database.PaymentAttemptStatusPending {
+		t.Errorf("attempt status = %s, want pending", got)
+	}
+	if got := repo.purchase(attempt.PurchaseID.UUID).Status; got != database.PurchaseStatusPendingPayment {
+		t.Errorf("purchase status = %s, want pending_payment", got)
+	}
+}
+
+func TestReconcileLeavesRecentAttempts(t *testing.T) {
+	repo := newFakeRepo()
+	provider := newRecordingProvider()
+	s := newReconcileService(repo, provider)
+	attempt := repo.addAttempt(database.PaymentAttemptStatusAuthorized, database.PurchaseStatusPendingPayment, time.Second)
+
+	if err := s.ReconcilePayments(context.Background()); err != nil {
+		t.Fatalf("ReconcilePayments: %v", err)
+	}
+
+	if got := repo.attempt(attempt.ID).Status; got != database.PaymentAttemptStatusAuthorized {
+		t.Errorf("attempt status = %s, want authorized", got)
+	}
+	if len(provider.voided) != 0 || len(provider.captured) != 0 {
+		t.Errorf("in-flight attempt was settled")
+	}
+}
+
+func TestConcurrentReconcilersSettleEachAttemptOnce(t *testing.T) {
+	repo := newFakeRepo()
+	provider := newRecordingProvider()
+	for i := 0; i < 2*reconcileBatchSize+10; i++ {
+		repo.addAttempt(database.PaymentAttemptStatusAuthorized, database.PurchaseStatusPaid, stale)
+		repo.addAttempt(database.PaymentAttemptStatusAuthorized, database.PurchaseStatusPendingPayment, stale)
+	}
+
+	var wg sync.WaitGroup
+	for i := 0; i < 4; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if err := newReconcileService(repo, provider).ReconcilePayments(context.Background()); err != nil {
+				t.Errorf("ReconcilePayments: %v", err)
+			}
+		}()
+	}
+	wg.Wait()
+
+	want := 2*reconcileBatchSize + 10
+	if len(provider.captured) != want {
+		t.Errorf("captured %d authorizations, want %d", len(provider.captured), want)
+	}
+	if len(provider.voided) != want {
+		t.Errorf("voided %d authorizations, want %d", len(provider.voided), want)
+	}
+	seen := make(map[string]bool)
+	for _, id := range append(provider.captured, provider.voided...) {
+		if seen[id] {
+			t.Errorf("authorization %s settled twice", id)
+		}
+		seen[id] = true
+	}
+}
+
+func TestPurchaseSold(t *testing.T) {
+	sold := map[database.PurchaseStatus]bool{
+		database.PurchaseStatusPendingPayment:    false,
+		database.PurchaseStatusPaid:              true,
+		database.PurchaseStatusFailed:            false,
+		database.PurchaseStatusCancelled:         false,
+		database.PurchaseStatusPartiallyRefunded: true,
+		database.PurchaseStatusRefunded:          true,
+	}
+	for status, want := range sold {
+		if got := purchaseSold(status); got != want {
+			t.Errorf("purchaseSold(%s) = %v, want %v", status, got, want)
+		}
+	}
+}
+
+func TestReconcileRefundsSettlesPendingRefunds(t *testing.T) {
+	repo := newFakeRepo()
+	provider := newRecordingProvider()
+	provider.refundErr = payment.ErrTimeout
+	s := newRefundService(repo, newFakeHoldStore(), provider)
+	purchaseID, ticketIDs := repo.addSoldPurchase(2500)
+
+	refund, err := s.RefundPurchase(context.Background(), purchaseID, nil, false, "")
+	if err != nil {
+		t.Fatalf("RefundPurchase: %v", err)
+	}
+
+	// Recent refunds may still be in flight
+	provider.refundErr = nil
+	if err := s.ReconcileRefunds(context.Background()); err != nil {
+		t.Fatalf("ReconcileRefunds: %v", err)
+	}
+	if got := repo.refund(refund.ID).Status; got != database.RefundStatusPending {
+		t.Fatalf("recent refund status = %s, want pending", got)
+	}
+
+	repo.ageRefunds(stale)
+	if err := s.ReconcileRefunds(context.Background()); err != nil {
+		t.Fatalf("ReconcileRefunds: %v", err)
+	}
+
+	if got := repo.refund(refund.ID).Status; got != database.RefundStatusSucceeded {
+		t.Errorf("refund status = %s, want succeeded", got)
+	}
+	// Retried under the refund's own reference, so a refund that did go
+	// through the first time is not paid out twice
+	if len(provider.refunded) != 1 || provider.refunded[0] != refund.ID.String() {
+		t.Errorf("provider refunds = %v, want one under reference %s", provider.refunded, refund.ID)
+	}
+	if status := repo.ticket(ticketIDs[0]).Status; status != string(database.TicketStatusRefunded) {
+		t.Errorf("ticket status = %s, want refunded", status)
+	}
+	if status := repo.purchase(purchaseID).Status; status != database.PurchaseStatusRefunded {
+		t.Errorf("purchase status = %s, want refunded", status)
+	}
+}
@@ -0,0 +1,297 @@
+package booking
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/ignisrex/tix/auth"
+	"github.com/ignisrex/tix/booking/internal/queue"
+	"github.com/ignisrex/tix/booking/internal/redis"
+	"github.com/ignisrex/tix/booking/types"
+)
+
+// Queue tokens only need to outlive the wait; visitors who come back after
+// this long join again at the back
+const queueTokenTTL = 12 * time.Hour
+
+// New anonymous visitors may join a waiting room queueJoinLimit times per
+// window from each client address
+const queueJoinWindow = time.Minute
+
+// Waiting room statuses reported to visitors
+const (
+	QueueStatusOpen     = "open" // the event has no waiting room
+	QueueStatusWaiting  = "waiting"
+	QueueStatusAdmitted = "admitted"
+)
+
+var (
+	ErrQueueNotFound      = errors.New("event has no waiting room")
+	ErrInvalidQueueToken  = errors.New("invalid queue token")
+	ErrAdmissionExpired   = errors.New("admission window has passed")
+	ErrAdmissionRequired  = errors.New("admission through the waiting room is required")
+	ErrInvalidQueueConfig = errors.New("invalid waiting room config")
+	ErrQueueJoinLimited   = errors.New("too many waiting room joins")
+)
+
+// ConfigureQueue opens a waiting room for the event, or changes the
+// admission rate of an existing one. Zero values use the configured defaults.
+func (s *Service) ConfigureQueue(ctx context.Context, eventID uuid.UUID, batchSize int, intervalSeconds int) (*types.QueueResponse, error) {
+	if batchSize < 0 || intervalSeconds < 0 {
+		return nil, fmt.Errorf("%w: batch_size and interval_seconds cannot be negative", ErrInvalidQueueConfig)
+	}
+	if batchSize == 0 {
+		batchSize = s.queueBatchSize
+	}
+	if intervalSeconds == 0 {
+		intervalSeconds = s.queueIntervalSeconds
+	}
+
+	if err := s.redisClient.ConfigureQueue(ctx, eventID, redis.QueueConfig{
+		BatchSize:       batchSize,
+		IntervalSeconds: intervalSeconds,
+	}); err != nil {
+		log.Printf("ConfigureQueue: failed to configure queue for event %s: %v", eventID, err)
+		return nil, fmt.Errorf("failed to configure queue: %w", err)
+	}
+	return s.GetQueue(ctx, eventID)
+}
+
+// GetQueue returns the event's admission rate and how many visitors wait
+func (s *Service) GetQueue(ctx context.Context, eventID uuid.UUID) (*types.QueueResponse, error) {
+	cfg, waiting, err := s.redisClient.GetQueue(ctx, eventID)
+	if err != nil {
+		if errors.Is(err, redis.ErrQueueNotFound) {
+			return nil, fmt.Errorf("%w: %s", ErrQueueNotFound, eventID)
+		}
+		log.Printf("GetQueue: failed to get queue for event %s: %v", eventID, err)
+		return nil, fmt.Errorf("failed to get queue: %w", err)
+	}
+	return &types.QueueResponse{
+		EventID:         eventID,
+		BatchSize:       cfg.BatchSize,
+		IntervalSeconds: cfg.IntervalSeconds,
+		Waiting:         waiting,
+	}, nil
+}
+
+// RemoveQueue closes the event's waiting room and lets everyone reserve
+func (s *Service) RemoveQueue(ctx context.Context, eventID uuid.UUID) error {
+	if err := s.redisClient.RemoveQueue(ctx, eventID); err != nil {
+		if errors.Is(err, redis.ErrQueueNotFound) {
+			return fmt.Errorf("%w: %s", ErrQueueNotFound, eventID)
+		}
+		log.Printf("RemoveQueue: failed to remove queue for event %s: %v", eventID, err)
+		return fmt.Errorf("failed to remove queue: %w", err)
+	}
+	return nil
+}
+
+// JoinQueue puts the caller in the event's waiting room and returns a signed
+// queue token to poll their status with. Visitors who already joined keep
+// their place. Events without a waiting room report the open status.
+func (s *Service) JoinQueue(ctx context.Context, eventID uuid.UUID, queueToken string, clientAddr string) (*types.QueueStatusResponse, error) {
+	visitorID, err := s.queueVisitor(ctx, eventID, queueToken, clientAddr)
+	if err != nil {
+		return nil, err
+	}
+
+	position, err := s.redisClient.JoinQueue(ctx, eventID, visitorID)
+	if err != nil {
+		if errors.Is(err, redis.ErrQueueNotFound) {
+			return &types.QueueStatusResponse{EventID: eventID, Status: QueueStatusOpen}, nil
+		}
+		log.Printf("JoinQueue: failed to join queue for event %s: %v", eventID, err)
+		return nil, fmt.Errorf("failed to join queue: %w", err)
+	}
+
+	issued, err := s.queueTokens.Issue(queue.KindQueue, eventID, visitorID, time.Now().Add(queueTokenTTL))
+	if err != nil {
+		log.Printf("JoinQueue: failed to issue queue token: %v", err)
+		return nil, fmt.Errorf("failed to issue queue token: %w", err)
+	}
+	return s.queueStatus(ctx, eventID, visitorID, issued, position)
+}
+
+// queueVisitor returns who the caller waits as. Signed-in callers wait as
+// their subject and anonymous callers as the visitor of the queue token they
+// send, if any. Other anonymous callers become a new visitor, at most
+// queueJoinLimit times per window from each client address, so they can't
+// fill the queue with places of their own.
+func (s *Service) queueVisitor(ctx context.Context, eventID uuid.UUID, queueToken string, clientAddr string) (uuid.UUID, error) {
+	if principal, ok := auth.PrincipalFromContext(ctx); ok {
+		return principal.Subject, nil
+	}
+	if queueToken != "" {
+		if visitorID, err := s.queueTokens.Verify(queueToken, queue.KindQueue, eventID); err == nil {
+			return visitorID, nil
+		}
+	}
+
+	queued, err := s.redisClient.IsQueued(ctx, eventID)
+	if err != nil {
+		log.Printf("JoinQueue: failed to check queue for event %s: %v", eventID, err)
+		return uuid.Nil, fmt.Errorf("failed to check queue: %w", err)
+	}
+	if queued && s.queueJoinLimit > 0 {
+		joins, err := s.redisClient.CountQueueJoin(ctx, eventID, clientAddr, queueJoinWindow)
+		if err != nil {
+			log.Printf("JoinQueue: failed to count joins for event %s: %v", eventID, err)
+			return uuid.Nil, fmt.Errorf("failed to join queue: %w", err)
+		}
+		if joins > int64(s.queueJoinLimit) {
+			return uuid.Nil, fmt.Errorf("%w: send the queue token you were given to keep your place", ErrQueueJoinLimited)
+		}
+	}
+	return uuid.New(), nil
+}
+
+// GetQueueStatus returns the position and estimated wait of the visitor the
+// queue token was issued to, or an admission token once they are admitted
+func (s *Service) GetQueueStatus(ctx context.Context, eventID uuid.UUID, queueToken string) (*types.QueueStatusResponse, error) {
+	queued, err := s.redisClient.IsQueued(ctx, eventID)
+	if err != nil {
+		log.Printf("GetQueueStatus: failed to check queue for event %s: %v", eventID, err)
+		return nil, fmt.Errorf("failed to check queue: %w", err)
+	}
+	if !queued {
+		return &types.QueueStatusResponse{EventID: eventID, Status: QueueStatusOpen}, nil
+	}
+
+	visitorID, err := s.queueTokens.Verify(queueToken, queue.KindQueue, eventID)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidQueueToken, err)
+	}
+
+	position, err := s.redisClient.GetQueuePosition(ctx, eventID, visitorID)
+	if err != nil {
+		if errors.Is(err, redis.ErrQueuePositionNotFound) {
+			return nil, fmt.Errorf("%w: join the queue again", ErrAdmissionExpired)
+		}
+		log.Printf("GetQueueStatus: failed to get queue position for event %s: %v", eventID, err)
+		return nil, fmt.Errorf("failed to get queue position: %w", err)
+	}
+	return s.queueStatus(ctx, eventID, visitorID, queueToken, position)
+}
+
+func (s *Service) queueStatus(ctx context.Context, eventID uuid.UUID, visitorID uuid.UUID, queueToken string, position redis.QueuePosition) (*types.QueueStatusResponse, error) {
+	resp := &types.QueueStatusResponse{
+		EventID:    eventID,
+		QueueToken: queueToken,
+	}
+
+	if position.Admitted {
+		// The admission token expires with the admission window, however
+		// often the visitor polls
+		expiresAt := time.Now().Add(position.AdmittedFor)
+		admissionToken, err := s.queueTokens.Issue(queue.KindAdmission, eventID, visitorID, expiresAt)
+		if err != nil {
+			log.Printf("queueStatus: failed to issue admission token: %v", err)
+			return nil, fmt.Errorf("failed to issue admission token: %w", err)
+		}
+		resp.Status = QueueStatusAdmitted
+		resp.AdmissionToken = admissionToken
+		resp.AdmissionExpiresAt = expiresAt.Format(time.RFC3339)
+		return resp, nil
+	}
+
+	cfg, _, err := s.redisClient.GetQueue(ctx, eventID)
+	if err != nil {
+		log.Printf("queueStatus: failed to get queue config for event %s: %v", eventID, err)
+		return nil, fmt.Errorf("failed to get queue: %w", err)
+	}
+	batches := (position.Position + int64(cfg.BatchSize) - 1) / int64(cfg.BatchSize)
+	resp.Status = QueueStatusWaiting
+	resp.Position = position.Position
+	resp.EstimatedWaitSeconds = batches * int64(cfg.IntervalSeconds)
+	return resp, nil
+}
+
+// checkAdmission requires an admission token for every event that has a
+// waiting room. The token must have been issued to the caller, if they are
+// signed in, and its visitor's admission window must still be open. Admins
+// and box office staff skip the queue.
+func (s *Service) checkAdmission(ctx context.Context, eventIDs []uuid.UUID, admissionToken string) error {
+	principal, signedIn := auth.PrincipalFromContext(ctx)
+	if signedIn && principal.HasRole(auth.RoleAdmin, auth.RoleBoxOffice) {
+		return nil
+	}
+
+	for _, eventID := range eventIDs {
+		queued, err := s.redisClient.IsQueued(ctx, eventID)
+		if err != nil {
+			return fmt.Errorf("failed to check queue: %w", err)
+		}
+		if !queued {
+			continue
+		}
+		if admissionToken == "" {
+			return fmt.Errorf("%w: event %s", ErrAdmissionRequired, eventID)
+		}
+		visitorID, err := s.queueTokens.Verify(admissionToken, queue.KindAdmission, eventID)
+		if err != nil {
+			return fmt.Errorf("%w: %v", ErrAdmissionRequired, err)
+		}
+		if signedIn && visitorID != principal.Subject {
+			return fmt.Errorf("%w: the admission token was issued to someone else", ErrAdmissionRequired)
+		}
+
+		// The visitor's admitted key, not the token, decides whether their
+		// window is still open
+		position, err := s.redisClient.GetQueuePosition(ctx, eventID, visitorID)
+		if err != nil && !errors.Is(err, redis.ErrQueuePositionNotFound) {
+			return fmt.Errorf("failed to check admission: %w", err)
+		}
+		if err != nil || !position.Admitted {
+			return fmt.Errorf("%w: the admission window for event %s has closed", ErrAdmissionRequired, eventID)
+		}
+	}
+	return nil
+}
+
+// AdmitQueuedVisitors admits the next batch of visitors of every waiting
+// room whose admission interval has passed
+func (s *Service) AdmitQueuedVisitors(ctx context.Context) error {
+	eventIDs, err := s.redisClient.ListQueuedEvents(ctx)
+	if err != nil {
+		return err
+	}
+
+	window := time.Duration(s.queueAdmissionSeconds) * time.Second
+	for _, eventID := range eventIDs {
+		admitted, err := s.redisClient.AdmitNext(ctx, eventID, window)
+		if err != nil {
+			log.Printf("AdmitQueuedVisitors: failed to admit visitors for event %s: %v", eventID, err)
+			continue
+		}
+		if admitted > 0 {
+			log.Printf("AdmitQueuedVisitors: admitted %d visitors for event %s", admitted, eventID)
+		}
+	}
+	return nil
+}
+
+// RunQueueAdmitter admits queued visitors every tick until ctx is cancelled.
+// The tick only bounds how late a batch can be; each waiting room admits at
+// its own interval.
+func (s *Service) RunQueueAdmitter(ctx context.Context, tick time.Duration) {
+	ticker := time.NewTicker(tick)
+	defer ticker.Stop()
+
+	for {
+		if err := s.AdmitQueuedVisitors(ctx); err != nil {
+			log.Printf("RunQueueAdmitter: failed to admit visitors: %v", err)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
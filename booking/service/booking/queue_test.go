@@ -0,0 +1,115 @@
+package booking
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/ignisrex/tix/auth"
+	"github.com/ignisrex/tix/booking/internal/queue"
+)
+
+func newQueueService(t *testing.T, holds *fakeHoldStore) *Service {
+	t.Helper()
+	signer, err := queue.NewSigner("test-secret")
+	if err != nil {
+		t.Fatalf("NewSigner: %v", err)
+	}
+	s := newTestService(newFakeRepo(), holds)
+	s.queueTokens = signer
+	s.queueJoinLimit = 2
+	return s
+}
+
+func TestCheckAdmissionBindsTokenToVisitor(t *testing.T) {
+	holds := newFakeHoldStore()
+	s := newQueueService(t, holds)
+	eventID := uuid.New()
+	holds.openQueue(eventID)
+
+	customerID := uuid.New()
+	closedID := uuid.New()
+	holds.admit(customerID, true)
+	holds.admit(closedID, false)
+
+	issue := func(visitorID uuid.UUID) string {
+		token, err := s.queueTokens.Issue(queue.KindAdmission, eventID, visitorID, time.Now().Add(time.Minute))
+		if err != nil {
+			t.Fatalf("Issue: %v", err)
+		}
+		return token
+	}
+
+	tests := []struct {
+		name    string
+		ctx     context.Context
+		token   string
+		wantErr bool
+	}{
+		{name: "admitted customer", ctx: asCaller(customerID, auth.RoleCustomer), token: issue(customerID)},
+		{name: "anonymous holder", ctx: context.Background(), token: issue(customerID)},
+		{name: "another customer", ctx: asCaller(uuid.New(), auth.RoleCustomer), token: issue(customerID), wantErr: true},
+		{name: "closed window", ctx: asCaller(closedID, auth.RoleCustomer), token: issue(closedID), wantErr: true},
+		{name: "never admitted", ctx: context.Background(), token: issue(uuid.New()), wantErr: true},
+		{name: "no token", ctx: context.Background(), wantErr: true},
+		{name: "box office", ctx: asCaller(uuid.New(), auth.RoleBoxOffice)},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := s.checkAdmission(tt.ctx, []uuid.UUID{eventID}, tt.token)
+			if tt.wantErr != errors.Is(err, ErrAdmissionRequired) {
+				t.Fatalf("checkAdmission = %v, want ErrAdmissionRequired: %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestJoinQueueKeepsAnonymousVisitorsPlace(t *testing.T) {
+	holds := newFakeHoldStore()
+	s := newQueueService(t, holds)
+	eventID := uuid.New()
+	holds.openQueue(eventID)
+	ctx := context.Background()
+
+	first, err := s.JoinQueue(ctx, eventID, "", "203.0.113.7")
+	if err != nil {
+		t.Fatalf("JoinQueue: %v", err)
+	}
+	for range 3 {
+		again, err := s.JoinQueue(ctx, eventID, first.QueueToken, "203.0.113.7")
+		if err != nil {
+			t.Fatalf("JoinQueue with queue token: %v", err)
+		}
+		if again.Position != first.Position {
+			t.Fatalf("position = %d after joining again, want %d", again.Position, first.Position)
+		}
+	}
+}
+
+func TestJoinQueueLimitsNewAnonymousVisitors(t *testing.T) {
+	holds := newFakeHoldStore()
+	s := newQueueService(t, holds)
+	eventID := uuid.New()
+	holds.openQueue(eventID)
+	ctx := context.Background()
+
+	for n := range 2 {
+		if _, err := s.JoinQueue(ctx, eventID, "", "203.0.113.7"); err != nil {
+			t.Fatalf("join %d: %v", n+1, err)
+		}
+	}
+	if _, err := s.JoinQueue(ctx, eventID, "", "203.0.113.7"); !errors.Is(err, ErrQueueJoinLimited) {
+		t.Fatalf("third join = %v, want ErrQueueJoinLimited", err)
+	}
+	if _, err := s.JoinQueue(ctx, eventID, "", "198.51.100.4"); err != nil {
+		t.Fatalf("join from another address: %v", err)
+	}
+	// Signed-in customers always keep a single place
+	customerID := uuid.New()
+	if _, err := s.JoinQueue(asCaller(customerID, auth.RoleCustomer), eventID, "", "203.0.113.7"); err != nil {
+		t.Fatalf("signed-in join: %v", err)
+	}
+}
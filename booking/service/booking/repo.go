@@ -2,20 +2,30 @@ package booking
 
 import (
 	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"slices"
+	"time"
 
 	"github.com/google/uuid"
+	"github.com/lib/pq"
 
 	"github.com/ignisrex/tix/booking/internal/database"
 	"github.com/ignisrex/tix/booking/types"
 	"github.com/ignisrex/tix/booking/mappers"
 )
 
+// foreignKeyViolation is the Postgres error code for a missing referenced row
+const foreignKeyViolation = "23503"
+
 type Repo struct {
+	db      *sql.DB
 	queries *database.Queries
 }
 
-func NewRepo(queries *database.Queries) *Repo {
-	return &Repo{queries: queries}
+func NewRepo(db *sql.DB, queries *database.Queries) *Repo {
+	return &Repo{db: db, queries: queries}
 }
 
 func (r *Repo) GetTicketsWithPrice(ctx context.Context, ticketID []uuid.UUID) ([]types.Ticket, error) {
@@ -26,14 +36,443 @@ func (r *Repo) GetTicketsWithPrice(ctx context.Context, ticketID []uuid.UUID) ([
 	return mappers.ToTickets(dbTickets), nil
 }
 
-func (r *Repo) PurchaseTickets(ctx context.Context, ticketIDs []uuid.UUID, totalCents int32) (uuid.UUID, error) {
-	return r.queries.PurchaseTickets(ctx, database.PurchaseTicketsParams{
+func (r *Repo) GetTicketTypeForEvent(ctx context.Context, eventID uuid.UUID, ticketTypeID uuid.UUID) (database.GetTicketTypeForEventRow, error) {
+	return r.queries.GetTicketTypeForEvent(ctx, database.GetTicketTypeForEventParams{
+		EventID: eventID,
+		ID:      ticketTypeID,
+	})
+}
+
+// ListAvailableTickets returns the unsold tickets of a ticket type in seat
+// map order, with their seat position when they are seated
+func (r *Repo) ListAvailableTickets(ctx context.Context, eventID uuid.UUID, ticketTypeID uuid.UUID) ([]database.ListAvailableTicketsForTypeRow, error) {
+	return r.queries.ListAvailableTicketsForType(ctx, database.ListAvailableTicketsForTypeParams{
+		EventID:      eventID,
+		TicketTypeID: ticketTypeID,
+	})
+}
+
+// CreatePurchase creates a purchase awaiting payment and records its initial
+// status. A nil customerID creates an anonymous purchase; an unknown one
+// returns ErrCustomerNotFound.
+func (r *Repo) CreatePurchase(ctx context.Context, totalCents int32, customerID uuid.UUID) (database.Purchase, error) {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return database.Purchase{}, err
+	}
+	defer tx.Rollback()
+
+	qtx := r.queries.WithTx(tx)
+	purchase, err := qtx.CreatePurchase(ctx, database.CreatePurchaseParams{
 		TotalCents: totalCents,
+		CustomerID: uuid.NullUUID{UUID: customerID, Valid: customerID != uuid.Nil},
+	})
+	if err != nil {
+		var pqErr *pq.Error
+		if errors.As(err, &pqErr) && pqErr.Code == foreignKeyViolation {
+			return database.Purchase{}, fmt.Errorf("%w: %s", ErrCustomerNotFound, customerID)
+		}
+		return database.Purchase{}, err
+	}
+
+	if err := qtx.CreatePurchaseStatusTransition(ctx, database.CreatePurchaseStatusTransitionParams{
+		PurchaseID: purchase.ID,
+		ToStatus:   purchase.Status,
+	}); err != nil {
+		return database.Purchase{}, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return database.Purchase{}, err
+	}
+	return purchase, nil
+}
+
+// SellTickets assigns every ticket to the purchase and marks the purchase
+// paid in one transaction, recording reason on the transition. If any ticket
+// was no longer available the transaction is rolled back and a
+// *TicketsSoldError listing those tickets is returned.
+func (r *Repo) SellTickets(ctx context.Context, purchaseID uuid.UUID, ticketIDs []uuid.UUID, reason string) error {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	qtx := r.queries.WithTx(tx)
+	soldIDs, err := qtx.SellTickets(ctx, database.SellTicketsParams{
+		PurchaseID: uuid.NullUUID{UUID: purchaseID, Valid: true},
 		Column2:    ticketIDs,
 	})
+	if err != nil {
+		return err
+	}
+
+	if len(soldIDs) != len(ticketIDs) {
+		sold := make(map[uuid.UUID]bool, len(soldIDs))
+		for _, id := range soldIDs {
+			sold[id] = true
+		}
+		var unavailable []uuid.UUID
+		for _, id := range ticketIDs {
+			if !sold[id] {
+				unavailable = append(unavailable, id)
+			}
+		}
+		return &TicketsSoldError{TicketIDs: unavailable}
+	}
+
+	if err := transitionPurchase(ctx, qtx, purchaseID, database.PurchaseStatusPendingPayment, database.PurchaseStatusPaid, reason); err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// TransitionPurchase moves a purchase from one status to another and records
+// the transition. It returns ErrInvalidTransition if the purchase is no longer
+// in status from.
+func (r *Repo) TransitionPurchase(ctx context.Context, purchaseID uuid.UUID, from, to database.PurchaseStatus, reason string) error {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if err := transitionPurchase(ctx, r.queries.WithTx(tx), purchaseID, from, to, reason); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+func transitionPurchase(ctx context.Context, qtx *database.Queries, purchaseID uuid.UUID, from, to database.PurchaseStatus, reason string) error {
+	updated, err := qtx.UpdatePurchaseStatus(ctx, database.UpdatePurchaseStatusParams{
+		ToStatus:   to,
+		ID:         purchaseID,
+		FromStatus: from,
+	})
+	if err != nil {
+		return err
+	}
+	if updated == 0 {
+		return fmt.Errorf("%w: purchase %s is no longer %s", ErrInvalidTransition, purchaseID, from)
+	}
+
+	return qtx.CreatePurchaseStatusTransition(ctx, database.CreatePurchaseStatusTransitionParams{
+		PurchaseID: purchaseID,
+		FromStatus: database.NullPurchaseStatus{PurchaseStatus: from, Valid: true},
+		ToStatus:   to,
+		Reason:     sql.NullString{String: reason, Valid: reason != ""},
+	})
+}
+
+func (r *Repo) ListPurchaseStatusTransitions(ctx context.Context, purchaseID uuid.UUID) ([]database.PurchaseStatusTransition, error) {
+	return r.queries.ListPurchaseStatusTransitions(ctx, purchaseID)
+}
+
+func (r *Repo) ListPurchasePaymentAttempts(ctx context.Context, purchaseID uuid.UUID) ([]database.PaymentAttempt, error) {
+	return r.queries.ListPurchasePaymentAttempts(ctx, uuid.NullUUID{UUID: purchaseID, Valid: true})
+}
+
+func (r *Repo) CreatePaymentAttempt(ctx context.Context, holdID uuid.UUID, amountCents int32, purchaseID uuid.UUID) (database.PaymentAttempt, error) {
+	return r.queries.CreatePaymentAttempt(ctx, database.CreatePaymentAttemptParams{
+		HoldID:      holdID,
+		AmountCents: amountCents,
+		PurchaseID:  uuid.NullUUID{UUID: purchaseID, Valid: true},
+	})
+}
+
+// The Mark methods move a payment attempt to a new status. They return
+// ErrPaymentSettled when the attempt has already left the status the move
+// starts from, e.g. because the reconciler settled it first.
+func (r *Repo) MarkPaymentAuthorized(ctx context.Context, id uuid.UUID, authorizationID string) error {
+	updated, err := r.queries.MarkPaymentAuthorized(ctx, database.MarkPaymentAuthorizedParams{
+		ID:              id,
+		AuthorizationID: sql.NullString{String: authorizationID, Valid: true},
+	})
+	return checkPaymentUpdate(id, database.PaymentAttemptStatusPending, updated, err)
+}
+
+func (r *Repo) MarkPaymentCaptured(ctx context.Context, id uuid.UUID) error {
+	updated, err := r.queries.MarkPaymentCaptured(ctx, id)
+	return checkPaymentUpdate(id, database.PaymentAttemptStatusAuthorized, updated, err)
+}
+
+func (r *Repo) MarkPaymentVoided(ctx context.Context, id uuid.UUID, reason string) error {
+	updated, err := r.queries.MarkPaymentVoided(ctx, database.MarkPaymentVoidedParams{
+		ID:            id,
+		FailureReason: sql.NullString{String: reason, Valid: true},
+	})
+	return checkPaymentUpdate(id, database.PaymentAttemptStatusAuthorized, updated, err)
+}
+
+func (r *Repo) MarkPaymentFailed(ctx context.Context, id uuid.UUID, reason string) error {
+	updated, err := r.queries.MarkPaymentFailed(ctx, database.MarkPaymentFailedParams{
+		ID:            id,
+		FailureReason: sql.NullString{String: reason, Valid: true},
+	})
+	return checkPaymentUpdate(id, database.PaymentAttemptStatusPending, updated, err)
+}
+
+func checkPaymentUpdate(id uuid.UUID, from database.PaymentAttemptStatus, updated int64, err error) error {
+	if err != nil {
+		return err
+	}
+	if updated == 0 {
+		return fmt.Errorf("%w: payment attempt %s is no longer %s", ErrPaymentSettled, id, from)
+	}
+	return nil
+}
+
+// ClaimUnsettledPaymentAttempts claims up to batchSize payment attempts that
+// have not moved since updatedBefore. A claimed attempt is left alone by
+// other reconcilers until it has been unsettled for as long again.
+func (r *Repo) ClaimUnsettledPaymentAttempts(ctx context.Context, updatedBefore time.Time, batchSize int32) ([]database.ClaimUnsettledPaymentAttemptsRow, error) {
+	return r.queries.ClaimUnsettledPaymentAttempts(ctx, database.ClaimUnsettledPaymentAttemptsParams{
+		UpdatedBefore: updatedBefore,
+		BatchSize:     batchSize,
+	})
 }
 
 func (r *Repo) GetPurchaseDetails(ctx context.Context, purchaseID uuid.UUID) (database.GetPurchaseDetailsRow, error) {
 	return r.queries.GetPurchaseDetails(ctx, purchaseID)
 }
 
+
+func (r *Repo) GetPurchase(ctx context.Context, purchaseID uuid.UUID) (database.Purchase, error) {
+	return r.queries.GetPurchase(ctx, purchaseID)
+}
+
+func (r *Repo) GetCapturedPaymentForPurchase(ctx context.Context, purchaseID uuid.UUID) (database.PaymentAttempt, error) {
+	return r.queries.GetCapturedPaymentForPurchase(ctx, uuid.NullUUID{UUID: purchaseID, Valid: true})
+}
+
+func (r *Repo) ListPurchaseRefunds(ctx context.Context, purchaseID uuid.UUID) ([]database.ListPurchaseRefundsRow, error) {
+	return r.queries.ListPurchaseRefunds(ctx, purchaseID)
+}
+
+// CreatePendingRefund records a pending refund of tickets of a purchase and
+// returns it with the refunded tickets. The purchase's tickets are locked
+// first so a ticket cannot be refunded twice; tickets of a refund still
+// waiting on the provider count as taken. An empty ticketIDs refunds every
+// ticket still sold. The refund is capped at what is left of capturedCents
+// after the purchase's earlier refunds, failing with ErrPaymentRefunded when
+// nothing is left. The tickets and purchase are only updated once the
+// provider confirms the refund, by SettleRefund.
+func (r *Repo) CreatePendingRefund(ctx context.Context, purchaseID uuid.UUID, capturedCents int32, ticketIDs []uuid.UUID, returnToInventory bool, reason string) (database.Refund, []uuid.UUID, error) {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return database.Refund{}, nil, err
+	}
+	defer tx.Rollback()
+
+	qtx := r.queries.WithTx(tx)
+
+	tickets, err := qtx.LockPurchaseTickets(ctx, uuid.NullUUID{UUID: purchaseID, Valid: true})
+	if err != nil {
+		return database.Refund{}, nil, err
+	}
+
+	byID := make(map[uuid.UUID]database.LockPurchaseTicketsRow, len(tickets))
+	for _, ticket := range tickets {
+		byID[ticket.ID] = ticket
+	}
+
+	var selected []database.LockPurchaseTicketsRow
+	if len(ticketIDs) == 0 {
+		inProgress := false
+		for _, ticket := range tickets {
+			if ticket.Status != database.TicketStatusSold {
+				continue
+			}
+			if ticket.RefundPending {
+				inProgress = true
+				continue
+			}
+			selected = append(selected, ticket)
+		}
+		if len(selected) == 0 && inProgress {
+			return database.Refund{}, nil, fmt.Errorf("%w: purchase %s", ErrRefundInProgress, purchaseID)
+		}
+	} else {
+		for _, id := range ticketIDs {
+			ticket, ok := byID[id]
+			if !ok {
+				return database.Refund{}, nil, fmt.Errorf("%w: %s", ErrTicketNotInPurchase, id)
+			}
+			if ticket.Status != database.TicketStatusSold {
+				return database.Refund{}, nil, fmt.Errorf("%w: %s", ErrTicketAlreadyRefunded, id)
+			}
+			if ticket.RefundPending {
+				return database.Refund{}, nil, fmt.Errorf("%w: %s", ErrRefundInProgress, id)
+			}
+			selected = append(selected, ticket)
+		}
+	}
+	if len(selected) == 0 {
+		return database.Refund{}, nil, fmt.Errorf("%w: no tickets left to refund", ErrTicketAlreadyRefunded)
+	}
+
+	// Tickets are locked, so the purchase status cannot change underneath us
+	purchase, err := qtx.GetPurchase(ctx, purchaseID)
+	if err != nil {
+		return database.Refund{}, nil, err
+	}
+	if err := checkTransition(purchase.Status, database.PurchaseStatusRefunded); err != nil {
+		return database.Refund{}, nil, err
+	}
+
+	// Earlier refunds are counted under the ticket locks, so concurrent
+	// refunds of the purchase can't both claim the same money
+	refundedCents, err := qtx.GetRefundedAmountForPurchase(ctx, purchaseID)
+	if err != nil {
+		return database.Refund{}, nil, err
+	}
+	remaining := capturedCents - refundedCents
+	if remaining <= 0 {
+		return database.Refund{}, nil, fmt.Errorf("%w: purchase %s", ErrPaymentRefunded, purchaseID)
+	}
+
+	// Tickets are refunded at their price until the rest of the payment runs out
+	amountCents := int32(0)
+	ticketAmounts := make([]int32, len(selected))
+	selectedIDs := make([]uuid.UUID, len(selected))
+	for i, ticket := range selected {
+		ticketAmounts[i] = min(ticket.PriceCents, remaining)
+		remaining -= ticketAmounts[i]
+		amountCents += ticketAmounts[i]
+		selectedIDs[i] = ticket.ID
+	}
+
+	record, err := qtx.CreateRefund(ctx, database.CreateRefundParams{
+		PurchaseID:          purchaseID,
+		AmountCents:         amountCents,
+		ReturnedToInventory: returnToInventory,
+		Reason:              sql.NullString{String: reason, Valid: reason != ""},
+	})
+	if err != nil {
+		return database.Refund{}, nil, err
+	}
+
+	for i, ticket := range selected {
+		if err := qtx.CreateRefundTicket(ctx, database.CreateRefundTicketParams{
+			RefundID:    record.ID,
+			TicketID:    ticket.ID,
+			AmountCents: ticketAmounts[i],
+		}); err != nil {
+			return database.Refund{}, nil, err
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return database.Refund{}, nil, err
+	}
+	return record, selectedIDs, nil
+}
+
+// SettleRefund completes a pending refund the provider confirmed: its
+// tickets are marked refunded, or put back on sale, and the purchase moves to
+// refunded once no sold tickets are left. It returns the refunded tickets,
+// or ErrRefundSettled if the refund is no longer pending.
+func (r *Repo) SettleRefund(ctx context.Context, refundID uuid.UUID, providerRefundID string) ([]uuid.UUID, error) {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	qtx := r.queries.WithTx(tx)
+
+	record, err := qtx.LockRefund(ctx, refundID)
+	if err != nil {
+		return nil, err
+	}
+	if record.Status != database.RefundStatusPending {
+		return nil, fmt.Errorf("%w: refund %s is %s", ErrRefundSettled, refundID, record.Status)
+	}
+
+	refundedIDs, err := qtx.ListRefundTicketIDs(ctx, refundID)
+	if err != nil {
+		return nil, err
+	}
+
+	nullPurchaseID := uuid.NullUUID{UUID: record.PurchaseID, Valid: true}
+	tickets, err := qtx.LockPurchaseTickets(ctx, nullPurchaseID)
+	if err != nil {
+		return nil, err
+	}
+
+	purchase, err := qtx.GetPurchase(ctx, record.PurchaseID)
+	if err != nil {
+		return nil, err
+	}
+	next := database.PurchaseStatusRefunded
+	for _, ticket := range tickets {
+		if ticket.Status == database.TicketStatusSold && !slices.Contains(refundedIDs, ticket.ID) {
+			next = database.PurchaseStatusPartiallyRefunded
+			break
+		}
+	}
+
+	var updated int64
+	if record.ReturnedToInventory {
+		updated, err = qtx.ReturnTicketsToInventory(ctx, database.ReturnTicketsToInventoryParams{
+			Column1:    refundedIDs,
+			PurchaseID: nullPurchaseID,
+		})
+	} else {
+		updated, err = qtx.MarkTicketsRefunded(ctx, database.MarkTicketsRefundedParams{
+			Column1:    refundedIDs,
+			PurchaseID: nullPurchaseID,
+		})
+	}
+	if err != nil {
+		return nil, err
+	}
+	if updated != int64(len(refundedIDs)) {
+		return nil, fmt.Errorf("refunded %d of %d tickets", updated, len(refundedIDs))
+	}
+
+	if err := transitionPurchase(ctx, qtx, record.PurchaseID, purchase.Status, next, record.Reason.String); err != nil {
+		return nil, err
+	}
+
+	if err := qtx.SettleRefund(ctx, database.SettleRefundParams{
+		ID:               refundID,
+		ProviderRefundID: sql.NullString{String: providerRefundID, Valid: true},
+	}); err != nil {
+		return nil, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+	return refundedIDs, nil
+}
+
+// FailRefund marks a pending refund the provider rejected as failed, which
+// frees its tickets to be refunded again. It returns ErrRefundSettled if the
+// refund is no longer pending.
+func (r *Repo) FailRefund(ctx context.Context, refundID uuid.UUID, reason string) error {
+	updated, err := r.queries.FailRefund(ctx, database.FailRefundParams{
+		ID:            refundID,
+		FailureReason: sql.NullString{String: reason, Valid: reason != ""},
+	})
+	if err != nil {
+		return err
+	}
+	if updated == 0 {
+		return fmt.Errorf("%w: refund %s", ErrRefundSettled, refundID)
+	}
+	return nil
+}
+
+// ClaimPendingRefunds claims up to batchSize refunds left pending since
+// before updatedBefore. Claimed refunds are not returned to other callers
+// until they go stale again.
+func (r *Repo) ClaimPendingRefunds(ctx context.Context, updatedBefore time.Time, batchSize int32) ([]database.Refund, error) {
+	return r.queries.ClaimPendingRefunds(ctx, database.ClaimPendingRefundsParams{
+		UpdatedBefore: updatedBefore,
+		BatchSize:     batchSize,
+	})
+}
@@ -0,0 +1,127 @@
+package booking
+
+import (
+	"context"
+	"errors"
+	"regexp"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/google/uuid"
+
+	"github.com/ignisrex/tix/booking/internal/database"
+)
+
+func newMockRepo(t *testing.T) (*Repo, sqlmock.Sqlmock) {
+	t.Helper()
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("failed to open sqlmock: %v", err)
+	}
+	t.Cleanup(func() { db.Close() })
+	return NewRepo(db, database.New(db)), mock
+}
+
+func TestMarkPaymentRejectsSettledAttempts(t *testing.T) {
+	tests := []struct {
+		name  string
+		query string
+		mark  func(r *Repo, id uuid.UUID) error
+	}{
+		{"authorized", "SET status = 'authorized'", func(r *Repo, id uuid.UUID) error {
+			return r.MarkPaymentAuthorized(context.Background(), id, "auth_1")
+		}},
+		{"captured", "SET status = 'captured'", func(r *Repo, id uuid.UUID) error {
+			return r.MarkPaymentCaptured(context.Background(), id)
+		}},
+		{"voided", "SET status = 'voided'", func(r *Repo, id uuid.UUID) error {
+			return r.MarkPaymentVoided(context.Background(), id, "reason")
+		}},
+		{"failed", "SET status = 'failed'", func(r *Repo, id uuid.UUID) error {
+			return r.MarkPaymentFailed(context.Background(), id, "reason")
+		}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo, mock := newMockRepo(t)
+			id := uuid.New()
+
+			mock.ExpectExec(regexp.QuoteMeta(tt.query)).WillReturnResult(sqlmock.NewResult(0, 1))
+			if err := tt.mark(repo, id); err != nil {
+				t.Errorf("first move: %v", err)
+			}
+
+			// The attempt already left the status the move starts from
+			mock.ExpectExec(regexp.QuoteMeta(tt.query)).WillReturnResult(sqlmock.NewResult(0, 0))
+			if err := tt.mark(repo, id); !errors.Is(err, ErrPaymentSettled) {
+				t.Errorf("second move: err = %v, want ErrPaymentSettled", err)
+			}
+
+			if err := mock.ExpectationsWereMet(); err != nil {
+				t.Error(err)
+			}
+		})
+	}
+}
+
+func TestCreatePendingRefundCapsAtCapturedPayment(t *testing.T) {
+	purchaseID := uuid.New()
+	first, second := uuid.New(), uuid.New()
+
+	expectRefundSetup := func(mock sqlmock.Sqlmock, refundedCents int32) {
+		mock.ExpectBegin()
+		// The ticket type was repriced to 5000 after the purchase paid 2 x 3000
+		mock.ExpectQuery(regexp.QuoteMeta("-- name: LockPurchaseTickets")).
+			WillReturnRows(sqlmock.NewRows([]string{"id", "status", "price_cents", "refund_pending"}).
+				AddRow(first, "sold", 5000, false).
+				AddRow(second, "sold", 5000, false))
+		mock.ExpectQuery(regexp.QuoteMeta("-- name: GetPurchase")).
+			WillReturnRows(sqlmock.NewRows([]string{"id", "total_cents", "created_at", "updated_at", "status", "customer_id"}).
+				AddRow(purchaseID, 6000, time.Now(), time.Now(), "paid", uuid.New()))
+		mock.ExpectQuery(regexp.QuoteMeta("-- name: GetRefundedAmountForPurchase")).
+			WillReturnRows(sqlmock.NewRows([]string{"refunded_cents"}).AddRow(refundedCents))
+	}
+
+	t.Run("capped", func(t *testing.T) {
+		repo, mock := newMockRepo(t)
+		expectRefundSetup(mock, 0)
+		mock.ExpectQuery(regexp.QuoteMeta("-- name: CreateRefund")).
+			WithArgs(purchaseID, int32(6000), false, sqlmock.AnyArg()).
+			WillReturnRows(sqlmock.NewRows([]string{"id", "purchase_id", "amount_cents", "returned_to_inventory", "provider_refund_id", "reason", "created_at", "status", "failure_reason", "updated_at"}).
+				AddRow(uuid.New(), purchaseID, 6000, false, nil, nil, time.Now(), "pending", nil, time.Now()))
+		mock.ExpectExec(regexp.QuoteMeta("-- name: CreateRefundTicket")).
+			WithArgs(sqlmock.AnyArg(), first, int32(5000)).
+			WillReturnResult(sqlmock.NewResult(0, 1))
+		mock.ExpectExec(regexp.QuoteMeta("-- name: CreateRefundTicket")).
+			WithArgs(sqlmock.AnyArg(), second, int32(1000)).
+			WillReturnResult(sqlmock.NewResult(0, 1))
+		mock.ExpectCommit()
+
+		record, refundedIDs, err := repo.CreatePendingRefund(context.Background(), purchaseID, 6000, nil, false, "")
+		if err != nil {
+			t.Fatalf("CreatePendingRefund: %v", err)
+		}
+		if record.AmountCents != 6000 || len(refundedIDs) != 2 {
+			t.Errorf("refund = %d cents for %d tickets, want 6000 cents for 2", record.AmountCents, len(refundedIDs))
+		}
+		if err := mock.ExpectationsWereMet(); err != nil {
+			t.Error(err)
+		}
+	})
+
+	t.Run("fully refunded", func(t *testing.T) {
+		repo, mock := newMockRepo(t)
+		expectRefundSetup(mock, 6000)
+		mock.ExpectRollback()
+
+		_, _, err := repo.CreatePendingRefund(context.Background(), purchaseID, 6000, nil, false, "")
+		if !errors.Is(err, ErrPaymentRefunded) {
+			t.Errorf("err = %v, want ErrPaymentRefunded", err)
+		}
+		if err := mock.ExpectationsWereMet(); err != nil {
+			t.Error(err)
+		}
+	})
+}
@@ -7,84 +7,198 @@ import (
 	"errors"
 	"fmt"
 	"log"
+	"slices"
 	"time"
 
 	"github.com/google/uuid"
 
+	"github.com/ignisrex/tix/auth"
+	"github.com/ignisrex/tix/booking/internal/config"
+	"github.com/ignisrex/tix/booking/internal/database"
 	"github.com/ignisrex/tix/booking/internal/payment"
+	"github.com/ignisrex/tix/booking/internal/queue"
 	"github.com/ignisrex/tix/booking/internal/redis"
+	"github.com/ignisrex/tix/booking/mappers"
 	"github.com/ignisrex/tix/booking/types"
 )
 
 type Service struct {
-	repo        *Repo
-	redisClient *redis.Client
+	repo                  PurchaseRepo
+	redisClient           HoldStore
+	paymentProvider       payment.PaymentProvider
+	reservationTTL        time.Duration
+	maxHoldExtensions     int
+	bestAvailableAttempts int
+
+	queueTokens           *queue.Signer
+	queueBatchSize        int
+	queueIntervalSeconds  int
+	queueAdmissionSeconds int
+	queueJoinLimit        int
 }
 
+// Held tickets are kept for this long while they are being paid for
+const purchaseHoldTTL = 10 * time.Minute
+
 // Domain-level error markers used by handlers to map to HTTP responses.
 var (
 	ErrTicketNotFound   = errors.New("ticket not found")
 	ErrTicketSold       = errors.New("ticket sold")
 	ErrTicketReserved   = errors.New("ticket reserved")
 	ErrPaymentFailed    = errors.New("payment failed")
+	ErrPaymentTimeout   = errors.New("payment timed out")
 	ErrPurchaseNotFound = errors.New("purchase not found")
+	ErrCustomerNotFound = errors.New("customer not found")
+	ErrHoldMismatch     = errors.New("tickets are held by another customer")
+	ErrHoldNotFound     = errors.New("hold not found")
+	ErrExtensionLimit   = errors.New("hold extension limit reached")
+	ErrOrderLimit       = errors.New("per-order ticket limit exceeded")
+
+	ErrTicketNotInPurchase   = errors.New("ticket does not belong to purchase")
+	ErrTicketAlreadyRefunded = errors.New("ticket already refunded")
+	ErrPaymentNotCaptured    = errors.New("purchase has no captured payment")
+	ErrRefundFailed          = errors.New("refund failed")
+	ErrRefundInProgress      = errors.New("refund already in progress")
+	ErrRefundSettled         = errors.New("refund already settled")
+	ErrPaymentRefunded       = errors.New("payment already fully refunded")
+	ErrPaymentSettled        = errors.New("payment attempt already settled")
+
+	ErrTicketTypeNotFound = errors.New("ticket type not found")
+	ErrNotEnoughTickets   = errors.New("not enough tickets available")
 )
 
-func NewService(repo *Repo, redisClient *redis.Client) *Service {
+// TicketsSoldError reports the tickets that were sold to someone else by the
+// time a purchase was written. It matches ErrTicketSold with errors.Is.
+type TicketsSoldError struct {
+	TicketIDs []uuid.UUID
+}
+
+func (e *TicketsSoldError) Error() string {
+	return fmt.Sprintf("%v: %v", ErrTicketSold, e.TicketIDs)
+}
+
+func (e *TicketsSoldError) Unwrap() error {
+	return ErrTicketSold
+}
+
+func NewService(repo PurchaseRepo, redisClient HoldStore, paymentProvider payment.PaymentProvider, queueTokens *queue.Signer) *Service {
 	return &Service{
-		repo:        repo,
-		redisClient: redisClient,
+		repo:                  repo,
+		redisClient:           redisClient,
+		paymentProvider:       paymentProvider,
+		reservationTTL:        time.Duration(config.Envs.ReservationTTLSeconds) * time.Second,
+		maxHoldExtensions:     config.Envs.MaxHoldExtensions,
+		bestAvailableAttempts: config.Envs.BestAvailableAttempts,
+		queueTokens:           queueTokens,
+		queueBatchSize:        config.Envs.QueueBatchSize,
+		queueIntervalSeconds:  config.Envs.QueueIntervalSeconds,
+		queueAdmissionSeconds: config.Envs.QueueAdmissionSeconds,
+		queueJoinLimit:        config.Envs.QueueJoinLimit,
 	}
 }
 
 // ReserveTickets validates that all tickets exist and are available, and then
-// attempts to reserve them atomically in Redis.
-// On success it returns the reserved ticket IDs; on failure it returns a
-// domain error (e.g. ErrTicketNotFound, ErrTicketSold).
-func (s *Service) ReserveTickets(ctx context.Context, ticketIDs []uuid.UUID) ([]uuid.UUID, error) {
+// attempts to reserve them atomically in Redis under a hold ID.
+// A new hold ID is minted unless holdID is set, in which case the tickets are
+// added to that existing hold.
+// Tickets of events with a waiting room need the admission token issued by
+// the waiting room.
+// On success it returns the reserved ticket IDs and the hold ID; on failure it
+// returns a domain error (e.g. ErrTicketNotFound, ErrTicketSold).
+func (s *Service) ReserveTickets(ctx context.Context, ticketIDs []uuid.UUID, holdID uuid.UUID, admissionToken string) ([]uuid.UUID, uuid.UUID, error) {
 	// Validate all tickets exist and are available
 	tickets, err := s.repo.GetTicketsWithPrice(ctx, ticketIDs)
 	if err != nil {
 		log.Printf("ReserveTickets: failed to get tickets with price: %v", err)
-		return nil, fmt.Errorf("failed to get tickets with price: %w", err)
+		return nil, uuid.Nil, fmt.Errorf("failed to get tickets with price: %w", err)
 	}
 
 	if len(tickets) != len(ticketIDs) {
 		log.Printf("ReserveTickets: some tickets not found (requested=%d, found=%d)", len(ticketIDs), len(tickets))
-		return nil, fmt.Errorf("%w: some tickets not found", ErrTicketNotFound)
+		return nil, uuid.Nil, fmt.Errorf("%w: some tickets not found", ErrTicketNotFound)
+	}
+
+	var eventIDs []uuid.UUID
+	for _, ticket := range tickets {
+		if !slices.Contains(eventIDs, ticket.EventID) {
+			eventIDs = append(eventIDs, ticket.EventID)
+		}
+	}
+	if err := s.checkAdmission(ctx, eventIDs, admissionToken); err != nil {
+		log.Printf("ReserveTickets: %v", err)
+		return nil, uuid.Nil, err
 	}
 
 	for _, ticket := range tickets {
-		//check if ticket is sold
-		if ticket.Status == "sold" {
-			log.Printf("ReserveTickets: ticket %s already sold", ticket.ID)
-			return nil, fmt.Errorf("%w: ticket %s already sold", ErrTicketSold, ticket.ID)
+		// Sold and refunded tickets are both off sale
+		if ticket.Status != string(database.TicketStatusAvailable) {
+			log.Printf("ReserveTickets: ticket %s is %s", ticket.ID, ticket.Status)
+			return nil, uuid.Nil, fmt.Errorf("%w: ticket %s is %s", ErrTicketSold, ticket.ID, ticket.Status)
+		}
+	}
+
+	reserving := tickets
+
+	// Tickets already in the hold count towards the per-order limits as
+	// they will be bought in the same order
+	if holdID != uuid.Nil {
+		held, err := s.heldTickets(ctx, holdID, ticketIDs)
+		if err != nil {
+			log.Printf("ReserveTickets: failed to get tickets in hold %s: %v", holdID, err)
+			return nil, uuid.Nil, fmt.Errorf("failed to get tickets in hold: %w", err)
 		}
+		tickets = append(tickets, held...)
+	}
+	if err := checkOrderLimits(tickets); err != nil {
+		return nil, uuid.Nil, err
+	}
+
+	if holdID == uuid.Nil {
+		holdID = uuid.New()
+	}
+
+	// Holds belong to whoever created them, so only they can add to one
+	ownerID := uuid.Nil
+	if principal, ok := auth.PrincipalFromContext(ctx); ok {
+		ownerID = principal.Subject
 	}
 
 	// Attempt to reserve all tickets atomically
-	if err := s.redisClient.ReserveTickets(ctx, ticketIDs); err != nil {
+	if err := s.redisClient.ReserveTickets(ctx, ticketIDs, holdID, ownerID); err != nil {
 		log.Printf("ReserveTickets: failed to reserve tickets in redis: %v", err)
-		return nil, fmt.Errorf("failed to reserve tickets: %w", err)
+		switch {
+		case errors.Is(err, redis.ErrAlreadyReserved):
+			return nil, uuid.Nil, fmt.Errorf("%w: %v", ErrTicketReserved, err)
+		case errors.Is(err, redis.ErrHoldMismatch):
+			return nil, uuid.Nil, fmt.Errorf("%w: %v", ErrHoldMismatch, err)
+		}
+		return nil, uuid.Nil, fmt.Errorf("failed to reserve tickets: %w", err)
 	}
+	s.trackHolds(ctx, reserving, s.reservationTTL)
 
-	return ticketIDs, nil
+	return ticketIDs, holdID, nil
 }
 
 // PurchaseTickets attempts to purchase multiple tickets atomically.
+// The caller must present the hold ID returned by ReserveTickets and the
+// payment method token to charge.
 // If any ticket fails, all operations are rolled back and tickets are released
 // It returns the purchase ID and total cents on success.
 // On failure it returns a domain error (e.g. ErrTicketNotFound, ErrPaymentFailed).
-func (s *Service) PurchaseTickets(ctx context.Context, ticketIDs []uuid.UUID) (uuid.UUID, int32, error) {
-	// Refresh the lock TTL for each ticket to 10 minutes while processing payment
-	ok, err := s.redisClient.RefreshTickets(ctx, ticketIDs, 10*time.Minute)
-	if err != nil {
-		log.Printf("failed to refresh ticket locks before purchase: %v", err)
-	}
-
-	if !ok {
-		log.Printf("PurchaseTickets: one or more tickets are not reserved at purchase time")
-		return uuid.Nil, 0, fmt.Errorf("%w: one or more tickets are not reserved", ErrTicketReserved)
+// When the payment is declined the failed purchase's ID is returned alongside
+// the error so that the attempt can be inspected.
+// The purchase is linked to customerID unless it is uuid.Nil.
+func (s *Service) PurchaseTickets(ctx context.Context, ticketIDs []uuid.UUID, holdID uuid.UUID, paymentToken string, customerID uuid.UUID) (uuid.UUID, int32, error) {
+	// Refresh the lock TTL for each ticket while processing payment
+	if err := s.redisClient.RefreshTickets(ctx, ticketIDs, holdID, purchaseHoldTTL); err != nil {
+		log.Printf("PurchaseTickets: failed to refresh ticket locks before purchase: %v", err)
+		switch {
+		case errors.Is(err, redis.ErrHoldMismatch):
+			return uuid.Nil, 0, fmt.Errorf("%w: %v", ErrHoldMismatch, err)
+		case errors.Is(err, redis.ErrHoldNotFound):
+			return uuid.Nil, 0, fmt.Errorf("%w: one or more tickets are not reserved", ErrTicketReserved)
+		}
+		return uuid.Nil, 0, fmt.Errorf("failed to refresh ticket locks: %w", err)
 	}
 
 	tickets, err := s.repo.GetTicketsWithPrice(ctx, ticketIDs)
@@ -98,28 +212,164 @@ func (s *Service) PurchaseTickets(ctx context.Context, ticketIDs []uuid.UUID) (u
 		return uuid.Nil, 0, fmt.Errorf("%w: some tickets not found", ErrTicketNotFound)
 	}
 
-	totalCents, err := payment.ProcessPayment(ctx, tickets...)
+	if err := checkOrderLimits(tickets); err != nil {
+		return uuid.Nil, 0, err
+	}
+	s.trackHolds(ctx, tickets, purchaseHoldTTL)
+
+	// Fail fast before authorizing a payment for tickets that are already
+	// gone; the purchase transaction re-checks this under lock
+	var soldIDs []uuid.UUID
+	totalCents := int32(0)
+	for _, ticket := range tickets {
+		if ticket.Status != string(database.TicketStatusAvailable) {
+			soldIDs = append(soldIDs, ticket.ID)
+		}
+		totalCents += ticket.PriceCents
+	}
+	if len(soldIDs) > 0 {
+		log.Printf("PurchaseTickets: tickets no longer available: %v", soldIDs)
+		return uuid.Nil, 0, &TicketsSoldError{TicketIDs: soldIDs}
+	}
+
+	// Record the purchase and the attempt before talking to the provider so
+	// that an authorization left behind by a crash can be found and reconciled
+	purchase, err := s.repo.CreatePurchase(ctx, totalCents, customerID)
 	if err != nil {
-		log.Printf("PurchaseTickets: payment failed: %v", err)
-		return uuid.Nil, 0, fmt.Errorf("%w: %v", ErrPaymentFailed, err)
+		log.Printf("PurchaseTickets: failed to create purchase: %v", err)
+		if errors.Is(err, ErrCustomerNotFound) {
+			return uuid.Nil, 0, err
+		}
+		return uuid.Nil, 0, fmt.Errorf("failed to create purchase: %w", err)
+	}
+
+	attempt, err := s.repo.CreatePaymentAttempt(ctx, holdID, totalCents, purchase.ID)
+	if err != nil {
+		log.Printf("PurchaseTickets: failed to record payment attempt: %v", err)
+		s.transitionPurchase(ctx, purchase.ID, database.PurchaseStatusPendingPayment, database.PurchaseStatusCancelled, "failed to record payment attempt")
+		return uuid.Nil, 0, fmt.Errorf("failed to record payment attempt: %w", err)
 	}
 
-	// Purchase all tickets in a transaction
-	purchaseID, err := s.repo.PurchaseTickets(ctx, ticketIDs, int32(totalCents))
+	authorizationID, err := s.paymentProvider.Authorize(ctx, attempt.ID.String(), paymentToken, totalCents)
 	if err != nil {
-		log.Printf("PurchaseTickets: failed to purchase tickets in db: %v", err)
+		log.Printf("PurchaseTickets: payment authorization failed: %v", err)
+		if markErr := s.repo.MarkPaymentFailed(context.WithoutCancel(ctx), attempt.ID, err.Error()); markErr != nil {
+			log.Printf("PurchaseTickets: failed to mark payment attempt %s as failed: %v", attempt.ID, markErr)
+		}
+		s.transitionPurchase(ctx, purchase.ID, database.PurchaseStatusPendingPayment, database.PurchaseStatusFailed, err.Error())
+		if errors.Is(err, payment.ErrTimeout) || errors.Is(err, context.DeadlineExceeded) {
+			return purchase.ID, 0, fmt.Errorf("%w: %v", ErrPaymentTimeout, err)
+		}
+		return purchase.ID, 0, fmt.Errorf("%w: %v", ErrPaymentFailed, err)
+	}
+
+	if err := s.repo.MarkPaymentAuthorized(ctx, attempt.ID, authorizationID); err != nil {
+		log.Printf("PurchaseTickets: failed to mark payment attempt %s as authorized: %v", attempt.ID, err)
+		s.cancelPurchase(ctx, purchase.ID, attempt.ID, authorizationID, "failed to record authorization")
+		return uuid.Nil, 0, fmt.Errorf("failed to record payment authorization: %w", err)
+	}
+
+	// The hold may have expired or been taken over while the payment was
+	// being authorized; don't sell tickets the caller no longer holds
+	if err := s.redisClient.RefreshTickets(ctx, ticketIDs, holdID, purchaseHoldTTL); err != nil {
+		log.Printf("PurchaseTickets: hold %s lost during payment authorization: %v", holdID, err)
+		s.cancelPurchase(ctx, purchase.ID, attempt.ID, authorizationID, "hold lost during payment")
+		if errors.Is(err, redis.ErrHoldMismatch) {
+			return uuid.Nil, 0, fmt.Errorf("%w: %v", ErrHoldMismatch, err)
+		}
+		return uuid.Nil, 0, fmt.Errorf("%w: one or more tickets are no longer reserved", ErrTicketReserved)
+	}
+
+	// Sell all tickets and mark the purchase paid in a transaction
+	if err := s.repo.SellTickets(ctx, purchase.ID, ticketIDs, ""); err != nil {
+		log.Printf("PurchaseTickets: failed to sell tickets in db: %v", err)
+		s.cancelPurchase(ctx, purchase.ID, attempt.ID, authorizationID, err.Error())
+		if errors.Is(err, ErrTicketSold) {
+			return uuid.Nil, 0, err
+		}
 		return uuid.Nil, 0, fmt.Errorf("failed to purchase tickets: %w", err)
 	}
 
+	// The purchase is committed at this point. A failed capture is left in the
+	// authorized state for the reconciler to retry rather than failing the
+	// purchase.
+	s.capturePayment(context.WithoutCancel(ctx), attempt.ID, authorizationID)
+
+	// Announce the sale before releasing the holds so that live availability
+	// never shows the tickets as free in between
+	s.publishTicketStatus(ctx, ticketIDs, database.TicketStatusSold)
+
 	// Release all reservations
-	if err := s.redisClient.ReleaseTickets(ctx, ticketIDs); err != nil {
+	if err := s.redisClient.ReleaseTickets(ctx, ticketIDs, holdID); err != nil {
 		log.Printf("failed to release tickets: %v", err)
 	}
+	s.untrackHolds(ctx, tickets)
 
-	return purchaseID, int32(totalCents), nil
+	return purchase.ID, totalCents, nil
 }
 
-// GetPurchaseDetails retrieves purchase details including all tickets
+// CompTickets issues held tickets free of charge as a paid purchase with a
+// zero total, skipping the payment provider. The reason is recorded on the
+// purchase's status history.
+// The purchase is linked to customerID unless it is uuid.Nil.
+func (s *Service) CompTickets(ctx context.Context, ticketIDs []uuid.UUID, holdID uuid.UUID, customerID uuid.UUID, reason string) (uuid.UUID, error) {
+	if err := s.redisClient.RefreshTickets(ctx, ticketIDs, holdID, purchaseHoldTTL); err != nil {
+		log.Printf("CompTickets: failed to refresh ticket locks: %v", err)
+		switch {
+		case errors.Is(err, redis.ErrHoldMismatch):
+			return uuid.Nil, fmt.Errorf("%w: %v", ErrHoldMismatch, err)
+		case errors.Is(err, redis.ErrHoldNotFound):
+			return uuid.Nil, fmt.Errorf("%w: one or more tickets are not reserved", ErrTicketReserved)
+		}
+		return uuid.Nil, fmt.Errorf("failed to refresh ticket locks: %w", err)
+	}
+
+	tickets, err := s.repo.GetTicketsWithPrice(ctx, ticketIDs)
+	if err != nil {
+		log.Printf("CompTickets: failed to get ticket details: %v", err)
+		return uuid.Nil, fmt.Errorf("failed to get ticket details: %w", err)
+	}
+	if len(tickets) != len(ticketIDs) {
+		return uuid.Nil, fmt.Errorf("%w: some tickets not found", ErrTicketNotFound)
+	}
+	s.trackHolds(ctx, tickets, purchaseHoldTTL)
+
+	purchase, err := s.repo.CreatePurchase(ctx, 0, customerID)
+	if err != nil {
+		log.Printf("CompTickets: failed to create purchase: %v", err)
+		if errors.Is(err, ErrCustomerNotFound) {
+			return uuid.Nil, err
+		}
+		return uuid.Nil, fmt.Errorf("failed to create purchase: %w", err)
+	}
+
+	compReason := "complimentary"
+	if reason != "" {
+		compReason += ": " + reason
+	}
+	if err := s.repo.SellTickets(ctx, purchase.ID, ticketIDs, compReason); err != nil {
+		log.Printf("CompTickets: failed to issue tickets in db: %v", err)
+		s.transitionPurchase(ctx, purchase.ID, database.PurchaseStatusPendingPayment, database.PurchaseStatusCancelled, err.Error())
+		if errors.Is(err, ErrTicketSold) {
+			return uuid.Nil, err
+		}
+		return uuid.Nil, fmt.Errorf("failed to issue tickets: %w", err)
+	}
+
+	s.publishTicketStatus(ctx, ticketIDs, database.TicketStatusSold)
+
+	if err := s.redisClient.ReleaseTickets(ctx, ticketIDs, holdID); err != nil {
+		log.Printf("CompTickets: failed to release tickets: %v", err)
+	}
+	s.untrackHolds(ctx, tickets)
+
+	return purchase.ID, nil
+}
+
+// GetPurchaseDetails retrieves purchase details including all tickets.
+// Purchases linked to a customer are only visible to that customer and to
+// staff (auth.ErrUnauthenticated or auth.ErrForbidden otherwise); anonymous
+// purchases remain visible to anyone holding their ID.
 func (s *Service) GetPurchaseDetails(ctx context.Context, purchaseID uuid.UUID) (*types.PurchaseDetailsResponse, error) {
 	details, err := s.repo.GetPurchaseDetails(ctx, purchaseID)
 	if err != nil {
@@ -130,6 +380,11 @@ func (s *Service) GetPurchaseDetails(ctx context.Context, purchaseID uuid.UUID)
 		log.Printf("GetPurchaseDetails: failed to get purchase details from db: %v", err)
 		return nil, fmt.Errorf("failed to get purchase details: %w", err)
 	}
+	if details.CustomerID.Valid {
+		if _, err := auth.AuthorizeOwner(ctx, details.CustomerID.UUID, auth.PermViewAllPurchases); err != nil {
+			return nil, err
+		}
+	}
 
 	var ticketDetails []types.PurchaseTicketDetail
 	if err := json.Unmarshal(details.Tickets, &ticketDetails); err != nil {
@@ -137,20 +392,327 @@ func (s *Service) GetPurchaseDetails(ctx context.Context, purchaseID uuid.UUID)
 		return nil, fmt.Errorf("failed to parse ticket details: %w", err)
 	}
 
+	refunds, err := s.repo.ListPurchaseRefunds(ctx, purchaseID)
+	if err != nil {
+		log.Printf("GetPurchaseDetails: failed to get refunds from db: %v", err)
+		return nil, fmt.Errorf("failed to get refunds: %w", err)
+	}
+
+	transitions, err := s.repo.ListPurchaseStatusTransitions(ctx, purchaseID)
+	if err != nil {
+		log.Printf("GetPurchaseDetails: failed to get status history from db: %v", err)
+		return nil, fmt.Errorf("failed to get status history: %w", err)
+	}
+
+	attempts, err := s.repo.ListPurchasePaymentAttempts(ctx, purchaseID)
+	if err != nil {
+		log.Printf("GetPurchaseDetails: failed to get payment attempts from db: %v", err)
+		return nil, fmt.Errorf("failed to get payment attempts: %w", err)
+	}
+
 	resp := &types.PurchaseDetailsResponse{
 		PurchaseID:        details.PurchaseID,
 		TotalCents:        details.TotalCents,
+		Status:            string(details.Status),
 		PurchaseCreatedAt: details.PurchaseCreatedAt.Format(time.RFC3339),
 		Tickets:           ticketDetails,
+		Refunds:           mappers.ToRefunds(refunds),
+		StatusHistory:     mappers.ToStatusTransitions(transitions),
+		PaymentAttempts:   mappers.ToPaymentAttempts(attempts),
+	}
+	if details.CustomerID.Valid {
+		resp.CustomerID = &details.CustomerID.UUID
+	}
+	for _, refund := range resp.Refunds {
+		if refund.Status == string(database.RefundStatusSucceeded) {
+			resp.RefundedCents += refund.AmountCents
+		}
 	}
 
 	return resp, nil
 }
 
+// RefundPurchase refunds a purchase through the payment provider, either in
+// full or for the given tickets only. Refunded tickets are marked refunded, or
+// put back on sale when returnToInventory is set.
+// The refund is recorded as pending before the provider is called, and only
+// settled once the provider confirms it. If the provider's answer is lost,
+// e.g. to a timeout, the refund is returned still pending and
+// ReconcileRefunds settles it later.
+// On failure it returns a domain error (e.g. ErrPurchaseNotFound,
+// ErrTicketAlreadyRefunded, ErrRefundInProgress, ErrPaymentRefunded,
+// ErrRefundFailed).
+func (s *Service) RefundPurchase(ctx context.Context, purchaseID uuid.UUID, ticketIDs []uuid.UUID, returnToInventory bool, reason string) (*types.Refund, error) {
+	purchase, err := s.repo.GetPurchase(ctx, purchaseID)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, fmt.Errorf("%w: %s", ErrPurchaseNotFound, purchaseID)
+		}
+		log.Printf("RefundPurchase: failed to get purchase %s: %v", purchaseID, err)
+		return nil, fmt.Errorf("failed to get purchase: %w", err)
+	}
+
+	// Only paid purchases can be refunded; partial refunds keep that true
+	if err := checkTransition(purchase.Status, database.PurchaseStatusRefunded); err != nil {
+		return nil, err
+	}
+
+	attempt, err := s.repo.GetCapturedPaymentForPurchase(ctx, purchaseID)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, fmt.Errorf("%w: %s", ErrPaymentNotCaptured, purchaseID)
+		}
+		log.Printf("RefundPurchase: failed to get payment for purchase %s: %v", purchaseID, err)
+		return nil, fmt.Errorf("failed to get payment: %w", err)
+	}
+
+	record, refundedIDs, err := s.repo.CreatePendingRefund(ctx, purchaseID, attempt.AmountCents, ticketIDs, returnToInventory, reason)
+	if err != nil {
+		log.Printf("RefundPurchase: failed to record refund of purchase %s: %v", purchaseID, err)
+		return nil, err
+	}
+
+	return s.processRefund(ctx, record, refundedIDs, attempt.AuthorizationID.String)
+}
+
+// processRefund pays out a pending refund and settles it. A refund the
+// provider rejected is failed; one whose outcome is unknown stays pending and
+// is returned as such.
+func (s *Service) processRefund(ctx context.Context, record database.Refund, refundedIDs []uuid.UUID, authorizationID string) (*types.Refund, error) {
+	providerRefundID, err := s.paymentProvider.Refund(ctx, record.ID.String(), authorizationID, record.AmountCents)
+	if err != nil {
+		if errors.Is(err, payment.ErrTimeout) || errors.Is(err, context.DeadlineExceeded) || errors.Is(err, context.Canceled) {
+			// The refund may have gone through; the provider won't pay it out
+			// twice, so the reconciler retries it under the same reference
+			log.Printf("processRefund: refund %s left pending: %v", record.ID, err)
+			return toRefund(record, refundedIDs), nil
+		}
+		log.Printf("processRefund: provider rejected refund %s: %v", record.ID, err)
+		if failErr := s.repo.FailRefund(context.WithoutCancel(ctx), record.ID, err.Error()); failErr != nil {
+			log.Printf("processRefund: failed to mark refund %s as failed: %v", record.ID, failErr)
+		}
+		return nil, fmt.Errorf("%w: %v", ErrRefundFailed, err)
+	}
+
+	// The money went back, so record it even if the caller has gone away
+	refundedIDs, err = s.repo.SettleRefund(context.WithoutCancel(ctx), record.ID, providerRefundID)
+	if err != nil {
+		log.Printf("processRefund: provider refund %s for refund %s was not recorded: %v", providerRefundID, record.ID, err)
+		return toRefund(record, refundedIDs), nil
+	}
+
+	status := database.TicketStatusRefunded
+	if record.ReturnedToInventory {
+		status = database.TicketStatusAvailable
+	}
+	s.publishTicketStatus(ctx, refundedIDs, status)
+
+	record.Status = database.RefundStatusSucceeded
+	return toRefund(record, refundedIDs), nil
+}
+
+func toRefund(record database.Refund, ticketIDs []uuid.UUID) *types.Refund {
+	refund := mappers.ToRefund(database.ListPurchaseRefundsRow{
+		ID:                  record.ID,
+		AmountCents:         record.AmountCents,
+		ReturnedToInventory: record.ReturnedToInventory,
+		Reason:              record.Reason,
+		CreatedAt:           record.CreatedAt,
+		Status:              record.Status,
+		TicketIds:           ticketIDs,
+	})
+	return &refund
+}
+
+// heldTickets returns the tickets reserved under holdID, skipping the ones
+// in exclude. A hold that no longer exists has no tickets.
+func (s *Service) heldTickets(ctx context.Context, holdID uuid.UUID, exclude []uuid.UUID) ([]types.Ticket, error) {
+	hold, err := s.redisClient.GetHold(ctx, holdID)
+	if err != nil {
+		if errors.Is(err, redis.ErrHoldNotFound) {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	excluded := make(map[uuid.UUID]bool, len(exclude))
+	for _, id := range exclude {
+		excluded[id] = true
+	}
+	var ids []uuid.UUID
+	for ticketID := range hold.Tickets {
+		if !excluded[ticketID] {
+			ids = append(ids, ticketID)
+		}
+	}
+	if len(ids) == 0 {
+		return nil, nil
+	}
+	return s.repo.GetTicketsWithPrice(ctx, ids)
+}
+
+// checkOrderLimits rejects orders with more tickets of a type than the type's
+// per-order limit allows
+func checkOrderLimits(tickets []types.Ticket) error {
+	counts := make(map[uuid.UUID]int32)
+	for _, ticket := range tickets {
+		if ticket.MaxPerOrder == 0 {
+			continue
+		}
+		counts[ticket.TicketTypeID]++
+		if counts[ticket.TicketTypeID] == ticket.MaxPerOrder+1 {
+			return fmt.Errorf("%w: at most %d %s tickets can be bought per order", ErrOrderLimit, ticket.MaxPerOrder, ticket.TicketTypeName)
+		}
+	}
+	return nil
+}
+
 // CheckTicketLocks checks the reservation status for multiple tickets.
 // Returns a map of ticketID -> is_reserved (true if reserved, false if available).
 func (s *Service) CheckTicketLocks(ctx context.Context, ticketIDs []uuid.UUID) (map[uuid.UUID]bool, error) {
 	return s.redisClient.AreReserved(ctx, ticketIDs)
 }
 
+// GetHold returns the tickets still reserved under holdID with their remaining TTL.
+// Like ExtendHold and ReleaseHold, it is limited to whoever created the hold
+// and to staff; holds created anonymously are open to anyone holding their ID.
+func (s *Service) GetHold(ctx context.Context, holdID uuid.UUID) (*types.HoldResponse, error) {
+	hold, err := s.redisClient.GetHold(ctx, holdID)
+	if err != nil {
+		if errors.Is(err, redis.ErrHoldNotFound) {
+			return nil, fmt.Errorf("%w: %v", ErrHoldNotFound, err)
+		}
+		log.Printf("GetHold: failed to get hold %s: %v", holdID, err)
+		return nil, fmt.Errorf("failed to get hold: %w", err)
+	}
+	if hold.Owner != uuid.Nil {
+		if _, err := auth.AuthorizeOwner(ctx, hold.Owner, auth.PermSellTickets); err != nil {
+			return nil, err
+		}
+	}
 
+	resp := &types.HoldResponse{
+		HoldID:        hold.ID,
+		Tickets:       make([]types.HeldTicket, 0, len(hold.Tickets)),
+		Extensions:    hold.Extensions,
+		MaxExtensions: s.maxHoldExtensions,
+	}
+	for ticketID, ttl := range hold.Tickets {
+		expiresIn := int(ttl.Seconds())
+		resp.Tickets = append(resp.Tickets, types.HeldTicket{
+			TicketID:         ticketID,
+			ExpiresInSeconds: expiresIn,
+		})
+		if len(resp.Tickets) == 1 || expiresIn < resp.ExpiresInSeconds {
+			resp.ExpiresInSeconds = expiresIn
+		}
+	}
+
+	return resp, nil
+}
+
+// ExtendHold resets every ticket in the hold to a full reservation TTL.
+// Holds can only be extended a limited number of times so abandoned
+// checkouts do not keep seats locked indefinitely.
+func (s *Service) ExtendHold(ctx context.Context, holdID uuid.UUID) (*types.HoldResponse, error) {
+	hold, err := s.GetHold(ctx, holdID)
+	if err != nil {
+		return nil, err
+	}
+
+	ticketIDs := make([]uuid.UUID, len(hold.Tickets))
+	for i, ticket := range hold.Tickets {
+		ticketIDs[i] = ticket.TicketID
+	}
+
+	if err := s.redisClient.ExtendHold(ctx, holdID, ticketIDs, s.maxHoldExtensions); err != nil {
+		switch {
+		case errors.Is(err, redis.ErrExtensionLimit):
+			return nil, fmt.Errorf("%w: hold %s was already extended %d times", ErrExtensionLimit, holdID, hold.Extensions)
+		case errors.Is(err, redis.ErrHoldNotFound), errors.Is(err, redis.ErrHoldMismatch):
+			return nil, fmt.Errorf("%w: %v", ErrHoldNotFound, err)
+		}
+		log.Printf("ExtendHold: failed to extend hold %s: %v", holdID, err)
+		return nil, fmt.Errorf("failed to extend hold: %w", err)
+	}
+	s.retrackHolds(ctx, ticketIDs, s.reservationTTL)
+
+	return s.GetHold(ctx, holdID)
+}
+
+// ReleaseHold releases every ticket still reserved under holdID so other
+// customers can pick them up straight away.
+func (s *Service) ReleaseHold(ctx context.Context, holdID uuid.UUID) error {
+	hold, err := s.GetHold(ctx, holdID)
+	if err != nil {
+		return err
+	}
+
+	ticketIDs := make([]uuid.UUID, len(hold.Tickets))
+	for i, ticket := range hold.Tickets {
+		ticketIDs[i] = ticket.TicketID
+	}
+
+	if err := s.redisClient.ReleaseTickets(ctx, ticketIDs, holdID); err != nil {
+		if errors.Is(err, redis.ErrHoldNotFound) || errors.Is(err, redis.ErrHoldMismatch) {
+			return fmt.Errorf("%w: %v", ErrHoldNotFound, err)
+		}
+		log.Printf("ReleaseHold: failed to release hold %s: %v", holdID, err)
+		return fmt.Errorf("failed to release hold: %w", err)
+	}
+	s.retrackHolds(ctx, ticketIDs, 0)
+
+	return nil
+}
+
+// publishTicketStatus announces a committed ticket status change to live
+// availability subscribers. Failures are only logged: the change is already
+// committed and subscribers resync periodically.
+func (s *Service) publishTicketStatus(ctx context.Context, ticketIDs []uuid.UUID, status database.TicketStatus) {
+	if err := s.redisClient.PublishTicketStatus(context.WithoutCancel(ctx), ticketIDs, string(status)); err != nil {
+		log.Printf("failed to publish %s status for tickets %v: %v", status, ticketIDs, err)
+	}
+}
+
+// trackHolds records held tickets in their event's hold index, which is what
+// availability counts are computed from. Like publishTicketStatus, failures
+// are only logged.
+func (s *Service) trackHolds(ctx context.Context, tickets []types.Ticket, ttl time.Duration) {
+	for eventID, ticketIDs := range ticketsByEvent(tickets) {
+		if err := s.redisClient.TrackHolds(ctx, eventID, ticketIDs, ttl); err != nil {
+			log.Printf("failed to track holds of event %s: %v", eventID, err)
+		}
+	}
+}
+
+// untrackHolds removes released tickets from their event's hold index
+func (s *Service) untrackHolds(ctx context.Context, tickets []types.Ticket) {
+	for eventID, ticketIDs := range ticketsByEvent(tickets) {
+		if err := s.redisClient.UntrackHolds(ctx, eventID, ticketIDs); err != nil {
+			log.Printf("failed to untrack holds of event %s: %v", eventID, err)
+		}
+	}
+}
+
+// retrackHolds looks up the events of the tickets of a hold and tracks them
+// for ttl, or untracks them when ttl is zero
+func (s *Service) retrackHolds(ctx context.Context, ticketIDs []uuid.UUID, ttl time.Duration) {
+	tickets, err := s.repo.GetTicketsWithPrice(ctx, ticketIDs)
+	if err != nil {
+		log.Printf("failed to get tickets to update hold index: %v", err)
+		return
+	}
+	if ttl == 0 {
+		s.untrackHolds(ctx, tickets)
+		return
+	}
+	s.trackHolds(ctx, tickets, ttl)
+}
+
+func ticketsByEvent(tickets []types.Ticket) map[uuid.UUID][]uuid.UUID {
+	byEvent := make(map[uuid.UUID][]uuid.UUID)
+	for _, ticket := range tickets {
+		byEvent[ticket.EventID] = append(byEvent[ticket.EventID], ticket.ID)
+	}
+	return byEvent
+}
@@ -0,0 +1,422 @@
+package booking
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/ignisrex/tix/auth"
+	"github.com/ignisrex/tix/booking/internal/database"
+	"github.com/ignisrex/tix/booking/internal/payment"
+	"github.com/ignisrex/tix/booking/types"
+)
+
+func newTestService(repo *fakeRepo, holds *fakeHoldStore) *Service {
+	return &Service{
+		repo:            repo,
+		redisClient:     holds,
+		paymentProvider: payment.NewDeterministic(),
+		reservationTTL:  3 * time.Minute,
+	}
+}
+
+// heldTickets sets up available tickets held under a new hold
+func heldTickets(t *testing.T, prices ...int32) (*fakeRepo, *fakeHoldStore, []uuid.UUID, uuid.UUID) {
+	t.Helper()
+	var tickets []types.Ticket
+	var ids []uuid.UUID
+	for _, price := range prices {
+		ticket := newTestTicket(price)
+		tickets = append(tickets, ticket)
+		ids = append(ids, ticket.ID)
+	}
+	repo := newFakeRepo(tickets...)
+	holds := newFakeHoldStore()
+	holdID := uuid.New()
+	if err := holds.ReserveTickets(context.Background(), ids, holdID, uuid.Nil); err != nil {
+		t.Fatalf("failed to hold tickets: %v", err)
+	}
+	return repo, holds, ids, holdID
+}
+
+func TestPurchaseTicketsSucceeds(t *testing.T) {
+	repo, holds, ticketIDs, holdID := heldTickets(t, 2500, 4000)
+	s := newTestService(repo, holds)
+
+	purchaseID, total, err := s.PurchaseTickets(context.Background(), ticketIDs, holdID, payment.TokenSuccess, uuid.Nil)
+	if err != nil {
+		t.Fatalf("PurchaseTickets: %v", err)
+	}
+
+	if total != 6500 {
+		t.Errorf("total = %d, want 6500", total)
+	}
+	if status := repo.purchase(purchaseID).Status; status != database.PurchaseStatusPaid {
+		t.Errorf("purchase status = %s, want paid", status)
+	}
+	attempt, ok := repo.attemptFor(purchaseID)
+	if !ok {
+		t.Fatalf("no payment attempt recorded")
+	}
+	if attempt.Status != database.PaymentAttemptStatusCaptured {
+		t.Errorf("payment attempt status = %s, want captured", attempt.Status)
+	}
+	if want := "auth_" + attempt.ID.String(); attempt.AuthorizationID.String != want {
+		t.Errorf("authorization ID = %q, want %q", attempt.AuthorizationID.String, want)
+	}
+	for _, id := range ticketIDs {
+		if status := repo.ticket(id).Status; status != string(database.TicketStatusSold) {
+			t.Errorf("ticket %s status = %s, want sold", id, status)
+		}
+		if _, held := holds.holder(id); held {
+			t.Errorf("ticket %s still held after purchase", id)
+		}
+		if status := holds.published[id]; status != string(database.TicketStatusSold) {
+			t.Errorf("ticket %s published status = %q, want sold", id, status)
+		}
+	}
+}
+
+func TestPurchaseTicketsPaymentFailures(t *testing.T) {
+	tests := []struct {
+		name    string
+		token   string
+		timeout time.Duration
+		wantErr error
+		wantMsg string
+	}{
+		{name: "declined", token: payment.TokenDecline, wantErr: ErrPaymentFailed, wantMsg: "card declined"},
+		{name: "insufficient funds", token: payment.TokenInsufficientFunds, wantErr: ErrPaymentFailed, wantMsg: "insufficient funds"},
+		{name: "unknown token", token: "tok_unknown", wantErr: ErrPaymentFailed, wantMsg: "invalid payment method token"},
+		{name: "provider timeout", token: payment.TokenTimeout, wantErr: ErrPaymentTimeout, wantMsg: "payment provider timed out"},
+		// The request gives up before the provider's own timeout
+		{name: "request timeout", token: payment.TokenTimeout, timeout: 50 * time.Millisecond, wantErr: ErrPaymentTimeout},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo, holds, ticketIDs, holdID := heldTickets(t, 2500)
+			s := newTestService(repo, holds)
+
+			ctx := context.Background()
+			if tt.timeout > 0 {
+				var cancel context.CancelFunc
+				ctx, cancel = context.WithTimeout(ctx, tt.timeout)
+				defer cancel()
+			}
+
+			purchaseID, total, err := s.PurchaseTickets(ctx, ticketIDs, holdID, tt.token, uuid.Nil)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("err = %v, want %v", err, tt.wantErr)
+			}
+			if tt.wantMsg != "" && !strings.Contains(err.Error(), tt.wantMsg) {
+				t.Errorf("err = %q, want it to mention %q", err, tt.wantMsg)
+			}
+			if total != 0 {
+				t.Errorf("total = %d, want 0", total)
+			}
+
+			// The failed purchase is returned so the attempt can be inspected
+			if purchaseID == uuid.Nil {
+				t.Fatalf("no purchase ID returned for a failed payment")
+			}
+			if status := repo.purchase(purchaseID).Status; status != database.PurchaseStatusFailed {
+				t.Errorf("purchase status = %s, want failed", status)
+			}
+			attempt, _ := repo.attemptFor(purchaseID)
+			if attempt.Status != database.PaymentAttemptStatusFailed {
+				t.Errorf("payment attempt status = %s, want failed", attempt.Status)
+			}
+
+			// The customer keeps their hold and can try another card
+			for _, id := range ticketIDs {
+				if status := repo.ticket(id).Status; status != string(database.TicketStatusAvailable) {
+					t.Errorf("ticket %s status = %s, want available", id, status)
+				}
+				if holder, _ := holds.holder(id); holder != holdID {
+					t.Errorf("ticket %s no longer held by the buyer", id)
+				}
+			}
+		})
+	}
+}
+
+func TestPurchaseTicketsRequiresHold(t *testing.T) {
+	repo, holds, ticketIDs, _ := heldTickets(t, 2500)
+	s := newTestService(repo, holds)
+
+	_, _, err := s.PurchaseTickets(context.Background(), ticketIDs, uuid.New(), payment.TokenSuccess, uuid.Nil)
+	if !errors.Is(err, ErrHoldMismatch) {
+		t.Fatalf("err = %v, want ErrHoldMismatch", err)
+	}
+	if len(repo.purchases) != 0 {
+		t.Errorf("a purchase was created without the hold")
+	}
+}
+
+func TestPurchaseTicketsRejectsUnavailableTickets(t *testing.T) {
+	for _, status := range []database.TicketStatus{database.TicketStatusSold, database.TicketStatusRefunded} {
+		t.Run(string(status), func(t *testing.T) {
+			repo, holds, ticketIDs, holdID := heldTickets(t, 2500, 2500)
+			ticket := repo.tickets[ticketIDs[1]]
+			ticket.Status = string(status)
+			repo.tickets[ticket.ID] = ticket
+			s := newTestService(repo, holds)
+
+			_, _, err := s.PurchaseTickets(context.Background(), ticketIDs, holdID, payment.TokenSuccess, uuid.Nil)
+			var soldErr *TicketsSoldError
+			if !errors.As(err, &soldErr) {
+				t.Fatalf("err = %v, want a TicketsSoldError", err)
+			}
+			if len(soldErr.TicketIDs) != 1 || soldErr.TicketIDs[0] != ticket.ID {
+				t.Errorf("unavailable tickets = %v, want [%s]", soldErr.TicketIDs, ticket.ID)
+			}
+			if len(repo.attempts) != 0 {
+				t.Errorf("payment was attempted for unavailable tickets")
+			}
+		})
+	}
+}
+
+func newRefundService(repo *fakeRepo, holds *fakeHoldStore, provider *recordingProvider) *Service {
+	return &Service{repo: repo, redisClient: holds, paymentProvider: provider}
+}
+
+func TestRefundPurchaseSettlesRefund(t *testing.T) {
+	tests := []struct {
+		name              string
+		refund            int // how many of the three tickets to refund; 0 refunds all
+		returnToInventory bool
+		wantPurchase      database.PurchaseStatus
+		wantTicket        database.TicketStatus
+	}{
+		{name: "full", wantPurchase: database.PurchaseStatusRefunded, wantTicket: database.TicketStatusRefunded},
+		{name: "partial", refund: 1, wantPurchase: database.PurchaseStatusPartiallyRefunded, wantTicket: database.TicketStatusRefunded},
+		{name: "back to inventory", returnToInventory: true, wantPurchase: database.PurchaseStatusRefunded, wantTicket: database.TicketStatusAvailable},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo := newFakeRepo()
+			holds := newFakeHoldStore()
+			provider := newRecordingProvider()
+			s := newRefundService(repo, holds, provider)
+			purchaseID, ticketIDs := repo.addSoldPurchase(2500, 2500, 4000)
+			requested := ticketIDs[:tt.refund]
+
+			refund, err := s.RefundPurchase(context.Background(), purchaseID, requested, tt.returnToInventory, "customer request")
+			if err != nil {
+				t.Fatalf("RefundPurchase: %v", err)
+			}
+
+			if refund.Status != string(database.RefundStatusSucceeded) {
+				t.Errorf("refund status = %s, want succeeded", refund.Status)
+			}
+			if len(provider.refunded) != 1 || provider.refunded[0] != refund.ID.String() {
+				t.Errorf("provider refunds = %v, want one under reference %s", provider.refunded, refund.ID)
+			}
+			if got := repo.refund(refund.ID).ProviderRefundID.String; got != "re_"+refund.ID.String() {
+				t.Errorf("provider refund ID = %q, want re_%s", got, refund.ID)
+			}
+			if status := repo.purchase(purchaseID).Status; status != tt.wantPurchase {
+				t.Errorf("purchase status = %s, want %s", status, tt.wantPurchase)
+			}
+			for _, id := range refund.TicketIDs {
+				if status := repo.ticket(id).Status; status != string(tt.wantTicket) {
+					t.Errorf("ticket %s status = %s, want %s", id, status, tt.wantTicket)
+				}
+				if status := holds.published[id]; status != string(tt.wantTicket) {
+					t.Errorf("ticket %s published status = %q, want %s", id, status, tt.wantTicket)
+				}
+			}
+
+			// Returned tickets leave the purchase but stay in its history
+			details, err := s.GetPurchaseDetails(context.Background(), purchaseID)
+			if err != nil {
+				t.Fatalf("GetPurchaseDetails: %v", err)
+			}
+			if len(details.Tickets) != len(ticketIDs) {
+				t.Fatalf("purchase lists %d tickets, want %d", len(details.Tickets), len(ticketIDs))
+			}
+			for _, ticket := range details.Tickets {
+				want := string(database.TicketStatusSold)
+				if slices.Contains(refund.TicketIDs, ticket.ID) {
+					want = string(database.TicketStatusRefunded)
+				}
+				if ticket.Status != want {
+					t.Errorf("purchase ticket %s status = %s, want %s", ticket.ID, ticket.Status, want)
+				}
+			}
+		})
+	}
+}
+
+func TestRefundPurchaseFailsRejectedRefund(t *testing.T) {
+	repo := newFakeRepo()
+	provider := newRecordingProvider()
+	provider.refundErr = errors.New("charge already disputed")
+	s := newRefundService(repo, newFakeHoldStore(), provider)
+	purchaseID, ticketIDs := repo.addSoldPurchase(2500)
+
+	_, err := s.RefundPurchase(context.Background(), purchaseID, nil, false, "")
+	if !errors.Is(err, ErrRefundFailed) {
+		t.Fatalf("err = %v, want ErrRefundFailed", err)
+	}
+	for _, refund := range repo.refunds {
+		if refund.Status != database.RefundStatusFailed {
+			t.Errorf("refund status = %s, want failed", refund.Status)
+		}
+	}
+	if status := repo.ticket(ticketIDs[0]).Status; status != string(database.TicketStatusSold) {
+		t.Errorf("ticket status = %s, want sold", status)
+	}
+	if status := repo.purchase(purchaseID).Status; status != database.PurchaseStatusPaid {
+		t.Errorf("purchase status = %s, want paid", status)
+	}
+
+	// A failed refund frees its tickets to be refunded again
+	provider.refundErr = nil
+	if _, err := s.RefundPurchase(context.Background(), purchaseID, nil, false, ""); err != nil {
+		t.Fatalf("second RefundPurchase: %v", err)
+	}
+	if status := repo.purchase(purchaseID).Status; status != database.PurchaseStatusRefunded {
+		t.Errorf("purchase status = %s, want refunded", status)
+	}
+}
+
+func TestRefundPurchaseLeavesLostRefundPending(t *testing.T) {
+	repo := newFakeRepo()
+	provider := newRecordingProvider()
+	provider.refundErr = payment.ErrTimeout
+	s := newRefundService(repo, newFakeHoldStore(), provider)
+	purchaseID, ticketIDs := repo.addSoldPurchase(2500)
+
+	refund, err := s.RefundPurchase(context.Background(), purchaseID, nil, false, "")
+	if err != nil {
+		t.Fatalf("RefundPurchase: %v", err)
+	}
+	if refund.Status != string(database.RefundStatusPending) {
+		t.Errorf("refund status = %s, want pending", refund.Status)
+	}
+	if status := repo.ticket(ticketIDs[0]).Status; status != string(database.TicketStatusSold) {
+		t.Errorf("ticket status = %s, want sold until the refund settles", status)
+	}
+
+	// The refund may have been paid out, so its tickets can't be refunded again
+	if _, err := s.RefundPurchase(context.Background(), purchaseID, ticketIDs, false, ""); !errors.Is(err, ErrRefundInProgress) {
+		t.Errorf("refunding again: err = %v, want ErrRefundInProgress", err)
+	}
+}
+
+// asCaller runs ctx as an authenticated caller with the given roles
+func asCaller(subject uuid.UUID, roles ...string) context.Context {
+	return auth.WithPrincipal(context.Background(), &auth.Principal{Subject: subject, Roles: roles})
+}
+
+func TestGetPurchaseDetailsChecksOwner(t *testing.T) {
+	repo := newFakeRepo()
+	s := newTestService(repo, newFakeHoldStore())
+	customerID := uuid.New()
+	purchase, _ := repo.CreatePurchase(context.Background(), 2500, customerID)
+	anonymous, _ := repo.CreatePurchase(context.Background(), 2500, uuid.Nil)
+
+	tests := []struct {
+		name     string
+		ctx      context.Context
+		purchase uuid.UUID
+		wantErr  error
+	}{
+		{name: "owner", ctx: asCaller(customerID, auth.RoleCustomer), purchase: purchase.ID},
+		{name: "box office", ctx: asCaller(uuid.New(), auth.RoleBoxOffice), purchase: purchase.ID},
+		{name: "admin", ctx: asCaller(uuid.New(), auth.RoleAdmin), purchase: purchase.ID},
+		{name: "other customer", ctx: asCaller(uuid.New(), auth.RoleCustomer), purchase: purchase.ID, wantErr: auth.ErrForbidden},
+		{name: "organizer", ctx: asCaller(uuid.New(), auth.RoleOrganizer), purchase: purchase.ID, wantErr: auth.ErrForbidden},
+		{name: "anonymous caller", ctx: context.Background(), purchase: purchase.ID, wantErr: auth.ErrUnauthenticated},
+		{name: "anonymous purchase", ctx: context.Background(), purchase: anonymous.ID},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			details, err := s.GetPurchaseDetails(tt.ctx, tt.purchase)
+			if tt.wantErr != nil {
+				if !errors.Is(err, tt.wantErr) {
+					t.Fatalf("err = %v, want %v", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("GetPurchaseDetails: %v", err)
+			}
+			if details.PurchaseID != tt.purchase {
+				t.Errorf("purchase ID = %s, want %s", details.PurchaseID, tt.purchase)
+			}
+		})
+	}
+}
+
+func TestHoldsAreLimitedToTheirOwner(t *testing.T) {
+	holds := newFakeHoldStore()
+	s := newTestService(newFakeRepo(), holds)
+	ownerID := uuid.New()
+	holdID := uuid.New()
+	ticketID := uuid.New()
+	if err := holds.ReserveTickets(context.Background(), []uuid.UUID{ticketID}, holdID, ownerID); err != nil {
+		t.Fatalf("failed to hold ticket: %v", err)
+	}
+
+	tests := []struct {
+		name    string
+		ctx     context.Context
+		wantErr error
+	}{
+		{name: "owner", ctx: asCaller(ownerID, auth.RoleCustomer)},
+		{name: "box office", ctx: asCaller(uuid.New(), auth.RoleBoxOffice)},
+		{name: "other customer", ctx: asCaller(uuid.New(), auth.RoleCustomer), wantErr: auth.ErrForbidden},
+		{name: "anonymous caller", ctx: context.Background(), wantErr: auth.ErrUnauthenticated},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := s.GetHold(tt.ctx, holdID); !errors.Is(err, tt.wantErr) {
+				t.Errorf("GetHold: err = %v, want %v", err, tt.wantErr)
+			}
+			if tt.wantErr == nil {
+				return
+			}
+			if _, err := s.ExtendHold(tt.ctx, holdID); !errors.Is(err, tt.wantErr) {
+				t.Errorf("ExtendHold: err = %v, want %v", err, tt.wantErr)
+			}
+			if err := s.ReleaseHold(tt.ctx, holdID); !errors.Is(err, tt.wantErr) {
+				t.Errorf("ReleaseHold: err = %v, want %v", err, tt.wantErr)
+			}
+			if holder, _ := holds.holder(ticketID); holder != holdID {
+				t.Errorf("ticket no longer held after a rejected call")
+			}
+		})
+	}
+}
+
+func TestReserveTicketsRejectsAnotherCallersHold(t *testing.T) {
+	first, second := newTestTicket(2500), newTestTicket(2500)
+	repo := newFakeRepo(first, second)
+	holds := newFakeHoldStore()
+	s := newTestService(repo, holds)
+
+	_, holdID, err := s.ReserveTickets(asCaller(uuid.New(), auth.RoleCustomer), []uuid.UUID{first.ID}, uuid.Nil, "")
+	if err != nil {
+		t.Fatalf("ReserveTickets: %v", err)
+	}
+
+	_, _, err = s.ReserveTickets(asCaller(uuid.New(), auth.RoleCustomer), []uuid.UUID{second.ID}, holdID, "")
+	if !errors.Is(err, ErrHoldMismatch) {
+		t.Fatalf("err = %v, want ErrHoldMismatch", err)
+	}
+	if _, held := holds.holder(second.ID); held {
+		t.Errorf("ticket was added to another caller's hold")
+	}
+}
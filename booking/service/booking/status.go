@@ -0,0 +1,56 @@
+package booking
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+
+	"github.com/google/uuid"
+
+	"github.com/ignisrex/tix/booking/internal/database"
+)
+
+var ErrInvalidTransition = errors.New("invalid purchase status transition")
+
+// purchaseTransitions lists the statuses each purchase status may move to.
+// failed, refunded and cancelled are final.
+var purchaseTransitions = map[database.PurchaseStatus][]database.PurchaseStatus{
+	database.PurchaseStatusPendingPayment: {
+		database.PurchaseStatusPaid,
+		database.PurchaseStatusFailed,
+		database.PurchaseStatusCancelled,
+	},
+	database.PurchaseStatusPaid: {
+		database.PurchaseStatusPartiallyRefunded,
+		database.PurchaseStatusRefunded,
+	},
+	database.PurchaseStatusPartiallyRefunded: {
+		database.PurchaseStatusPartiallyRefunded,
+		database.PurchaseStatusRefunded,
+	},
+}
+
+// checkTransition returns ErrInvalidTransition unless a purchase in status
+// from may move to status to.
+func checkTransition(from, to database.PurchaseStatus) error {
+	for _, allowed := range purchaseTransitions[from] {
+		if allowed == to {
+			return nil
+		}
+	}
+	return fmt.Errorf("%w: %s -> %s", ErrInvalidTransition, from, to)
+}
+
+// transitionPurchase moves a purchase between statuses on a best-effort basis
+// for the failure paths of PurchaseTickets, where the original error is what
+// the caller needs to see. It runs even if the request context is cancelled.
+func (s *Service) transitionPurchase(ctx context.Context, purchaseID uuid.UUID, from, to database.PurchaseStatus, reason string) {
+	if err := checkTransition(from, to); err != nil {
+		log.Printf("transitionPurchase: purchase %s: %v", purchaseID, err)
+		return
+	}
+	if err := s.repo.TransitionPurchase(context.WithoutCancel(ctx), purchaseID, from, to, reason); err != nil {
+		log.Printf("transitionPurchase: failed to move purchase %s from %s to %s: %v", purchaseID, from, to, err)
+	}
+}
@@ -0,0 +1,73 @@
+package booking
+
+import (
+	"context"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/ignisrex/tix/booking/internal/database"
+	"github.com/ignisrex/tix/booking/internal/redis"
+	"github.com/ignisrex/tix/booking/types"
+)
+
+// PurchaseRepo is the Postgres state the service reads and writes. *Repo
+// implements it; tests substitute an in-memory one.
+type PurchaseRepo interface {
+	GetTicketsWithPrice(ctx context.Context, ticketIDs []uuid.UUID) ([]types.Ticket, error)
+	GetTicketTypeForEvent(ctx context.Context, eventID uuid.UUID, ticketTypeID uuid.UUID) (database.GetTicketTypeForEventRow, error)
+	ListAvailableTickets(ctx context.Context, eventID uuid.UUID, ticketTypeID uuid.UUID) ([]database.ListAvailableTicketsForTypeRow, error)
+
+	CreatePurchase(ctx context.Context, totalCents int32, customerID uuid.UUID) (database.Purchase, error)
+	SellTickets(ctx context.Context, purchaseID uuid.UUID, ticketIDs []uuid.UUID, reason string) error
+	TransitionPurchase(ctx context.Context, purchaseID uuid.UUID, from, to database.PurchaseStatus, reason string) error
+	GetPurchase(ctx context.Context, purchaseID uuid.UUID) (database.Purchase, error)
+	GetPurchaseDetails(ctx context.Context, purchaseID uuid.UUID) (database.GetPurchaseDetailsRow, error)
+	ListPurchaseStatusTransitions(ctx context.Context, purchaseID uuid.UUID) ([]database.PurchaseStatusTransition, error)
+
+	CreatePaymentAttempt(ctx context.Context, holdID uuid.UUID, amountCents int32, purchaseID uuid.UUID) (database.PaymentAttempt, error)
+	MarkPaymentAuthorized(ctx context.Context, id uuid.UUID, authorizationID string) error
+	MarkPaymentCaptured(ctx context.Context, id uuid.UUID) error
+	MarkPaymentVoided(ctx context.Context, id uuid.UUID, reason string) error
+	MarkPaymentFailed(ctx context.Context, id uuid.UUID, reason string) error
+	ListPurchasePaymentAttempts(ctx context.Context, purchaseID uuid.UUID) ([]database.PaymentAttempt, error)
+	ClaimUnsettledPaymentAttempts(ctx context.Context, updatedBefore time.Time, batchSize int32) ([]database.ClaimUnsettledPaymentAttemptsRow, error)
+	GetCapturedPaymentForPurchase(ctx context.Context, purchaseID uuid.UUID) (database.PaymentAttempt, error)
+
+	CreatePendingRefund(ctx context.Context, purchaseID uuid.UUID, capturedCents int32, ticketIDs []uuid.UUID, returnToInventory bool, reason string) (database.Refund, []uuid.UUID, error)
+	SettleRefund(ctx context.Context, refundID uuid.UUID, providerRefundID string) ([]uuid.UUID, error)
+	FailRefund(ctx context.Context, refundID uuid.UUID, reason string) error
+	ClaimPendingRefunds(ctx context.Context, updatedBefore time.Time, batchSize int32) ([]database.Refund, error)
+	ListPurchaseRefunds(ctx context.Context, purchaseID uuid.UUID) ([]database.ListPurchaseRefundsRow, error)
+}
+
+// HoldStore keeps the short-lived state of the service: ticket holds, the
+// per-event hold index, waiting rooms and live status updates.
+// *redis.Client implements it.
+type HoldStore interface {
+	ReserveTickets(ctx context.Context, ticketIDs []uuid.UUID, holdID uuid.UUID, ownerID uuid.UUID) error
+	RefreshTickets(ctx context.Context, ticketIDs []uuid.UUID, holdID uuid.UUID, ttl time.Duration) error
+	ReleaseTickets(ctx context.Context, ticketIDs []uuid.UUID, holdID uuid.UUID) error
+	AreReserved(ctx context.Context, ticketIDs []uuid.UUID) (map[uuid.UUID]bool, error)
+	GetHold(ctx context.Context, holdID uuid.UUID) (*redis.Hold, error)
+	ExtendHold(ctx context.Context, holdID uuid.UUID, ticketIDs []uuid.UUID, maxExtensions int) error
+
+	TrackHolds(ctx context.Context, eventID uuid.UUID, ticketIDs []uuid.UUID, ttl time.Duration) error
+	UntrackHolds(ctx context.Context, eventID uuid.UUID, ticketIDs []uuid.UUID) error
+	PublishTicketStatus(ctx context.Context, ticketIDs []uuid.UUID, status string) error
+
+	ConfigureQueue(ctx context.Context, eventID uuid.UUID, cfg redis.QueueConfig) error
+	RemoveQueue(ctx context.Context, eventID uuid.UUID) error
+	GetQueue(ctx context.Context, eventID uuid.UUID) (redis.QueueConfig, int64, error)
+	IsQueued(ctx context.Context, eventID uuid.UUID) (bool, error)
+	ListQueuedEvents(ctx context.Context) ([]uuid.UUID, error)
+	JoinQueue(ctx context.Context, eventID uuid.UUID, visitorID uuid.UUID) (redis.QueuePosition, error)
+	CountQueueJoin(ctx context.Context, eventID uuid.UUID, addr string, window time.Duration) (int64, error)
+	GetQueuePosition(ctx context.Context, eventID uuid.UUID, visitorID uuid.UUID) (redis.QueuePosition, error)
+	AdmitNext(ctx context.Context, eventID uuid.UUID, window time.Duration) (int, error)
+}
+
+var (
+	_ PurchaseRepo = (*Repo)(nil)
+	_ HoldStore    = (*redis.Client)(nil)
+)
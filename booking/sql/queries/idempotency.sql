@@ -0,0 +1,26 @@
+-- name: CreateIdempotencyKey :execrows
+INSERT INTO idempotency_keys (key, request_path, request_hash)
+VALUES ($1, $2, $3)
+ON CONFLICT (key, request_path) DO NOTHING;
+
+-- name: GetIdempotencyKey :one
+SELECT * FROM idempotency_keys
+WHERE key = $1 AND request_path = $2;
+
+-- Takes over a key whose request never completed (e.g. the process crashed)
+-- name: ReclaimIdempotencyKey :execrows
+UPDATE idempotency_keys
+SET updated_at = CURRENT_TIMESTAMP
+WHERE key = $1 AND request_path = $2
+  AND response_status IS NULL
+  AND updated_at < $3;
+
+-- name: SaveIdempotencyResponse :exec
+UPDATE idempotency_keys
+SET response_status = $3,
+    response_body = $4
+WHERE key = $1 AND request_path = $2;
+
+-- name: DeleteIdempotencyKey :exec
+DELETE FROM idempotency_keys
+WHERE key = $1 AND request_path = $2;
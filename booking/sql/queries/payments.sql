@@ -0,0 +1,66 @@
+-- name: CreatePaymentAttempt :one
+INSERT INTO payment_attempts (hold_id, amount_cents, purchase_id)
+VALUES ($1, $2, $3)
+RETURNING *;
+
+-- Each Mark query only moves an attempt out of the status it expects, so a
+-- purchase and the reconciler can't both settle the same attempt:
+-- pending -> authorized | failed, authorized -> captured | voided.
+-- name: MarkPaymentAuthorized :execrows
+UPDATE payment_attempts
+SET status = 'authorized', authorization_id = $2
+WHERE id = $1 AND status = 'pending';
+
+-- name: MarkPaymentCaptured :execrows
+UPDATE payment_attempts
+SET status = 'captured'
+WHERE id = $1 AND status = 'authorized';
+
+-- name: MarkPaymentVoided :execrows
+UPDATE payment_attempts
+SET status = 'voided', failure_reason = $2
+WHERE id = $1 AND status = 'authorized';
+
+-- name: MarkPaymentFailed :execrows
+UPDATE payment_attempts
+SET status = 'failed', failure_reason = $2
+WHERE id = $1 AND status = 'pending';
+
+-- Claims attempts that stopped between authorization and capture, e.g.
+-- because the process crashed mid-purchase. Claiming touches updated_at, so
+-- an attempt is not claimed again until it has been unsettled for as long
+-- once more, and rows another reconciler is claiming are skipped.
+-- name: ClaimUnsettledPaymentAttempts :many
+WITH claimed AS (
+    UPDATE payment_attempts
+    SET updated_at = CURRENT_TIMESTAMP
+    WHERE id IN (
+        SELECT id FROM payment_attempts
+        WHERE status IN ('pending', 'authorized')
+          AND updated_at < sqlc.arg(updated_before)
+        ORDER BY created_at
+        LIMIT sqlc.arg(batch_size)
+        FOR UPDATE SKIP LOCKED
+    )
+    RETURNING id, status, authorization_id, purchase_id, created_at
+)
+SELECT
+    c.id,
+    c.status,
+    c.authorization_id,
+    c.purchase_id,
+    p.status AS purchase_status
+FROM claimed c
+LEFT JOIN purchases p ON p.id = c.purchase_id
+ORDER BY c.created_at;
+
+-- name: ListPurchasePaymentAttempts :many
+SELECT * FROM payment_attempts
+WHERE purchase_id = $1
+ORDER BY created_at;
+
+-- name: GetCapturedPaymentForPurchase :one
+SELECT * FROM payment_attempts
+WHERE purchase_id = $1 AND status = 'captured'
+ORDER BY created_at DESC
+LIMIT 1;
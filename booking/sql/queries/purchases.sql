@@ -0,0 +1,24 @@
+-- name: CreatePurchase :one
+INSERT INTO purchases (total_cents, customer_id)
+VALUES ($1, $2)
+RETURNING *;
+
+-- name: GetPurchase :one
+SELECT * FROM purchases
+WHERE id = $1;
+
+-- Moves a purchase to a new status only if it is still in the expected one,
+-- so concurrent transitions cannot overwrite each other
+-- name: UpdatePurchaseStatus :execrows
+UPDATE purchases
+SET status = sqlc.arg(to_status)
+WHERE id = sqlc.arg(id) AND status = sqlc.arg(from_status);
+
+-- name: CreatePurchaseStatusTransition :exec
+INSERT INTO purchase_status_transitions (purchase_id, from_status, to_status, reason)
+VALUES ($1, $2, $3, $4);
+
+-- name: ListPurchaseStatusTransitions :many
+SELECT * FROM purchase_status_transitions
+WHERE purchase_id = $1
+ORDER BY created_at;
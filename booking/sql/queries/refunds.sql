@@ -0,0 +1,94 @@
+-- Locks the purchase's tickets so concurrent refunds of the same ticket
+-- serialize. Tickets of a refund still waiting on the provider are flagged,
+-- as they can't be refunded again.
+-- name: LockPurchaseTickets :many
+SELECT
+    t.id,
+    t.status,
+    tt.price_cents,
+    EXISTS (
+        SELECT 1 FROM refund_tickets rt
+        JOIN refunds r ON r.id = rt.refund_id
+        WHERE rt.ticket_id = t.id AND r.status = 'pending'
+    ) AS refund_pending
+FROM tickets t
+JOIN ticket_types tt ON t.ticket_type_id = tt.id
+WHERE t.purchase_id = $1
+ORDER BY t.id
+FOR UPDATE OF t;
+
+-- name: CreateRefund :one
+INSERT INTO refunds (purchase_id, amount_cents, returned_to_inventory, reason)
+VALUES ($1, $2, $3, $4)
+RETURNING *;
+
+-- name: CreateRefundTicket :exec
+INSERT INTO refund_tickets (refund_id, ticket_id, amount_cents)
+VALUES ($1, $2, $3);
+
+-- Sums the purchase's refunds that were paid out or may still be, so new
+-- refunds never exceed the captured payment.
+-- name: GetRefundedAmountForPurchase :one
+SELECT COALESCE(SUM(amount_cents), 0)::int AS refunded_cents
+FROM refunds
+WHERE purchase_id = $1 AND status <> 'failed';
+
+-- name: LockRefund :one
+SELECT * FROM refunds
+WHERE id = $1
+FOR UPDATE;
+
+-- name: ListRefundTicketIDs :many
+SELECT ticket_id FROM refund_tickets
+WHERE refund_id = $1
+ORDER BY ticket_id;
+
+-- name: SettleRefund :exec
+UPDATE refunds
+SET status = 'succeeded', provider_refund_id = $2
+WHERE id = $1;
+
+-- name: FailRefund :execrows
+UPDATE refunds
+SET status = 'failed', failure_reason = $2
+WHERE id = $1 AND status = 'pending';
+
+-- Claims refunds the provider has not confirmed, e.g. because the process
+-- crashed mid-refund, the same way unsettled payment attempts are claimed.
+-- name: ClaimPendingRefunds :many
+UPDATE refunds
+SET updated_at = CURRENT_TIMESTAMP
+WHERE id IN (
+    SELECT id FROM refunds
+    WHERE status = 'pending'
+      AND updated_at < sqlc.arg(updated_before)
+    ORDER BY created_at
+    LIMIT sqlc.arg(batch_size)
+    FOR UPDATE SKIP LOCKED
+)
+RETURNING *;
+
+-- name: MarkTicketsRefunded :execrows
+UPDATE tickets
+SET status = 'refunded'
+WHERE id = ANY($1::uuid[]) AND purchase_id = $2 AND status = 'sold';
+
+-- name: ReturnTicketsToInventory :execrows
+UPDATE tickets
+SET status = 'available', purchase_id = NULL
+WHERE id = ANY($1::uuid[]) AND purchase_id = $2 AND status = 'sold';
+
+-- name: ListPurchaseRefunds :many
+SELECT
+    r.id,
+    r.amount_cents,
+    r.returned_to_inventory,
+    r.reason,
+    r.created_at,
+    r.status,
+    ARRAY_AGG(rt.ticket_id ORDER BY rt.ticket_id)::uuid[] AS ticket_ids
+FROM refunds r
+JOIN refund_tickets rt ON rt.refund_id = r.id
+WHERE r.purchase_id = $1
+GROUP BY r.id
+ORDER BY r.created_at;
@@ -4,47 +4,73 @@ SELECT
     t.event_id,
     t.ticket_type_id,
     t.status,
-    tt.price_cents
+    tt.price_cents,
+    tt.name AS ticket_type_name,
+    tt.max_per_order
 FROM tickets t
 JOIN ticket_types tt ON t.ticket_type_id = tt.id
 WHERE t.id = ANY($1::uuid[]);
 
--- This query creates a purchase record and updates all tickets atomically
--- name: PurchaseTickets :one
-WITH purchase_insert AS (
-    INSERT INTO purchases (total_cents)
-    VALUES ($1)
-    RETURNING id
-),
-updated_tickets AS (
+-- This query assigns all tickets to the purchase and marks them sold.
+-- Tickets that are no longer available are skipped, so callers must compare
+-- sold_ticket_ids with the requested IDs and roll back on a mismatch.
+-- name: SellTickets :one
+WITH updated_tickets AS (
     UPDATE tickets
-    SET status = 'sold', purchase_id = (SELECT id FROM purchase_insert)
+    SET status = 'sold', purchase_id = $1
     WHERE id = ANY($2::uuid[]) AND status = 'available'
-    RETURNING purchase_id
+    RETURNING id
 )
-SELECT id FROM purchase_insert;
+SELECT ARRAY(SELECT id FROM updated_tickets)::uuid[] AS sold_ticket_ids;
 
 -- need to test this query performance; can converted to view?
+-- Tickets returned to inventory by a refund no longer point at the purchase,
+-- so they are found through the refund and listed as refunded.
 -- name: GetPurchaseDetails :one
 SELECT 
     p.id as purchase_id,
     p.total_cents,
+    p.status,
+    p.customer_id,
     p.created_at as purchase_created_at,
-    json_agg(
+    COALESCE(json_agg(
         json_build_object(
             'id', t.id,
             'event_id', t.event_id,
             'ticket_type_id', t.ticket_type_id,
-            'status', t.status,
+            'status', CASE WHEN t.purchase_id = p.id THEN t.status ELSE 'refunded' END,
             'ticket_type_name', tt.name,
             'ticket_type_display_name', tt.display_name,
             'ticket_type_price_cents', tt.price_cents
         )
-    ) as tickets
+    ) FILTER (WHERE t.id IS NOT NULL), '[]') as tickets
 FROM purchases p
-JOIN tickets t ON t.purchase_id = p.id
-JOIN ticket_types tt ON t.ticket_type_id = tt.id
+LEFT JOIN tickets t ON t.purchase_id = p.id OR t.id IN (
+    SELECT rt.ticket_id FROM refund_tickets rt
+    JOIN refunds r ON r.id = rt.refund_id
+    WHERE r.purchase_id = p.id AND r.status = 'succeeded' AND r.returned_to_inventory
+)
+LEFT JOIN ticket_types tt ON t.ticket_type_id = tt.id
 WHERE p.id = $1
-GROUP BY p.id, p.total_cents, p.created_at;
+GROUP BY p.id, p.total_cents, p.status, p.customer_id, p.created_at;
 
 
+
+-- name: GetTicketTypeForEvent :one
+SELECT id, name, max_per_order
+FROM ticket_types
+WHERE event_id = $1 AND id = $2;
+
+-- Seated tickets come first in seat map order (section, then row front to
+-- back, then seat number) so adjacent seats are next to each other.
+-- name: ListAvailableTicketsForType :many
+SELECT
+    t.id,
+    s.section_id,
+    s.row_index,
+    s.seat_number
+FROM tickets t
+LEFT JOIN venue_seats s ON t.seat_id = s.id
+LEFT JOIN venue_sections vs ON s.section_id = vs.id
+WHERE t.event_id = $1 AND t.ticket_type_id = $2 AND t.status = 'available'
+ORDER BY vs.position, s.row_index, s.seat_number, t.created_at, t.id;
@@ -6,16 +6,52 @@ import (
 
 type ReserveRequest struct {
 	TicketIDs []uuid.UUID `json:"ticket_ids"`
+	HoldID    uuid.UUID   `json:"hold_id"` // Optional: add tickets to an existing hold
+}
+
+// BestAvailableRequest reserves quantity tickets of a ticket type picked by
+// the server instead of by ticket ID
+type BestAvailableRequest struct {
+	EventID      uuid.UUID `json:"event_id"`
+	TicketTypeID uuid.UUID `json:"ticket_type_id"`
+	Quantity     int       `json:"quantity"`
+	HoldID       uuid.UUID `json:"hold_id"` // Optional: add tickets to an existing hold
 }
 
 type ReserveResponse struct {
 	Success   bool        `json:"success"`
 	Message   string      `json:"message"`
 	TicketIDs []uuid.UUID `json:"ticket_ids"` // IDs of successfully reserved tickets
+	HoldID    uuid.UUID   `json:"hold_id"`    // Owner token required to purchase or release the tickets
+}
+
+type HeldTicket struct {
+	TicketID         uuid.UUID `json:"ticket_id"`
+	ExpiresInSeconds int       `json:"expires_in_seconds"`
+}
+
+type HoldResponse struct {
+	HoldID           uuid.UUID    `json:"hold_id"`
+	Tickets          []HeldTicket `json:"tickets"`
+	ExpiresInSeconds int          `json:"expires_in_seconds"` // Time until the first ticket in the hold expires
+	Extensions       int          `json:"extensions"`
+	MaxExtensions    int          `json:"max_extensions"`
 }
 
 type PurchaseRequest struct {
-	TicketIDs []uuid.UUID `json:"ticket_ids"`
+	TicketIDs    []uuid.UUID `json:"ticket_ids"`
+	HoldID       uuid.UUID   `json:"hold_id"`
+	PaymentToken string      `json:"payment_token"` // Payment method to charge, e.g. tok_success for the deterministic provider
+	CustomerID   uuid.UUID   `json:"customer_id"`   // Optional: customer the purchase belongs to
+}
+
+// CompRequest issues held tickets free of charge, e.g. for guests or to
+// replace lost tickets at the box office
+type CompRequest struct {
+	TicketIDs  []uuid.UUID `json:"ticket_ids"`
+	HoldID     uuid.UUID   `json:"hold_id"`
+	CustomerID uuid.UUID   `json:"customer_id"` // Optional: customer the tickets are issued to
+	Reason     string      `json:"reason"`
 }
 
 type PurchaseResponse struct {
@@ -24,6 +60,8 @@ type PurchaseResponse struct {
 	TicketIDs  []uuid.UUID `json:"ticket_ids"`  // IDs of successfully purchased tickets
 	Total      int32       `json:"total"`       // Total price in cents
 	PurchaseID uuid.UUID   `json:"purchase_id"` // ID of the purchase record
+
+	UnavailableTicketIDs []uuid.UUID `json:"unavailable_ticket_ids,omitempty"` // Tickets already sold when the purchase failed
 }
 
 type PurchaseTicketDetail struct {
@@ -39,8 +77,70 @@ type PurchaseTicketDetail struct {
 type PurchaseDetailsResponse struct {
 	PurchaseID        uuid.UUID             `json:"purchase_id"`
 	TotalCents        int32                 `json:"total_cents"`
+	Status            string                `json:"status"` // pending_payment, paid, failed, refunded, partially_refunded or cancelled
+	CustomerID        *uuid.UUID            `json:"customer_id,omitempty"` // Unset for anonymous purchases
 	PurchaseCreatedAt string                `json:"purchase_created_at"` // ISO timestamp
 	Tickets           []PurchaseTicketDetail `json:"tickets"`
+	Refunds           []Refund               `json:"refunds"`
+	RefundedCents     int32                  `json:"refunded_cents"`
+	StatusHistory     []StatusTransition     `json:"status_history"`
+	PaymentAttempts   []PaymentAttempt       `json:"payment_attempts"`
+}
+
+type StatusTransition struct {
+	From   string `json:"from,omitempty"` // Empty for the initial status
+	To     string `json:"to"`
+	Reason string `json:"reason,omitempty"`
+	At     string `json:"at"` // ISO timestamp
+}
+
+type PaymentAttempt struct {
+	ID            uuid.UUID `json:"id"`
+	Status        string    `json:"status"` // pending, authorized, captured, voided or failed
+	AmountCents   int32     `json:"amount_cents"`
+	FailureReason string    `json:"failure_reason,omitempty"`
+	CreatedAt     string    `json:"created_at"` // ISO timestamp
+	UpdatedAt     string    `json:"updated_at"` // ISO timestamp
+}
+
+type RefundRequest struct {
+	TicketIDs         []uuid.UUID `json:"ticket_ids"`          // Optional: refund only these tickets; empty refunds the whole purchase
+	ReturnToInventory bool        `json:"return_to_inventory"` // Put the tickets back on sale instead of marking them refunded
+	Reason            string      `json:"reason"`
+}
+
+type Refund struct {
+	ID                  uuid.UUID   `json:"id"`
+	TicketIDs           []uuid.UUID `json:"ticket_ids"`
+	AmountCents         int32       `json:"amount_cents"`
+	ReturnedToInventory bool        `json:"returned_to_inventory"`
+	Reason              string      `json:"reason,omitempty"`
+	Status              string      `json:"status"`     // pending, succeeded or failed
+	CreatedAt           string      `json:"created_at"` // ISO timestamp
+}
+
+// QueueConfigRequest opens or updates an event's waiting room. Zero values
+// use the service defaults.
+type QueueConfigRequest struct {
+	BatchSize       int `json:"batch_size"`       // Visitors admitted per interval
+	IntervalSeconds int `json:"interval_seconds"` // Seconds between admitted batches
+}
+
+type QueueResponse struct {
+	EventID         uuid.UUID `json:"event_id"`
+	BatchSize       int       `json:"batch_size"`
+	IntervalSeconds int       `json:"interval_seconds"`
+	Waiting         int64     `json:"waiting"` // Visitors not admitted yet
+}
+
+type QueueStatusResponse struct {
+	EventID              uuid.UUID `json:"event_id"`
+	Status               string    `json:"status"`                           // open, waiting or admitted
+	QueueToken           string    `json:"queue_token,omitempty"`            // Required to poll the status
+	Position             int64     `json:"position,omitempty"`               // 1-based, while waiting
+	EstimatedWaitSeconds int64     `json:"estimated_wait_seconds,omitempty"` // While waiting
+	AdmissionToken       string    `json:"admission_token,omitempty"`        // Once admitted; send as X-Admission-Token when reserving
+	AdmissionExpiresAt   string    `json:"admission_expires_at,omitempty"`   // ISO timestamp
 }
 
 type CheckLocksRequest struct {
@@ -57,5 +157,7 @@ type Ticket struct {
 	TicketTypeID uuid.UUID `json:"ticket_type_id"`
 	Status string `json:"status"`
 	PriceCents int32 `json:"price_cents"`
+	TicketTypeName string `json:"ticket_type_name"`
+	MaxPerOrder int32 `json:"max_per_order,omitempty"` // 0 when the ticket type has no per-order limit
 }
 
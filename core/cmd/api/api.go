@@ -7,12 +7,14 @@ import (
 	"github.com/go-chi/chi/v5"
 	"github.com/go-chi/chi/v5/middleware"
 	"github.com/go-chi/cors"
+	"github.com/redis/go-redis/v9"
 
+	"github.com/ignisrex/tix/auth"
 	bookingclient "github.com/ignisrex/tix/core/internal/booking"
 	"github.com/ignisrex/tix/core/internal/database"
-	"github.com/ignisrex/tix/core/internal/elasticsearch"
 	"github.com/ignisrex/tix/core/internal/search"
 	"github.com/ignisrex/tix/core/service/booking"
+	"github.com/ignisrex/tix/core/service/customers"
 	"github.com/ignisrex/tix/core/service/events"
 	"github.com/ignisrex/tix/core/service/venues"
 )
@@ -21,20 +23,22 @@ type APIServer struct {
 	addr  string
 	sqlDB *sql.DB
 	q    *database.Queries
-	esClient *elasticsearch.Client
-	searchClient *search.Client
+	searcher     search.Searcher
 	bookingClient *bookingclient.Client
+	redisClient *redis.Client
+	verifier *auth.Verifier
 }
 
-func NewAPIServer(addr string, sqlDB *sql.DB, esClient *elasticsearch.Client, searchClient *search.Client, bookingClient *bookingclient.Client) *APIServer {
+func NewAPIServer(addr string, sqlDB *sql.DB, searcher search.Searcher, bookingClient *bookingclient.Client, redisClient *redis.Client, verifier *auth.Verifier) *APIServer {
 	queries := database.New(sqlDB)
 	return &APIServer{
 		addr:  addr,
 		sqlDB: sqlDB,
 		q:    queries,
-		esClient: esClient,
-		searchClient: searchClient,
+		searcher:     searcher,
 		bookingClient: bookingClient,
+		redisClient: redisClient,
+		verifier: verifier,
 	}
 }
 
@@ -45,13 +49,14 @@ func (s *APIServer) Run() error {
 	router.Use(cors.Handler(cors.Options{
 		AllowedOrigins: []string{"https://*", "http://*"},
 		AllowedMethods:   []string{"GET", "POST", "PUT", "DELETE", "OPTIONS"},
-		AllowedHeaders:   []string{"Accept", "Authorization", "Content-Type", "X-CSRF-Token"},
-		ExposedHeaders:   []string{"Link"},
+		AllowedHeaders:   []string{"Accept", "Authorization", "Content-Type", "Idempotency-Key", "X-Admission-Token", "X-CSRF-Token", "Last-Event-ID", "X-Queue-Token"},
+		ExposedHeaders:   []string{"Idempotent-Replayed", "Link", "Retry-After", search.DegradedHeader},
 		AllowCredentials: false,
 		MaxAge:           300, // Maximum value not ignored by any of major browsers
 	}))
 
 	router.Use(middleware.Logger)
+	router.Use(s.verifier.Authenticate)
 	router.Get("/", func(w http.ResponseWriter, r *http.Request) {
 		w.Write([]byte("tix api!"))
 	})
@@ -60,15 +65,18 @@ func (s *APIServer) Run() error {
 	v1 := chi.NewRouter()
 	v1.Get("/healthz", nil)
 
-	eventHandler := events.NewHandler(s.q, s.sqlDB, s.esClient, s.searchClient, s.bookingClient)
+	eventHandler := events.NewHandler(s.q, s.sqlDB, s.searcher, s.bookingClient, s.redisClient)
 	eventHandler.RegisterRoutes(v1)
 
-	venueHandler := venues.NewHandler(s.q)
+	venueHandler := venues.NewHandler(s.q, s.sqlDB)
 	venueHandler.RegisterRoutes(v1)
 
 	bookingHandler := booking.NewHandler(s.bookingClient)
 	bookingHandler.RegisterRoutes(v1)
 
+	customerHandler := customers.NewHandler(s.q)
+	customerHandler.RegisterRoutes(v1)
+
 	router.Mount("/api/v1", v1)
 	return http.ListenAndServe(s.addr, router)
 }
\ No newline at end of file
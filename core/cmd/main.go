@@ -7,11 +7,15 @@ import (
 	"os"
 
 	_ "github.com/lib/pq"
+	"github.com/redis/go-redis/v9"
 
+	"github.com/ignisrex/tix/auth"
 	"github.com/ignisrex/tix/core/cmd/api"
 	bookingclient "github.com/ignisrex/tix/core/internal/booking"
 	"github.com/ignisrex/tix/core/internal/config"
+	"github.com/ignisrex/tix/core/internal/database"
 	"github.com/ignisrex/tix/core/internal/elasticsearch"
+	"github.com/ignisrex/tix/core/internal/indexer"
 	"github.com/ignisrex/tix/core/internal/search"
 	"github.com/ignisrex/tix/core/internal/seed"
 )
@@ -39,30 +43,67 @@ func main() {
 		log.Fatal("Error pinging database -> ", err)
 	}
 
-	var esClient *elasticsearch.Client
+	// The client connects on first use, so Elasticsearch may still be
+	// starting or down
 	esAddresses := config.Envs.ESAddresses()
-	log.Printf("Attempting to connect to Elasticsearch at: %v", esAddresses)
-	esClient, err = elasticsearch.NewClient(esAddresses)
+	esClient, err := elasticsearch.NewClient(esAddresses)
 	if err != nil {
-		log.Printf("Warning: Failed to connect to Elasticsearch: %v. Continuing without search indexing.", err)
-		esClient = nil
-	} else {
-		log.Printf("Successfully connected to Elasticsearch")
+		log.Fatal("Error creating Elasticsearch client -> ", err)
+	}
+	log.Printf("Elasticsearch client initialized with addresses: %v", esAddresses)
+
+	if len(os.Args) > 1 && os.Args[1] == "reindex" {
+		reindex(ctx, conn, esClient)
+		return
 	}
 
 	if os.Getenv("SEED_ON_START") == "true" {
-		seedDatabase(ctx, conn, esClient)
+		seedDatabase(ctx, conn)
 	}
 
+	// Event changes reach the search index through the outbox, so changes
+	// made while Elasticsearch is down are indexed once it is back
+	go indexer.New(conn, esClient).Run(ctx)
+
 	
 	searchClient := search.NewClient(config.Envs.SearchServiceURL)
 	log.Printf("Search service client initialized with URL: %s", config.Envs.SearchServiceURL)
 
+	// Searches fall back to Postgres full-text search while the search
+	// service or Elasticsearch is down
+	searcher := search.NewFailover(searchClient, search.NewPostgresSearcher(database.New(conn)))
+	go searcher.Run(ctx)
+
 	
 	bookingClient := bookingclient.NewClient(config.Envs.BookingServiceURL)
 	log.Printf("Booking service client initialized with URL: %s", config.Envs.BookingServiceURL)
 
-	server := api.NewAPIServer(":"+port, conn, esClient, searchClient, bookingClient)
+	// Live ticket availability follows the booking service's holds in Redis.
+	// The client reconnects on its own, so a Redis that is down at startup
+	// only delays live updates.
+	redisClient := redis.NewClient(&redis.Options{Addr: config.Envs.RedisAddr()})
+	defer redisClient.Close()
+	if err := redisClient.Ping(ctx).Err(); err != nil {
+		log.Printf("Warning: Failed to connect to Redis at %s: %v. Live ticket availability is unavailable until it is reachable.", config.Envs.RedisAddr(), err)
+	} else {
+		log.Printf("Successfully connected to Redis at %s", config.Envs.RedisAddr())
+	}
+
+	verifier, err := auth.NewVerifier(auth.KeyConfig{
+		Algorithm:      config.Envs.JWTAlgorithm,
+		Secret:         config.Envs.JWTSecret,
+		SecretFile:     config.Envs.JWTSecretFile,
+		PublicKeyFile:  config.Envs.JWTPublicKeyFile,
+		Issuer:         config.Envs.JWTIssuer,
+		Audience:       config.Envs.JWTAudience,
+		AllowDevSecret: config.Envs.AppEnv == "development",
+	})
+	if err != nil {
+		log.Fatal("Error creating jwt verifier -> ", err)
+	}
+	log.Printf("Verifying %s tokens", config.Envs.JWTAlgorithm)
+
+	server := api.NewAPIServer(":"+port, conn, searcher, bookingClient, redisClient, verifier)
 	err = server.Run()
 	if err != nil {
 		log.Fatal("Error starting API server -> ", err)
@@ -70,11 +111,20 @@ func main() {
 
 }
 
-func seedDatabase(ctx context.Context, conn *sql.DB, esClient *elasticsearch.Client) {
+func seedDatabase(ctx context.Context, conn *sql.DB) {
 	log.Println("SEED_ON_START=true detected, running database seeder...")
-	if err := seed.Run(ctx, conn, esClient,"seed.json"); err != nil {
+	if err := seed.Run(ctx, conn, "seed.json"); err != nil {
 		log.Printf("Warning: seeding failed: %v", err)
 	} else {
 		log.Println("Seeding completed successfully")
 	}
-}
\ No newline at end of file
+}
+
+// reindex rebuilds the search index from the database: `core reindex`
+func reindex(ctx context.Context, conn *sql.DB, esClient *elasticsearch.Client) {
+	index, err := indexer.New(conn, esClient).Reindex(ctx)
+	if err != nil {
+		log.Fatal("Error reindexing events -> ", err)
+	}
+	log.Printf("Reindex complete, searches now use %s", index)
+}
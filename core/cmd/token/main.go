@@ -0,0 +1,52 @@
+// Command token mints HS256 tokens signed with JWT_SECRET for local
+// development, e.g.
+//
+//	go run ./cmd/token -roles admin
+package main
+
+import (
+	"flag"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/google/uuid"
+
+	"github.com/ignisrex/tix/auth"
+	"github.com/ignisrex/tix/core/internal/config"
+)
+
+func main() {
+	subject := flag.String("sub", uuid.NewString(), "subject (user or customer ID)")
+	email := flag.String("email", "", "email claim")
+	roles := flag.String("roles", auth.RoleCustomer, "comma separated roles")
+	ttl := flag.Duration("ttl", time.Hour, "token lifetime")
+	flag.Parse()
+
+	if config.Envs.JWTSecret == "" {
+		log.Fatal("JWT_SECRET not found in the env")
+	}
+
+	now := time.Now()
+	claims := auth.Claims{
+		RegisteredClaims: jwt.RegisteredClaims{
+			Subject:   *subject,
+			Issuer:    config.Envs.JWTIssuer,
+			IssuedAt:  jwt.NewNumericDate(now),
+			ExpiresAt: jwt.NewNumericDate(now.Add(*ttl)),
+		},
+		Email: *email,
+		Roles: strings.Split(*roles, ","),
+	}
+	if config.Envs.JWTAudience != "" {
+		claims.Audience = jwt.ClaimStrings{config.Envs.JWTAudience}
+	}
+
+	token, err := jwt.NewWithClaims(jwt.SigningMethodHS256, claims).SignedString([]byte(config.Envs.JWTSecret))
+	if err != nil {
+		log.Fatal("Error signing token -> ", err)
+	}
+	fmt.Println(token)
+}
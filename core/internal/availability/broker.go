@@ -0,0 +1,270 @@
+package availability
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/redis/go-redis/v9"
+
+	"github.com/ignisrex/tix/core/types"
+)
+
+// The booking service keeps a ticket:<id> key in Redis for as long as the
+// ticket is held, so keyspace notifications on those keys tell when holds
+// are taken, released or expire. Sales and refunds are published by the
+// booking service on ticketStatusChannel.
+const (
+	ticketKeyspacePattern = "__keyspace@*__:ticket:*"
+	ticketStatusChannel   = "tickets:status"
+
+	// keyspaceEvents are the notify-keyspace-events flags the broker needs:
+	// keyspace channels for generic (del, expire), string (set) and
+	// expired events
+	keyspaceEvents = "Kg$x"
+)
+
+// Notifications can be lost, e.g. while the Redis connection is down, so
+// every hub reloads its event this often and sends whatever changed
+const resyncInterval = 30 * time.Second
+
+const loadTimeout = 10 * time.Second
+
+// LoadFunc loads every ticket of an event along with its hold status
+type LoadFunc func(ctx context.Context, eventID uuid.UUID) ([]types.Ticket, error)
+
+// Broker keeps one hub per event that has subscribers and feeds them from a
+// single Redis subscription
+type Broker struct {
+	rdb  *redis.Client
+	load LoadFunc
+
+	listenOnce sync.Once
+
+	mu      sync.Mutex
+	hubs    map[uuid.UUID]*hub
+	tickets map[uuid.UUID]*hub // ticket ID -> hub of the ticket's event
+}
+
+func NewBroker(rdb *redis.Client, load LoadFunc) *Broker {
+	return &Broker{
+		rdb:     rdb,
+		load:    load,
+		hubs:    make(map[uuid.UUID]*hub),
+		tickets: make(map[uuid.UUID]*hub),
+	}
+}
+
+// Subscribe subscribes to the availability of an event. The first message is
+// a snapshot, unless lastEventID is the ID of a recent message, in which case
+// the deltas since that message are sent instead.
+func (b *Broker) Subscribe(ctx context.Context, eventID uuid.UUID, lastEventID string) (*Subscription, error) {
+	b.listenOnce.Do(func() {
+		go b.listen(context.Background())
+	})
+
+	b.mu.Lock()
+	h, ok := b.hubs[eventID]
+	if !ok {
+		h = newHub(eventID)
+		b.hubs[eventID] = h
+		go b.start(h)
+	}
+	h.refs++
+	b.mu.Unlock()
+
+	select {
+	case <-h.ready:
+	case <-ctx.Done():
+		b.release(h)
+		return nil, ctx.Err()
+	}
+	if h.err != nil {
+		b.release(h)
+		return nil, h.err
+	}
+
+	sub := h.subscribe(lastEventID)
+	sub.close = func() {
+		h.unsubscribe(sub)
+		b.release(h)
+	}
+	return sub, nil
+}
+
+// release drops a reference to the hub and stops it once nobody uses it
+func (b *Broker) release(h *hub) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	h.refs--
+	if h.refs > 0 {
+		return
+	}
+	delete(b.hubs, h.eventID)
+	for id, owner := range b.tickets {
+		if owner == h {
+			delete(b.tickets, id)
+		}
+	}
+	close(h.done)
+}
+
+// start loads the hub's first snapshot and then keeps it in sync until the
+// hub is stopped
+func (b *Broker) start(h *hub) {
+	ctx, cancel := context.WithTimeout(context.Background(), loadTimeout)
+	tickets, err := b.load(ctx, h.eventID)
+	cancel()
+	if err != nil {
+		log.Printf("availability: failed to load tickets for event %s: %v", h.eventID, err)
+		h.err = fmt.Errorf("failed to load tickets: %w", err)
+		close(h.ready)
+		return
+	}
+	b.index(h, h.sync(tickets))
+	close(h.ready)
+
+	ticker := time.NewTicker(resyncInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-h.done:
+			return
+		case <-ticker.C:
+			b.resync(h)
+		}
+	}
+}
+
+func (b *Broker) resync(h *hub) {
+	ctx, cancel := context.WithTimeout(context.Background(), loadTimeout)
+	defer cancel()
+
+	tickets, err := b.load(ctx, h.eventID)
+	if err != nil {
+		log.Printf("availability: failed to resync event %s: %v", h.eventID, err)
+		return
+	}
+	b.index(h, h.sync(tickets))
+}
+
+// index routes notifications for the tickets to the hub
+func (b *Broker) index(h *hub, ticketIDs []uuid.UUID) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if b.hubs[h.eventID] != h {
+		return
+	}
+	for _, id := range ticketIDs {
+		b.tickets[id] = h
+	}
+}
+
+// listen receives hold notifications and status messages for as long as the
+// process runs. The Redis client reconnects and resubscribes on its own.
+func (b *Broker) listen(ctx context.Context) {
+	b.enableKeyspaceEvents(ctx)
+
+	pubsub := b.rdb.PSubscribe(ctx, ticketKeyspacePattern)
+	defer pubsub.Close()
+	if err := pubsub.Subscribe(ctx, ticketStatusChannel); err != nil {
+		log.Printf("availability: failed to subscribe to %s: %v", ticketStatusChannel, err)
+	}
+
+	for msg := range pubsub.Channel() {
+		if msg.Channel == ticketStatusChannel {
+			b.handleStatus(msg.Payload)
+			continue
+		}
+		b.handleKeyspace(msg.Channel, msg.Payload)
+	}
+}
+
+// enableKeyspaceEvents turns on the keyspace notifications the broker relies
+// on, keeping any that are already enabled. Failing is not fatal: Redis may
+// not allow CONFIG, in which case it has to be configured up front.
+func (b *Broker) enableKeyspaceEvents(ctx context.Context) {
+	current, err := b.rdb.ConfigGet(ctx, "notify-keyspace-events").Result()
+	if err != nil {
+		log.Printf("availability: failed to read notify-keyspace-events: %v", err)
+		return
+	}
+
+	flags := current["notify-keyspace-events"]
+	for _, flag := range keyspaceEvents {
+		enabled := strings.ContainsRune(flags, flag) || (flag != 'K' && strings.ContainsRune(flags, 'A'))
+		if !enabled {
+			if err := b.rdb.ConfigSet(ctx, "notify-keyspace-events", flags+keyspaceEvents).Err(); err != nil {
+				log.Printf("availability: failed to enable keyspace notifications, live availability will only update on resync: %v", err)
+			}
+			return
+		}
+	}
+}
+
+// handleKeyspace applies a notification for a ticket key, whose payload is
+// the command or event that touched the key
+func (b *Broker) handleKeyspace(channel string, event string) {
+	_, key, _ := strings.Cut(channel, ":")
+	ticketID, err := uuid.Parse(strings.TrimPrefix(key, "ticket:"))
+	if err != nil {
+		return
+	}
+
+	var reserved bool
+	switch event {
+	case "set":
+		reserved = true
+	case "del", "expired", "evicted":
+		reserved = false
+	default:
+		// expire only refreshes the hold
+		return
+	}
+
+	b.mu.Lock()
+	h := b.tickets[ticketID]
+	b.mu.Unlock()
+	if h == nil {
+		return
+	}
+	h.update([]uuid.UUID{ticketID}, func(ticket *types.Ticket) {
+		ticket.IsReserved = reserved
+	})
+}
+
+func (b *Broker) handleStatus(payload string) {
+	var msg struct {
+		TicketIDs []uuid.UUID `json:"ticket_ids"`
+		Status    string      `json:"status"`
+	}
+	if err := json.Unmarshal([]byte(payload), &msg); err != nil {
+		log.Printf("availability: invalid ticket status message: %v", err)
+		return
+	}
+
+	// Tickets of a purchase may belong to several events
+	byHub := make(map[*hub][]uuid.UUID)
+	b.mu.Lock()
+	for _, id := range msg.TicketIDs {
+		if h := b.tickets[id]; h != nil {
+			byHub[h] = append(byHub[h], id)
+		}
+	}
+	b.mu.Unlock()
+
+	status := types.TicketStatus(msg.Status)
+	for h, ids := range byHub {
+		h.update(ids, func(ticket *types.Ticket) {
+			ticket.Status = status
+		})
+	}
+}
@@ -0,0 +1,56 @@
+package availability
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/redis/go-redis/v9"
+)
+
+// HoldIndex reads the per-event hold index the booking service keeps in
+// Redis: a sorted set event:<event>:holds of held ticket IDs scored by when
+// their hold expires in unix milliseconds
+type HoldIndex struct {
+	rdb *redis.Client
+}
+
+func NewHoldIndex(rdb *redis.Client) *HoldIndex {
+	return &HoldIndex{rdb: rdb}
+}
+
+func eventHoldsKey(eventID uuid.UUID) string {
+	return "event:" + eventID.String() + ":holds"
+}
+
+// HeldTickets returns the IDs of the events' tickets whose hold has not
+// expired yet. The result is never nil.
+func (i *HoldIndex) HeldTickets(ctx context.Context, eventIDs []uuid.UUID) ([]uuid.UUID, error) {
+	held := []uuid.UUID{}
+	if len(eventIDs) == 0 {
+		return held, nil
+	}
+
+	now := "(" + strconv.FormatInt(time.Now().UnixMilli(), 10)
+	pipe := i.rdb.Pipeline()
+	cmds := make([]*redis.StringSliceCmd, len(eventIDs))
+	for n, eventID := range eventIDs {
+		cmds[n] = pipe.ZRangeByScore(ctx, eventHoldsKey(eventID), &redis.ZRangeBy{Min: now, Max: "+inf"})
+	}
+	if _, err := pipe.Exec(ctx); err != nil && err != redis.Nil {
+		return nil, fmt.Errorf("failed to read hold index: %w", err)
+	}
+
+	for _, cmd := range cmds {
+		for _, member := range cmd.Val() {
+			id, err := uuid.Parse(member)
+			if err != nil {
+				continue
+			}
+			held = append(held, id)
+		}
+	}
+	return held, nil
+}
@@ -0,0 +1,262 @@
+package availability
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/ignisrex/tix/core/types"
+)
+
+// Message types sent to subscribers
+const (
+	MessageSnapshot = "snapshot" // every ticket of the event
+	MessageDelta    = "delta"    // only the tickets that changed
+)
+
+// Deltas kept per event for subscribers resuming after a reconnect; those
+// that missed more get a fresh snapshot
+const historySize = 256
+
+// Updates buffered per subscriber before it is considered too slow and
+// disconnected
+const subscriberBuffer = 64
+
+// Message is an availability update for one event. ID increases with every
+// delta and is what subscribers pass back as their last seen ID to resume.
+type Message struct {
+	ID      string         `json:"-"`
+	Type    string         `json:"-"`
+	EventID uuid.UUID      `json:"event_id"`
+	Tickets []types.Ticket `json:"tickets"`
+	Removed []uuid.UUID    `json:"removed,omitempty"`
+}
+
+// Subscription receives the updates of one event on C. C is closed when the
+// subscriber falls too far behind; it should then subscribe again with the
+// ID of the last message it handled.
+type Subscription struct {
+	C <-chan Message
+
+	ch        chan Message
+	closeOnce sync.Once
+	close     func()
+}
+
+// Close stops the subscription. It is safe to call more than once.
+func (s *Subscription) Close() {
+	s.closeOnce.Do(s.close)
+}
+
+type historyEntry struct {
+	seq uint64
+	msg Message
+}
+
+// hub holds the current availability of one event and fans its deltas out
+// to the event's subscribers
+type hub struct {
+	eventID uuid.UUID
+
+	ready chan struct{} // closed once the first snapshot is loaded
+	done  chan struct{} // closed when the last subscriber leaves
+	err   error         // set before ready is closed if loading failed
+	refs  int           // guarded by the broker's mutex
+
+	mu      sync.Mutex
+	epoch   string // tells message IDs of this hub apart from earlier ones
+	seq     uint64
+	order   []uuid.UUID
+	tickets map[uuid.UUID]types.Ticket
+	history []historyEntry
+	subs    map[*Subscription]struct{}
+}
+
+func newHub(eventID uuid.UUID) *hub {
+	return &hub{
+		eventID: eventID,
+		ready:   make(chan struct{}),
+		done:    make(chan struct{}),
+		epoch:   strconv.FormatInt(time.Now().UnixNano(), 36),
+		tickets: make(map[uuid.UUID]types.Ticket),
+		subs:    make(map[*Subscription]struct{}),
+	}
+}
+
+// subscribe registers a subscriber. Subscribers whose lastEventID is still
+// covered by the history get the deltas they missed, everyone else starts
+// with a snapshot.
+func (h *hub) subscribe(lastEventID string) *Subscription {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	var backlog []Message
+	if missed, ok := h.since(lastEventID); ok {
+		backlog = missed
+	} else {
+		backlog = []Message{h.snapshot()}
+	}
+
+	ch := make(chan Message, len(backlog)+subscriberBuffer)
+	for _, msg := range backlog {
+		ch <- msg
+	}
+	sub := &Subscription{C: ch, ch: ch}
+	h.subs[sub] = struct{}{}
+	return sub
+}
+
+func (h *hub) unsubscribe(sub *Subscription) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	if _, ok := h.subs[sub]; ok {
+		delete(h.subs, sub)
+		close(sub.ch)
+	}
+}
+
+// since returns the deltas after lastEventID, or false when they are no
+// longer all in the history
+func (h *hub) since(lastEventID string) ([]Message, bool) {
+	epoch, seqStr, found := strings.Cut(lastEventID, "-")
+	if !found || epoch != h.epoch {
+		return nil, false
+	}
+	seq, err := strconv.ParseUint(seqStr, 10, 64)
+	if err != nil || seq > h.seq {
+		return nil, false
+	}
+	if seq < h.seq && (len(h.history) == 0 || h.history[0].seq > seq+1) {
+		return nil, false
+	}
+
+	var missed []Message
+	for _, entry := range h.history {
+		if entry.seq > seq {
+			missed = append(missed, entry.msg)
+		}
+	}
+	return missed, true
+}
+
+func (h *hub) snapshot() Message {
+	tickets := make([]types.Ticket, 0, len(h.order))
+	for _, id := range h.order {
+		tickets = append(tickets, h.tickets[id])
+	}
+	return Message{
+		ID:      h.messageID(),
+		Type:    MessageSnapshot,
+		EventID: h.eventID,
+		Tickets: tickets,
+	}
+}
+
+func (h *hub) messageID() string {
+	return fmt.Sprintf("%s-%d", h.epoch, h.seq)
+}
+
+// update applies change to the given tickets of the event and sends a delta
+// with the tickets that actually changed. Unknown tickets are ignored.
+func (h *hub) update(ticketIDs []uuid.UUID, change func(*types.Ticket)) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	var changed []types.Ticket
+	for _, id := range ticketIDs {
+		ticket, ok := h.tickets[id]
+		if !ok {
+			continue
+		}
+		updated := ticket
+		change(&updated)
+		if !sameTicket(ticket, updated) {
+			h.tickets[id] = updated
+			changed = append(changed, updated)
+		}
+	}
+	h.publish(changed, nil)
+}
+
+// sync replaces the hub's state with freshly loaded tickets and sends a delta
+// with whatever the notifications missed. It returns the IDs of tickets the
+// hub did not know yet.
+func (h *hub) sync(tickets []types.Ticket) []uuid.UUID {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	var changed []types.Ticket
+	var added []uuid.UUID
+	loaded := make(map[uuid.UUID]types.Ticket, len(tickets))
+	order := make([]uuid.UUID, 0, len(tickets))
+	for _, ticket := range tickets {
+		loaded[ticket.ID] = ticket
+		order = append(order, ticket.ID)
+
+		old, ok := h.tickets[ticket.ID]
+		if !ok {
+			added = append(added, ticket.ID)
+		}
+		if !ok || !sameTicket(old, ticket) {
+			changed = append(changed, ticket)
+		}
+	}
+
+	var removed []uuid.UUID
+	for _, id := range h.order {
+		if _, ok := loaded[id]; !ok {
+			removed = append(removed, id)
+		}
+	}
+
+	h.tickets = loaded
+	h.order = order
+	h.publish(changed, removed)
+	return added
+}
+
+// publish records a delta and sends it to every subscriber. Subscribers
+// whose buffer is full are disconnected so one slow client can't hold up
+// the event. Must be called with h.mu held.
+func (h *hub) publish(changed []types.Ticket, removed []uuid.UUID) {
+	if len(changed) == 0 && len(removed) == 0 {
+		return
+	}
+
+	h.seq++
+	msg := Message{
+		ID:      h.messageID(),
+		Type:    MessageDelta,
+		EventID: h.eventID,
+		Tickets: changed,
+		Removed: removed,
+	}
+	h.history = append(h.history, historyEntry{seq: h.seq, msg: msg})
+	if len(h.history) > historySize {
+		h.history = h.history[len(h.history)-historySize:]
+	}
+
+	for sub := range h.subs {
+		select {
+		case sub.ch <- msg:
+		default:
+			delete(h.subs, sub)
+			close(sub.ch)
+		}
+	}
+}
+
+// sameTicket compares tickets by value, including their seats
+func sameTicket(a, b types.Ticket) bool {
+	seatA, seatB := a.Seat, b.Seat
+	a.Seat, b.Seat = nil, nil
+	if a != b || (seatA == nil) != (seatB == nil) {
+		return false
+	}
+	return seatA == nil || *seatA == *seatB
+}
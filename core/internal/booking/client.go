@@ -2,17 +2,47 @@ package booking
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"io"
 	"net/http"
+	"strconv"
 	"time"
 
 	"github.com/google/uuid"
+	"github.com/ignisrex/tix/auth"
 	"github.com/ignisrex/tix/core/internal/utils"
 )
 
+const (
+	IdempotencyKeyHeader = "Idempotency-Key"
+
+	// Requests carrying an idempotency key are safe to resend when the
+	// booking service could not be reached or failed with a server error.
+	maxIdempotentAttempts = 3
+	idempotentRetryDelay  = 200 * time.Millisecond
+
+	// A purchase authorizes and captures a payment before it responds, so it
+	// gets far longer than other calls. It must outlast the payment
+	// provider's own timeouts, or the client gives up on purchases that
+	// still go through.
+	purchaseTimeout = 30 * time.Second
+
+	// While booking is still processing an earlier request with the same key
+	// it answers 409 with Retry-After. The client waits and asks again until
+	// the first request's response can be replayed or this much time passes.
+	maxInProgressWait = 30 * time.Second
+)
+
+// ErrInProgress is returned when booking was still processing an earlier
+// request with the same Idempotency-Key after maxInProgressWait. The request
+// is safe to resend with the same key later.
+var ErrInProgress = errors.New("a request with this " + IdempotencyKeyHeader + " is still being processed")
+
 type Client struct {
-	baseURL    string
-	httpClient *http.Client
+	baseURL        string
+	httpClient     *http.Client
+	purchaseClient *http.Client
 }
 
 func NewClient(baseURL string) *Client {
@@ -21,21 +51,75 @@ func NewClient(baseURL string) *Client {
 		httpClient: &http.Client{
 			Timeout: 5 * time.Second,
 		},
+		purchaseClient: &http.Client{
+			Timeout: purchaseTimeout,
+		},
 	}
 }
 
+// newRequest builds a request to the booking service that carries the
+// caller's bearer token, so booking can attribute the request to them, and
+// their waiting room admission token if they have one
+func (c *Client) newRequest(ctx context.Context, method, url string, body interface{}) (*http.Request, error) {
+	req, err := utils.MakeJSONRequest(ctx, method, url, body)
+	if err != nil {
+		return nil, err
+	}
+	if principal, ok := auth.PrincipalFromContext(ctx); ok && principal.Token != "" {
+		req.Header.Set("Authorization", "Bearer "+principal.Token)
+	}
+	if token := admissionTokenFromContext(ctx); token != "" {
+		req.Header.Set(AdmissionTokenHeader, token)
+	}
+	return req, nil
+}
+
 type ReserveRequest struct {
 	TicketIDs []uuid.UUID `json:"ticket_ids"`
+	HoldID    uuid.UUID   `json:"hold_id"`
+}
+
+// BestAvailableRequest asks booking to pick and reserve quantity tickets of
+// a ticket type
+type BestAvailableRequest struct {
+	EventID      uuid.UUID `json:"event_id"`
+	TicketTypeID uuid.UUID `json:"ticket_type_id"`
+	Quantity     int       `json:"quantity"`
+	HoldID       uuid.UUID `json:"hold_id"`
 }
 
 type ReserveResponse struct {
 	Success   bool        `json:"success"`
 	Message   string      `json:"message"`
 	TicketIDs []uuid.UUID `json:"ticket_ids"`
+	HoldID    uuid.UUID   `json:"hold_id"`
+}
+
+type HeldTicket struct {
+	TicketID         uuid.UUID `json:"ticket_id"`
+	ExpiresInSeconds int       `json:"expires_in_seconds"`
+}
+
+type HoldResponse struct {
+	HoldID           uuid.UUID    `json:"hold_id"`
+	Tickets          []HeldTicket `json:"tickets"`
+	ExpiresInSeconds int          `json:"expires_in_seconds"`
+	Extensions       int          `json:"extensions"`
+	MaxExtensions    int          `json:"max_extensions"`
 }
 
 type PurchaseRequest struct {
-	TicketIDs []uuid.UUID `json:"ticket_ids"`
+	TicketIDs    []uuid.UUID `json:"ticket_ids"`
+	HoldID       uuid.UUID   `json:"hold_id"`
+	PaymentToken string      `json:"payment_token"`
+	CustomerID   uuid.UUID   `json:"customer_id"`
+}
+
+type CompRequest struct {
+	TicketIDs  []uuid.UUID `json:"ticket_ids"`
+	HoldID     uuid.UUID   `json:"hold_id"`
+	CustomerID uuid.UUID   `json:"customer_id"`
+	Reason     string      `json:"reason"`
 }
 
 type PurchaseResponse struct {
@@ -44,6 +128,8 @@ type PurchaseResponse struct {
 	TicketIDs  []uuid.UUID `json:"ticket_ids"`
 	Total      int32       `json:"total"`
 	PurchaseID uuid.UUID   `json:"purchase_id"`
+
+	UnavailableTicketIDs []uuid.UUID `json:"unavailable_ticket_ids,omitempty"`
 }
 
 type PurchaseTicketDetail struct {
@@ -59,8 +145,46 @@ type PurchaseTicketDetail struct {
 type PurchaseDetailsResponse struct {
 	PurchaseID        uuid.UUID             `json:"purchase_id"`
 	TotalCents        int32                 `json:"total_cents"`
+	Status            string                 `json:"status"`
+	CustomerID        *uuid.UUID             `json:"customer_id,omitempty"`
 	PurchaseCreatedAt string                 `json:"purchase_created_at"`
 	Tickets           []PurchaseTicketDetail `json:"tickets"`
+	Refunds           []Refund               `json:"refunds"`
+	RefundedCents     int32                  `json:"refunded_cents"`
+	StatusHistory     []StatusTransition     `json:"status_history"`
+	PaymentAttempts   []PaymentAttempt       `json:"payment_attempts"`
+}
+
+type StatusTransition struct {
+	From   string `json:"from,omitempty"`
+	To     string `json:"to"`
+	Reason string `json:"reason,omitempty"`
+	At     string `json:"at"`
+}
+
+type PaymentAttempt struct {
+	ID            uuid.UUID `json:"id"`
+	Status        string    `json:"status"`
+	AmountCents   int32     `json:"amount_cents"`
+	FailureReason string    `json:"failure_reason,omitempty"`
+	CreatedAt     string    `json:"created_at"`
+	UpdatedAt     string    `json:"updated_at"`
+}
+
+type RefundRequest struct {
+	TicketIDs         []uuid.UUID `json:"ticket_ids"`
+	ReturnToInventory bool        `json:"return_to_inventory"`
+	Reason            string      `json:"reason"`
+}
+
+type Refund struct {
+	ID                  uuid.UUID   `json:"id"`
+	TicketIDs           []uuid.UUID `json:"ticket_ids"`
+	AmountCents         int32       `json:"amount_cents"`
+	ReturnedToInventory bool        `json:"returned_to_inventory"`
+	Reason              string      `json:"reason,omitempty"`
+	Status              string      `json:"status"` // pending, succeeded or failed
+	CreatedAt           string      `json:"created_at"`
 }
 
 type CheckLocksRequest struct {
@@ -71,50 +195,71 @@ type CheckLocksResponse struct {
 	Locks map[string]bool `json:"locks"` // ticket_id (string) -> is_reserved (bool)
 }
 
-func (c *Client) ReserveTickets(ctx context.Context, ticketIDs []uuid.UUID) (*ReserveResponse, int, error) {
+func (c *Client) ReserveTickets(ctx context.Context, ticketIDs []uuid.UUID, holdID uuid.UUID, idempotencyKey string) (*ReserveResponse, int, error) {
 	url := fmt.Sprintf("%s/api/v1/booking/reserve", c.baseURL)
-	
+
 	reqBody := ReserveRequest{
 		TicketIDs: ticketIDs,
+		HoldID:    holdID,
 	}
-	
-	req, err := utils.MakeJSONRequest(ctx, "POST", url, reqBody)
+
+	body, statusCode, err := c.executeIdempotent(ctx, c.httpClient, url, reqBody, idempotencyKey)
 	if err != nil {
-		return nil, http.StatusInternalServerError, err
+		return nil, statusCode, err
 	}
-	
-	body, statusCode, err := utils.ExecuteRequest(c.httpClient, req)
+
+	return utils.UnmarshalJSONResponse[ReserveResponse](body, statusCode, "booking service")
+}
+
+// ReserveBestAvailable reserves the best available tickets of a ticket type,
+// preferring adjacent seats
+func (c *Client) ReserveBestAvailable(ctx context.Context, reqBody BestAvailableRequest, idempotencyKey string) (*ReserveResponse, int, error) {
+	url := fmt.Sprintf("%s/api/v1/booking/reserve/best-available", c.baseURL)
+
+	body, statusCode, err := c.executeIdempotent(ctx, c.httpClient, url, reqBody, idempotencyKey)
 	if err != nil {
 		return nil, statusCode, err
 	}
-	
+
 	return utils.UnmarshalJSONResponse[ReserveResponse](body, statusCode, "booking service")
 }
 
-func (c *Client) PurchaseTickets(ctx context.Context, ticketIDs []uuid.UUID) (*PurchaseResponse, int, error) {
+// PurchaseTickets buys the held tickets. customerID links the purchase to a
+// customer; uuid.Nil makes an anonymous purchase.
+func (c *Client) PurchaseTickets(ctx context.Context, ticketIDs []uuid.UUID, holdID uuid.UUID, paymentToken string, customerID uuid.UUID, idempotencyKey string) (*PurchaseResponse, int, error) {
 	url := fmt.Sprintf("%s/api/v1/booking/purchase", c.baseURL)
-	
+
 	reqBody := PurchaseRequest{
-		TicketIDs: ticketIDs,
+		TicketIDs:    ticketIDs,
+		HoldID:       holdID,
+		PaymentToken: paymentToken,
+		CustomerID:   customerID,
 	}
-	
-	req, err := utils.MakeJSONRequest(ctx, "POST", url, reqBody)
+
+	body, statusCode, err := c.executeIdempotent(ctx, c.purchaseClient, url, reqBody, idempotencyKey)
 	if err != nil {
-		return nil, http.StatusInternalServerError, err
+		return nil, statusCode, err
 	}
-	
-	body, statusCode, err := utils.ExecuteRequest(c.httpClient, req)
+
+	return utils.UnmarshalJSONResponse[PurchaseResponse](body, statusCode, "booking service")
+}
+
+// CompTickets issues held tickets free of charge
+func (c *Client) CompTickets(ctx context.Context, reqBody CompRequest, idempotencyKey string) (*PurchaseResponse, int, error) {
+	url := fmt.Sprintf("%s/api/v1/booking/comp", c.baseURL)
+
+	body, statusCode, err := c.executeIdempotent(ctx, c.httpClient, url, reqBody, idempotencyKey)
 	if err != nil {
 		return nil, statusCode, err
 	}
-	
+
 	return utils.UnmarshalJSONResponse[PurchaseResponse](body, statusCode, "booking service")
 }
 
 func (c *Client) GetPurchaseDetails(ctx context.Context, purchaseID uuid.UUID) (*PurchaseDetailsResponse, int, error) {
 	url := fmt.Sprintf("%s/api/v1/booking/purchases/%s", c.baseURL, purchaseID.String())
 	
-	req, err := utils.MakeJSONRequest(ctx, "GET", url, nil)
+	req, err := c.newRequest(ctx, "GET", url, nil)
 	if err != nil {
 		return nil, http.StatusInternalServerError, err
 	}
@@ -127,6 +272,22 @@ func (c *Client) GetPurchaseDetails(ctx context.Context, purchaseID uuid.UUID) (
 	return utils.UnmarshalJSONResponse[PurchaseDetailsResponse](body, statusCode, "booking service")
 }
 
+func (c *Client) RefundPurchase(ctx context.Context, purchaseID uuid.UUID, reqBody RefundRequest, idempotencyKey string) (*Refund, int, error) {
+	url := fmt.Sprintf("%s/api/v1/booking/purchases/%s/refund", c.baseURL, purchaseID.String())
+
+	body, statusCode, err := c.executeIdempotent(ctx, c.purchaseClient, url, reqBody, idempotencyKey)
+	if err != nil {
+		return nil, statusCode, err
+	}
+
+	// 202 means the refund is recorded but still pending with the provider
+	if statusCode != http.StatusOK && statusCode != http.StatusAccepted {
+		return nil, statusCode, fmt.Errorf("booking service returned status %d: %s", statusCode, string(body))
+	}
+
+	return utils.UnmarshalJSONResponse[Refund](body, statusCode, "booking service")
+}
+
 func (c *Client) CheckTicketLocks(ctx context.Context, ticketIDs []uuid.UUID) (map[uuid.UUID]bool, int, error) {
 	url := fmt.Sprintf("%s/api/v1/booking/locks/check", c.baseURL)
 	
@@ -134,7 +295,7 @@ func (c *Client) CheckTicketLocks(ctx context.Context, ticketIDs []uuid.UUID) (m
 		TicketIDs: ticketIDs,
 	}
 	
-	req, err := utils.MakeJSONRequest(ctx, "POST", url, reqBody)
+	req, err := c.newRequest(ctx, "POST", url, reqBody)
 	if err != nil {
 		return nil, http.StatusInternalServerError, err
 	}
@@ -162,3 +323,139 @@ func (c *Client) CheckTicketLocks(ctx context.Context, ticketIDs []uuid.UUID) (m
 	return locks, statusCode, nil
 }
 
+func (c *Client) GetHold(ctx context.Context, holdID uuid.UUID) (*HoldResponse, int, error) {
+	url := fmt.Sprintf("%s/api/v1/booking/holds/%s", c.baseURL, holdID.String())
+
+	req, err := c.newRequest(ctx, "GET", url, nil)
+	if err != nil {
+		return nil, http.StatusInternalServerError, err
+	}
+
+	return c.doHoldRequest(req)
+}
+
+func (c *Client) ExtendHold(ctx context.Context, holdID uuid.UUID) (*HoldResponse, int, error) {
+	url := fmt.Sprintf("%s/api/v1/booking/holds/%s/extend", c.baseURL, holdID.String())
+
+	req, err := c.newRequest(ctx, "POST", url, nil)
+	if err != nil {
+		return nil, http.StatusInternalServerError, err
+	}
+
+	return c.doHoldRequest(req)
+}
+
+func (c *Client) ReleaseHold(ctx context.Context, holdID uuid.UUID) (int, error) {
+	url := fmt.Sprintf("%s/api/v1/booking/holds/%s", c.baseURL, holdID.String())
+
+	req, err := c.newRequest(ctx, "DELETE", url, nil)
+	if err != nil {
+		return http.StatusInternalServerError, err
+	}
+
+	body, statusCode, err := utils.ExecuteRequest(c.httpClient, req)
+	if err != nil {
+		return statusCode, err
+	}
+
+	if statusCode != http.StatusNoContent {
+		return statusCode, fmt.Errorf("booking service returned status %d: %s", statusCode, string(body))
+	}
+
+	return statusCode, nil
+}
+
+// doHoldRequest executes a hold lookup/extension request. Unlike reserve and
+// purchase, hold errors carry no response body worth forwarding, so any
+// non-200 status is turned into an error.
+func (c *Client) doHoldRequest(req *http.Request) (*HoldResponse, int, error) {
+	body, statusCode, err := utils.ExecuteRequest(c.httpClient, req)
+	if err != nil {
+		return nil, statusCode, err
+	}
+
+	if statusCode != http.StatusOK {
+		return nil, statusCode, fmt.Errorf("booking service returned status %d: %s", statusCode, string(body))
+	}
+
+	return utils.UnmarshalJSONResponse[HoldResponse](body, statusCode, "booking service")
+}
+
+// executeIdempotent POSTs reqBody with the given Idempotency-Key, retrying on
+// transport errors and 5xx responses. The booking service replays the stored
+// response for a key it has already processed, so a retry never reserves or
+// charges twice. When an earlier request with the key is still running, it
+// polls until that request's response is stored; if it is still running
+// after maxInProgressWait, ErrInProgress is returned with a 409.
+func (c *Client) executeIdempotent(ctx context.Context, httpClient *http.Client, url string, reqBody interface{}, idempotencyKey string) ([]byte, int, error) {
+	var (
+		body       []byte
+		statusCode int
+		err        error
+	)
+
+	deadline := time.Now().Add(maxInProgressWait)
+	for attempt := 1; attempt <= maxIdempotentAttempts; {
+		req, reqErr := c.newRequest(ctx, "POST", url, reqBody)
+		if reqErr != nil {
+			return nil, http.StatusInternalServerError, reqErr
+		}
+		req.Header.Set(IdempotencyKeyHeader, idempotencyKey)
+
+		var retryAfter time.Duration
+		body, statusCode, retryAfter, err = executeRequest(httpClient, req)
+		if err == nil && statusCode == http.StatusConflict && retryAfter > 0 {
+			// Still in progress. Polling does not use up an attempt.
+			if time.Now().Add(retryAfter).After(deadline) {
+				return body, statusCode, ErrInProgress
+			}
+			if err := sleep(ctx, retryAfter); err != nil {
+				return nil, http.StatusGatewayTimeout, err
+			}
+			continue
+		}
+		if err == nil && statusCode < http.StatusInternalServerError {
+			return body, statusCode, nil
+		}
+
+		if attempt < maxIdempotentAttempts {
+			if err := sleep(ctx, time.Duration(attempt)*idempotentRetryDelay); err != nil {
+				return nil, http.StatusGatewayTimeout, err
+			}
+		}
+		attempt++
+	}
+
+	return body, statusCode, err
+}
+
+// executeRequest is utils.ExecuteRequest that also reports the response's
+// Retry-After, in seconds, as a duration. It is zero when the header is
+// missing or not a number of seconds.
+func executeRequest(httpClient *http.Client, req *http.Request) ([]byte, int, time.Duration, error) {
+	resp, err := httpClient.Do(req)
+	if err != nil {
+		return nil, http.StatusInternalServerError, 0, fmt.Errorf("failed to execute request: %w", err)
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, http.StatusInternalServerError, 0, fmt.Errorf("failed to read response: %w", err)
+	}
+
+	var retryAfter time.Duration
+	if seconds, err := strconv.Atoi(resp.Header.Get("Retry-After")); err == nil && seconds > 0 {
+		retryAfter = time.Duration(seconds) * time.Second
+	}
+	return body, resp.StatusCode, retryAfter, nil
+}
+
+func sleep(ctx context.Context, d time.Duration) error {
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-time.After(d):
+		return nil
+	}
+}
@@ -0,0 +1,112 @@
+package booking
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+
+	"github.com/google/uuid"
+)
+
+func TestExecuteIdempotentWaitsForRequestInProgress(t *testing.T) {
+	var calls atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get(IdempotencyKeyHeader) != "checkout-1" {
+			t.Errorf("%s = %q, want checkout-1", IdempotencyKeyHeader, r.Header.Get(IdempotencyKeyHeader))
+		}
+		if calls.Add(1) == 1 {
+			w.Header().Set("Retry-After", "1")
+			w.WriteHeader(http.StatusConflict)
+			_, _ = w.Write([]byte(`{"error":"still being processed"}`))
+			return
+		}
+		w.WriteHeader(http.StatusCreated)
+		_, _ = w.Write([]byte(`{"success":true,"purchase_id":"` + uuid.Nil.String() + `"}`))
+	}))
+	defer server.Close()
+
+	client := NewClient(server.URL)
+	resp, statusCode, err := client.PurchaseTickets(context.Background(), []uuid.UUID{uuid.New()}, uuid.New(), "tok_success", uuid.Nil, "checkout-1")
+	if err != nil {
+		t.Fatalf("PurchaseTickets: %v", err)
+	}
+	if statusCode != http.StatusCreated || !resp.Success {
+		t.Errorf("got status %d success %v, want %d and success", statusCode, resp.Success, http.StatusCreated)
+	}
+	if got := calls.Load(); got != 2 {
+		t.Errorf("booking was called %d times, want 2", got)
+	}
+}
+
+func TestExecuteIdempotentDoesNotRetryOtherConflicts(t *testing.T) {
+	var calls atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls.Add(1)
+		w.WriteHeader(http.StatusConflict)
+		_, _ = w.Write([]byte(`{"success":false,"message":"tickets sold"}`))
+	}))
+	defer server.Close()
+
+	client := NewClient(server.URL)
+	resp, statusCode, err := client.PurchaseTickets(context.Background(), []uuid.UUID{uuid.New()}, uuid.New(), "tok_success", uuid.Nil, "checkout-1")
+	if err != nil {
+		t.Fatalf("PurchaseTickets: %v", err)
+	}
+	if statusCode != http.StatusConflict || resp.Success {
+		t.Errorf("got status %d success %v, want a failed %d", statusCode, resp.Success, http.StatusConflict)
+	}
+	if got := calls.Load(); got != 1 {
+		t.Errorf("booking was called %d times, want 1", got)
+	}
+}
+
+func TestExecuteIdempotentRetriesServerErrorsWithSameKey(t *testing.T) {
+	var calls atomic.Int32
+	keys := make(chan string, maxIdempotentAttempts)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		keys <- r.Header.Get(IdempotencyKeyHeader)
+		if calls.Add(1) < maxIdempotentAttempts {
+			w.WriteHeader(http.StatusBadGateway)
+			return
+		}
+		w.WriteHeader(http.StatusCreated)
+		_, _ = w.Write([]byte(`{"success":true}`))
+	}))
+	defer server.Close()
+
+	client := NewClient(server.URL)
+	_, statusCode, err := client.PurchaseTickets(context.Background(), []uuid.UUID{uuid.New()}, uuid.New(), "tok_success", uuid.Nil, "checkout-1")
+	if err != nil {
+		t.Fatalf("PurchaseTickets: %v", err)
+	}
+	if statusCode != http.StatusCreated {
+		t.Errorf("status = %d, want %d", statusCode, http.StatusCreated)
+	}
+	close(keys)
+	for key := range keys {
+		if key != "checkout-1" {
+			t.Errorf("retry sent key %q, want checkout-1", key)
+		}
+	}
+}
+
+func TestExecuteIdempotentGivesUpOnRequestStuckInProgress(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		// Longer than the client is willing to wait
+		w.Header().Set("Retry-After", "60")
+		w.WriteHeader(http.StatusConflict)
+	}))
+	defer server.Close()
+
+	client := NewClient(server.URL)
+	_, statusCode, err := client.PurchaseTickets(context.Background(), []uuid.UUID{uuid.New()}, uuid.New(), "tok_success", uuid.Nil, "checkout-1")
+	if !errors.Is(err, ErrInProgress) {
+		t.Fatalf("err = %v, want ErrInProgress", err)
+	}
+	if statusCode != http.StatusConflict {
+		t.Errorf("status = %d, want %d", statusCode, http.StatusConflict)
+	}
+}
@@ -0,0 +1,148 @@
+package booking
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+
+	"github.com/google/uuid"
+
+	"github.com/ignisrex/tix/core/internal/utils"
+)
+
+// Waiting room tokens are forwarded to the booking service in these headers
+const (
+	QueueTokenHeader     = "X-Queue-Token"
+	AdmissionTokenHeader = "X-Admission-Token"
+)
+
+// ClientIPHeader carries the address of the client core is calling booking
+// for. Booking is only reachable through core, so it trusts the header.
+const ClientIPHeader = "X-Real-IP"
+
+type admissionTokenKey struct{}
+
+// WithAdmissionToken attaches the caller's waiting room admission token to
+// ctx so requests made with it forward the token to the booking service
+func WithAdmissionToken(ctx context.Context, token string) context.Context {
+	if token == "" {
+		return ctx
+	}
+	return context.WithValue(ctx, admissionTokenKey{}, token)
+}
+
+func admissionTokenFromContext(ctx context.Context) string {
+	token, _ := ctx.Value(admissionTokenKey{}).(string)
+	return token
+}
+
+type QueueConfigRequest struct {
+	BatchSize       int `json:"batch_size"`
+	IntervalSeconds int `json:"interval_seconds"`
+}
+
+type QueueResponse struct {
+	EventID         uuid.UUID `json:"event_id"`
+	BatchSize       int       `json:"batch_size"`
+	IntervalSeconds int       `json:"interval_seconds"`
+	Waiting         int64     `json:"waiting"`
+}
+
+type QueueStatusResponse struct {
+	EventID              uuid.UUID `json:"event_id"`
+	Status               string    `json:"status"` // open, waiting or admitted
+	QueueToken           string    `json:"queue_token,omitempty"`
+	Position             int64     `json:"position,omitempty"`
+	EstimatedWaitSeconds int64     `json:"estimated_wait_seconds,omitempty"`
+	AdmissionToken       string    `json:"admission_token,omitempty"`
+	AdmissionExpiresAt   string    `json:"admission_expires_at,omitempty"`
+}
+
+func (c *Client) GetQueue(ctx context.Context, eventID uuid.UUID) (*QueueResponse, int, error) {
+	url := fmt.Sprintf("%s/api/v1/booking/queue/%s", c.baseURL, eventID.String())
+
+	req, err := c.newRequest(ctx, "GET", url, nil)
+	if err != nil {
+		return nil, http.StatusInternalServerError, err
+	}
+
+	return doQueueRequest[QueueResponse](c, req)
+}
+
+func (c *Client) ConfigureQueue(ctx context.Context, eventID uuid.UUID, reqBody QueueConfigRequest) (*QueueResponse, int, error) {
+	url := fmt.Sprintf("%s/api/v1/booking/queue/%s", c.baseURL, eventID.String())
+
+	req, err := c.newRequest(ctx, "PUT", url, reqBody)
+	if err != nil {
+		return nil, http.StatusInternalServerError, err
+	}
+
+	return doQueueRequest[QueueResponse](c, req)
+}
+
+func (c *Client) RemoveQueue(ctx context.Context, eventID uuid.UUID) (int, error) {
+	url := fmt.Sprintf("%s/api/v1/booking/queue/%s", c.baseURL, eventID.String())
+
+	req, err := c.newRequest(ctx, "DELETE", url, nil)
+	if err != nil {
+		return http.StatusInternalServerError, err
+	}
+
+	body, statusCode, err := utils.ExecuteRequest(c.httpClient, req)
+	if err != nil {
+		return statusCode, err
+	}
+
+	if statusCode != http.StatusNoContent {
+		return statusCode, fmt.Errorf("booking service returned status %d: %s", statusCode, string(body))
+	}
+
+	return statusCode, nil
+}
+
+// JoinQueue joins the event's waiting room for the client at clientIP. A
+// queue token from an earlier join keeps the visitor's place; without one,
+// booking limits how often each client IP joins as a new visitor.
+func (c *Client) JoinQueue(ctx context.Context, eventID uuid.UUID, queueToken string, clientIP string) (*QueueStatusResponse, int, error) {
+	url := fmt.Sprintf("%s/api/v1/booking/queue/%s/join", c.baseURL, eventID.String())
+
+	req, err := c.newRequest(ctx, "POST", url, nil)
+	if err != nil {
+		return nil, http.StatusInternalServerError, err
+	}
+	if queueToken != "" {
+		req.Header.Set(QueueTokenHeader, queueToken)
+	}
+	if clientIP != "" {
+		req.Header.Set(ClientIPHeader, clientIP)
+	}
+
+	return doQueueRequest[QueueStatusResponse](c, req)
+}
+
+func (c *Client) GetQueueStatus(ctx context.Context, eventID uuid.UUID, queueToken string) (*QueueStatusResponse, int, error) {
+	url := fmt.Sprintf("%s/api/v1/booking/queue/%s/status", c.baseURL, eventID.String())
+
+	req, err := c.newRequest(ctx, "GET", url, nil)
+	if err != nil {
+		return nil, http.StatusInternalServerError, err
+	}
+	req.Header.Set(QueueTokenHeader, queueToken)
+
+	return doQueueRequest[QueueStatusResponse](c, req)
+}
+
+// doQueueRequest executes a waiting room request; like holds, any non-200
+// status is turned into an error
+func doQueueRequest[T any](c *Client, req *http.Request) (*T, int, error) {
+	body, statusCode, err := utils.ExecuteRequest(c.httpClient, req)
+	if err != nil {
+		return nil, statusCode, err
+	}
+
+	if statusCode != http.StatusOK {
+		return nil, statusCode, fmt.Errorf("booking service returned status %d: %s", statusCode, string(body))
+	}
+
+	return utils.UnmarshalJSONResponse[T](body, statusCode, "booking service")
+}
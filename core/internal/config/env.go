@@ -19,8 +19,22 @@ type Config struct {
 	ESHost string
 	ESPort string
 
+	RedisHost string
+	RedisPort string
+
 	SearchServiceURL string
 	BookingServiceURL string
+
+	// AppEnv is "development" for local setups, which may use the
+	// placeholder JWT secret
+	AppEnv string
+
+	JWTAlgorithm     string
+	JWTSecret        string
+	JWTSecretFile    string
+	JWTPublicKeyFile string
+	JWTIssuer        string
+	JWTAudience      string
 }
 
 var Envs Config = initConfig()
@@ -39,8 +53,17 @@ func initConfig() Config {
 		DBName:     getEnv("DB_NAME", "tix_db"),
 		ESHost:     getEnv("ES_HOST", "localhost"),
 		ESPort:     getEnv("ES_PORT", "9200"),
+		RedisHost:  getEnv("REDIS_HOST", "ticket-lock"),
+		RedisPort:  getEnv("REDIS_PORT", "6379"),
 		SearchServiceURL: getEnv("SEARCH_SERVICE_URL", "http://search:8082"),
 		BookingServiceURL: getEnv("BOOKING_SERVICE_URL", "http://booking:8081"),
+		AppEnv:           getEnv("APP_ENV", "production"),
+		JWTAlgorithm:     getEnv("JWT_ALGORITHM", "HS256"),
+		JWTSecret:        getEnv("JWT_SECRET", ""),
+		JWTSecretFile:    getEnv("JWT_SECRET_FILE", ""),
+		JWTPublicKeyFile: getEnv("JWT_PUBLIC_KEY_FILE", ""),
+		JWTIssuer:        getEnv("JWT_ISSUER", ""),
+		JWTAudience:      getEnv("JWT_AUDIENCE", ""),
 	}
 }
 
@@ -52,6 +75,10 @@ func (c Config) ESAddresses() []string {
 	return []string{"http://" + c.ESHost + ":" + c.ESPort}
 }
 
+func (c Config) RedisAddr() string {
+	return c.RedisHost + ":" + c.RedisPort
+}
+
 func getEnv(key string, fallback string) string {
 	value := os.Getenv(key)
 	if value == "" {
@@ -7,15 +7,17 @@ package database
 
 import (
 	"context"
+	"database/sql"
 	"time"
 
 	"github.com/google/uuid"
+	"github.com/lib/pq"
 )
 
 const createEvent = `-- name: CreateEvent :one
-INSERT INTO events (title, description, start_date, venue_id)
-VALUES ($1, $2, $3, $4)
-RETURNING id, title, description, start_date, venue_id, created_at, updated_at
+INSERT INTO events (title, description, start_date, venue_id, organizer_id, category, performers)
+VALUES ($1, $2, $3, $4, $5, $6, $7)
+RETURNING id, title, description, start_date, venue_id, created_at, updated_at, organizer_id, category, performers
 `
 
 type CreateEventParams struct {
@@ -23,6 +25,9 @@ type CreateEventParams struct {
 	Description string
 	StartDate   time.Time
 	VenueID     uuid.UUID
+	OrganizerID uuid.NullUUID
+	Category    sql.NullString
+	Performers  []string
 }
 
 func (q *Queries) CreateEvent(ctx context.Context, arg CreateEventParams) (Event, error) {
@@ -31,6 +36,9 @@ func (q *Queries) CreateEvent(ctx context.Context, arg CreateEventParams) (Event
 		arg.Description,
 		arg.StartDate,
 		arg.VenueID,
+		arg.OrganizerID,
+		arg.Category,
+		pq.Array(arg.Performers),
 	)
 	var i Event
 	err := row.Scan(
@@ -41,6 +49,9 @@ func (q *Queries) CreateEvent(ctx context.Context, arg CreateEventParams) (Event
 		&i.VenueID,
 		&i.CreatedAt,
 		&i.UpdatedAt,
+		&i.OrganizerID,
+		&i.Category,
+		pq.Array(&i.Performers),
 	)
 	return i, err
 }
@@ -55,7 +66,7 @@ func (q *Queries) DeleteEvent(ctx context.Context, id uuid.UUID) error {
 }
 
 const getEvent = `-- name: GetEvent :one
-SELECT id, title, description, start_date, venue_id, created_at, updated_at FROM events
+SELECT id, title, description, start_date, venue_id, created_at, updated_at, organizer_id, category, performers FROM events
 WHERE id = $1
 `
 
@@ -70,12 +81,15 @@ func (q *Queries) GetEvent(ctx context.Context, id uuid.UUID) (Event, error) {
 		&i.VenueID,
 		&i.CreatedAt,
 		&i.UpdatedAt,
+		&i.OrganizerID,
+		&i.Category,
+		pq.Array(&i.Performers),
 	)
 	return i, err
 }
 
 const getEvents = `-- name: GetEvents :many
-SELECT id, title, description, start_date, venue_id, created_at, updated_at FROM events
+SELECT id, title, description, start_date, venue_id, created_at, updated_at, organizer_id, category, performers FROM events
 ORDER BY start_date DESC
 LIMIT $1
 OFFSET $2
@@ -103,6 +117,75 @@ func (q *Queries) GetEvents(ctx context.Context, arg GetEventsParams) ([]Event,
 			&i.VenueID,
 			&i.CreatedAt,
 			&i.UpdatedAt,
+			&i.OrganizerID,
+			&i.Category,
+			pq.Array(&i.Performers),
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listEventsForIndexing = `-- name: ListEventsForIndexing :many
+SELECT e.id, e.title, e.description, e.start_date, e.venue_id, e.created_at, e.updated_at, e.organizer_id, e.category, e.performers, v.name AS venue_name, v.location AS venue_location
+FROM events e
+JOIN venues v ON v.id = e.venue_id
+WHERE e.id > $1
+ORDER BY e.id
+LIMIT $2
+`
+
+type ListEventsForIndexingParams struct {
+	AfterID   uuid.UUID
+	BatchSize int32
+}
+
+type ListEventsForIndexingRow struct {
+	ID            uuid.UUID
+	Title         string
+	Description   string
+	StartDate     time.Time
+	VenueID       uuid.UUID
+	CreatedAt     time.Time
+	UpdatedAt     time.Time
+	OrganizerID   uuid.NullUUID
+	Category      sql.NullString
+	Performers    []string
+	VenueName     string
+	VenueLocation string
+}
+
+// Pages through every event with its venue for a full reindex, in ID order.
+func (q *Queries) ListEventsForIndexing(ctx context.Context, arg ListEventsForIndexingParams) ([]ListEventsForIndexingRow, error) {
+	rows, err := q.db.QueryContext(ctx, listEventsForIndexing, arg.AfterID, arg.BatchSize)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []ListEventsForIndexingRow
+	for rows.Next() {
+		var i ListEventsForIndexingRow
+		if err := rows.Scan(
+			&i.ID,
+			&i.Title,
+			&i.Description,
+			&i.StartDate,
+			&i.VenueID,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+			&i.OrganizerID,
+			&i.Category,
+			pq.Array(&i.Performers),
+			&i.VenueName,
+			&i.VenueLocation,
 		); err != nil {
 			return nil, err
 		}
@@ -118,9 +201,9 @@ func (q *Queries) GetEvents(ctx context.Context, arg GetEventsParams) ([]Event,
 }
 
 const updateEvent = `-- name: UpdateEvent :one
-UPDATE events SET title = $2, description = $3, start_date = $4, venue_id = $5
+UPDATE events SET title = $2, description = $3, start_date = $4, venue_id = $5, category = $6, performers = $7
 WHERE id = $1
-RETURNING id, title, description, start_date, venue_id, created_at, updated_at
+RETURNING id, title, description, start_date, venue_id, created_at, updated_at, organizer_id, category, performers
 `
 
 type UpdateEventParams struct {
@@ -129,6 +212,8 @@ type UpdateEventParams struct {
 	Description string
 	StartDate   time.Time
 	VenueID     uuid.UUID
+	Category    sql.NullString
+	Performers  []string
 }
 
 func (q *Queries) UpdateEvent(ctx context.Context, arg UpdateEventParams) (Event, error) {
@@ -138,6 +223,8 @@ func (q *Queries) UpdateEvent(ctx context.Context, arg UpdateEventParams) (Event
 		arg.Description,
 		arg.StartDate,
 		arg.VenueID,
+		arg.Category,
+		pq.Array(arg.Performers),
 	)
 	var i Event
 	err := row.Scan(
@@ -148,6 +235,9 @@ func (q *Queries) UpdateEvent(ctx context.Context, arg UpdateEventParams) (Event
 		&i.VenueID,
 		&i.CreatedAt,
 		&i.UpdatedAt,
+		&i.OrganizerID,
+		&i.Category,
+		pq.Array(&i.Performers),
 	)
 	return i, err
 }
@@ -5,6 +5,7 @@
 package database
 
 import (
+	"database/sql"
 	"database/sql/driver"
 	"fmt"
 	"time"
@@ -13,11 +14,58 @@ import (
 	"github.com/ignisrex/tix/core/types"
 )
 
+type PurchaseStatus string
+
+const (
+	PurchaseStatusPendingPayment    PurchaseStatus = "pending_payment"
+	PurchaseStatusPaid              PurchaseStatus = "paid"
+	PurchaseStatusFailed            PurchaseStatus = "failed"
+	PurchaseStatusRefunded          PurchaseStatus = "refunded"
+	PurchaseStatusPartiallyRefunded PurchaseStatus = "partially_refunded"
+	PurchaseStatusCancelled         PurchaseStatus = "cancelled"
+)
+
+func (e *PurchaseStatus) Scan(src interface{}) error {
+	switch s := src.(type) {
+	case []byte:
+		*e = PurchaseStatus(s)
+	case string:
+		*e = PurchaseStatus(s)
+	default:
+		return fmt.Errorf("unsupported scan type for PurchaseStatus: %T", src)
+	}
+	return nil
+}
+
+type NullPurchaseStatus struct {
+	PurchaseStatus PurchaseStatus
+	Valid          bool // Valid is true if PurchaseStatus is not NULL
+}
+
+// Scan implements the Scanner interface.
+func (ns *NullPurchaseStatus) Scan(value interface{}) error {
+	if value == nil {
+		ns.PurchaseStatus, ns.Valid = "", false
+		return nil
+	}
+	ns.Valid = true
+	return ns.PurchaseStatus.Scan(value)
+}
+
+// Value implements the driver Valuer interface.
+func (ns NullPurchaseStatus) Value() (driver.Value, error) {
+	if !ns.Valid {
+		return nil, nil
+	}
+	return string(ns.PurchaseStatus), nil
+}
+
 type TicketStatus string
 
 const (
 	TicketStatusAvailable TicketStatus = "available"
 	TicketStatusSold      TicketStatus = "sold"
+	TicketStatusRefunded  TicketStatus = "refunded"
 )
 
 func (e *TicketStatus) Scan(src interface{}) error {
@@ -55,6 +103,14 @@ func (ns NullTicketStatus) Value() (driver.Value, error) {
 	return string(ns.TicketStatus), nil
 }
 
+type Customer struct {
+	ID        uuid.UUID
+	Email     string
+	Name      string
+	CreatedAt time.Time
+	UpdatedAt time.Time
+}
+
 type EnrichedTicket struct {
 	ID                    uuid.UUID
 	EventID               uuid.UUID
@@ -65,6 +121,11 @@ type EnrichedTicket struct {
 	TicketTypeName        string
 	TicketTypeDisplayName string
 	TicketTypePriceCents  int32
+	SeatID                uuid.NullUUID
+	SectionName           sql.NullString
+	RowLabel              sql.NullString
+	SeatNumber            sql.NullInt32
+	SeatAccessible        sql.NullBool
 }
 
 type Event struct {
@@ -75,6 +136,27 @@ type Event struct {
 	VenueID     uuid.UUID
 	CreatedAt   time.Time
 	UpdatedAt   time.Time
+	OrganizerID uuid.NullUUID
+	Category    sql.NullString
+	Performers  []string
+}
+
+type Purchase struct {
+	ID         uuid.UUID
+	TotalCents int32
+	CreatedAt  time.Time
+	UpdatedAt  time.Time
+	Status     PurchaseStatus
+	CustomerID uuid.NullUUID
+}
+
+type SearchOutbox struct {
+	ID          int64
+	EventID     uuid.UUID
+	Attempts    int32
+	LastError   sql.NullString
+	AvailableAt time.Time
+	CreatedAt   time.Time
 }
 
 type Ticket struct {
@@ -91,6 +173,11 @@ type TicketType struct {
 	Name        string
 	DisplayName string
 	PriceCents  int32
+	EventID     uuid.UUID
+	Quantity    int32
+	MaxPerOrder sql.NullInt32
+	CreatedAt   time.Time
+	UpdatedAt   time.Time
 }
 
 type Venue struct {
@@ -98,3 +185,22 @@ type Venue struct {
 	Name     string
 	Location string
 }
+
+type VenueSeat struct {
+	ID         uuid.UUID
+	SectionID  uuid.UUID
+	RowLabel   string
+	RowIndex   int32
+	SeatNumber int32
+	X          float64
+	Y          float64
+	Accessible bool
+	Companion  bool
+}
+
+type VenueSection struct {
+	ID       uuid.UUID
+	VenueID  uuid.UUID
+	Name     string
+	Position int32
+}
@@ -12,6 +12,52 @@ import (
 	"github.com/lib/pq"
 )
 
+const batchCreateSeatedTickets = `-- name: BatchCreateSeatedTickets :many
+INSERT INTO tickets (event_id, ticket_type_id, seat_id, status)
+SELECT
+    $1::uuid,
+    $2::uuid,
+    unnest($3::uuid[]),
+    'available'::ticket_status
+RETURNING id, event_id, ticket_type_id, status, created_at, updated_at
+`
+
+type BatchCreateSeatedTicketsParams struct {
+	Column1 uuid.UUID
+	Column2 uuid.UUID
+	Column3 []uuid.UUID
+}
+
+func (q *Queries) BatchCreateSeatedTickets(ctx context.Context, arg BatchCreateSeatedTicketsParams) ([]Ticket, error) {
+	rows, err := q.db.QueryContext(ctx, batchCreateSeatedTickets, arg.Column1, arg.Column2, pq.Array(arg.Column3))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []Ticket
+	for rows.Next() {
+		var i Ticket
+		if err := rows.Scan(
+			&i.ID,
+			&i.EventID,
+			&i.TicketTypeID,
+			&i.Status,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
 const batchCreateTickets = `-- name: BatchCreateTickets :many
 INSERT INTO tickets (event_id, ticket_type_id, status)
 SELECT 
@@ -56,6 +102,48 @@ func (q *Queries) BatchCreateTickets(ctx context.Context, arg BatchCreateTickets
 	return items, nil
 }
 
+const deleteAvailableTicketsForType = `-- name: DeleteAvailableTicketsForType :execrows
+DELETE FROM tickets
+WHERE id IN (
+    SELECT t.id FROM tickets t
+    WHERE t.ticket_type_id = $1
+      AND t.status = 'available'
+      AND NOT EXISTS (SELECT 1 FROM refund_tickets rt WHERE rt.ticket_id = t.id)
+      AND NOT t.id = ANY($3::uuid[])
+    ORDER BY t.created_at DESC
+    LIMIT $2
+    FOR UPDATE SKIP LOCKED
+)
+`
+
+type DeleteAvailableTicketsForTypeParams struct {
+	TicketTypeID  uuid.UUID
+	Limit         int32
+	HeldTicketIds []uuid.UUID
+}
+
+// Deletes up to $2 tickets of a type that were never sold, newest first, when
+// a ticket type's quantity is reduced. Tickets returned to inventory by a
+// refund are kept because refund_tickets references them, and tickets held
+// in the booking service are kept so their holds can still be purchased.
+func (q *Queries) DeleteAvailableTicketsForType(ctx context.Context, arg DeleteAvailableTicketsForTypeParams) (int64, error) {
+	result, err := q.db.ExecContext(ctx, deleteAvailableTicketsForType, arg.TicketTypeID, arg.Limit, pq.Array(arg.HeldTicketIds))
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+const deleteTicketsForType = `-- name: DeleteTicketsForType :exec
+DELETE FROM tickets
+WHERE ticket_type_id = $1
+`
+
+func (q *Queries) DeleteTicketsForType(ctx context.Context, ticketTypeID uuid.UUID) error {
+	_, err := q.db.ExecContext(ctx, deleteTicketsForType, ticketTypeID)
+	return err
+}
+
 const getTicket = `-- name: GetTicket :one
 SELECT 
     id,
@@ -66,7 +154,12 @@ SELECT
     updated_at,
     ticket_type_name,
     ticket_type_display_name,
-    ticket_type_price_cents
+    ticket_type_price_cents,
+    seat_id,
+    section_name,
+    row_label,
+    seat_number,
+    seat_accessible
 FROM enriched_tickets
 WHERE event_id = $1 AND id = $2
 `
@@ -89,28 +182,72 @@ func (q *Queries) GetTicket(ctx context.Context, arg GetTicketParams) (EnrichedT
 		&i.TicketTypeName,
 		&i.TicketTypeDisplayName,
 		&i.TicketTypePriceCents,
+		&i.SeatID,
+		&i.SectionName,
+		&i.RowLabel,
+		&i.SeatNumber,
+		&i.SeatAccessible,
 	)
 	return i, err
 }
 
-const getTicketTypes = `-- name: GetTicketTypes :many
-SELECT id, name, display_name, price_cents FROM ticket_types
+const getTicketAvailability = `-- name: GetTicketAvailability :many
+SELECT
+    event_id,
+    ticket_type_id,
+    ticket_type_name,
+    ticket_type_display_name,
+    ticket_type_price_cents,
+    COUNT(*) FILTER (
+        WHERE status = 'available' AND NOT id = ANY($1::uuid[])
+    ) AS available,
+    COUNT(*) FILTER (
+        WHERE status = 'available' AND id = ANY($1::uuid[])
+    ) AS held,
+    COUNT(*) FILTER (WHERE status = 'sold') AS sold
+FROM enriched_tickets
+WHERE event_id = ANY($2::uuid[])
+GROUP BY event_id, ticket_type_id, ticket_type_name, ticket_type_display_name, ticket_type_price_cents
+ORDER BY event_id, ticket_type_price_cents, ticket_type_name
 `
 
-func (q *Queries) GetTicketTypes(ctx context.Context) ([]TicketType, error) {
-	rows, err := q.db.QueryContext(ctx, getTicketTypes)
+type GetTicketAvailabilityParams struct {
+	HeldTicketIds []uuid.UUID
+	EventIds      []uuid.UUID
+}
+
+type GetTicketAvailabilityRow struct {
+	EventID               uuid.UUID
+	TicketTypeID          uuid.UUID
+	TicketTypeName        string
+	TicketTypeDisplayName string
+	TicketTypePriceCents  int32
+	Available             int64
+	Held                  int64
+	Sold                  int64
+}
+
+// Counts the tickets of every ticket type of the events by availability.
+// Held tickets come from the booking service's hold index; only unsold
+// tickets count as held.
+func (q *Queries) GetTicketAvailability(ctx context.Context, arg GetTicketAvailabilityParams) ([]GetTicketAvailabilityRow, error) {
+	rows, err := q.db.QueryContext(ctx, getTicketAvailability, pq.Array(arg.HeldTicketIds), pq.Array(arg.EventIds))
 	if err != nil {
 		return nil, err
 	}
 	defer rows.Close()
-	var items []TicketType
+	var items []GetTicketAvailabilityRow
 	for rows.Next() {
-		var i TicketType
+		var i GetTicketAvailabilityRow
 		if err := rows.Scan(
-			&i.ID,
-			&i.Name,
-			&i.DisplayName,
-			&i.PriceCents,
+			&i.EventID,
+			&i.TicketTypeID,
+			&i.TicketTypeName,
+			&i.TicketTypeDisplayName,
+			&i.TicketTypePriceCents,
+			&i.Available,
+			&i.Held,
+			&i.Sold,
 		); err != nil {
 			return nil, err
 		}
@@ -125,6 +262,31 @@ func (q *Queries) GetTicketTypes(ctx context.Context) ([]TicketType, error) {
 	return items, nil
 }
 
+const getTicketTypeInventory = `-- name: GetTicketTypeInventory :one
+SELECT
+    COUNT(*) AS total,
+    COUNT(*) FILTER (
+        WHERE t.status = 'available'
+          AND NOT EXISTS (SELECT 1 FROM refund_tickets rt WHERE rt.ticket_id = t.id)
+    ) AS unsold,
+    COUNT(t.seat_id) AS seated
+FROM tickets t
+WHERE t.ticket_type_id = $1
+`
+
+type GetTicketTypeInventoryRow struct {
+	Total  int64
+	Unsold int64
+	Seated int64
+}
+
+func (q *Queries) GetTicketTypeInventory(ctx context.Context, ticketTypeID uuid.UUID) (GetTicketTypeInventoryRow, error) {
+	row := q.db.QueryRowContext(ctx, getTicketTypeInventory, ticketTypeID)
+	var i GetTicketTypeInventoryRow
+	err := row.Scan(&i.Total, &i.Unsold, &i.Seated)
+	return i, err
+}
+
 const getTicketsForEvent = `-- name: GetTicketsForEvent :many
 SELECT 
     id,
@@ -135,7 +297,12 @@ SELECT
     updated_at,
     ticket_type_name,
     ticket_type_display_name,
-    ticket_type_price_cents
+    ticket_type_price_cents,
+    seat_id,
+    section_name,
+    row_label,
+    seat_number,
+    seat_accessible
 FROM enriched_tickets
 WHERE event_id = $1
 ORDER BY ticket_type_id, id
@@ -160,6 +327,11 @@ func (q *Queries) GetTicketsForEvent(ctx context.Context, eventID uuid.UUID) ([]
 			&i.TicketTypeName,
 			&i.TicketTypeDisplayName,
 			&i.TicketTypePriceCents,
+			&i.SeatID,
+			&i.SectionName,
+			&i.RowLabel,
+			&i.SeatNumber,
+			&i.SeatAccessible,
 		); err != nil {
 			return nil, err
 		}
@@ -9,8 +9,47 @@ import (
 	"context"
 
 	"github.com/google/uuid"
+	"github.com/lib/pq"
 )
 
+const batchCreateVenueSeats = `-- name: BatchCreateVenueSeats :exec
+INSERT INTO venue_seats (section_id, row_label, row_index, seat_number, x, y, accessible, companion)
+SELECT
+    $1::uuid,
+    unnest($2::text[]),
+    unnest($3::int[]),
+    unnest($4::int[]),
+    unnest($5::float8[]),
+    unnest($6::float8[]),
+    unnest($7::bool[]),
+    unnest($8::bool[])
+`
+
+type BatchCreateVenueSeatsParams struct {
+	Column1 uuid.UUID
+	Column2 []string
+	Column3 []int32
+	Column4 []int32
+	Column5 []float64
+	Column6 []float64
+	Column7 []bool
+	Column8 []bool
+}
+
+func (q *Queries) BatchCreateVenueSeats(ctx context.Context, arg BatchCreateVenueSeatsParams) error {
+	_, err := q.db.ExecContext(ctx, batchCreateVenueSeats,
+		arg.Column1,
+		pq.Array(arg.Column2),
+		pq.Array(arg.Column3),
+		pq.Array(arg.Column4),
+		pq.Array(arg.Column5),
+		pq.Array(arg.Column6),
+		pq.Array(arg.Column7),
+		pq.Array(arg.Column8),
+	)
+	return err
+}
+
 const createVenue = `-- name: CreateVenue :one
 INSERT INTO venues (name, location)
 VALUES ($1, $2)
@@ -29,6 +68,30 @@ func (q *Queries) CreateVenue(ctx context.Context, arg CreateVenueParams) (Venue
 	return i, err
 }
 
+const createVenueSection = `-- name: CreateVenueSection :one
+INSERT INTO venue_sections (venue_id, name, position)
+VALUES ($1, $2, $3)
+RETURNING id, venue_id, name, position
+`
+
+type CreateVenueSectionParams struct {
+	VenueID  uuid.UUID
+	Name     string
+	Position int32
+}
+
+func (q *Queries) CreateVenueSection(ctx context.Context, arg CreateVenueSectionParams) (VenueSection, error) {
+	row := q.db.QueryRowContext(ctx, createVenueSection, arg.VenueID, arg.Name, arg.Position)
+	var i VenueSection
+	err := row.Scan(
+		&i.ID,
+		&i.VenueID,
+		&i.Name,
+		&i.Position,
+	)
+	return i, err
+}
+
 const deleteVenue = `-- name: DeleteVenue :exec
 DELETE FROM venues
 WHERE id = $1
@@ -39,6 +102,18 @@ func (q *Queries) DeleteVenue(ctx context.Context, id uuid.UUID) error {
 	return err
 }
 
+const deleteVenueSeatMap = `-- name: DeleteVenueSeatMap :exec
+DELETE FROM venue_sections
+WHERE venue_id = $1
+`
+
+// Sections are deleted with their seats. Fails with a foreign key violation
+// when tickets were issued for any of the seats.
+func (q *Queries) DeleteVenueSeatMap(ctx context.Context, venueID uuid.UUID) error {
+	_, err := q.db.ExecContext(ctx, deleteVenueSeatMap, venueID)
+	return err
+}
+
 const getVenue = `-- name: GetVenue :one
 SELECT id, name, location FROM venues
 WHERE id = $1
@@ -86,6 +161,114 @@ func (q *Queries) GetVenues(ctx context.Context, arg GetVenuesParams) ([]Venue,
 	return items, nil
 }
 
+const listSeatsInSections = `-- name: ListSeatsInSections :many
+SELECT
+    s.id,
+    vs.name AS section_name
+FROM venue_seats s
+JOIN venue_sections vs ON s.section_id = vs.id
+WHERE vs.venue_id = $1 AND vs.name = ANY($2::text[])
+ORDER BY vs.position, s.row_index, s.seat_number
+`
+
+type ListSeatsInSectionsParams struct {
+	VenueID uuid.UUID
+	Column2 []string
+}
+
+type ListSeatsInSectionsRow struct {
+	ID          uuid.UUID
+	SectionName string
+}
+
+func (q *Queries) ListSeatsInSections(ctx context.Context, arg ListSeatsInSectionsParams) ([]ListSeatsInSectionsRow, error) {
+	rows, err := q.db.QueryContext(ctx, listSeatsInSections, arg.VenueID, pq.Array(arg.Column2))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []ListSeatsInSectionsRow
+	for rows.Next() {
+		var i ListSeatsInSectionsRow
+		if err := rows.Scan(&i.ID, &i.SectionName); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listVenueSeats = `-- name: ListVenueSeats :many
+SELECT
+    vs.id AS section_id,
+    vs.name AS section_name,
+    s.id,
+    s.row_label,
+    s.row_index,
+    s.seat_number,
+    s.x,
+    s.y,
+    s.accessible,
+    s.companion
+FROM venue_sections vs
+JOIN venue_seats s ON s.section_id = vs.id
+WHERE vs.venue_id = $1
+ORDER BY vs.position, s.row_index, s.seat_number
+`
+
+type ListVenueSeatsRow struct {
+	SectionID   uuid.UUID
+	SectionName string
+	ID          uuid.UUID
+	RowLabel    string
+	RowIndex    int32
+	SeatNumber  int32
+	X           float64
+	Y           float64
+	Accessible  bool
+	Companion   bool
+}
+
+func (q *Queries) ListVenueSeats(ctx context.Context, venueID uuid.UUID) ([]ListVenueSeatsRow, error) {
+	rows, err := q.db.QueryContext(ctx, listVenueSeats, venueID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []ListVenueSeatsRow
+	for rows.Next() {
+		var i ListVenueSeatsRow
+		if err := rows.Scan(
+			&i.SectionID,
+			&i.SectionName,
+			&i.ID,
+			&i.RowLabel,
+			&i.RowIndex,
+			&i.SeatNumber,
+			&i.X,
+			&i.Y,
+			&i.Accessible,
+			&i.Companion,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
 const updateVenue = `-- name: UpdateVenue :one
 UPDATE venues
 SET name = $2,
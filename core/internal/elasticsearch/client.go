@@ -1,6 +1,7 @@
 package elasticsearch
 
 import (
+	"bytes"
 	"context"
 	"encoding/json"
 	"fmt"
@@ -17,6 +18,9 @@ type Client struct {
 	es *elasticsearch.Client
 }
 
+// NewClient returns a client for the cluster at addresses. It does not
+// connect, so Elasticsearch may be down; callers create the index with
+// EnsureIndex once it is reachable.
 func NewClient(addresses []string) (*Client, error) {
 	cfg := elasticsearch.Config{
 		Addresses: addresses,
@@ -27,74 +31,87 @@ func NewClient(addresses []string) (*Client, error) {
 		return nil, fmt.Errorf("failed to create elasticsearch client: %w", err)
 	}
 
-	client := &Client{es: es}
-
-	if err := client.EnsureIndex(context.Background()); err != nil {
-		return nil, fmt.Errorf("failed to ensure index exists: %w", err)
-	}
-
-	return client, nil
+	return &Client{es: es}, nil
 }
 
 
+// EnsureIndex makes sure there is an events index to search and write to. A
+// fresh cluster gets the first versioned index behind the events alias. An
+// unversioned events index from before aliases keeps serving until a reindex
+// replaces it.
 func (c *Client) EnsureIndex(ctx context.Context) error {
-	indexName := "events"
-
-	res, err := c.es.Indices.Exists([]string{indexName})
+	current, err := c.CurrentEventsIndex(ctx)
 	if err != nil {
 		return err
 	}
+	if current != "" {
+		return nil
+	}
+	return c.CreateEventsIndex(ctx, EventsIndexName(firstEventsIndexVersion), true)
+}
+
+// CurrentEventsIndex returns the index behind the events alias, EventsAlias
+// itself for an unversioned index, or "" when there is none
+func (c *Client) CurrentEventsIndex(ctx context.Context) (string, error) {
+	res, err := c.es.Indices.GetAlias(
+		c.es.Indices.GetAlias.WithContext(ctx),
+		c.es.Indices.GetAlias.WithName(EventsAlias),
+	)
+	if err != nil {
+		return "", fmt.Errorf("failed to get alias: %w", err)
+	}
 	defer res.Body.Close()
 
 	if res.StatusCode == 200 {
-		return nil
+		var indices map[string]interface{}
+		if err := json.NewDecoder(res.Body).Decode(&indices); err != nil {
+			return "", fmt.Errorf("failed to decode alias: %w", err)
+		}
+		for index := range indices {
+			return index, nil
+		}
+	} else if res.StatusCode != 404 {
+		return "", fmt.Errorf("error getting alias: %s", res.String())
 	}
 
-	mapping := `{
-		"mappings": {
-			"properties": {
-				"id": { "type": "keyword" },
-				"title": { 
-					"type": "text",
-					"analyzer": "standard",
-					"fields": {
-						"keyword": { "type": "keyword" }
-					}
-				},
-				"description": { 
-					"type": "text",
-					"analyzer": "standard"
-				},
-				"start_date": { "type": "date" },
-				"venue_id": { "type": "keyword" },
-				"venue_name": { 
-					"type": "text",
-					"fields": {
-						"keyword": { "type": "keyword" }
-					}
-				},
-				"venue_location": { 
-					"type": "text",
-					"fields": {
-						"keyword": { "type": "keyword" }
-					}
-				},
-				"created_at": { "type": "date" }
-			}
-		},
-		"settings": {
-			"number_of_shards": 1,
-			"number_of_replicas": 0,
-			"refresh_interval": "1s"
-		}
-	}`
+	exists, err := c.es.Indices.Exists([]string{EventsAlias}, c.es.Indices.Exists.WithContext(ctx))
+	if err != nil {
+		return "", err
+	}
+	defer exists.Body.Close()
+
+	if exists.StatusCode == 200 {
+		return EventsAlias, nil
+	}
+	return "", nil
+}
+
+// CreateEventsIndex creates an events index with the current mapping,
+// behind the events alias when withAlias is set. An index of the same name
+// left behind by an interrupted reindex is replaced.
+func (c *Client) CreateEventsIndex(ctx context.Context, name string, withAlias bool) error {
+	if err := c.DeleteIndex(ctx, name); err != nil {
+		return err
+	}
+
+	var body map[string]interface{}
+	if err := json.Unmarshal([]byte(eventsIndexSettings), &body); err != nil {
+		return fmt.Errorf("invalid index settings: %w", err)
+	}
+	if withAlias {
+		body["aliases"] = map[string]interface{}{EventsAlias: map[string]interface{}{}}
+	}
+	bodyJSON, err := json.Marshal(body)
+	if err != nil {
+		return fmt.Errorf("failed to marshal index settings: %w", err)
+	}
 
 	req := esapi.IndicesCreateRequest{
-		Index: indexName,
-		Body:  strings.NewReader(mapping),
+		Index: name,
+		Body:  bytes.NewReader(bodyJSON),
 	}
 
-	res, err = req.Do(ctx, c.es)
+	res, err := req.Do(ctx, c.es)
 	if err != nil {
 		return fmt.Errorf("failed to create index: %w", err)
 	}
@@ -107,21 +124,203 @@ func (c *Client) EnsureIndex(ctx context.Context) error {
 	return nil
 }
 
+// DeleteIndex deletes an index if it exists
+func (c *Client) DeleteIndex(ctx context.Context, name string) error {
+	req := esapi.IndicesDeleteRequest{
+		Index: []string{name},
+	}
+
+	res, err := req.Do(ctx, c.es)
+	if err != nil {
+		return fmt.Errorf("failed to delete index: %w", err)
+	}
+	defer res.Body.Close()
+
+	if res.IsError() && res.StatusCode != 404 {
+		return fmt.Errorf("error deleting index: %s", res.String())
+	}
+
+	return nil
+}
+
+// RefreshIndex makes everything written to an index searchable
+func (c *Client) RefreshIndex(ctx context.Context, name string) error {
+	req := esapi.IndicesRefreshRequest{
+		Index: []string{name},
+	}
+
+	res, err := req.Do(ctx, c.es)
+	if err != nil {
+		return fmt.Errorf("failed to refresh index: %w", err)
+	}
+	defer res.Body.Close()
+
+	if res.IsError() {
+		return fmt.Errorf("error refreshing index: %s", res.String())
+	}
+
+	return nil
+}
+
+// SwapEventsAlias points the events alias at newIndex instead of oldIndex in
+// one atomic step, so searches never see a missing or half-built index. An
+// unversioned events index is deleted as part of the swap since the alias
+// can't be created while an index has its name.
+func (c *Client) SwapEventsAlias(ctx context.Context, newIndex, oldIndex string) error {
+	actions := []map[string]interface{}{}
+	switch oldIndex {
+	case "":
+	case EventsAlias:
+		actions = append(actions, map[string]interface{}{
+			"remove_index": map[string]interface{}{"index": oldIndex},
+		})
+	default:
+		actions = append(actions, map[string]interface{}{
+			"remove": map[string]interface{}{"index": oldIndex, "alias": EventsAlias},
+		})
+	}
+	actions = append(actions, map[string]interface{}{
+		"add": map[string]interface{}{"index": newIndex, "alias": EventsAlias},
+	})
+
+	bodyJSON, err := json.Marshal(map[string]interface{}{"actions": actions})
+	if err != nil {
+		return fmt.Errorf("failed to marshal alias actions: %w", err)
+	}
+
+	req := esapi.IndicesUpdateAliasesRequest{
+		Body: bytes.NewReader(bodyJSON),
+	}
+
+	res, err := req.Do(ctx, c.es)
+	if err != nil {
+		return fmt.Errorf("failed to swap alias: %w", err)
+	}
+	defer res.Body.Close()
+
+	if res.IsError() {
+		return fmt.Errorf("error swapping alias: %s", res.String())
+	}
 
-func (c *Client) IndexEvent(ctx context.Context, event types.Event, venue types.Venue) error {
-	indexName := "events"
+	return nil
+}
+
+// EventDocument is an event along with the venue and ticket types its
+// search document embeds
+type EventDocument struct {
+	Event       types.Event
+	Venue       types.Venue
+	TicketTypes []types.TicketType
+}
+
+func eventDocument(doc EventDocument) map[string]interface{} {
+	names := make([]string, len(doc.TicketTypes))
+	prices := make([]int32, len(doc.TicketTypes))
+	var minPrice, maxPrice *int32
+	for i, ticketType := range doc.TicketTypes {
+		names[i] = ticketType.Name
+		prices[i] = ticketType.PriceCents
+		if minPrice == nil || prices[i] < *minPrice {
+			minPrice = &prices[i]
+		}
+		if maxPrice == nil || prices[i] > *maxPrice {
+			maxPrice = &prices[i]
+		}
+	}
+
+	var category *string // null for events without a category
+	if doc.Event.Category != "" {
+		category = &doc.Event.Category
+	}
 
-	doc := map[string]interface{}{
-		"id":             event.ID.String(),
-		"title":          event.Title,
-		"description":    event.Description,
-		"start_date":     event.StartDate.Format("2006-01-02T15:04:05Z07:00"),
-		"venue_id":       event.VenueID.String(),
-		"venue_name":      venue.Name,
-		"venue_location":  venue.Location,
-		"created_at":      event.CreatedAt.Format("2006-01-02T15:04:05Z07:00"), 
+	return map[string]interface{}{
+		"id":                  doc.Event.ID.String(),
+		"title":               doc.Event.Title,
+		"description":         doc.Event.Description,
+		"start_date":          doc.Event.StartDate.Format("2006-01-02T15:04:05Z07:00"),
+		"venue_id":            doc.Event.VenueID.String(),
+		"venue_name":          doc.Venue.Name,
+		"venue_location":      doc.Venue.Location,
+		"created_at":          doc.Event.CreatedAt.Format("2006-01-02T15:04:05Z07:00"),
+		"category":            category,
+		"performers":          doc.Event.Performers,
+		"ticket_types":        names,
+		"ticket_prices_cents": prices,
+		"min_price_cents":     minPrice, // null for events without ticket types
+		"max_price_cents":     maxPrice,
+	}
+}
+
+// BulkIndexEvents writes the documents to the given index in one bulk
+// request and fails if any of them was rejected
+func (c *Client) BulkIndexEvents(ctx context.Context, index string, docs []EventDocument) error {
+	if len(docs) == 0 {
+		return nil
 	}
 
+	var body bytes.Buffer
+	enc := json.NewEncoder(&body)
+	for _, doc := range docs {
+		action := map[string]interface{}{
+			"index": map[string]interface{}{"_index": index, "_id": doc.Event.ID.String()},
+		}
+		if err := enc.Encode(action); err != nil {
+			return fmt.Errorf("failed to marshal bulk action: %w", err)
+		}
+		if err := enc.Encode(eventDocument(doc)); err != nil {
+			return fmt.Errorf("failed to marshal document: %w", err)
+		}
+	}
+
+	req := esapi.BulkRequest{
+		Body: &body,
+	}
+
+	res, err := req.Do(ctx, c.es)
+	if err != nil {
+		return fmt.Errorf("failed to bulk index documents: %w", err)
+	}
+	defer res.Body.Close()
+
+	if res.IsError() {
+		return fmt.Errorf("error bulk indexing documents: %s", res.String())
+	}
+
+	var result struct {
+		Errors bool `json:"errors"`
+		Items  []map[string]struct {
+			ID     string          `json:"_id"`
+			Status int             `json:"status"`
+			Error  json.RawMessage `json:"error"`
+		} `json:"items"`
+	}
+	if err := json.NewDecoder(res.Body).Decode(&result); err != nil {
+		return fmt.Errorf("failed to decode bulk response: %w", err)
+	}
+	if !result.Errors {
+		return nil
+	}
+
+	failed := 0
+	var first string
+	for _, item := range result.Items {
+		for _, op := range item {
+			if op.Status >= 300 {
+				if failed == 0 {
+					first = fmt.Sprintf("%s: %s", op.ID, op.Error)
+				}
+				failed++
+			}
+		}
+	}
+	return fmt.Errorf("%d of %d documents failed to index, first: %s", failed, len(docs), first)
+}
+
+func (c *Client) IndexEvent(ctx context.Context, event EventDocument) error {
+	indexName := EventsAlias
+
+	doc := eventDocument(event)
+
 	docJSON, err := json.Marshal(doc)
 	if err != nil {
 		return fmt.Errorf("failed to marshal document: %w", err)
@@ -129,7 +328,7 @@ func (c *Client) IndexEvent(ctx context.Context, event types.Event, venue types.
 
 	req := esapi.IndexRequest{
 		Index:      indexName,
-		DocumentID: event.ID.String(),
+		DocumentID: event.Event.ID.String(),
 		Body:       strings.NewReader(string(docJSON)),
 		Refresh:    "true", 
 	}
@@ -148,12 +347,12 @@ func (c *Client) IndexEvent(ctx context.Context, event types.Event, venue types.
 }
 
 
-func (c *Client) UpdateEvent(ctx context.Context, event types.Event, venue types.Venue) error {
-	return c.IndexEvent(ctx, event, venue)
+func (c *Client) UpdateEvent(ctx context.Context, event EventDocument) error {
+	return c.IndexEvent(ctx, event)
 }
 
 func (c *Client) DeleteEvent(ctx context.Context, eventID uuid.UUID) error {
-	indexName := "events"
+	indexName := EventsAlias
 
 	req := esapi.DeleteRequest{
 		Index:      indexName,
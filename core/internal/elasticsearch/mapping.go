@@ -0,0 +1,102 @@
+package elasticsearch
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// EventsAlias is the name searches and writes use. It points at one
+// versioned index, events_v<N>, so the index can be rebuilt next to the live
+// one and swapped in atomically.
+const EventsAlias = "events"
+
+// firstEventsIndexVersion is the version of the first versioned index; the
+// unversioned events index it replaces counts as version 1
+const firstEventsIndexVersion = 2
+
+// EventsIndexName returns the name of version v of the events index
+func EventsIndexName(v int) string {
+	return fmt.Sprintf("%s_v%d", EventsAlias, v)
+}
+
+// eventsIndexVersion parses the version out of an events index name. The
+// unversioned events index is version 1.
+func eventsIndexVersion(name string) (int, bool) {
+	if name == EventsAlias {
+		return 1, true
+	}
+	v, err := strconv.Atoi(strings.TrimPrefix(name, EventsAlias+"_v"))
+	if err != nil || !strings.HasPrefix(name, EventsAlias+"_v") {
+		return 0, false
+	}
+	return v, true
+}
+
+// NextEventsIndex returns the name of the index a reindex should build, one
+// version above current, which is "" when there is no events index yet
+func NextEventsIndex(current string) (string, error) {
+	if current == "" {
+		return EventsIndexName(firstEventsIndexVersion), nil
+	}
+	v, ok := eventsIndexVersion(current)
+	if !ok {
+		return "", fmt.Errorf("events alias points at unexpected index %q", current)
+	}
+	return EventsIndexName(max(v+1, firstEventsIndexVersion)), nil
+}
+
+// eventsIndexSettings are the settings and mappings every version of the
+// events index is created with. Changing them takes a reindex.
+const eventsIndexSettings = `{
+	"mappings": {
+		"properties": {
+			"id": { "type": "keyword" },
+			"title": {
+				"type": "text",
+				"analyzer": "standard",
+				"fields": {
+					"keyword": { "type": "keyword" },
+					"suggest": { "type": "completion" }
+				}
+			},
+			"description": {
+				"type": "text",
+				"analyzer": "standard"
+			},
+			"start_date": { "type": "date" },
+			"venue_id": { "type": "keyword" },
+			"venue_name": {
+				"type": "text",
+				"fields": {
+					"keyword": { "type": "keyword" },
+					"suggest": { "type": "completion" }
+				}
+			},
+			"venue_location": {
+				"type": "text",
+				"fields": {
+					"keyword": { "type": "keyword" }
+				}
+			},
+			"created_at": { "type": "date" },
+			"category": { "type": "keyword" },
+			"performers": {
+				"type": "text",
+				"fields": {
+					"keyword": { "type": "keyword" },
+					"suggest": { "type": "completion" }
+				}
+			},
+			"ticket_types": { "type": "keyword" },
+			"ticket_prices_cents": { "type": "integer" },
+			"min_price_cents": { "type": "integer" },
+			"max_price_cents": { "type": "integer" }
+		}
+	},
+	"settings": {
+		"number_of_shards": 1,
+		"number_of_replicas": 0,
+		"refresh_interval": "1s"
+	}
+}`
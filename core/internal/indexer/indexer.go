@@ -0,0 +1,199 @@
+package indexer
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/ignisrex/tix/core/internal/database"
+	"github.com/ignisrex/tix/core/internal/elasticsearch"
+	"github.com/ignisrex/tix/core/mappers"
+)
+
+const (
+	// pollInterval is how long the indexer waits when the outbox is empty
+	pollInterval = time.Second
+
+	batchSize = 100
+
+	// A claimed batch is hidden from other indexers for this long, so rows
+	// claimed by an instance that dies are picked up again afterwards
+	leaseDuration = time.Minute
+
+	// Failed rows are retried after 2^attempts seconds, up to maxBackoff
+	maxBackoff = 5 * time.Minute
+)
+
+// Indexer drains the search outbox into Elasticsearch. Each row names an
+// event whose document is out of date; the indexer indexes the event as it
+// is now, or deletes its document if the event no longer exists, so rows
+// can be applied more than once and in any order.
+type Indexer struct {
+	db       *sql.DB
+	queries  *database.Queries
+	esClient *elasticsearch.Client
+
+	paused bool // a reindex held the lock on the last attempt
+}
+
+func New(db *sql.DB, esClient *elasticsearch.Client) *Indexer {
+	return &Indexer{
+		db:       db,
+		queries:  database.New(db),
+		esClient: esClient,
+	}
+}
+
+// Run drains the outbox until ctx is done. Elasticsearch may be down when it
+// starts; rows wait in the outbox until the events index can be ensured.
+func (i *Indexer) Run(ctx context.Context) {
+	if !i.ensureIndex(ctx) {
+		return
+	}
+
+	log.Printf("indexer: draining search outbox")
+	for {
+		claimed, err := i.drain(ctx)
+		if err != nil {
+			log.Printf("indexer: failed to drain search outbox: %v", err)
+		}
+		if claimed == batchSize {
+			// More rows are probably due
+			continue
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(pollInterval):
+		}
+	}
+}
+
+// ensureIndex creates the events index if there is none, retrying with
+// backoff until Elasticsearch is reachable. It returns false when ctx is done
+// first.
+func (i *Indexer) ensureIndex(ctx context.Context) bool {
+	for attempts := int32(0); ; attempts++ {
+		err := i.esClient.EnsureIndex(ctx)
+		if err == nil {
+			return true
+		}
+		backoff := retryBackoff(attempts)
+		log.Printf("indexer: failed to ensure search index (attempt %d), retrying in %s: %v", attempts+1, backoff, err)
+
+		select {
+		case <-ctx.Done():
+			return false
+		case <-time.After(backoff):
+		}
+	}
+}
+
+// drain applies one batch of due rows and returns how many were claimed.
+// Nothing is applied while a reindex runs.
+func (i *Indexer) drain(ctx context.Context) (int, error) {
+	tx, err := i.db.BeginTx(ctx, nil)
+	if err != nil {
+		return 0, err
+	}
+	// Ending the transaction releases the lock
+	defer tx.Rollback()
+
+	locked, err := i.queries.WithTx(tx).TryLockSearchIndexShared(ctx)
+	if err != nil {
+		return 0, fmt.Errorf("failed to lock search index: %w", err)
+	}
+	if !locked {
+		if !i.paused {
+			log.Printf("indexer: reindex in progress, holding outbox rows until it finishes")
+		}
+		i.paused = true
+		return 0, nil
+	}
+	i.paused = false
+
+	entries, err := i.queries.ClaimSearchOutbox(ctx, database.ClaimSearchOutboxParams{
+		LeaseSeconds: leaseDuration.Seconds(),
+		BatchSize:    batchSize,
+	})
+	if err != nil {
+		return 0, fmt.Errorf("failed to claim outbox rows: %w", err)
+	}
+
+	// An event changed several times is only indexed once
+	var order []uuid.UUID
+	byEvent := make(map[uuid.UUID][]database.SearchOutbox)
+	for _, entry := range entries {
+		if _, ok := byEvent[entry.EventID]; !ok {
+			order = append(order, entry.EventID)
+		}
+		byEvent[entry.EventID] = append(byEvent[entry.EventID], entry)
+	}
+
+	for _, eventID := range order {
+		eventEntries := byEvent[eventID]
+		ids := make([]int64, len(eventEntries))
+		var attempts int32
+		for n, entry := range eventEntries {
+			ids[n] = entry.ID
+			attempts = max(attempts, entry.Attempts)
+		}
+
+		if err := i.sync(ctx, eventID); err != nil {
+			backoff := retryBackoff(attempts)
+			log.Printf("indexer: failed to sync event %s (attempt %d), retrying in %s: %v", eventID, attempts+1, backoff, err)
+			if err := i.queries.RetrySearchOutboxEntries(ctx, database.RetrySearchOutboxEntriesParams{
+				LastError:      sql.NullString{String: err.Error(), Valid: true},
+				BackoffSeconds: backoff.Seconds(),
+				Ids:            ids,
+			}); err != nil {
+				log.Printf("indexer: failed to reschedule outbox rows for event %s: %v", eventID, err)
+			}
+			continue
+		}
+
+		if err := i.queries.DeleteSearchOutboxEntries(ctx, ids); err != nil {
+			// The rows come back after their lease and are applied again
+			log.Printf("indexer: failed to delete outbox rows for event %s: %v", eventID, err)
+		}
+	}
+	return len(entries), nil
+}
+
+// sync makes the event's search document match the database
+func (i *Indexer) sync(ctx context.Context, eventID uuid.UUID) error {
+	dbEvent, err := i.queries.GetEvent(ctx, eventID)
+	if errors.Is(err, sql.ErrNoRows) {
+		return i.esClient.DeleteEvent(ctx, eventID)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to get event: %w", err)
+	}
+
+	dbVenue, err := i.queries.GetVenue(ctx, dbEvent.VenueID)
+	if err != nil {
+		return fmt.Errorf("failed to get venue: %w", err)
+	}
+	dbTicketTypes, err := i.queries.ListEventTicketTypes(ctx, eventID)
+	if err != nil {
+		return fmt.Errorf("failed to list ticket types: %w", err)
+	}
+	return i.esClient.IndexEvent(ctx, elasticsearch.EventDocument{
+		Event:       mappers.ToEvent(dbEvent),
+		Venue:       mappers.ToVenue(dbVenue),
+		TicketTypes: mappers.ToTicketTypes(dbTicketTypes),
+	})
+}
+
+func retryBackoff(attempts int32) time.Duration {
+	if attempts >= 9 {
+		return maxBackoff
+	}
+	return min(time.Duration(1<<attempts)*time.Second, maxBackoff)
+}
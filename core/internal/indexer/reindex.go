@@ -0,0 +1,142 @@
+package indexer
+
+import (
+	"context"
+	"fmt"
+	"log"
+
+	"github.com/google/uuid"
+
+	"github.com/ignisrex/tix/core/internal/database"
+	"github.com/ignisrex/tix/core/internal/elasticsearch"
+	"github.com/ignisrex/tix/core/mappers"
+	"github.com/ignisrex/tix/core/types"
+)
+
+// Events are read from Postgres and sent to Elasticsearch this many at a time
+const reindexBatchSize = 500
+
+// Reindex rebuilds the events index from Postgres into a new versioned index
+// and swaps the events alias over to it once it is complete, so searches
+// keep using the old index until then. It returns the new index's name.
+//
+// Indexers of running core instances hold off while the reindex runs, so
+// changes made in the meantime stay in the outbox and are applied to the new
+// index afterwards.
+func (i *Indexer) Reindex(ctx context.Context) (string, error) {
+	tx, err := i.db.BeginTx(ctx, nil)
+	if err != nil {
+		return "", err
+	}
+	defer tx.Rollback()
+
+	log.Printf("reindex: waiting for running indexers to finish their batch")
+	if err := i.queries.WithTx(tx).LockSearchIndex(ctx); err != nil {
+		return "", fmt.Errorf("failed to lock search index: %w", err)
+	}
+
+	current, err := i.esClient.CurrentEventsIndex(ctx)
+	if err != nil {
+		return "", err
+	}
+	next, err := elasticsearch.NextEventsIndex(current)
+	if err != nil {
+		return "", err
+	}
+
+	log.Printf("reindex: building %s to replace %q", next, current)
+	if err := i.esClient.CreateEventsIndex(ctx, next, false); err != nil {
+		return "", err
+	}
+	if err := i.load(ctx, next); err != nil {
+		if err := i.esClient.DeleteIndex(context.WithoutCancel(ctx), next); err != nil {
+			log.Printf("reindex: failed to delete incomplete index %s: %v", next, err)
+		}
+		return "", err
+	}
+
+	if err := i.esClient.SwapEventsAlias(ctx, next, current); err != nil {
+		return "", err
+	}
+	log.Printf("reindex: %s now serves the %s alias", next, elasticsearch.EventsAlias)
+
+	if current != "" && current != elasticsearch.EventsAlias {
+		if err := i.esClient.DeleteIndex(ctx, current); err != nil {
+			log.Printf("reindex: failed to delete old index %s: %v", current, err)
+		}
+	}
+
+	return next, tx.Commit()
+}
+
+// load bulk-loads every event into index and makes it searchable
+func (i *Indexer) load(ctx context.Context, index string) error {
+	var after uuid.UUID
+	total := 0
+	for {
+		rows, err := i.queries.ListEventsForIndexing(ctx, database.ListEventsForIndexingParams{
+			AfterID:   after,
+			BatchSize: reindexBatchSize,
+		})
+		if err != nil {
+			return fmt.Errorf("failed to list events: %w", err)
+		}
+		if len(rows) == 0 {
+			break
+		}
+
+		eventIDs := make([]uuid.UUID, len(rows))
+		for n, row := range rows {
+			eventIDs[n] = row.ID
+		}
+		dbTicketTypes, err := i.queries.ListTicketTypesForEvents(ctx, eventIDs)
+		if err != nil {
+			return fmt.Errorf("failed to list ticket types: %w", err)
+		}
+		ticketTypes := make(map[uuid.UUID][]types.TicketType)
+		for _, ticketType := range mappers.ToTicketTypes(dbTicketTypes) {
+			ticketTypes[ticketType.EventID] = append(ticketTypes[ticketType.EventID], ticketType)
+		}
+
+		docs := make([]elasticsearch.EventDocument, len(rows))
+		for n, row := range rows {
+			docs[n] = toEventDocument(row, ticketTypes[row.ID])
+		}
+		if err := i.esClient.BulkIndexEvents(ctx, index, docs); err != nil {
+			return err
+		}
+
+		total += len(rows)
+		after = rows[len(rows)-1].ID
+		log.Printf("reindex: indexed %d events", total)
+	}
+
+	return i.esClient.RefreshIndex(ctx, index)
+}
+
+func toEventDocument(row database.ListEventsForIndexingRow, ticketTypes []types.TicketType) elasticsearch.EventDocument {
+	event := types.Event{
+		ID:          row.ID,
+		Title:       row.Title,
+		Description: row.Description,
+		StartDate:   row.StartDate,
+		VenueID:     row.VenueID,
+		Performers:  row.Performers,
+		CreatedAt:   row.CreatedAt,
+	}
+	if row.OrganizerID.Valid {
+		event.OrganizerID = &row.OrganizerID.UUID
+	}
+	if row.Category.Valid {
+		event.Category = row.Category.String
+	}
+	return elasticsearch.EventDocument{
+		Event: event,
+		Venue: types.Venue{
+			ID:       row.VenueID,
+			Name:     row.VenueName,
+			Location: row.VenueLocation,
+		},
+		TicketTypes: ticketTypes,
+	}
+}
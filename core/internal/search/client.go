@@ -3,6 +3,7 @@ package search
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 	"net/http"
@@ -12,6 +13,10 @@ import (
 	"github.com/ignisrex/tix/core/types"
 )
 
+// ErrInvalidSearch is returned when the search service rejects a search's
+// parameters, such as a cursor from a different sort order
+var ErrInvalidSearch = errors.New("invalid search")
+
 type Client struct {
 	baseURL    string
 	httpClient *http.Client
@@ -26,18 +31,14 @@ func NewClient(baseURL string) *Client {
 	}
 }
 
-func (c *Client) SearchEvents(ctx context.Context, query string, limit, offset int) ([]types.SearchEventResult, error) {
+func (c *Client) SearchEvents(ctx context.Context, params types.SearchEventsParams) (*types.SearchEventResults, error) {
 	// Build URL with query parameters
 	u, err := url.Parse(c.baseURL + "/api/v1/search/events")
 	if err != nil {
 		return nil, fmt.Errorf("invalid search service URL: %w", err)
 	}
 
-	q := u.Query()
-	q.Set("q", query)
-	q.Set("limit", fmt.Sprintf("%d", limit))
-	q.Set("offset", fmt.Sprintf("%d", offset))
-	u.RawQuery = q.Encode()
+	u.RawQuery = searchQuery(params).Encode()
 
 	req, err := http.NewRequestWithContext(ctx, "GET", u.String(), nil)
 	if err != nil {
@@ -50,6 +51,13 @@ func (c *Client) SearchEvents(ctx context.Context, query string, limit, offset i
 	}
 	defer resp.Body.Close()
 
+	if resp.StatusCode == http.StatusBadRequest {
+		var errResp struct {
+			Error string `json:"error"`
+		}
+		json.NewDecoder(resp.Body).Decode(&errResp)
+		return nil, fmt.Errorf("%w: %s", ErrInvalidSearch, errResp.Error)
+	}
 	if resp.StatusCode != http.StatusOK {
 		body, _ := io.ReadAll(resp.Body)
 		return nil, fmt.Errorf("search service returned status %d: %s", resp.StatusCode, string(body))
@@ -60,5 +68,104 @@ func (c *Client) SearchEvents(ctx context.Context, query string, limit, offset i
 		return nil, fmt.Errorf("failed to decode search response: %w", err)
 	}
 
-	return searchResp.Results, nil
-}
\ No newline at end of file
+	return &searchResp, nil
+}
+
+// Health checks that the search service is up and can reach Elasticsearch
+func (c *Client) Health(ctx context.Context) error {
+	req, err := http.NewRequestWithContext(ctx, "GET", c.baseURL+"/api/v1/healthz", nil)
+	if err != nil {
+		return fmt.Errorf("failed to create request: %w", err)
+	}
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to call search service: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(resp.Body)
+		return fmt.Errorf("search service returned status %d: %s", resp.StatusCode, string(body))
+	}
+	return nil
+}
+
+// Suggest fetches completions of prefix, at most limit per type
+func (c *Client) Suggest(ctx context.Context, prefix string, limit int) (*types.EventSuggestions, error) {
+	u, err := url.Parse(c.baseURL + "/api/v1/search/suggest")
+	if err != nil {
+		return nil, fmt.Errorf("invalid search service URL: %w", err)
+	}
+
+	q := url.Values{}
+	q.Set("q", prefix)
+	q.Set("limit", fmt.Sprintf("%d", limit))
+	u.RawQuery = q.Encode()
+
+	req, err := http.NewRequestWithContext(ctx, "GET", u.String(), nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to call search service: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(resp.Body)
+		return nil, fmt.Errorf("search service returned status %d: %s", resp.StatusCode, string(body))
+	}
+
+	var suggestions types.EventSuggestions
+	if err := json.NewDecoder(resp.Body).Decode(&suggestions); err != nil {
+		return nil, fmt.Errorf("failed to decode suggest response: %w", err)
+	}
+	return &suggestions, nil
+}
+
+// searchQuery encodes the params as the search service's query string
+func searchQuery(params types.SearchEventsParams) url.Values {
+	q := url.Values{}
+	q.Set("q", params.Query)
+	q.Set("limit", fmt.Sprintf("%d", params.Limit))
+	if params.Cursor != "" {
+		q.Set("cursor", params.Cursor)
+	} else {
+		q.Set("offset", fmt.Sprintf("%d", params.Offset))
+	}
+
+	if params.StartFrom != nil {
+		q.Set("start_from", params.StartFrom.Format(time.RFC3339Nano))
+	}
+	if params.StartTo != nil {
+		q.Set("start_to", params.StartTo.Format(time.RFC3339Nano))
+	}
+	for _, venueID := range params.VenueIDs {
+		q.Add("venue_id", venueID.String())
+	}
+	if params.Location != "" {
+		q.Set("location", params.Location)
+	}
+	for _, ticketType := range params.TicketTypes {
+		q.Add("ticket_type", ticketType)
+	}
+	for _, category := range params.Categories {
+		q.Add("category", category)
+	}
+	if params.MinPriceCents != nil {
+		q.Set("min_price_cents", fmt.Sprintf("%d", *params.MinPriceCents))
+	}
+	if params.MaxPriceCents != nil {
+		q.Set("max_price_cents", fmt.Sprintf("%d", *params.MaxPriceCents))
+	}
+	if params.Sort != "" {
+		q.Set("sort", params.Sort)
+	}
+	if params.Facets {
+		q.Set("facets", "true")
+	}
+	return q
+}
@@ -0,0 +1,175 @@
+package search
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/ignisrex/tix/core/internal/database"
+	"github.com/ignisrex/tix/core/types"
+)
+
+// PostgresSearcher searches events with Postgres full-text search, for when
+// the search service or Elasticsearch is down. It takes the same filters and
+// sort orders, but ranks more simply, has no fuzzy matching, and returns no
+// facets or cursors, so its results are marked degraded.
+type PostgresSearcher struct {
+	queries *database.Queries
+}
+
+func NewPostgresSearcher(queries *database.Queries) *PostgresSearcher {
+	return &PostgresSearcher{queries: queries}
+}
+
+func (s *PostgresSearcher) SearchEvents(ctx context.Context, params types.SearchEventsParams) (*types.SearchEventResults, error) {
+	if params.Cursor != "" {
+		return nil, fmt.Errorf("%w: cursors are unavailable while search is degraded, page with offset instead", ErrInvalidSearch)
+	}
+
+	startFrom := time.Now().UTC()
+	if params.StartFrom != nil {
+		startFrom = params.StartFrom.UTC()
+	}
+	var startTo sql.NullTime
+	if params.StartTo != nil {
+		startTo = sql.NullTime{Time: params.StartTo.UTC(), Valid: true}
+	}
+	sort := params.Sort
+	if sort == "" {
+		sort = types.SearchSortDate
+	}
+
+	filters := database.CountSearchEventsParams{
+		Query:           params.Query,
+		StartFrom:       startFrom,
+		StartTo:         startTo,
+		VenueIds:        params.VenueIDs,
+		Location:        params.Location,
+		Categories:      params.Categories,
+		TicketTypeNames: params.TicketTypes,
+		MinPriceCents:   toNullInt32(params.MinPriceCents),
+		MaxPriceCents:   toNullInt32(params.MaxPriceCents),
+	}
+	rows, err := s.queries.SearchEvents(ctx, database.SearchEventsParams{
+		Query:           filters.Query,
+		StartFrom:       filters.StartFrom,
+		StartTo:         filters.StartTo,
+		VenueIds:        filters.VenueIds,
+		Location:        filters.Location,
+		Categories:      filters.Categories,
+		TicketTypeNames: filters.TicketTypeNames,
+		MinPriceCents:   filters.MinPriceCents,
+		MaxPriceCents:   filters.MaxPriceCents,
+		Sort:            sort,
+		ResultLimit:     int32(params.Limit),
+		ResultOffset:    int32(params.Offset),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to search events in postgres: %w", err)
+	}
+	// Counted apart from the page, which is empty past the last result
+	total, err := s.queries.CountSearchEvents(ctx, filters)
+	if err != nil {
+		return nil, fmt.Errorf("failed to count events in postgres: %w", err)
+	}
+
+	results := &types.SearchEventResults{
+		Results:  make([]types.SearchEventResult, len(rows)),
+		Total:    int(total),
+		Degraded: true,
+	}
+	for i, row := range rows {
+		results.Results[i] = toSearchEventResult(row)
+	}
+	if next := params.Offset + params.Limit; next < results.Total {
+		results.NextOffset = &next
+	}
+	return results, nil
+}
+
+func (s *PostgresSearcher) Suggest(ctx context.Context, prefix string, limit int) (*types.EventSuggestions, error) {
+	pattern := likeEscaper.Replace(prefix) + "%"
+
+	titles, err := s.queries.SuggestEventTitles(ctx, database.SuggestEventTitlesParams{
+		Pattern:     pattern,
+		ResultLimit: int32(limit),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to suggest titles: %w", err)
+	}
+	venues, err := s.queries.SuggestVenueNames(ctx, database.SuggestVenueNamesParams{
+		Pattern:     pattern,
+		ResultLimit: int32(limit),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to suggest venues: %w", err)
+	}
+	performers, err := s.queries.SuggestPerformers(ctx, database.SuggestPerformersParams{
+		Pattern:     pattern,
+		ResultLimit: int32(limit),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to suggest performers: %w", err)
+	}
+
+	suggestions := &types.EventSuggestions{
+		Titles:     make([]types.EventSuggestion, len(titles)),
+		Venues:     make([]types.EventSuggestion, len(venues)),
+		Performers: make([]types.EventSuggestion, len(performers)),
+		Degraded:   true,
+	}
+	for i, title := range titles {
+		suggestions.Titles[i] = types.EventSuggestion{
+			Text:      title.Title,
+			EventID:   title.ID.String(),
+			StartDate: &title.StartDate,
+		}
+	}
+	for i, venue := range venues {
+		suggestions.Venues[i] = types.EventSuggestion{Text: venue.Name, VenueID: venue.ID.String()}
+	}
+	for i, performer := range performers {
+		suggestions.Performers[i] = types.EventSuggestion{Text: performer}
+	}
+	return suggestions, nil
+}
+
+// likeEscaper makes a prefix match itself literally in a LIKE pattern
+var likeEscaper = strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`)
+
+func toSearchEventResult(row database.SearchEventsRow) types.SearchEventResult {
+	result := types.SearchEventResult{
+		ID:            row.ID.String(),
+		Title:         row.Title,
+		Description:   row.Description,
+		StartDate:     row.StartDate,
+		VenueID:       row.VenueID.String(),
+		VenueName:     row.VenueName,
+		VenueLocation: row.VenueLocation,
+		CreatedAt:     row.CreatedAt,
+		Performers:    row.Performers,
+		TicketTypes:   row.TicketTypes,
+	}
+	if row.Category.Valid {
+		result.Category = row.Category.String
+	}
+	if row.MinPriceCents.Valid {
+		result.MinPriceCents = &row.MinPriceCents.Int32
+	}
+	if row.MaxPriceCents.Valid {
+		result.MaxPriceCents = &row.MaxPriceCents.Int32
+	}
+	if result.TicketTypes == nil {
+		result.TicketTypes = []string{}
+	}
+	return result
+}
+
+func toNullInt32(v *int32) sql.NullInt32 {
+	if v == nil {
+		return sql.NullInt32{}
+	}
+	return sql.NullInt32{Int32: *v, Valid: true}
+}
@@ -0,0 +1,41 @@
+package search
+
+import (
+	"context"
+	"regexp"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+
+	"github.com/ignisrex/tix/core/internal/database"
+	"github.com/ignisrex/tix/core/types"
+)
+
+func TestPostgresSearchCountsTotalPastLastPage(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("failed to open sqlmock: %v", err)
+	}
+	defer db.Close()
+	searcher := NewPostgresSearcher(database.New(db))
+
+	// The page past the last result is empty, but the matches still count
+	mock.ExpectQuery(regexp.QuoteMeta("-- name: SearchEvents")).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "title", "description", "start_date", "venue_id", "created_at", "category", "performers", "venue_name", "venue_location", "min_price_cents", "max_price_cents", "ticket_types"}))
+	mock.ExpectQuery(regexp.QuoteMeta("-- name: CountSearchEvents")).
+		WillReturnRows(sqlmock.NewRows([]string{"total"}).AddRow(25))
+
+	results, err := searcher.SearchEvents(context.Background(), types.SearchEventsParams{Limit: 20, Offset: 40})
+	if err != nil {
+		t.Fatalf("SearchEvents: %v", err)
+	}
+	if results.Total != 25 || len(results.Results) != 0 {
+		t.Errorf("got %d results of %d, want 0 of 25", len(results.Results), results.Total)
+	}
+	if results.NextOffset != nil {
+		t.Errorf("NextOffset = %d, want none", *results.NextOffset)
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Error(err)
+	}
+}
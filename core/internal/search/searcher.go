@@ -0,0 +1,109 @@
+package search
+
+import (
+	"context"
+	"errors"
+	"log"
+	"sync/atomic"
+	"time"
+
+	"github.com/ignisrex/tix/core/types"
+)
+
+// Searcher searches events and suggests completions for a search box. The
+// search service is the primary searcher and Postgres the fallback.
+type Searcher interface {
+	SearchEvents(ctx context.Context, params types.SearchEventsParams) (*types.SearchEventResults, error)
+	Suggest(ctx context.Context, prefix string, limit int) (*types.EventSuggestions, error)
+}
+
+// DegradedHeader is set to "true" on responses served by the fallback
+const DegradedHeader = "Search-Degraded"
+
+const (
+	healthCheckInterval = 5 * time.Second
+	healthCheckTimeout  = 2 * time.Second
+)
+
+// Failover sends searches to the search service while it is healthy and to
+// the fallback otherwise. The search service counts as unhealthy from a
+// failed health check or search until a health check passes again.
+type Failover struct {
+	client   *Client
+	fallback Searcher
+	healthy  atomic.Bool
+}
+
+func NewFailover(client *Client, fallback Searcher) *Failover {
+	f := &Failover{
+		client:   client,
+		fallback: fallback,
+	}
+	// Searches before the first health check try the search service, and
+	// fall back if it fails
+	f.healthy.Store(true)
+	return f
+}
+
+// Run checks the search service's health until ctx is done
+func (f *Failover) Run(ctx context.Context) {
+	for {
+		checkCtx, cancel := context.WithTimeout(ctx, healthCheckTimeout)
+		err := f.client.Health(checkCtx)
+		cancel()
+		if err != nil {
+			f.setHealthy(false, err)
+		} else {
+			f.setHealthy(true, nil)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(healthCheckInterval):
+		}
+	}
+}
+
+func (f *Failover) SearchEvents(ctx context.Context, params types.SearchEventsParams) (*types.SearchEventResults, error) {
+	if f.healthy.Load() {
+		results, err := f.client.SearchEvents(ctx, params)
+		if !f.failedOver(ctx, err) {
+			return results, err
+		}
+	}
+	return f.fallback.SearchEvents(ctx, params)
+}
+
+func (f *Failover) Suggest(ctx context.Context, prefix string, limit int) (*types.EventSuggestions, error) {
+	if f.healthy.Load() {
+		suggestions, err := f.client.Suggest(ctx, prefix, limit)
+		if !f.failedOver(ctx, err) {
+			return suggestions, err
+		}
+	}
+	return f.fallback.Suggest(ctx, prefix, limit)
+}
+
+// failedOver reports whether a call to the search service failed in a way
+// the fallback should handle, and marks the search service unhealthy if so.
+// Invalid searches would fail there too, and canceled ones aren't worth
+// retrying.
+func (f *Failover) failedOver(ctx context.Context, err error) bool {
+	if err == nil || errors.Is(err, ErrInvalidSearch) || ctx.Err() != nil {
+		return false
+	}
+	f.setHealthy(false, err)
+	return true
+}
+
+func (f *Failover) setHealthy(healthy bool, err error) {
+	if f.healthy.Swap(healthy) == healthy {
+		return
+	}
+	if healthy {
+		log.Printf("search: search service is healthy again, leaving the Postgres fallback")
+	} else {
+		log.Printf("Warning: search service is unhealthy, searching Postgres until it recovers: %v", err)
+	}
+}
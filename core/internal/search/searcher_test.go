@@ -0,0 +1,168 @@
+package search
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/ignisrex/tix/core/types"
+)
+
+// fakeSearchService answers searches and health checks with the given
+// statuses and counts the searches it gets
+type fakeSearchService struct {
+	searchStatus atomic.Int32
+	healthStatus atomic.Int32
+	searches     atomic.Int32
+}
+
+func newFakeSearchService(t *testing.T) (*fakeSearchService, *Client) {
+	t.Helper()
+	s := &fakeSearchService{}
+	s.searchStatus.Store(http.StatusOK)
+	s.healthStatus.Store(http.StatusOK)
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/api/v1/healthz":
+			w.WriteHeader(int(s.healthStatus.Load()))
+		case "/api/v1/search/events":
+			s.searches.Add(1)
+			status := int(s.searchStatus.Load())
+			w.WriteHeader(status)
+			switch status {
+			case http.StatusOK:
+				_, _ = w.Write([]byte(`{"results":[{"id":"from-search-service"}],"total":1}`))
+			case http.StatusBadRequest:
+				_, _ = w.Write([]byte(`{"error":"invalid cursor"}`))
+			}
+		case "/api/v1/search/suggest":
+			w.WriteHeader(int(s.searchStatus.Load()))
+			_, _ = w.Write([]byte(`{"titles":[],"venues":[],"performers":[]}`))
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	t.Cleanup(server.Close)
+	return s, NewClient(server.URL)
+}
+
+// fallbackSearcher answers like the Postgres fallback and counts its calls
+type fallbackSearcher struct {
+	calls atomic.Int32
+}
+
+func (f *fallbackSearcher) SearchEvents(ctx context.Context, params types.SearchEventsParams) (*types.SearchEventResults, error) {
+	f.calls.Add(1)
+	return &types.SearchEventResults{Results: []types.SearchEventResult{{ID: "from-postgres"}}, Degraded: true}, nil
+}
+
+func (f *fallbackSearcher) Suggest(ctx context.Context, prefix string, limit int) (*types.EventSuggestions, error) {
+	f.calls.Add(1)
+	return &types.EventSuggestions{Degraded: true}, nil
+}
+
+func searchFrom(t *testing.T, f *Failover) string {
+	t.Helper()
+	results, err := f.SearchEvents(context.Background(), types.SearchEventsParams{Query: "jazz", Limit: 10})
+	if err != nil {
+		t.Fatalf("SearchEvents: %v", err)
+	}
+	if len(results.Results) != 1 {
+		t.Fatalf("got %d results, want 1", len(results.Results))
+	}
+	return results.Results[0].ID
+}
+
+func TestFailoverFallsBackUntilHealthCheckPasses(t *testing.T) {
+	service, client := newFakeSearchService(t)
+	fallback := &fallbackSearcher{}
+	f := NewFailover(client, fallback)
+
+	if got := searchFrom(t, f); got != "from-search-service" {
+		t.Fatalf("healthy search came from %s", got)
+	}
+
+	// A failed search falls back at once and keeps later searches away from
+	// the search service
+	service.searchStatus.Store(http.StatusServiceUnavailable)
+	if got := searchFrom(t, f); got != "from-postgres" {
+		t.Fatalf("failed search came from %s, want the fallback", got)
+	}
+	service.searchStatus.Store(http.StatusOK)
+	before := service.searches.Load()
+	if got := searchFrom(t, f); got != "from-postgres" {
+		t.Fatalf("search after a failure came from %s, want the fallback", got)
+	}
+	if service.searches.Load() != before {
+		t.Error("the search service was called while unhealthy")
+	}
+
+	// The next passing health check switches back
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go f.Run(ctx)
+	waitForHealth(t, f, true)
+	if got := searchFrom(t, f); got != "from-search-service" {
+		t.Fatalf("search after recovery came from %s", got)
+	}
+}
+
+func TestFailoverFailedHealthCheckFallsBack(t *testing.T) {
+	service, client := newFakeSearchService(t)
+	service.healthStatus.Store(http.StatusServiceUnavailable)
+	fallback := &fallbackSearcher{}
+	f := NewFailover(client, fallback)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go f.Run(ctx)
+	waitForHealth(t, f, false)
+
+	if got := searchFrom(t, f); got != "from-postgres" {
+		t.Fatalf("search came from %s, want the fallback", got)
+	}
+	suggestions, err := f.Suggest(context.Background(), "ja", 5)
+	if err != nil {
+		t.Fatalf("Suggest: %v", err)
+	}
+	if !suggestions.Degraded {
+		t.Error("suggestions did not come from the fallback")
+	}
+	if service.searches.Load() != 0 {
+		t.Errorf("the search service got %d searches while unhealthy", service.searches.Load())
+	}
+}
+
+func TestFailoverDoesNotFallBackForInvalidSearches(t *testing.T) {
+	service, client := newFakeSearchService(t)
+	service.searchStatus.Store(http.StatusBadRequest)
+	fallback := &fallbackSearcher{}
+	f := NewFailover(client, fallback)
+
+	_, err := f.SearchEvents(context.Background(), types.SearchEventsParams{Cursor: "bogus", Limit: 10})
+	if !errors.Is(err, ErrInvalidSearch) {
+		t.Fatalf("SearchEvents = %v, want ErrInvalidSearch", err)
+	}
+	if fallback.calls.Load() != 0 {
+		t.Error("an invalid search was retried on the fallback")
+	}
+	if !f.healthy.Load() {
+		t.Error("an invalid search marked the search service unhealthy")
+	}
+}
+
+func waitForHealth(t *testing.T, f *Failover, healthy bool) {
+	t.Helper()
+	deadline := time.Now().Add(2 * time.Second)
+	for f.healthy.Load() != healthy {
+		if time.Now().After(deadline) {
+			t.Fatalf("search service never became healthy=%v", healthy)
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+}
@@ -10,31 +10,28 @@ import (
 
 	"github.com/google/uuid"
 
+	"github.com/ignisrex/tix/auth"
 	"github.com/ignisrex/tix/core/internal/database"
-	"github.com/ignisrex/tix/core/internal/elasticsearch"
 	"github.com/ignisrex/tix/core/service/events"
 	"github.com/ignisrex/tix/core/service/tickets"
 	"github.com/ignisrex/tix/core/service/venues"
 	"github.com/ignisrex/tix/core/types"
 )
 
-type TicketAllocation struct {
-	VIP      int `json:"vip"`
-	GA       int `json:"ga"`
-	FrontRow int `json:"front_row"`
-}
-
 type Event struct {
-	Title           string          `json:"title"`
-	Description     string          `json:"description"`
-	StartDate       string          `json:"start_date"`
-	VenueName       string          `json:"venue_name"`
-	TicketAllocation TicketAllocation `json:"ticket_allocation"`
+	Title       string                          `json:"title"`
+	Description string                          `json:"description"`
+	StartDate   string                          `json:"start_date"`
+	VenueName   string                          `json:"venue_name"`
+	Category    string                          `json:"category"`
+	Performers  []string                        `json:"performers"`
+	TicketTypes []types.CreateTicketTypeRequest `json:"ticket_types"`
 }
 
 type Venue struct {
-	Name     string `json:"name"`
-	Location string `json:"location"`
+	Name     string         `json:"name"`
+	Location string         `json:"location"`
+	SeatMap  *types.SeatMap `json:"seat_map"`
 }
 
 type Data struct {
@@ -43,17 +40,18 @@ type Data struct {
 }
 
 // Run seeds venues and events+tickets using the provided DB connection and JSON file path.
-func Run(ctx context.Context, db *sql.DB, esClient *elasticsearch.Client, path string) error {
+func Run(ctx context.Context, db *sql.DB, path string) error {
 	queries := database.New(db)
+	ctx = auth.WithPrincipal(ctx, auth.SystemPrincipal())
 
-	venueRepo := venues.NewRepo(queries)
+	venueRepo := venues.NewRepo(queries, db)
 	venueSvc := venues.NewService(venueRepo)
 
-	ticketRepo := tickets.NewRepo(queries)
+	ticketRepo := tickets.NewRepo(queries, db)
 	ticketSvc := tickets.NewService(ticketRepo)
 
 	eventRepo := events.NewRepo(queries, db)
-	eventSvc := events.NewService(eventRepo, ticketSvc, venueSvc, esClient, nil)
+	eventSvc := events.NewService(eventRepo, ticketSvc, venueSvc, nil, nil)
 
 	f, err := os.Open(path)
 	if err != nil {
@@ -71,6 +69,7 @@ func Run(ctx context.Context, db *sql.DB, esClient *elasticsearch.Client, path s
 		req := types.CreateVenueRequest{
 			Name:     v.Name,
 			Location: v.Location,
+			SeatMap:  v.SeatMap,
 		}
 		_, err := venueSvc.CreateVenue(ctx, req)
 		if err != nil {
@@ -108,11 +107,9 @@ func Run(ctx context.Context, db *sql.DB, esClient *elasticsearch.Client, path s
 			Description: e.Description,
 			StartDate:   start,
 			VenueID:     venueID,
-			TicketAllocation: types.TicketAllocation{
-				VIP:      e.TicketAllocation.VIP,
-				GA:       e.TicketAllocation.GA,
-				FrontRow: e.TicketAllocation.FrontRow,
-			},
+			Category:    e.Category,
+			Performers:  e.Performers,
+			TicketTypes: e.TicketTypes,
 		}
 
 		ev, err := eventSvc.CreateEvent(ctx, createReq)
@@ -1,19 +1,29 @@
 package mappers
 
 import (
+	"github.com/google/uuid"
+
 	"github.com/ignisrex/tix/core/internal/database"
 	"github.com/ignisrex/tix/core/types"
 )
 
 func ToEvent(dbEvent database.Event) types.Event {
-	return types.Event{
+	event := types.Event{
 		ID: dbEvent.ID,
 		Title: dbEvent.Title,
 		Description: dbEvent.Description,
 		StartDate: dbEvent.StartDate,
 		VenueID: dbEvent.VenueID,
+		Performers: dbEvent.Performers,
 		CreatedAt: dbEvent.CreatedAt,
 	}
+	if dbEvent.OrganizerID.Valid {
+		event.OrganizerID = &dbEvent.OrganizerID.UUID
+	}
+	if dbEvent.Category.Valid {
+		event.Category = dbEvent.Category.String
+	}
+	return event
 }
 
 func ToEvents(dbEvents []database.Event) []types.Event {
@@ -60,7 +70,7 @@ func ToTickets(dbTickets []database.Ticket) []types.Ticket {
 
 // ToEnrichedTicket maps an enriched database.EnrichedTicket to types.Ticket
 func ToEnrichedTicket(dbTicket database.EnrichedTicket) types.Ticket {
-	return types.Ticket{
+	ticket := types.Ticket{
 		ID:                    dbTicket.ID,
 		EventID:               dbTicket.EventID,
 		TicketTypeID:          dbTicket.TicketTypeID,
@@ -69,6 +79,16 @@ func ToEnrichedTicket(dbTicket database.EnrichedTicket) types.Ticket {
 		TicketTypeDisplayName: dbTicket.TicketTypeDisplayName,
 		TicketTypePriceCents:  dbTicket.TicketTypePriceCents,
 	}
+	if dbTicket.SeatID.Valid {
+		ticket.Seat = &types.TicketSeat{
+			ID:         dbTicket.SeatID.UUID,
+			Section:    dbTicket.SectionName.String,
+			Row:        dbTicket.RowLabel.String,
+			Number:     dbTicket.SeatNumber.Int32,
+			Accessible: dbTicket.SeatAccessible.Bool,
+		}
+	}
+	return ticket
 }
 
 func ToEnrichedTickets(dbTickets []database.EnrichedTicket) []types.Ticket {
@@ -77,4 +97,131 @@ func ToEnrichedTickets(dbTickets []database.EnrichedTicket) []types.Ticket {
 		tickets[i] = ToEnrichedTicket(dbTicket)
 	}
 	return tickets
-}
\ No newline at end of file
+}
+// ToSeatMap groups a venue's seats, ordered by section, row and seat number,
+// into sections and rows
+func ToSeatMap(venueID uuid.UUID, dbSeats []database.ListVenueSeatsRow) types.SeatMap {
+	seatMap := types.SeatMap{
+		VenueID:  venueID,
+		Sections: []types.SeatMapSection{},
+	}
+	for _, dbSeat := range dbSeats {
+		if n := len(seatMap.Sections); n == 0 || seatMap.Sections[n-1].ID != dbSeat.SectionID {
+			seatMap.Sections = append(seatMap.Sections, types.SeatMapSection{
+				ID:   dbSeat.SectionID,
+				Name: dbSeat.SectionName,
+			})
+		}
+		section := &seatMap.Sections[len(seatMap.Sections)-1]
+
+		if n := len(section.Rows); n == 0 || section.Rows[n-1].Label != dbSeat.RowLabel {
+			section.Rows = append(section.Rows, types.SeatMapRow{Label: dbSeat.RowLabel})
+		}
+		row := &section.Rows[len(section.Rows)-1]
+
+		row.Seats = append(row.Seats, types.Seat{
+			ID:         dbSeat.ID,
+			Number:     dbSeat.SeatNumber,
+			X:          dbSeat.X,
+			Y:          dbSeat.Y,
+			Accessible: dbSeat.Accessible,
+			Companion:  dbSeat.Companion,
+		})
+	}
+	return seatMap
+}
+
+func ToTicketType(dbTicketType database.TicketType) types.TicketType {
+	ticketType := types.TicketType{
+		ID:          dbTicketType.ID,
+		EventID:     dbTicketType.EventID,
+		Name:        dbTicketType.Name,
+		DisplayName: dbTicketType.DisplayName,
+		PriceCents:  dbTicketType.PriceCents,
+		Quantity:    dbTicketType.Quantity,
+	}
+	if dbTicketType.MaxPerOrder.Valid {
+		ticketType.MaxPerOrder = &dbTicketType.MaxPerOrder.Int32
+	}
+	return ticketType
+}
+
+func ToTicketTypes(dbTicketTypes []database.TicketType) []types.TicketType {
+	ticketTypes := make([]types.TicketType, len(dbTicketTypes))
+	for i, dbTicketType := range dbTicketTypes {
+		ticketTypes[i] = ToTicketType(dbTicketType)
+	}
+	return ticketTypes
+}
+
+// ToEventAvailabilities groups ticket type counts by event, returning one
+// summary per event in eventIDs, in the same order
+func ToEventAvailabilities(eventIDs []uuid.UUID, rows []database.GetTicketAvailabilityRow) []types.EventAvailability {
+	byEvent := make(map[uuid.UUID]*types.EventAvailability, len(eventIDs))
+	availabilities := make([]types.EventAvailability, len(eventIDs))
+	for i, eventID := range eventIDs {
+		availabilities[i] = types.EventAvailability{
+			EventID:     eventID,
+			TicketTypes: []types.TicketTypeAvailability{},
+		}
+		byEvent[eventID] = &availabilities[i]
+	}
+
+	for _, row := range rows {
+		availability, ok := byEvent[row.EventID]
+		if !ok {
+			continue
+		}
+		availability.Available += row.Available
+		availability.Held += row.Held
+		availability.Sold += row.Sold
+		availability.TicketTypes = append(availability.TicketTypes, types.TicketTypeAvailability{
+			TicketTypeID: row.TicketTypeID,
+			Name:         row.TicketTypeName,
+			DisplayName:  row.TicketTypeDisplayName,
+			PriceCents:   row.TicketTypePriceCents,
+			Available:    row.Available,
+			Held:         row.Held,
+			Sold:         row.Sold,
+		})
+
+		if row.Available == 0 {
+			continue
+		}
+		price := row.TicketTypePriceCents
+		if availability.MinPriceCents == nil || price < *availability.MinPriceCents {
+			availability.MinPriceCents = &price
+		}
+		if availability.MaxPriceCents == nil || price > *availability.MaxPriceCents {
+			availability.MaxPriceCents = &price
+		}
+	}
+	return availabilities
+}
+
+func ToCustomer(dbCustomer database.Customer) types.Customer {
+	return types.Customer{
+		ID:        dbCustomer.ID,
+		Email:     dbCustomer.Email,
+		Name:      dbCustomer.Name,
+		CreatedAt: dbCustomer.CreatedAt,
+	}
+}
+
+func ToCustomerPurchase(dbPurchase database.ListCustomerPurchasesRow) types.CustomerPurchase {
+	return types.CustomerPurchase{
+		ID:          dbPurchase.ID,
+		TotalCents:  dbPurchase.TotalCents,
+		Status:      string(dbPurchase.Status),
+		TicketCount: int(dbPurchase.TicketCount),
+		CreatedAt:   dbPurchase.CreatedAt,
+	}
+}
+
+func ToCustomerPurchases(dbPurchases []database.ListCustomerPurchasesRow) []types.CustomerPurchase {
+	purchases := make([]types.CustomerPurchase, len(dbPurchases))
+	for i, dbPurchase := range dbPurchases {
+		purchases[i] = ToCustomerPurchase(dbPurchase)
+	}
+	return purchases
+}
@@ -1,12 +1,16 @@
 package booking
 
 import (
+	"context"
+	"errors"
 	"fmt"
+	"net"
 	"net/http"
 
 	"github.com/go-chi/chi/v5"
 	"github.com/google/uuid"
 
+	"github.com/ignisrex/tix/auth"
 	bookingclient "github.com/ignisrex/tix/core/internal/booking"
 	"github.com/ignisrex/tix/core/internal/utils"
 )
@@ -25,14 +29,26 @@ func NewHandler(bookingClient *bookingclient.Client) *Handler {
 func (h *Handler) RegisterRoutes(r chi.Router) {
 	r.Route("/booking", func(r chi.Router) {
 		r.Post("/reserve", h.ReserveTickets)
+		r.Post("/reserve/best-available", h.ReserveBestAvailable)
 		r.Post("/purchase", h.PurchaseTickets)
+		r.With(auth.RequirePermission(auth.PermCompTickets)).Post("/comp", h.CompTickets)
 		r.Get("/purchases/{id}", h.GetPurchaseDetails)
+		r.With(auth.RequirePermission(auth.PermRefundPurchases)).Post("/purchases/{id}/refund", h.RefundPurchase)
+		r.Get("/holds/{id}", h.GetHold)
+		r.Post("/holds/{id}/extend", h.ExtendHold)
+		r.Delete("/holds/{id}", h.ReleaseHold)
+		r.Get("/queue/{event_id}", h.GetQueue)
+		r.With(auth.RequirePermission(auth.PermManageAllEvents)).Put("/queue/{event_id}", h.ConfigureQueue)
+		r.With(auth.RequirePermission(auth.PermManageAllEvents)).Delete("/queue/{event_id}", h.RemoveQueue)
+		r.Post("/queue/{event_id}/join", h.JoinQueue)
+		r.Get("/queue/{event_id}/status", h.GetQueueStatus)
 	})
 }
 
 func (h *Handler) ReserveTickets(w http.ResponseWriter, r *http.Request) {
 	var req struct {
 		TicketIDs []uuid.UUID `json:"ticket_ids"`
+		HoldID    uuid.UUID   `json:"hold_id"`
 	}
 	
 	if err := utils.ParseJSON(r, &req); err != nil {
@@ -45,7 +61,37 @@ func (h *Handler) ReserveTickets(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	response, statusCode, err := h.service.ReserveTickets(r.Context(), req.TicketIDs)
+	response, statusCode, err := h.service.ReserveTickets(admissionContext(r), req.TicketIDs, req.HoldID, idempotencyKey(r))
+	if err != nil {
+		if response != nil && !response.Success {
+			utils.WriteJSON(w, statusCode, response)
+			return
+		}
+		utils.WriteError(w, statusCode, fmt.Errorf("failed to reserve tickets: %w", err))
+		return
+	}
+
+	utils.WriteJSON(w, statusCode, response)
+}
+
+func (h *Handler) ReserveBestAvailable(w http.ResponseWriter, r *http.Request) {
+	var req bookingclient.BestAvailableRequest
+	if err := utils.ParseJSON(r, &req); err != nil {
+		utils.WriteError(w, http.StatusBadRequest, fmt.Errorf("invalid request body: %w", err))
+		return
+	}
+
+	if req.EventID == uuid.Nil || req.TicketTypeID == uuid.Nil {
+		utils.WriteError(w, http.StatusBadRequest, fmt.Errorf("event_id and ticket_type_id are required"))
+		return
+	}
+
+	if req.Quantity <= 0 {
+		utils.WriteError(w, http.StatusBadRequest, fmt.Errorf("quantity must be positive"))
+		return
+	}
+
+	response, statusCode, err := h.service.ReserveBestAvailable(admissionContext(r), req, idempotencyKey(r))
 	if err != nil {
 		if response != nil && !response.Success {
 			utils.WriteJSON(w, statusCode, response)
@@ -60,7 +106,10 @@ func (h *Handler) ReserveTickets(w http.ResponseWriter, r *http.Request) {
 
 func (h *Handler) PurchaseTickets(w http.ResponseWriter, r *http.Request) {
 	var req struct {
-		TicketIDs []uuid.UUID `json:"ticket_ids"`
+		TicketIDs    []uuid.UUID `json:"ticket_ids"`
+		HoldID       uuid.UUID   `json:"hold_id"`
+		PaymentToken string      `json:"payment_token"`
+		CustomerID   uuid.UUID   `json:"customer_id"`
 	}
 	
 	if err := utils.ParseJSON(r, &req); err != nil {
@@ -73,12 +122,26 @@ func (h *Handler) PurchaseTickets(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	response, statusCode, err := h.service.PurchaseTickets(r.Context(), req.TicketIDs)
+	if req.HoldID == uuid.Nil {
+		utils.WriteError(w, http.StatusBadRequest, fmt.Errorf("hold_id is required"))
+		return
+	}
+
+	if req.PaymentToken == "" {
+		utils.WriteError(w, http.StatusBadRequest, fmt.Errorf("payment_token is required"))
+		return
+	}
+
+	response, statusCode, err := h.service.PurchaseTickets(r.Context(), req.TicketIDs, req.HoldID, req.PaymentToken, req.CustomerID, idempotencyKey(r))
 	if err != nil {
 		if response != nil && !response.Success {
 			utils.WriteJSON(w, statusCode, response)
 			return
 		}
+		if errors.Is(err, bookingclient.ErrInProgress) {
+			// The client should resend the purchase with the same key
+			w.Header().Set("Retry-After", "1")
+		}
 		utils.WriteError(w, statusCode, fmt.Errorf("failed to purchase tickets: %w", err))
 		return
 	}
@@ -86,6 +149,36 @@ func (h *Handler) PurchaseTickets(w http.ResponseWriter, r *http.Request) {
 	_ = utils.WriteJSON(w, statusCode, response)
 }
 
+func (h *Handler) CompTickets(w http.ResponseWriter, r *http.Request) {
+	var req bookingclient.CompRequest
+	if err := utils.ParseJSON(r, &req); err != nil {
+		utils.WriteError(w, http.StatusBadRequest, fmt.Errorf("invalid request body: %w", err))
+		return
+	}
+
+	if len(req.TicketIDs) == 0 {
+		utils.WriteError(w, http.StatusBadRequest, fmt.Errorf("ticket_ids cannot be empty"))
+		return
+	}
+
+	if req.HoldID == uuid.Nil {
+		utils.WriteError(w, http.StatusBadRequest, fmt.Errorf("hold_id is required"))
+		return
+	}
+
+	response, statusCode, err := h.service.CompTickets(r.Context(), req, idempotencyKey(r))
+	if err != nil {
+		if response != nil && !response.Success {
+			utils.WriteJSON(w, statusCode, response)
+			return
+		}
+		utils.WriteError(w, statusCode, fmt.Errorf("failed to comp tickets: %w", err))
+		return
+	}
+
+	_ = utils.WriteJSON(w, statusCode, response)
+}
+
 func (h *Handler) GetPurchaseDetails(w http.ResponseWriter, r *http.Request) {
 	idStr := chi.URLParam(r, "id")
 	id, err := uuid.Parse(idStr)
@@ -103,3 +196,183 @@ func (h *Handler) GetPurchaseDetails(w http.ResponseWriter, r *http.Request) {
 	_ = utils.WriteJSON(w, statusCode, response)
 }
 
+func (h *Handler) RefundPurchase(w http.ResponseWriter, r *http.Request) {
+	id, err := uuid.Parse(chi.URLParam(r, "id"))
+	if err != nil {
+		utils.WriteError(w, http.StatusBadRequest, fmt.Errorf("invalid purchase id: %w", err))
+		return
+	}
+
+	var req bookingclient.RefundRequest
+	if err := utils.ParseJSON(r, &req); err != nil {
+		utils.WriteError(w, http.StatusBadRequest, fmt.Errorf("invalid request body: %w", err))
+		return
+	}
+
+	response, statusCode, err := h.service.RefundPurchase(r.Context(), id, req, idempotencyKey(r))
+	if err != nil {
+		utils.WriteError(w, statusCode, fmt.Errorf("failed to refund purchase: %w", err))
+		return
+	}
+
+	_ = utils.WriteJSON(w, statusCode, response)
+}
+
+func (h *Handler) GetHold(w http.ResponseWriter, r *http.Request) {
+	holdID, err := uuid.Parse(chi.URLParam(r, "id"))
+	if err != nil {
+		utils.WriteError(w, http.StatusBadRequest, fmt.Errorf("invalid hold id: %w", err))
+		return
+	}
+
+	response, statusCode, err := h.service.GetHold(r.Context(), holdID)
+	if err != nil {
+		utils.WriteError(w, statusCode, fmt.Errorf("failed to get hold: %w", err))
+		return
+	}
+
+	_ = utils.WriteJSON(w, statusCode, response)
+}
+
+func (h *Handler) ExtendHold(w http.ResponseWriter, r *http.Request) {
+	holdID, err := uuid.Parse(chi.URLParam(r, "id"))
+	if err != nil {
+		utils.WriteError(w, http.StatusBadRequest, fmt.Errorf("invalid hold id: %w", err))
+		return
+	}
+
+	response, statusCode, err := h.service.ExtendHold(r.Context(), holdID)
+	if err != nil {
+		utils.WriteError(w, statusCode, fmt.Errorf("failed to extend hold: %w", err))
+		return
+	}
+
+	_ = utils.WriteJSON(w, statusCode, response)
+}
+
+func (h *Handler) ReleaseHold(w http.ResponseWriter, r *http.Request) {
+	holdID, err := uuid.Parse(chi.URLParam(r, "id"))
+	if err != nil {
+		utils.WriteError(w, http.StatusBadRequest, fmt.Errorf("invalid hold id: %w", err))
+		return
+	}
+
+	statusCode, err := h.service.ReleaseHold(r.Context(), holdID)
+	if err != nil {
+		utils.WriteError(w, statusCode, fmt.Errorf("failed to release hold: %w", err))
+		return
+	}
+
+	w.WriteHeader(statusCode)
+}
+
+func (h *Handler) GetQueue(w http.ResponseWriter, r *http.Request) {
+	eventID, err := uuid.Parse(chi.URLParam(r, "event_id"))
+	if err != nil {
+		utils.WriteError(w, http.StatusBadRequest, fmt.Errorf("invalid event id: %w", err))
+		return
+	}
+
+	response, statusCode, err := h.service.GetQueue(r.Context(), eventID)
+	if err != nil {
+		utils.WriteError(w, statusCode, fmt.Errorf("failed to get queue: %w", err))
+		return
+	}
+
+	_ = utils.WriteJSON(w, statusCode, response)
+}
+
+func (h *Handler) ConfigureQueue(w http.ResponseWriter, r *http.Request) {
+	eventID, err := uuid.Parse(chi.URLParam(r, "event_id"))
+	if err != nil {
+		utils.WriteError(w, http.StatusBadRequest, fmt.Errorf("invalid event id: %w", err))
+		return
+	}
+
+	var req bookingclient.QueueConfigRequest
+	if err := utils.ParseJSON(r, &req); err != nil {
+		utils.WriteError(w, http.StatusBadRequest, fmt.Errorf("invalid request body: %w", err))
+		return
+	}
+
+	response, statusCode, err := h.service.ConfigureQueue(r.Context(), eventID, req)
+	if err != nil {
+		utils.WriteError(w, statusCode, fmt.Errorf("failed to configure queue: %w", err))
+		return
+	}
+
+	_ = utils.WriteJSON(w, statusCode, response)
+}
+
+func (h *Handler) RemoveQueue(w http.ResponseWriter, r *http.Request) {
+	eventID, err := uuid.Parse(chi.URLParam(r, "event_id"))
+	if err != nil {
+		utils.WriteError(w, http.StatusBadRequest, fmt.Errorf("invalid event id: %w", err))
+		return
+	}
+
+	statusCode, err := h.service.RemoveQueue(r.Context(), eventID)
+	if err != nil {
+		utils.WriteError(w, statusCode, fmt.Errorf("failed to remove queue: %w", err))
+		return
+	}
+
+	w.WriteHeader(statusCode)
+}
+
+// JoinQueue joins an event's waiting room. The client's queue token, if it
+// sends one, and its address are passed on so booking can keep the
+// visitor's place and limit new anonymous visitors per client.
+func (h *Handler) JoinQueue(w http.ResponseWriter, r *http.Request) {
+	eventID, err := uuid.Parse(chi.URLParam(r, "event_id"))
+	if err != nil {
+		utils.WriteError(w, http.StatusBadRequest, fmt.Errorf("invalid event id: %w", err))
+		return
+	}
+
+	clientIP, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		clientIP = r.RemoteAddr
+	}
+
+	response, statusCode, err := h.service.JoinQueue(r.Context(), eventID, r.Header.Get(bookingclient.QueueTokenHeader), clientIP)
+	if err != nil {
+		utils.WriteError(w, statusCode, fmt.Errorf("failed to join queue: %w", err))
+		return
+	}
+
+	_ = utils.WriteJSON(w, statusCode, response)
+}
+
+func (h *Handler) GetQueueStatus(w http.ResponseWriter, r *http.Request) {
+	eventID, err := uuid.Parse(chi.URLParam(r, "event_id"))
+	if err != nil {
+		utils.WriteError(w, http.StatusBadRequest, fmt.Errorf("invalid event id: %w", err))
+		return
+	}
+
+	response, statusCode, err := h.service.GetQueueStatus(r.Context(), eventID, r.Header.Get(bookingclient.QueueTokenHeader))
+	if err != nil {
+		utils.WriteError(w, statusCode, fmt.Errorf("failed to get queue status: %w", err))
+		return
+	}
+
+	_ = utils.WriteJSON(w, statusCode, response)
+}
+
+// admissionContext returns the request context carrying the client's
+// waiting room admission token, which reserve calls forward to booking
+func admissionContext(r *http.Request) context.Context {
+	return bookingclient.WithAdmissionToken(r.Context(), r.Header.Get(bookingclient.AdmissionTokenHeader))
+}
+
+// idempotencyKey returns the client's Idempotency-Key, or a fresh one so that
+// retries to the booking service are still deduplicated. Only a key sent by
+// the client protects against the client itself resending, so the UI sends
+// one per checkout attempt.
+func idempotencyKey(r *http.Request) string {
+	if key := r.Header.Get(bookingclient.IdempotencyKeyHeader); key != "" {
+		return key
+	}
+	return uuid.New().String()
+}
@@ -0,0 +1,47 @@
+package booking
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/google/uuid"
+
+	bookingclient "github.com/ignisrex/tix/core/internal/booking"
+)
+
+func TestJoinQueueForwardsQueueTokenAndClientIP(t *testing.T) {
+	eventID := uuid.New()
+	var gotToken, gotIP string
+	booking := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/api/v1/booking/queue/"+eventID.String()+"/join" {
+			http.NotFound(w, r)
+			return
+		}
+		gotToken = r.Header.Get(bookingclient.QueueTokenHeader)
+		gotIP = r.Header.Get(bookingclient.ClientIPHeader)
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(`{"event_id":"` + eventID.String() + `","status":"waiting","queue_token":"queue-token","position":3}`))
+	}))
+	defer booking.Close()
+
+	router := chi.NewRouter()
+	NewHandler(bookingclient.NewClient(booking.URL)).RegisterRoutes(router)
+
+	req := httptest.NewRequest(http.MethodPost, "/booking/queue/"+eventID.String()+"/join", nil)
+	req.RemoteAddr = "203.0.113.7:51234"
+	req.Header.Set(bookingclient.QueueTokenHeader, "queue-token")
+	rec := httptest.NewRecorder()
+	router.ServeHTTP(rec, req)
+
+	if rec.Code != http.StatusOK {
+		t.Fatalf("status = %d, want 200: %s", rec.Code, rec.Body.String())
+	}
+	if gotToken != "queue-token" {
+		t.Errorf("booking got queue token %q, want the client's", gotToken)
+	}
+	if gotIP != "203.0.113.7" {
+		t.Errorf("booking got client IP %q, want 203.0.113.7", gotIP)
+	}
+}
@@ -2,9 +2,11 @@ package booking
 
 import (
 	"context"
+	"net/http"
 
 	"github.com/google/uuid"
 
+	"github.com/ignisrex/tix/auth"
 	bookingclient "github.com/ignisrex/tix/core/internal/booking"
 )
 
@@ -18,15 +20,89 @@ func NewService(bookingClient *bookingclient.Client) *Service {
 	}
 }
 
-func (s *Service) ReserveTickets(ctx context.Context, ticketIDs []uuid.UUID) (*bookingclient.ReserveResponse, int, error) {
-	return s.bookingClient.ReserveTickets(ctx, ticketIDs)
+func (s *Service) ReserveTickets(ctx context.Context, ticketIDs []uuid.UUID, holdID uuid.UUID, idempotencyKey string) (*bookingclient.ReserveResponse, int, error) {
+	return s.bookingClient.ReserveTickets(ctx, ticketIDs, holdID, idempotencyKey)
 }
 
-func (s *Service) PurchaseTickets(ctx context.Context, ticketIDs []uuid.UUID) (*bookingclient.PurchaseResponse, int, error) {
-	return s.bookingClient.PurchaseTickets(ctx, ticketIDs)
+func (s *Service) ReserveBestAvailable(ctx context.Context, req bookingclient.BestAvailableRequest, idempotencyKey string) (*bookingclient.ReserveResponse, int, error) {
+	return s.bookingClient.ReserveBestAvailable(ctx, req, idempotencyKey)
 }
 
+// PurchaseTickets buys held tickets. Only the customer themselves or box
+// office staff can buy for a given customer.
+func (s *Service) PurchaseTickets(ctx context.Context, ticketIDs []uuid.UUID, holdID uuid.UUID, paymentToken string, customerID uuid.UUID, idempotencyKey string) (*bookingclient.PurchaseResponse, int, error) {
+	if customerID != uuid.Nil {
+		if _, err := auth.AuthorizeOwner(ctx, customerID, auth.PermSellTickets); err != nil {
+			return nil, auth.ErrorStatus(err, http.StatusForbidden), err
+		}
+	}
+	return s.bookingClient.PurchaseTickets(ctx, ticketIDs, holdID, paymentToken, customerID, idempotencyKey)
+}
+
+func (s *Service) CompTickets(ctx context.Context, req bookingclient.CompRequest, idempotencyKey string) (*bookingclient.PurchaseResponse, int, error) {
+	if _, err := auth.Authorize(ctx, auth.PermCompTickets); err != nil {
+		return nil, auth.ErrorStatus(err, http.StatusForbidden), err
+	}
+	return s.bookingClient.CompTickets(ctx, req, idempotencyKey)
+}
+
+// GetPurchaseDetails returns a purchase. Purchases linked to a customer are
+// only visible to that customer and to staff; anonymous purchases remain
+// visible to anyone holding their ID.
 func (s *Service) GetPurchaseDetails(ctx context.Context, purchaseID uuid.UUID) (*bookingclient.PurchaseDetailsResponse, int, error) {
-	return s.bookingClient.GetPurchaseDetails(ctx, purchaseID)
+	details, statusCode, err := s.bookingClient.GetPurchaseDetails(ctx, purchaseID)
+	if err != nil || statusCode != http.StatusOK || details.CustomerID == nil {
+		return details, statusCode, err
+	}
+
+	if _, err := auth.AuthorizeOwner(ctx, *details.CustomerID, auth.PermViewAllPurchases); err != nil {
+		return nil, auth.ErrorStatus(err, http.StatusForbidden), err
+	}
+	return details, statusCode, nil
+}
+
+func (s *Service) RefundPurchase(ctx context.Context, purchaseID uuid.UUID, req bookingclient.RefundRequest, idempotencyKey string) (*bookingclient.Refund, int, error) {
+	if _, err := auth.Authorize(ctx, auth.PermRefundPurchases); err != nil {
+		return nil, auth.ErrorStatus(err, http.StatusForbidden), err
+	}
+	return s.bookingClient.RefundPurchase(ctx, purchaseID, req, idempotencyKey)
 }
 
+func (s *Service) GetHold(ctx context.Context, holdID uuid.UUID) (*bookingclient.HoldResponse, int, error) {
+	return s.bookingClient.GetHold(ctx, holdID)
+}
+
+func (s *Service) ExtendHold(ctx context.Context, holdID uuid.UUID) (*bookingclient.HoldResponse, int, error) {
+	return s.bookingClient.ExtendHold(ctx, holdID)
+}
+
+func (s *Service) ReleaseHold(ctx context.Context, holdID uuid.UUID) (int, error) {
+	return s.bookingClient.ReleaseHold(ctx, holdID)
+}
+
+func (s *Service) GetQueue(ctx context.Context, eventID uuid.UUID) (*bookingclient.QueueResponse, int, error) {
+	return s.bookingClient.GetQueue(ctx, eventID)
+}
+
+// ConfigureQueue opens or updates an event's waiting room
+func (s *Service) ConfigureQueue(ctx context.Context, eventID uuid.UUID, req bookingclient.QueueConfigRequest) (*bookingclient.QueueResponse, int, error) {
+	if _, err := auth.Authorize(ctx, auth.PermManageAllEvents); err != nil {
+		return nil, auth.ErrorStatus(err, http.StatusForbidden), err
+	}
+	return s.bookingClient.ConfigureQueue(ctx, eventID, req)
+}
+
+func (s *Service) RemoveQueue(ctx context.Context, eventID uuid.UUID) (int, error) {
+	if _, err := auth.Authorize(ctx, auth.PermManageAllEvents); err != nil {
+		return auth.ErrorStatus(err, http.StatusForbidden), err
+	}
+	return s.bookingClient.RemoveQueue(ctx, eventID)
+}
+
+func (s *Service) JoinQueue(ctx context.Context, eventID uuid.UUID, queueToken string, clientIP string) (*bookingclient.QueueStatusResponse, int, error) {
+	return s.bookingClient.JoinQueue(ctx, eventID, queueToken, clientIP)
+}
+
+func (s *Service) GetQueueStatus(ctx context.Context, eventID uuid.UUID, queueToken string) (*bookingclient.QueueStatusResponse, int, error) {
+	return s.bookingClient.GetQueueStatus(ctx, eventID, queueToken)
+}
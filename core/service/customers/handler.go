@@ -0,0 +1,125 @@
+package customers
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/google/uuid"
+
+	"github.com/ignisrex/tix/auth"
+	"github.com/ignisrex/tix/core/internal/database"
+	"github.com/ignisrex/tix/core/internal/utils"
+	"github.com/ignisrex/tix/core/types"
+)
+
+type Handler struct {
+	service *Service
+}
+
+func NewHandler(queries *database.Queries) *Handler {
+	repo := NewRepo(queries)
+	service := NewService(repo)
+	return &Handler{
+		service: service,
+	}
+}
+
+func (h *Handler) RegisterRoutes(r chi.Router) {
+	r.Route("/customers", func(r chi.Router) {
+		r.With(auth.RequirePermission(auth.PermManageCustomers)).Get("/", h.GetCustomerByEmail)
+		r.With(auth.RequireAuth).Post("/", h.CreateCustomer)
+		r.With(auth.RequireAuth).Get("/{id}", h.GetCustomer)
+		r.With(auth.RequireAuth).Get("/{id}/purchases", h.GetCustomerPurchases)
+	})
+}
+
+func (h *Handler) CreateCustomer(w http.ResponseWriter, r *http.Request) {
+	var req types.CreateCustomerRequest
+	if err := utils.ParseJSON(r, &req); err != nil {
+		utils.WriteError(w, http.StatusBadRequest, fmt.Errorf("failed to parse create customer request body: %w", err))
+		return
+	}
+
+	customer, err := h.service.CreateCustomer(r.Context(), req)
+	if err != nil {
+		utils.WriteError(w, customerErrorStatus(err), fmt.Errorf("failed to create customer: %w", err))
+		return
+	}
+	utils.WriteJSON(w, http.StatusCreated, customer)
+}
+
+// GetCustomerByEmail looks a customer up by the email query parameter, so
+// support can find a buyer from the address they contacted us with
+func (h *Handler) GetCustomerByEmail(w http.ResponseWriter, r *http.Request) {
+	email := r.URL.Query().Get("email")
+	if email == "" {
+		utils.WriteError(w, http.StatusBadRequest, fmt.Errorf("email query parameter is required"))
+		return
+	}
+
+	customer, err := h.service.GetCustomerByEmail(r.Context(), email)
+	if err != nil {
+		utils.WriteError(w, customerErrorStatus(err), fmt.Errorf("failed to get customer: %w", err))
+		return
+	}
+	utils.WriteJSON(w, http.StatusOK, customer)
+}
+
+func (h *Handler) GetCustomer(w http.ResponseWriter, r *http.Request) {
+	id, err := uuid.Parse(chi.URLParam(r, "id"))
+	if err != nil {
+		utils.WriteError(w, http.StatusBadRequest, fmt.Errorf("invalid customer id: %w", err))
+		return
+	}
+
+	customer, err := h.service.GetCustomer(r.Context(), id)
+	if err != nil {
+		utils.WriteError(w, customerErrorStatus(err), fmt.Errorf("failed to get customer: %w", err))
+		return
+	}
+	utils.WriteJSON(w, http.StatusOK, customer)
+}
+
+func (h *Handler) GetCustomerPurchases(w http.ResponseWriter, r *http.Request) {
+	id, err := uuid.Parse(chi.URLParam(r, "id"))
+	if err != nil {
+		utils.WriteError(w, http.StatusBadRequest, fmt.Errorf("invalid customer id: %w", err))
+		return
+	}
+
+	limit := 20
+	if limitStr := r.URL.Query().Get("limit"); limitStr != "" {
+		if l, err := strconv.Atoi(limitStr); err == nil && l > 0 && l <= 100 {
+			limit = l
+		}
+	}
+
+	offset := 0
+	if offsetStr := r.URL.Query().Get("offset"); offsetStr != "" {
+		if o, err := strconv.Atoi(offsetStr); err == nil && o >= 0 {
+			offset = o
+		}
+	}
+
+	purchases, err := h.service.GetCustomerPurchases(r.Context(), id, limit, offset)
+	if err != nil {
+		utils.WriteError(w, customerErrorStatus(err), fmt.Errorf("failed to get customer purchases: %w", err))
+		return
+	}
+	utils.WriteJSON(w, http.StatusOK, purchases)
+}
+
+func customerErrorStatus(err error) int {
+	switch {
+	case errors.Is(err, ErrCustomerNotFound):
+		return http.StatusNotFound
+	case errors.Is(err, ErrInvalidCustomer):
+		return http.StatusBadRequest
+	case errors.Is(err, ErrEmailTaken), errors.Is(err, ErrCustomerExists):
+		return http.StatusConflict
+	}
+	return auth.ErrorStatus(err, http.StatusInternalServerError)
+}
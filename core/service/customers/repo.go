@@ -0,0 +1,70 @@
+package customers
+
+import (
+	"context"
+
+	"github.com/google/uuid"
+
+	"github.com/ignisrex/tix/core/internal/database"
+	"github.com/ignisrex/tix/core/mappers"
+	"github.com/ignisrex/tix/core/types"
+)
+
+type Repo struct {
+	queries *database.Queries
+}
+
+func NewRepo(queries *database.Queries) *Repo {
+	return &Repo{
+		queries: queries,
+	}
+}
+
+func (r *Repo) CreateCustomer(ctx context.Context, id uuid.UUID, customer types.CreateCustomerRequest) (types.Customer, error) {
+	dbCustomer, err := r.queries.CreateCustomer(ctx, database.CreateCustomerParams{
+		ID:    id,
+		Email: customer.Email,
+		Name:  customer.Name,
+	})
+	if err != nil {
+		return types.Customer{}, err
+	}
+	return mappers.ToCustomer(dbCustomer), nil
+}
+
+func (r *Repo) GetCustomer(ctx context.Context, id uuid.UUID) (types.Customer, error) {
+	dbCustomer, err := r.queries.GetCustomer(ctx, id)
+	if err != nil {
+		return types.Customer{}, err
+	}
+	return mappers.ToCustomer(dbCustomer), nil
+}
+
+func (r *Repo) GetCustomerByEmail(ctx context.Context, email string) (types.Customer, error) {
+	dbCustomer, err := r.queries.GetCustomerByEmail(ctx, email)
+	if err != nil {
+		return types.Customer{}, err
+	}
+	return mappers.ToCustomer(dbCustomer), nil
+}
+
+// ListCustomerPurchases returns a page of the customer's purchases, newest
+// first, along with the total number of purchases they have made
+func (r *Repo) ListCustomerPurchases(ctx context.Context, customerID uuid.UUID, limit, offset int) ([]types.CustomerPurchase, int, error) {
+	nullCustomerID := uuid.NullUUID{UUID: customerID, Valid: true}
+
+	dbPurchases, err := r.queries.ListCustomerPurchases(ctx, database.ListCustomerPurchasesParams{
+		CustomerID: nullCustomerID,
+		Limit:      int32(limit),
+		Offset:     int32(offset),
+	})
+	if err != nil {
+		return nil, 0, err
+	}
+
+	total, err := r.queries.CountCustomerPurchases(ctx, nullCustomerID)
+	if err != nil {
+		return nil, 0, err
+	}
+	return mappers.ToCustomerPurchases(dbPurchases), int(total), nil
+}
@@ -0,0 +1,139 @@
+package customers
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"log"
+	"strings"
+
+	"github.com/google/uuid"
+	"github.com/lib/pq"
+
+	"github.com/ignisrex/tix/auth"
+	"github.com/ignisrex/tix/core/internal/utils"
+	"github.com/ignisrex/tix/core/types"
+)
+
+// uniqueViolation is the Postgres error code for a duplicate key
+const uniqueViolation = "23505"
+
+var (
+	ErrCustomerNotFound = errors.New("customer not found")
+	ErrInvalidCustomer  = errors.New("invalid customer")
+	ErrEmailTaken       = errors.New("email already registered")
+	ErrCustomerExists   = errors.New("customer already registered")
+)
+
+type Service struct {
+	repo *Repo
+}
+
+func NewService(repo *Repo) *Service {
+	return &Service{
+		repo: repo,
+	}
+}
+
+// CreateCustomer registers a customer. A caller with the customer role
+// registers themselves, keeping their token's subject as the customer ID so
+// their purchases can be attributed to it; staff who manage customers can
+// register anyone.
+// Emails are stored lower-cased so the same address cannot be registered
+// twice with different casing.
+func (s *Service) CreateCustomer(ctx context.Context, req types.CreateCustomerRequest) (types.Customer, error) {
+	principal, ok := auth.PrincipalFromContext(ctx)
+	if !ok {
+		return types.Customer{}, auth.ErrUnauthenticated
+	}
+
+	var id uuid.UUID
+	switch {
+	case principal.Can(auth.PermManageCustomers):
+		id = uuid.New()
+	case principal.HasRole(auth.RoleCustomer):
+		id = principal.Subject
+	default:
+		return types.Customer{}, fmt.Errorf("%w: only customers and staff can register customers", auth.ErrForbidden)
+	}
+
+	req.Email = strings.ToLower(strings.TrimSpace(req.Email))
+	req.Name = strings.TrimSpace(req.Name)
+
+	if err := utils.Validate.Var(req.Email, "required,email"); err != nil {
+		return types.Customer{}, fmt.Errorf("%w: a valid email is required", ErrInvalidCustomer)
+	}
+	if req.Name == "" {
+		return types.Customer{}, fmt.Errorf("%w: name is required", ErrInvalidCustomer)
+	}
+
+	customer, err := s.repo.CreateCustomer(ctx, id, req)
+	if err != nil {
+		var pqErr *pq.Error
+		if errors.As(err, &pqErr) && pqErr.Code == uniqueViolation {
+			if pqErr.Constraint == "customers_pkey" {
+				return types.Customer{}, fmt.Errorf("%w: %s", ErrCustomerExists, id)
+			}
+			return types.Customer{}, fmt.Errorf("%w: %s", ErrEmailTaken, req.Email)
+		}
+		log.Printf("CreateCustomer: failed to create customer: %v", err)
+		return types.Customer{}, err
+	}
+	return customer, nil
+}
+
+// GetCustomer returns a customer to themselves or to staff who manage
+// customers
+func (s *Service) GetCustomer(ctx context.Context, id uuid.UUID) (types.Customer, error) {
+	if _, err := auth.AuthorizeOwner(ctx, id, auth.PermManageCustomers); err != nil {
+		return types.Customer{}, err
+	}
+
+	customer, err := s.repo.GetCustomer(ctx, id)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return types.Customer{}, fmt.Errorf("%w: %s", ErrCustomerNotFound, id)
+		}
+		return types.Customer{}, err
+	}
+	return customer, nil
+}
+
+func (s *Service) GetCustomerByEmail(ctx context.Context, email string) (types.Customer, error) {
+	if _, err := auth.Authorize(ctx, auth.PermManageCustomers); err != nil {
+		return types.Customer{}, err
+	}
+
+	email = strings.ToLower(strings.TrimSpace(email))
+	customer, err := s.repo.GetCustomerByEmail(ctx, email)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return types.Customer{}, fmt.Errorf("%w: %s", ErrCustomerNotFound, email)
+		}
+		return types.Customer{}, err
+	}
+	return customer, nil
+}
+
+// GetCustomerPurchases returns a page of the customer's purchase history.
+// Customers can only list their own purchases. It returns
+// ErrCustomerNotFound for unknown customers rather than an empty page.
+func (s *Service) GetCustomerPurchases(ctx context.Context, customerID uuid.UUID, limit, offset int) (types.CustomerPurchases, error) {
+	if _, err := s.GetCustomer(ctx, customerID); err != nil {
+		return types.CustomerPurchases{}, err
+	}
+
+	purchases, total, err := s.repo.ListCustomerPurchases(ctx, customerID, limit, offset)
+	if err != nil {
+		log.Printf("GetCustomerPurchases: failed to list purchases for customer %s: %v", customerID, err)
+		return types.CustomerPurchases{}, err
+	}
+
+	return types.CustomerPurchases{
+		Purchases: purchases,
+		Total:     total,
+		Limit:     limit,
+		Offset:    offset,
+	}, nil
+}
@@ -4,55 +4,75 @@ import (
 	"context"
 	"database/sql"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"log"
 	"net/http"
+	"slices"
 	"strconv"
+	"strings"
 	"time"
 
 	"github.com/go-chi/chi/v5"
 	"github.com/google/uuid"
+	"github.com/redis/go-redis/v9"
 
+	"github.com/ignisrex/tix/auth"
+	"github.com/ignisrex/tix/core/internal/availability"
 	bookingclient "github.com/ignisrex/tix/core/internal/booking"
 	"github.com/ignisrex/tix/core/internal/database"
-	"github.com/ignisrex/tix/core/internal/elasticsearch"
 	"github.com/ignisrex/tix/core/internal/search"
 	"github.com/ignisrex/tix/core/internal/utils"
 	"github.com/ignisrex/tix/core/service/tickets"
 	"github.com/ignisrex/tix/core/service/venues"
 	"github.com/ignisrex/tix/core/types"
+	"github.com/ignisrex/tix/searchquery"
 )
 
 type Handler struct {
 	eventService   *Service
 	ticketService  *tickets.Service
 	bookingClient  *bookingclient.Client
+	availability   *availability.Broker
 }
 
-func NewHandler(queries *database.Queries, db *sql.DB, esClient *elasticsearch.Client, searchClient *search.Client, bookingClient *bookingclient.Client) *Handler {
-	ticketRepo := tickets.NewRepo(queries)
+func NewHandler(queries *database.Queries, db *sql.DB, searcher search.Searcher, bookingClient *bookingclient.Client, redisClient *redis.Client) *Handler {
+	ticketRepo := tickets.NewRepo(queries, db)
 	ticketService := tickets.NewService(ticketRepo)
 
-	venueRepo := venues.NewRepo(queries)
+	venueRepo := venues.NewRepo(queries, db)
 	venueService := venues.NewService(venueRepo)
 
 	eventRepo := NewRepo(queries, db)
-	eventService := NewService(eventRepo, ticketService, venueService, esClient, searchClient)
+	eventService := NewService(eventRepo, ticketService, venueService, searcher, availability.NewHoldIndex(redisClient))
 	
-	return &Handler{
+	h := &Handler{
 		eventService:  eventService,
 		ticketService: ticketService,
 		bookingClient: bookingClient,
 	}
+	h.availability = availability.NewBroker(redisClient, h.loadTicketsWithLocks)
+	return h
 }
 
 func (h *Handler) RegisterRoutes(r chi.Router) {
 	r.Route("/events", func(r chi.Router) {
 		r.Get("/", h.GetEvents)
-		r.Post("/", h.CreateEvent)
+		r.With(auth.RequirePermission(auth.PermManageOwnEvents)).Post("/", h.CreateEvent)
+		r.Get("/live", h.LiveTickets)
+		r.Get("/suggest", h.SuggestEvents)
 		r.Get("/{event_id}", h.GetEvent)
-		r.Put("/{event_id}", h.UpdateEvent)
-		r.Delete("/{event_id}", h.DeleteEvent)
+		r.Get("/{event_id}/availability", h.GetEventAvailability)
+		r.With(auth.RequirePermission(auth.PermManageOwnEvents)).Put("/{event_id}", h.UpdateEvent)
+		r.With(auth.RequirePermission(auth.PermManageOwnEvents)).Delete("/{event_id}", h.DeleteEvent)
+
+		r.Route("/{event_id}/ticket-types", func(r chi.Router) {
+			r.Get("/", h.ListTicketTypes)
+			r.With(auth.RequirePermission(auth.PermManageOwnEvents)).Post("/", h.CreateTicketType)
+			r.Get("/{ticket_type_id}", h.GetTicketType)
+			r.With(auth.RequirePermission(auth.PermManageOwnEvents)).Put("/{ticket_type_id}", h.UpdateTicketType)
+			r.With(auth.RequirePermission(auth.PermManageOwnEvents)).Delete("/{ticket_type_id}", h.DeleteTicketType)
+		})
 
 		r.Route("/{event_id}/tickets", func(r chi.Router) {
 			r.Get("/", h.GetTickets)
@@ -63,30 +83,61 @@ func (h *Handler) RegisterRoutes(r chi.Router) {
 }
 
 func (h *Handler) GetEvents(w http.ResponseWriter, r *http.Request) {
-	
-	query := r.URL.Query().Get("q")
-	limit := 10
-	if limitStr := r.URL.Query().Get("limit"); limitStr != "" {
-		if l, err := strconv.Atoi(limitStr); err == nil && l > 0 && l <= 100 {
-			limit = l
-		}
+	params, err := parseSearchParams(r.URL.Query())
+	if err != nil {
+		utils.WriteError(w, http.StatusBadRequest, err)
+		return
 	}
-	
-	offset := 0
-	if offsetStr := r.URL.Query().Get("offset"); offsetStr != "" {
-		if o, err := strconv.Atoi(offsetStr); err == nil && o >= 0 {
-			offset = o
-		}
+
+	events, err := h.eventService.GetEventsWithQuery(r.Context(), params)
+	if errors.Is(err, search.ErrInvalidSearch) {
+		utils.WriteError(w, http.StatusBadRequest, err)
+		return
 	}
-	
-	events, err := h.eventService.GetEventsWithQuery(r.Context(), query, limit, offset)
 	if err != nil {
 		utils.WriteError(w, http.StatusInternalServerError, fmt.Errorf("failed to get events: %w", err))
 		return
 	}
+
+	// Availability is optional, so results are still returned without it
+	if slices.Contains(searchquery.ListParam(r.URL.Query(), "include"), "availability") {
+		if err := h.eventService.AddAvailability(r.Context(), events.Results); err != nil {
+			log.Printf("Warning: failed to add availability to search results: %v", err)
+		}
+	}
+	if events.Degraded {
+		w.Header().Set(search.DegradedHeader, "true")
+	}
 	utils.WriteJSON(w, http.StatusOK, events)
 }
 
+// SuggestEvents completes q for a search box: event titles, venue names and
+// performers starting with it, grouped by type
+func (h *Handler) SuggestEvents(w http.ResponseWriter, r *http.Request) {
+	prefix := strings.TrimSpace(r.URL.Query().Get("q"))
+	if prefix == "" {
+		utils.WriteError(w, http.StatusBadRequest, fmt.Errorf("query parameter 'q' is required"))
+		return
+	}
+
+	limit := 5
+	if limitStr := r.URL.Query().Get("limit"); limitStr != "" {
+		if l, err := strconv.Atoi(limitStr); err == nil && l > 0 && l <= 20 {
+			limit = l
+		}
+	}
+
+	suggestions, err := h.eventService.SuggestEvents(r.Context(), prefix, limit)
+	if err != nil {
+		utils.WriteError(w, http.StatusInternalServerError, fmt.Errorf("failed to get suggestions: %w", err))
+		return
+	}
+	if suggestions.Degraded {
+		w.Header().Set(search.DegradedHeader, "true")
+	}
+	utils.WriteJSON(w, http.StatusOK, suggestions)
+}
+
 func (h *Handler) CreateEvent(w http.ResponseWriter, r *http.Request) {
 	var createEventRequest types.CreateEventRequest
 	if err := utils.ParseJSON(r, &createEventRequest); err != nil {
@@ -96,7 +147,7 @@ func (h *Handler) CreateEvent(w http.ResponseWriter, r *http.Request) {
 
 	event, err := h.eventService.CreateEvent(r.Context(), createEventRequest)
 	if err != nil {
-		utils.WriteError(w, http.StatusInternalServerError, fmt.Errorf("failed to create event: %w", err))
+		utils.WriteError(w, eventErrorStatus(err), fmt.Errorf("failed to create event: %w", err))
 		return
 	}
 	utils.WriteJSON(w, http.StatusCreated, fmt.Sprintf("event created successfully with id: %v", event))
@@ -112,6 +163,21 @@ func (h *Handler) GetEvent(w http.ResponseWriter, r *http.Request) {
 	utils.WriteJSON(w, http.StatusOK, event)
 }
 
+func (h *Handler) GetEventAvailability(w http.ResponseWriter, r *http.Request) {
+	eventID, err := uuid.Parse(chi.URLParam(r, "event_id"))
+	if err != nil {
+		utils.WriteError(w, http.StatusBadRequest, fmt.Errorf("invalid event id: %w", err))
+		return
+	}
+
+	summary, err := h.eventService.GetEventAvailability(r.Context(), eventID)
+	if err != nil {
+		utils.WriteError(w, eventErrorStatus(err), fmt.Errorf("failed to get event availability: %w", err))
+		return
+	}
+	utils.WriteJSON(w, http.StatusOK, summary)
+}
+
 func (h *Handler) UpdateEvent(w http.ResponseWriter, r *http.Request) {
 	id := chi.URLParam(r, "event_id")
 	var updateEventRequest types.UpdateEventRequest
@@ -121,7 +187,7 @@ func (h *Handler) UpdateEvent(w http.ResponseWriter, r *http.Request) {
 	}
 	event, err := h.eventService.UpdateEvent(r.Context(), uuid.MustParse(id), updateEventRequest)
 	if err != nil {
-		utils.WriteError(w, http.StatusInternalServerError, fmt.Errorf("failed to update event: %w", err))
+		utils.WriteError(w, eventErrorStatus(err), fmt.Errorf("failed to update event: %w", err))
 		return
 	}
 	utils.WriteJSON(w, http.StatusOK, event)
@@ -131,7 +197,7 @@ func (h *Handler) DeleteEvent(w http.ResponseWriter, r *http.Request) {
 	id := chi.URLParam(r, "event_id")
 	err := h.eventService.DeleteEvent(r.Context(), uuid.MustParse(id))
 	if err != nil {
-		utils.WriteError(w, http.StatusInternalServerError, fmt.Errorf("failed to delete event: %w", err))
+		utils.WriteError(w, eventErrorStatus(err), fmt.Errorf("failed to delete event: %w", err))
 		return
 	}
 	utils.WriteJSON(w, http.StatusOK, fmt.Sprintf("event deleted successfully with id: %v", id))
@@ -158,6 +224,109 @@ func (h *Handler) GetTicket(w http.ResponseWriter, r *http.Request) {
 	utils.WriteJSON(w, http.StatusOK, ticket)
 }
 
+func (h *Handler) ListTicketTypes(w http.ResponseWriter, r *http.Request) {
+	eventID, err := uuid.Parse(chi.URLParam(r, "event_id"))
+	if err != nil {
+		utils.WriteError(w, http.StatusBadRequest, fmt.Errorf("invalid event id: %w", err))
+		return
+	}
+	ticketTypes, err := h.eventService.ListTicketTypes(r.Context(), eventID)
+	if err != nil {
+		utils.WriteError(w, http.StatusInternalServerError, fmt.Errorf("failed to list ticket types: %w", err))
+		return
+	}
+	utils.WriteJSON(w, http.StatusOK, ticketTypes)
+}
+
+func (h *Handler) CreateTicketType(w http.ResponseWriter, r *http.Request) {
+	eventID, err := uuid.Parse(chi.URLParam(r, "event_id"))
+	if err != nil {
+		utils.WriteError(w, http.StatusBadRequest, fmt.Errorf("invalid event id: %w", err))
+		return
+	}
+	var req types.CreateTicketTypeRequest
+	if err := utils.ParseJSON(r, &req); err != nil {
+		utils.WriteError(w, http.StatusBadRequest, fmt.Errorf("failed to parse create ticket type request body: %w", err))
+		return
+	}
+	ticketType, err := h.eventService.CreateTicketType(r.Context(), eventID, req)
+	if err != nil {
+		utils.WriteError(w, eventErrorStatus(err), fmt.Errorf("failed to create ticket type: %w", err))
+		return
+	}
+	utils.WriteJSON(w, http.StatusCreated, ticketType)
+}
+
+func (h *Handler) GetTicketType(w http.ResponseWriter, r *http.Request) {
+	eventID, ticketTypeID, err := ticketTypePathIDs(r)
+	if err != nil {
+		utils.WriteError(w, http.StatusBadRequest, err)
+		return
+	}
+	ticketType, err := h.eventService.GetTicketType(r.Context(), eventID, ticketTypeID)
+	if err != nil {
+		utils.WriteError(w, eventErrorStatus(err), fmt.Errorf("failed to get ticket type: %w", err))
+		return
+	}
+	utils.WriteJSON(w, http.StatusOK, ticketType)
+}
+
+func (h *Handler) UpdateTicketType(w http.ResponseWriter, r *http.Request) {
+	eventID, ticketTypeID, err := ticketTypePathIDs(r)
+	if err != nil {
+		utils.WriteError(w, http.StatusBadRequest, err)
+		return
+	}
+	var req types.UpdateTicketTypeRequest
+	if err := utils.ParseJSON(r, &req); err != nil {
+		utils.WriteError(w, http.StatusBadRequest, fmt.Errorf("failed to parse update ticket type request body: %w", err))
+		return
+	}
+	ticketType, err := h.eventService.UpdateTicketType(r.Context(), eventID, ticketTypeID, req)
+	if err != nil {
+		utils.WriteError(w, eventErrorStatus(err), fmt.Errorf("failed to update ticket type: %w", err))
+		return
+	}
+	utils.WriteJSON(w, http.StatusOK, ticketType)
+}
+
+func (h *Handler) DeleteTicketType(w http.ResponseWriter, r *http.Request) {
+	eventID, ticketTypeID, err := ticketTypePathIDs(r)
+	if err != nil {
+		utils.WriteError(w, http.StatusBadRequest, err)
+		return
+	}
+	if err := h.eventService.DeleteTicketType(r.Context(), eventID, ticketTypeID); err != nil {
+		utils.WriteError(w, eventErrorStatus(err), fmt.Errorf("failed to delete ticket type: %w", err))
+		return
+	}
+	utils.WriteJSON(w, http.StatusOK, fmt.Sprintf("ticket type deleted successfully with id: %v", ticketTypeID))
+}
+
+func ticketTypePathIDs(r *http.Request) (uuid.UUID, uuid.UUID, error) {
+	eventID, err := uuid.Parse(chi.URLParam(r, "event_id"))
+	if err != nil {
+		return uuid.Nil, uuid.Nil, fmt.Errorf("invalid event id: %w", err)
+	}
+	ticketTypeID, err := uuid.Parse(chi.URLParam(r, "ticket_type_id"))
+	if err != nil {
+		return uuid.Nil, uuid.Nil, fmt.Errorf("invalid ticket type id: %w", err)
+	}
+	return eventID, ticketTypeID, nil
+}
+
+func eventErrorStatus(err error) int {
+	switch {
+	case errors.Is(err, ErrEventNotFound), errors.Is(err, tickets.ErrTicketTypeNotFound):
+		return http.StatusNotFound
+	case errors.Is(err, ErrInvalidEvent), errors.Is(err, tickets.ErrInvalidTicketType):
+		return http.StatusBadRequest
+	case errors.Is(err, tickets.ErrTicketTypeExists), errors.Is(err, tickets.ErrTicketTypeInUse), errors.Is(err, tickets.ErrSeatTaken):
+		return http.StatusConflict
+	}
+	return auth.ErrorStatus(err, http.StatusInternalServerError)
+}
+
 // enrichTicketsWithLocks adds lock status to tickets by checking the booking service
 func (h *Handler) enrichTicketsWithLocks(ctx context.Context, tickets []types.Ticket) ([]types.Ticket, error) {
 	if h.bookingClient == nil {
@@ -194,66 +363,110 @@ func (h *Handler) enrichTicketsWithLocks(ctx context.Context, tickets []types.Ti
 	return enriched, nil
 }
 
+// Comment lines keep idle streams from being closed by proxies
+const streamKeepAlive = 15 * time.Second
 
+// StreamTickets streams the availability of an event's tickets as server-sent
+// events: a snapshot of every ticket first, then deltas with the tickets that
+// changed. Clients reconnecting with Last-Event-ID get the deltas they missed,
+// or a new snapshot when too many were missed.
 func (h *Handler) StreamTickets(w http.ResponseWriter, r *http.Request) {
-	eventID := chi.URLParam(r, "event_id")
-	eventUUID := uuid.MustParse(eventID)
+	eventID, err := uuid.Parse(chi.URLParam(r, "event_id"))
+	if err != nil {
+		utils.WriteError(w, http.StatusBadRequest, fmt.Errorf("invalid event ID: %v", err))
+		return
+	}
+
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		utils.WriteError(w, http.StatusInternalServerError, fmt.Errorf("streaming is not supported"))
+		return
+	}
+
+	// Create a context that cancels when client disconnects
+	ctx := r.Context()
+
+	sub, err := h.availability.Subscribe(ctx, eventID, r.Header.Get("Last-Event-ID"))
+	if err != nil {
+		log.Printf("Error subscribing to tickets of event %s: %v", eventID, err)
+		utils.WriteError(w, http.StatusServiceUnavailable, fmt.Errorf("failed to stream tickets"))
+		return
+	}
+	defer sub.Close()
 
 	// Set up SSE headers
 	w.Header().Set("Content-Type", "text/event-stream")
 	w.Header().Set("Cache-Control", "no-cache")
 	w.Header().Set("Connection", "keep-alive")
 	w.Header().Set("Access-Control-Allow-Origin", "*")
-	w.Header().Set("Access-Control-Allow-Headers", "Cache-Control")
+	w.Header().Set("Access-Control-Allow-Headers", "Cache-Control, Last-Event-ID")
+	w.WriteHeader(http.StatusOK)
+	flusher.Flush()
 
-	// Create a context that cancels when client disconnects
-	ctx := r.Context()
-
-	// Create a ticker for 2-second updates;TODO: should be configurable
-	ticker := time.NewTicker(2 * time.Second)
-	defer ticker.Stop()
+	keepAlive := time.NewTicker(streamKeepAlive)
+	defer keepAlive.Stop()
 
-	// Send initial data immediately
-	h.sendTicketUpdate(w, ctx, eventUUID)
-
-	// Stream updates every 2 seconds
 	for {
 		select {
 		case <-ctx.Done():
 			// Client disconnected
 			return
-		case <-ticker.C:
-			if err := h.sendTicketUpdate(w, ctx, eventUUID); err != nil {
+		case msg, ok := <-sub.C:
+			if !ok {
+				// Fell too far behind; the client reconnects and resumes
+				return
+			}
+			if err := writeTicketMessage(w, msg); err != nil {
 				log.Printf("Error sending ticket update: %v", err)
 				return
 			}
-			// Flush the response to ensure data is sent immediately
-			if flusher, ok := w.(http.Flusher); ok {
-				flusher.Flush()
+		case <-keepAlive.C:
+			if _, err := fmt.Fprint(w, ": keep-alive\n\n"); err != nil {
+				return
 			}
 		}
+		flusher.Flush()
 	}
 }
 
-func (h *Handler) sendTicketUpdate(w http.ResponseWriter, ctx context.Context, eventID uuid.UUID) error {
-	// Fetch tickets from database - could overwhelm the database, if we have read replicas this would mitigate the issue
-	tickets, err := h.ticketService.GetTicketsForEvent(ctx, eventID)
-	if err != nil {
-		return fmt.Errorf("failed to get tickets: %w", err)
-	}
-
-	enrichedTickets, _ := h.enrichTicketsWithLocks(ctx, tickets)
-	
-	jsonData, err := json.Marshal(enrichedTickets)
+func writeTicketMessage(w http.ResponseWriter, msg availability.Message) error {
+	jsonData, err := json.Marshal(msg)
 	if err != nil {
 		return fmt.Errorf("failed to marshal tickets: %w", err)
 	}
 
 	// Write SSE formatted data
-	_, err = fmt.Fprintf(w, "data: %s\n\n", jsonData)
+	_, err = fmt.Fprintf(w, "id: %s\nevent: %s\ndata: %s\n\n", msg.ID, msg.Type, jsonData)
 	if err != nil {
 		return fmt.Errorf("failed to write SSE data: %w", err)
 	}
 
 	return nil
-}
\ No newline at end of file
+}
+
+// loadTicketsWithLocks loads the tickets of an event with their hold status.
+// Unlike enrichTicketsWithLocks it fails when the hold status is unknown, so
+// that live availability never reports held tickets as free.
+func (h *Handler) loadTicketsWithLocks(ctx context.Context, eventID uuid.UUID) ([]types.Ticket, error) {
+	tickets, err := h.ticketService.GetTicketsForEvent(ctx, eventID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get tickets: %w", err)
+	}
+	if len(tickets) == 0 {
+		return tickets, nil
+	}
+
+	ticketIDs := make([]uuid.UUID, len(tickets))
+	for i, ticket := range tickets {
+		ticketIDs[i] = ticket.ID
+	}
+	locks, _, err := h.bookingClient.CheckTicketLocks(ctx, ticketIDs)
+	if err != nil {
+		return nil, fmt.Errorf("failed to check ticket locks: %w", err)
+	}
+
+	for i := range tickets {
+		tickets[i].IsReserved = locks[tickets[i].ID]
+	}
+	return tickets, nil
+}
@@ -0,0 +1,397 @@
+package events
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/gorilla/websocket"
+
+	"github.com/ignisrex/tix/core/internal/availability"
+	bookingclient "github.com/ignisrex/tix/core/internal/booking"
+)
+
+const (
+	liveWriteTimeout   = 10 * time.Second
+	livePongWait       = 60 * time.Second
+	livePingInterval   = 30 * time.Second // must be shorter than livePongWait
+	liveMaxMessageSize = 64 << 10
+	liveSendBuffer     = 64
+
+	// Events a single connection may follow at once
+	liveMaxSubscriptions = 50
+	// Reserve and release commands a single connection may have in flight
+	liveMaxPendingCommands = 4
+)
+
+// Commands sent by live clients
+const (
+	liveSubscribe   = "subscribe"
+	liveUnsubscribe = "unsubscribe"
+	liveReserve     = "reserve"
+	liveRelease     = "release"
+)
+
+var liveUpgrader = websocket.Upgrader{
+	ReadBufferSize:  4096,
+	WriteBufferSize: 4096,
+	// Clients authenticate with a bearer token rather than cookies, so any
+	// origin is allowed, like the CORS config does for the REST API
+	CheckOrigin: func(r *http.Request) bool { return true },
+}
+
+// liveCommand is a message from a live client. RequestID is echoed in the
+// reply so clients can match replies to commands.
+type liveCommand struct {
+	Type      string `json:"type"`
+	RequestID string `json:"request_id,omitempty"`
+
+	// subscribe / unsubscribe
+	EventIDs     []uuid.UUID          `json:"event_ids,omitempty"`
+	LastEventIDs map[uuid.UUID]string `json:"last_event_ids,omitempty"` // resume like SSE's Last-Event-ID
+
+	// reserve / release
+	TicketIDs      []uuid.UUID `json:"ticket_ids,omitempty"`
+	HoldID         uuid.UUID   `json:"hold_id,omitempty"`
+	AdmissionToken string      `json:"admission_token,omitempty"`
+	IdempotencyKey string      `json:"idempotency_key,omitempty"`
+}
+
+// liveUpdate carries a snapshot or delta of one event, like the SSE stream
+type liveUpdate struct {
+	Type string `json:"type"`
+	ID   string `json:"id"`
+	availability.Message
+}
+
+// liveReply answers a command. Failed commands are answered with the error
+// type and the HTTP status the REST API would have returned.
+type liveReply struct {
+	Type      string      `json:"type"`
+	RequestID string      `json:"request_id,omitempty"`
+	Status    int         `json:"status,omitempty"`
+	Error     string      `json:"error,omitempty"`
+	EventIDs  []uuid.UUID `json:"event_ids,omitempty"`
+	Result    any         `json:"result,omitempty"`
+}
+
+// liveConn is one live client connection. Only writeLoop writes to the
+// socket; everything else queues messages on send.
+type liveConn struct {
+	h      *Handler
+	ws     *websocket.Conn
+	ctx    context.Context
+	cancel context.CancelFunc
+	send   chan any
+
+	// Admission token of the upgrade request, used by reserve commands that
+	// don't carry their own
+	admissionToken string
+
+	// One slot per reserve or release command in flight
+	pending chan struct{}
+
+	mu   sync.Mutex
+	subs map[uuid.UUID]*availability.Subscription
+	wg   sync.WaitGroup
+}
+
+// LiveTickets upgrades the request to a WebSocket over which the client can
+// follow the availability of several events and reserve or release tickets
+func (h *Handler) LiveTickets(w http.ResponseWriter, r *http.Request) {
+	ws, err := liveUpgrader.Upgrade(w, r, nil)
+	if err != nil {
+		// The upgrader has already replied with an error
+		log.Printf("Error upgrading live connection: %v", err)
+		return
+	}
+
+	ctx, cancel := context.WithCancel(r.Context())
+	c := &liveConn{
+		h:              h,
+		ws:             ws,
+		ctx:            ctx,
+		cancel:         cancel,
+		send:           make(chan any, liveSendBuffer),
+		admissionToken: r.Header.Get(bookingclient.AdmissionTokenHeader),
+		pending:        make(chan struct{}, liveMaxPendingCommands),
+		subs:           make(map[uuid.UUID]*availability.Subscription),
+	}
+	defer c.close()
+
+	go c.writeLoop()
+	c.readLoop()
+}
+
+func (c *liveConn) close() {
+	c.cancel()
+
+	c.mu.Lock()
+	subs := c.subs
+	c.subs = make(map[uuid.UUID]*availability.Subscription)
+	c.mu.Unlock()
+	for _, sub := range subs {
+		sub.Close()
+	}
+
+	c.wg.Wait()
+	c.ws.Close()
+}
+
+func (c *liveConn) readLoop() {
+	c.ws.SetReadLimit(liveMaxMessageSize)
+	c.ws.SetReadDeadline(time.Now().Add(livePongWait))
+	c.ws.SetPongHandler(func(string) error {
+		return c.ws.SetReadDeadline(time.Now().Add(livePongWait))
+	})
+
+	for {
+		_, data, err := c.ws.ReadMessage()
+		if err != nil {
+			if websocket.IsUnexpectedCloseError(err, websocket.CloseNormalClosure, websocket.CloseGoingAway) {
+				log.Printf("Live connection closed: %v", err)
+			}
+			return
+		}
+
+		var cmd liveCommand
+		if err := json.Unmarshal(data, &cmd); err != nil {
+			c.reply(liveReply{Type: "error", Status: http.StatusBadRequest, Error: fmt.Sprintf("invalid message: %v", err)})
+			continue
+		}
+
+		switch cmd.Type {
+		case liveSubscribe:
+			c.subscribe(cmd)
+		case liveUnsubscribe:
+			c.unsubscribe(cmd)
+		case liveReserve:
+			c.runPending(cmd, c.reserve)
+		case liveRelease:
+			c.runPending(cmd, c.release)
+		default:
+			c.fail(cmd, http.StatusBadRequest, fmt.Errorf("unknown message type %q", cmd.Type))
+		}
+	}
+}
+
+// runPending runs a booking command in the background so that slow booking
+// calls don't hold up reading. Commands beyond liveMaxPendingCommands in
+// flight are rejected rather than queued.
+func (c *liveConn) runPending(cmd liveCommand, run func(liveCommand)) {
+	select {
+	case c.pending <- struct{}{}:
+	default:
+		c.fail(cmd, http.StatusTooManyRequests, fmt.Errorf("at most %d reserve or release commands can be pending at once", liveMaxPendingCommands))
+		return
+	}
+
+	c.wg.Add(1)
+	go func() {
+		defer func() {
+			<-c.pending
+			c.wg.Done()
+		}()
+		run(cmd)
+	}()
+}
+
+func (c *liveConn) writeLoop() {
+	ping := time.NewTicker(livePingInterval)
+	defer ping.Stop()
+
+	for {
+		select {
+		case <-c.ctx.Done():
+			return
+		case msg := <-c.send:
+			c.ws.SetWriteDeadline(time.Now().Add(liveWriteTimeout))
+			if err := c.ws.WriteJSON(msg); err != nil {
+				log.Printf("Error writing to live connection: %v", err)
+				c.cancel()
+				return
+			}
+		case <-ping.C:
+			if err := c.ws.WriteControl(websocket.PingMessage, nil, time.Now().Add(liveWriteTimeout)); err != nil {
+				c.cancel()
+				return
+			}
+		}
+	}
+}
+
+// reply queues a message for the client. It returns false once the
+// connection is closing.
+func (c *liveConn) reply(msg any) bool {
+	select {
+	case c.send <- msg:
+		return true
+	case <-c.ctx.Done():
+		return false
+	}
+}
+
+func (c *liveConn) fail(cmd liveCommand, status int, err error) {
+	c.reply(liveReply{Type: "error", RequestID: cmd.RequestID, Status: status, Error: err.Error()})
+}
+
+// subscribe starts following the events. Events already followed are left
+// as they are; the first update of every new event is a snapshot, or the
+// deltas since its entry in LastEventIDs.
+func (c *liveConn) subscribe(cmd liveCommand) {
+	if len(cmd.EventIDs) == 0 {
+		c.fail(cmd, http.StatusBadRequest, fmt.Errorf("event_ids cannot be empty"))
+		return
+	}
+
+	var subscribed []uuid.UUID
+	for _, eventID := range cmd.EventIDs {
+		c.mu.Lock()
+		_, exists := c.subs[eventID]
+		full := len(c.subs) >= liveMaxSubscriptions
+		c.mu.Unlock()
+		if exists {
+			subscribed = append(subscribed, eventID)
+			continue
+		}
+		if full {
+			c.reply(liveReply{Type: "error", RequestID: cmd.RequestID, Status: http.StatusTooManyRequests, EventIDs: []uuid.UUID{eventID}, Error: fmt.Sprintf("at most %d events can be followed at once", liveMaxSubscriptions)})
+			continue
+		}
+
+		sub, err := c.h.availability.Subscribe(c.ctx, eventID, cmd.LastEventIDs[eventID])
+		if err != nil {
+			log.Printf("Error subscribing live connection to event %s: %v", eventID, err)
+			c.reply(liveReply{Type: "error", RequestID: cmd.RequestID, Status: http.StatusServiceUnavailable, EventIDs: []uuid.UUID{eventID}, Error: "failed to follow event"})
+			continue
+		}
+
+		c.mu.Lock()
+		c.subs[eventID] = sub
+		c.mu.Unlock()
+		c.wg.Add(1)
+		go c.forward(eventID, sub)
+		subscribed = append(subscribed, eventID)
+	}
+
+	if len(subscribed) > 0 {
+		c.reply(liveReply{Type: "subscribed", RequestID: cmd.RequestID, EventIDs: subscribed})
+	}
+}
+
+func (c *liveConn) unsubscribe(cmd liveCommand) {
+	for _, eventID := range cmd.EventIDs {
+		c.mu.Lock()
+		sub := c.subs[eventID]
+		delete(c.subs, eventID)
+		c.mu.Unlock()
+		if sub != nil {
+			sub.Close()
+		}
+	}
+	c.reply(liveReply{Type: "unsubscribed", RequestID: cmd.RequestID, EventIDs: cmd.EventIDs})
+}
+
+// forward sends an event's updates to the client until it unsubscribes.
+// When the client falls behind and the hub drops the subscription, it is
+// resumed from the last update sent.
+func (c *liveConn) forward(eventID uuid.UUID, sub *availability.Subscription) {
+	defer c.wg.Done()
+
+	var lastID string
+	for {
+		for msg := range sub.C {
+			lastID = msg.ID
+			if !c.reply(liveUpdate{Type: msg.Type, ID: msg.ID, Message: msg}) {
+				return
+			}
+		}
+
+		c.mu.Lock()
+		current := c.subs[eventID]
+		c.mu.Unlock()
+		if current != sub || c.ctx.Err() != nil {
+			// Unsubscribed or closing
+			return
+		}
+
+		next, err := c.h.availability.Subscribe(c.ctx, eventID, lastID)
+		// Only let go of the old subscription now so that the event's hub,
+		// and the history to resume from, stays alive
+		sub.Close()
+		if err != nil {
+			log.Printf("Error resuming live subscription to event %s: %v", eventID, err)
+			c.mu.Lock()
+			if c.subs[eventID] == sub {
+				delete(c.subs, eventID)
+			}
+			c.mu.Unlock()
+			c.reply(liveReply{Type: "error", Status: http.StatusServiceUnavailable, EventIDs: []uuid.UUID{eventID}, Error: "stopped following event"})
+			return
+		}
+
+		c.mu.Lock()
+		if c.subs[eventID] != sub {
+			c.mu.Unlock()
+			next.Close()
+			return
+		}
+		c.subs[eventID] = next
+		c.mu.Unlock()
+		sub = next
+	}
+}
+
+// reserve reserves tickets like POST /booking/reserve and replies with the
+// booking service's response
+func (c *liveConn) reserve(cmd liveCommand) {
+	if len(cmd.TicketIDs) == 0 {
+		c.fail(cmd, http.StatusBadRequest, fmt.Errorf("ticket_ids cannot be empty"))
+		return
+	}
+
+	admissionToken := cmd.AdmissionToken
+	if admissionToken == "" {
+		admissionToken = c.admissionToken
+	}
+	idempotencyKey := cmd.IdempotencyKey
+	if idempotencyKey == "" {
+		idempotencyKey = uuid.New().String()
+	}
+
+	ctx := bookingclient.WithAdmissionToken(c.ctx, admissionToken)
+	response, statusCode, err := c.h.bookingClient.ReserveTickets(ctx, cmd.TicketIDs, cmd.HoldID, idempotencyKey)
+	if response == nil {
+		c.fail(cmd, statusCode, fmt.Errorf("failed to reserve tickets: %w", err))
+		return
+	}
+	// Like the REST API, failed reservations carry the booking service's
+	// response, e.g. 409 for tickets reserved by someone else
+	if err != nil || !response.Success {
+		c.reply(liveReply{Type: "error", RequestID: cmd.RequestID, Status: statusCode, Error: response.Message, Result: response})
+		return
+	}
+
+	c.reply(liveReply{Type: "reserved", RequestID: cmd.RequestID, Status: statusCode, Result: response})
+}
+
+// release releases every ticket of a hold like DELETE /booking/holds/{id}
+func (c *liveConn) release(cmd liveCommand) {
+	if cmd.HoldID == uuid.Nil {
+		c.fail(cmd, http.StatusBadRequest, fmt.Errorf("hold_id is required"))
+		return
+	}
+
+	statusCode, err := c.h.bookingClient.ReleaseHold(c.ctx, cmd.HoldID)
+	if err != nil {
+		c.fail(cmd, statusCode, fmt.Errorf("failed to release hold: %w", err))
+		return
+	}
+
+	c.reply(liveReply{Type: "released", RequestID: cmd.RequestID, Status: statusCode, Result: map[string]uuid.UUID{"hold_id": cmd.HoldID}})
+}
@@ -23,6 +23,14 @@ func NewRepo(queries *database.Queries, db *sql.DB) *Repo {
 	}
 }
 
+// withTx returns queries bound to tx, or the default queries when tx is nil
+func (r *Repo) withTx(tx *sql.Tx) *database.Queries {
+	if tx != nil {
+		return r.queries.WithTx(tx)
+	}
+	return r.queries
+}
+
 func (r *Repo) GetEvents(ctx context.Context) ([]types.Event, error) {	
 	dbEvents, err := r.queries.GetEvents(ctx, database.GetEventsParams{
 		Limit:  10, //make this a configurable parameter
@@ -47,6 +55,9 @@ func (r *Repo) CreateEvent(ctx context.Context, event types.CreateEventRequest,
 		Description: event.Description,
 		StartDate: event.StartDate,
 		VenueID: event.VenueID,
+		OrganizerID: uuid.NullUUID{UUID: event.OrganizerID, Valid: event.OrganizerID != uuid.Nil},
+		Category: toNullString(event.Category),
+		Performers: event.Performers,
 	})
 	if err != nil {
 		return types.Event{}, err
@@ -62,13 +73,15 @@ func (r *Repo) GetEvent(ctx context.Context, id uuid.UUID) (types.Event, error)
 	return mappers.ToEvent(dbEvent), nil
 }
 
-func (r *Repo) UpdateEvent(ctx context.Context, id uuid.UUID, event types.UpdateEventRequest) (types.Event, error) {
-	dbEvent, err := r.queries.UpdateEvent(ctx, database.UpdateEventParams{
+func (r *Repo) UpdateEvent(ctx context.Context, id uuid.UUID, event types.UpdateEventRequest, tx *sql.Tx) (types.Event, error) {
+	dbEvent, err := r.withTx(tx).UpdateEvent(ctx, database.UpdateEventParams{
 		ID: id,
 		Title: event.Title,
 		Description: event.Description,
 		StartDate: event.StartDate,
 		VenueID: event.VenueID,
+		Category: toNullString(event.Category),
+		Performers: event.Performers,
 	})
 	if err != nil {
 		return types.Event{}, err
@@ -76,10 +89,21 @@ func (r *Repo) UpdateEvent(ctx context.Context, id uuid.UUID, event types.Update
 	return mappers.ToEvent(dbEvent), nil
 }
 
-func (r *Repo) DeleteEvent(ctx context.Context, id uuid.UUID) error {
-	err := r.queries.DeleteEvent(ctx, id)
+func (r *Repo) DeleteEvent(ctx context.Context, id uuid.UUID, tx *sql.Tx) error {
+	err := r.withTx(tx).DeleteEvent(ctx, id)
 	if err != nil {
 		return err
 	}
 	return nil
-}
\ No newline at end of file
+}
+
+// EnqueueIndexing records in the search outbox that the event's search
+// document must be rewritten. It belongs in the transaction that changes the
+// event.
+func (r *Repo) EnqueueIndexing(ctx context.Context, id uuid.UUID, tx *sql.Tx) error {
+	return r.withTx(tx).EnqueueEventIndexing(ctx, id)
+}
+
+func toNullString(s string) sql.NullString {
+	return sql.NullString{String: s, Valid: s != ""}
+}
@@ -0,0 +1,49 @@
+package events
+
+import (
+	"fmt"
+	"net/url"
+	"slices"
+
+	"github.com/google/uuid"
+
+	"github.com/ignisrex/tix/core/types"
+	"github.com/ignisrex/tix/searchquery"
+)
+
+// parseSearchParams reads an event search from the query string, parsed the
+// same way as by the search service. Out of range pagination falls back to
+// the defaults; invalid filters are an error.
+func parseSearchParams(values url.Values) (types.SearchEventsParams, error) {
+	parsed, err := searchquery.Parse(values)
+	if err != nil {
+		return types.SearchEventsParams{}, err
+	}
+	params := types.SearchEventsParams{
+		Query:         parsed.Query,
+		Cursor:        values.Get("cursor"),
+		Limit:         parsed.Limit,
+		Offset:        parsed.Offset,
+		StartFrom:     parsed.StartFrom,
+		StartTo:       parsed.StartTo,
+		Location:      parsed.Location,
+		TicketTypes:   parsed.TicketTypes,
+		Categories:    parsed.Categories,
+		MinPriceCents: parsed.MinPriceCents,
+		MaxPriceCents: parsed.MaxPriceCents,
+		Sort:          parsed.Sort,
+	}
+
+	// The Postgres fallback compares venue IDs as UUIDs
+	for _, value := range parsed.VenueIDs {
+		venueID, err := uuid.Parse(value)
+		if err != nil {
+			return params, fmt.Errorf("invalid venue_id %q: %w", value, err)
+		}
+		params.VenueIDs = append(params.VenueIDs, venueID)
+	}
+
+	params.Facets = slices.Contains(searchquery.ListParam(values, "include"), "facets")
+
+	return params, nil
+}
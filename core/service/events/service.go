@@ -2,33 +2,45 @@ package events
 
 import (
 	"context"
+	"database/sql"
 	"errors"
+	"fmt"
 	"log"
+	"strings"
 
 	"github.com/google/uuid"
 
-	"github.com/ignisrex/tix/core/internal/elasticsearch"
+	"github.com/ignisrex/tix/auth"
+	"github.com/ignisrex/tix/core/internal/availability"
 	"github.com/ignisrex/tix/core/internal/search"
 	"github.com/ignisrex/tix/core/service/tickets"
 	"github.com/ignisrex/tix/core/service/venues"
 	"github.com/ignisrex/tix/core/types"
 )
 
+var (
+	ErrEventNotFound = errors.New("event not found")
+	ErrInvalidEvent  = errors.New("invalid event")
+)
+
+// maxCategoryLength matches the events.category column
+const maxCategoryLength = 64
+
 type Service struct {
 	repo          *Repo
 	ticketService *tickets.Service
 	venueService  *venues.Service
-	esClient      *elasticsearch.Client
-	searchClient  *search.Client
+	searcher      search.Searcher
+	holds         *availability.HoldIndex
 }
 
-func NewService(repo *Repo, ticketService *tickets.Service, venueService *venues.Service, esClient *elasticsearch.Client, searchClient *search.Client) *Service {
+func NewService(repo *Repo, ticketService *tickets.Service, venueService *venues.Service, searcher search.Searcher, holds *availability.HoldIndex) *Service {
 	return &Service{
 		repo:          repo,
 		ticketService: ticketService,
 		venueService:  venueService,
-		esClient:      esClient,
-		searchClient:  searchClient,
+		searcher:      searcher,
+		holds:         holds,
 	}
 }
 
@@ -36,14 +48,36 @@ func (s *Service) GetEvents(ctx context.Context) ([]types.Event, error) {
 	return s.repo.GetEvents(ctx)
 }
 
-func (s *Service) GetEventsWithQuery(ctx context.Context, query string, limit, offset int) ([]types.SearchEventResult, error) {
-	if s.searchClient != nil {
-		return s.searchClient.SearchEvents(ctx, query, limit, offset)
+func (s *Service) GetEventsWithQuery(ctx context.Context, params types.SearchEventsParams) (*types.SearchEventResults, error) {
+	if s.searcher != nil {
+		return s.searcher.SearchEvents(ctx, params)
 	}
-	return nil, errors.New("search client is not available")
+	return nil, errors.New("search is not available")
 }
 
+func (s *Service) SuggestEvents(ctx context.Context, prefix string, limit int) (*types.EventSuggestions, error) {
+	if s.searcher != nil {
+		return s.searcher.Suggest(ctx, prefix, limit)
+	}
+	return nil, errors.New("search is not available")
+}
+
+// CreateEvent creates an event with its ticket types and tickets. Organizers always own the
+// events they create; admins may create an event for an organizer by setting
+// OrganizerID.
 func (s *Service) CreateEvent(ctx context.Context, createEventRequest types.CreateEventRequest) (types.Event, error) {
+	principal, err := auth.Authorize(ctx, auth.PermManageOwnEvents)
+	if err != nil {
+		return types.Event{}, err
+	}
+	if !principal.Can(auth.PermManageAllEvents) || createEventRequest.OrganizerID == uuid.Nil {
+		createEventRequest.OrganizerID = principal.Subject
+	}
+	if createEventRequest.Category, err = normalizeCategory(createEventRequest.Category); err != nil {
+		return types.Event{}, err
+	}
+	createEventRequest.Performers = normalizePerformers(createEventRequest.Performers)
+
 	tx, err := s.repo.db.BeginTx(ctx, nil)
 	if err != nil {
 		return types.Event{}, err
@@ -56,33 +90,20 @@ func (s *Service) CreateEvent(ctx context.Context, createEventRequest types.Crea
 		return types.Event{}, err
 	}
 
-	_, err = s.ticketService.CreateTicketsForEvent(ctx, event.ID, createEventRequest.TicketAllocation, tx)
+	_, err = s.ticketService.CreateTicketsForEvent(ctx, event.ID, event.VenueID, createEventRequest.TicketTypes, tx)
 	if err != nil {
 		log.Printf("Warning: failed to create tickets for event: %v", err)
 		return types.Event{}, err
 	}
 
-	if err := tx.Commit(); err != nil {
+	// The search indexer picks the event up from the outbox once committed
+	if err := s.repo.EnqueueIndexing(ctx, event.ID, tx); err != nil {
 		return types.Event{}, err
 	}
 
-
-	if s.esClient != nil {
-		log.Printf("Indexing event %s in Elasticsearch", event.ID)
-		venue, err := s.venueService.GetVenue(ctx, event.VenueID)
-		if err != nil {
-			log.Printf("Warning: failed to fetch venue for ES indexing: %v", err)
-			return types.Event{}, err
-		}
-		if err := s.esClient.IndexEvent(ctx, event, venue); err != nil {
-			log.Printf("Warning: failed to index event in Elasticsearch: %v", err)
-		} else {
-			log.Printf("Successfully indexed event %s in Elasticsearch", event.ID)
-		}
-	} else {
-		log.Printf("Elasticsearch client is nil, skipping indexing")
+	if err := tx.Commit(); err != nil {
+		return types.Event{}, err
 	}
-	
 	return event, nil
 }
 
@@ -91,9 +112,197 @@ func (s *Service) GetEvent(ctx context.Context, id uuid.UUID) (types.Event, erro
 }
 
 func (s *Service) UpdateEvent(ctx context.Context, id uuid.UUID, event types.UpdateEventRequest) (types.Event, error) {
-	return s.repo.UpdateEvent(ctx, id, event)
+	if _, err := s.authorizeEvent(ctx, id); err != nil {
+		return types.Event{}, err
+	}
+	category, err := normalizeCategory(event.Category)
+	if err != nil {
+		return types.Event{}, err
+	}
+	event.Category = category
+	event.Performers = normalizePerformers(event.Performers)
+
+	tx, err := s.repo.db.BeginTx(ctx, nil)
+	if err != nil {
+		return types.Event{}, err
+	}
+	defer tx.Rollback()
+
+	updated, err := s.repo.UpdateEvent(ctx, id, event, tx)
+	if err != nil {
+		return types.Event{}, err
+	}
+	if err := s.repo.EnqueueIndexing(ctx, id, tx); err != nil {
+		return types.Event{}, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return types.Event{}, err
+	}
+	return updated, nil
 }
 
 func (s *Service) DeleteEvent(ctx context.Context, id uuid.UUID) error {
-	return s.repo.DeleteEvent(ctx, id)
-}
\ No newline at end of file
+	if _, err := s.authorizeEvent(ctx, id); err != nil {
+		return err
+	}
+
+	tx, err := s.repo.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if err := s.repo.DeleteEvent(ctx, id, tx); err != nil {
+		return err
+	}
+	// Removes the event's search document
+	if err := s.repo.EnqueueIndexing(ctx, id, tx); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// GetEventAvailability summarizes the availability of an event's tickets
+func (s *Service) GetEventAvailability(ctx context.Context, id uuid.UUID) (types.EventAvailability, error) {
+	if _, err := s.repo.GetEvent(ctx, id); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return types.EventAvailability{}, fmt.Errorf("%w: %s", ErrEventNotFound, id)
+		}
+		return types.EventAvailability{}, err
+	}
+
+	availabilities, err := s.GetAvailability(ctx, []uuid.UUID{id})
+	if err != nil {
+		return types.EventAvailability{}, err
+	}
+	return availabilities[0], nil
+}
+
+// GetAvailability summarizes the availability of each of the events, in the
+// order given. Ticket counts come from one aggregate query and held tickets
+// from the booking service's per-event hold index.
+func (s *Service) GetAvailability(ctx context.Context, eventIDs []uuid.UUID) ([]types.EventAvailability, error) {
+	var held []uuid.UUID
+	if s.holds != nil {
+		var err error
+		held, err = s.holds.HeldTickets(ctx, eventIDs)
+		if err != nil {
+			return nil, err
+		}
+	}
+	return s.ticketService.GetAvailability(ctx, eventIDs, held)
+}
+
+// AddAvailability fills in the availability of search results. Results whose
+// ID is not a valid event ID are left without one.
+func (s *Service) AddAvailability(ctx context.Context, results []types.SearchEventResult) error {
+	eventIDs := make([]uuid.UUID, 0, len(results))
+	positions := make([]int, 0, len(results))
+	for i, result := range results {
+		id, err := uuid.Parse(result.ID)
+		if err != nil {
+			continue
+		}
+		eventIDs = append(eventIDs, id)
+		positions = append(positions, i)
+	}
+	if len(eventIDs) == 0 {
+		return nil
+	}
+
+	availabilities, err := s.GetAvailability(ctx, eventIDs)
+	if err != nil {
+		return err
+	}
+	for n, i := range positions {
+		results[i].Availability = &availabilities[n]
+	}
+	return nil
+}
+
+func (s *Service) ListTicketTypes(ctx context.Context, eventID uuid.UUID) ([]types.TicketType, error) {
+	return s.ticketService.ListTicketTypes(ctx, eventID)
+}
+
+func (s *Service) GetTicketType(ctx context.Context, eventID uuid.UUID, id uuid.UUID) (types.TicketType, error) {
+	return s.ticketService.GetTicketType(ctx, eventID, id)
+}
+
+func (s *Service) CreateTicketType(ctx context.Context, eventID uuid.UUID, req types.CreateTicketTypeRequest) (types.TicketType, error) {
+	event, err := s.authorizeEvent(ctx, eventID)
+	if err != nil {
+		return types.TicketType{}, err
+	}
+	return s.ticketService.CreateTicketType(ctx, eventID, event.VenueID, req)
+}
+
+// UpdateTicketType replaces a ticket type. Tickets held in the booking
+// service are never removed when its quantity is lowered.
+func (s *Service) UpdateTicketType(ctx context.Context, eventID uuid.UUID, id uuid.UUID, req types.UpdateTicketTypeRequest) (types.TicketType, error) {
+	if _, err := s.authorizeEvent(ctx, eventID); err != nil {
+		return types.TicketType{}, err
+	}
+	var held []uuid.UUID
+	if s.holds != nil {
+		var err error
+		held, err = s.holds.HeldTickets(ctx, []uuid.UUID{eventID})
+		if err != nil {
+			return types.TicketType{}, err
+		}
+	}
+	return s.ticketService.UpdateTicketType(ctx, eventID, id, req, held)
+}
+
+func (s *Service) DeleteTicketType(ctx context.Context, eventID uuid.UUID, id uuid.UUID) error {
+	if _, err := s.authorizeEvent(ctx, eventID); err != nil {
+		return err
+	}
+	return s.ticketService.DeleteTicketType(ctx, eventID, id)
+}
+
+// authorizeEvent allows admins to manage any event and organizers to manage
+// the events they own. It returns the event being managed.
+func (s *Service) authorizeEvent(ctx context.Context, id uuid.UUID) (types.Event, error) {
+	principal, err := auth.Authorize(ctx, auth.PermManageOwnEvents)
+	if err != nil {
+		return types.Event{}, err
+	}
+
+	event, err := s.repo.GetEvent(ctx, id)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return types.Event{}, fmt.Errorf("%w: %s", ErrEventNotFound, id)
+		}
+		return types.Event{}, err
+	}
+	if principal.Can(auth.PermManageAllEvents) {
+		return event, nil
+	}
+	if event.OrganizerID == nil || *event.OrganizerID != principal.Subject {
+		return types.Event{}, fmt.Errorf("%w: event %s belongs to another organizer", auth.ErrForbidden, id)
+	}
+	return event, nil
+}
+
+// normalizeCategory lower-cases a category so that facets and filters don't
+// split on spelling
+func normalizeCategory(category string) (string, error) {
+	category = strings.ToLower(strings.TrimSpace(category))
+	if len(category) > maxCategoryLength {
+		return "", fmt.Errorf("%w: category is longer than %d characters", ErrInvalidEvent, maxCategoryLength)
+	}
+	return category, nil
+}
+
+// normalizePerformers trims performer names and drops blank ones. The result
+// is never nil, as the performers column is NOT NULL.
+func normalizePerformers(performers []string) []string {
+	normalized := make([]string, 0, len(performers))
+	for _, performer := range performers {
+		if performer = strings.TrimSpace(performer); performer != "" {
+			normalized = append(normalized, performer)
+		}
+	}
+	return normalized
+}
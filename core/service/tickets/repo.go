@@ -3,7 +3,6 @@ package tickets
 import (
 	"context"
 	"database/sql"
-	"fmt"
 
 	"github.com/google/uuid"
 
@@ -14,15 +13,24 @@ import (
 
 type Repo struct {
 	queries *database.Queries
-	ticketTypes map[string]uuid.UUID
+	db *sql.DB
 }
 
-func NewRepo(queries *database.Queries) *Repo {
+func NewRepo(queries *database.Queries, db *sql.DB) *Repo {
 	return &Repo{
 		queries: queries,
+		db: db,
 	}
 }
 
+// withTx returns queries bound to tx, or the default queries when tx is nil
+func (r *Repo) withTx(tx *sql.Tx) *database.Queries {
+	if tx != nil {
+		return r.queries.WithTx(tx)
+	}
+	return r.queries
+}
+
 func (r *Repo) GetTicket(ctx context.Context, eventID uuid.UUID, ticketID uuid.UUID) (types.Ticket, error) {
 	dbTicket, err := r.queries.GetTicket(ctx, database.GetTicketParams{
 		EventID: eventID,
@@ -43,15 +51,7 @@ func (r *Repo) GetTicketsForEvent(ctx context.Context, eventID uuid.UUID) ([]typ
 }
 
 func (r *Repo) CreateTicketsForEvent(ctx context.Context, eventID uuid.UUID, ticketTypeIDs []uuid.UUID, tx *sql.Tx) ([]types.Ticket, error) {
-	
-	var queries *database.Queries
-	if tx != nil {
-		queries = r.queries.WithTx(tx)
-	} else {
-		queries = r.queries
-	}
-	
-	dbTickets, err := queries.BatchCreateTickets(ctx,
+	dbTickets, err := r.withTx(tx).BatchCreateTickets(ctx,
 		database.BatchCreateTicketsParams{
 			Column1: eventID,
 			Column2: ticketTypeIDs,
@@ -62,22 +62,140 @@ func (r *Repo) CreateTicketsForEvent(ctx context.Context, eventID uuid.UUID, tic
 	return mappers.ToTickets(dbTickets), nil
 }
 
+// CreateSeatedTickets issues one ticket of a ticket type for each seat
+func (r *Repo) CreateSeatedTickets(ctx context.Context, eventID uuid.UUID, ticketTypeID uuid.UUID, seatIDs []uuid.UUID, tx *sql.Tx) ([]types.Ticket, error) {
+	dbTickets, err := r.withTx(tx).BatchCreateSeatedTickets(ctx,
+		database.BatchCreateSeatedTicketsParams{
+			Column1: eventID,
+			Column2: ticketTypeID,
+			Column3: seatIDs,
+		})
+	if err != nil {
+		return nil, err
+	}
+	return mappers.ToTickets(dbTickets), nil
+}
 
-func (r *Repo) getTicketTypeID(ctx context.Context, ticketTypeName string) (uuid.UUID, error) {
-	if r.ticketTypes == nil {
-		dbTicketTypes, err := r.queries.GetTicketTypes(ctx)
-		if err != nil {
-			return uuid.Nil, err
-		}
-		r.ticketTypes = make(map[string]uuid.UUID)
-		for _, tt := range dbTicketTypes {
-			r.ticketTypes[tt.Name] = tt.ID
-		}
+// ListSeatsInSections returns the IDs of the seats in the named sections of
+// a venue's seat map, and the names of the sections that were found
+func (r *Repo) ListSeatsInSections(ctx context.Context, venueID uuid.UUID, sections []string, tx *sql.Tx) ([]uuid.UUID, map[string]bool, error) {
+	dbSeats, err := r.withTx(tx).ListSeatsInSections(ctx, database.ListSeatsInSectionsParams{
+		VenueID: venueID,
+		Column2: sections,
+	})
+	if err != nil {
+		return nil, nil, err
 	}
+	seatIDs := make([]uuid.UUID, len(dbSeats))
+	found := make(map[string]bool)
+	for i, dbSeat := range dbSeats {
+		seatIDs[i] = dbSeat.ID
+		found[dbSeat.SectionName] = true
+	}
+	return seatIDs, found, nil
+}
+
+func (r *Repo) CreateTicketType(ctx context.Context, eventID uuid.UUID, req types.CreateTicketTypeRequest, tx *sql.Tx) (types.TicketType, error) {
+	dbTicketType, err := r.withTx(tx).CreateTicketType(ctx, database.CreateTicketTypeParams{
+		EventID:     eventID,
+		Name:        req.Name,
+		DisplayName: req.DisplayName,
+		PriceCents:  req.PriceCents,
+		Quantity:    req.Quantity,
+		MaxPerOrder: toNullInt32(req.MaxPerOrder),
+	})
+	if err != nil {
+		return types.TicketType{}, err
+	}
+	return mappers.ToTicketType(dbTicketType), nil
+}
 
-	id, ok := r.ticketTypes[ticketTypeName]
-	if !ok {
-		return uuid.Nil, fmt.Errorf("ticket type %s not found", ticketTypeName)
+func (r *Repo) GetTicketType(ctx context.Context, eventID uuid.UUID, id uuid.UUID, tx *sql.Tx) (types.TicketType, error) {
+	dbTicketType, err := r.withTx(tx).GetTicketType(ctx, database.GetTicketTypeParams{
+		EventID: eventID,
+		ID:      id,
+	})
+	if err != nil {
+		return types.TicketType{}, err
 	}
-	return id, nil
-}
\ No newline at end of file
+	return mappers.ToTicketType(dbTicketType), nil
+}
+
+func (r *Repo) ListTicketTypes(ctx context.Context, eventID uuid.UUID) ([]types.TicketType, error) {
+	dbTicketTypes, err := r.queries.ListEventTicketTypes(ctx, eventID)
+	if err != nil {
+		return nil, err
+	}
+	return mappers.ToTicketTypes(dbTicketTypes), nil
+}
+
+func (r *Repo) UpdateTicketType(ctx context.Context, eventID uuid.UUID, id uuid.UUID, req types.UpdateTicketTypeRequest, tx *sql.Tx) (types.TicketType, error) {
+	dbTicketType, err := r.withTx(tx).UpdateTicketType(ctx, database.UpdateTicketTypeParams{
+		EventID:     eventID,
+		ID:          id,
+		Name:        req.Name,
+		DisplayName: req.DisplayName,
+		PriceCents:  req.PriceCents,
+		Quantity:    req.Quantity,
+		MaxPerOrder: toNullInt32(req.MaxPerOrder),
+	})
+	if err != nil {
+		return types.TicketType{}, err
+	}
+	return mappers.ToTicketType(dbTicketType), nil
+}
+
+// DeleteTicketType deletes a ticket type together with its tickets
+func (r *Repo) DeleteTicketType(ctx context.Context, eventID uuid.UUID, id uuid.UUID, tx *sql.Tx) error {
+	queries := r.withTx(tx)
+	if err := queries.DeleteTicketsForType(ctx, id); err != nil {
+		return err
+	}
+	return queries.DeleteTicketType(ctx, database.DeleteTicketTypeParams{
+		EventID: eventID,
+		ID:      id,
+	})
+}
+
+// GetTicketTypeInventory counts a ticket type's tickets, how many of them
+// were never sold and how many are for a seat
+func (r *Repo) GetTicketTypeInventory(ctx context.Context, id uuid.UUID, tx *sql.Tx) (database.GetTicketTypeInventoryRow, error) {
+	return r.withTx(tx).GetTicketTypeInventory(ctx, id)
+}
+
+// GetAvailability counts the events' tickets per ticket type by whether they
+// are available, held or sold. heldTicketIDs are the tickets currently held
+// in the booking service.
+func (r *Repo) GetAvailability(ctx context.Context, eventIDs []uuid.UUID, heldTicketIDs []uuid.UUID) ([]types.EventAvailability, error) {
+	rows, err := r.queries.GetTicketAvailability(ctx, database.GetTicketAvailabilityParams{
+		HeldTicketIds: heldTicketIDs,
+		EventIds:      eventIDs,
+	})
+	if err != nil {
+		return nil, err
+	}
+	return mappers.ToEventAvailabilities(eventIDs, rows), nil
+}
+
+// EnqueueEventIndexing records in the search outbox that the event's search
+// document must be rewritten
+func (r *Repo) EnqueueEventIndexing(ctx context.Context, eventID uuid.UUID, tx *sql.Tx) error {
+	return r.withTx(tx).EnqueueEventIndexing(ctx, eventID)
+}
+
+// DeleteUnsoldTickets deletes up to count unsold tickets of a ticket type,
+// skipping heldTicketIDs, and returns how many were deleted
+func (r *Repo) DeleteUnsoldTickets(ctx context.Context, ticketTypeID uuid.UUID, count int32, heldTicketIDs []uuid.UUID, tx *sql.Tx) (int64, error) {
+	return r.withTx(tx).DeleteAvailableTicketsForType(ctx, database.DeleteAvailableTicketsForTypeParams{
+		TicketTypeID:  ticketTypeID,
+		Limit:         count,
+		HeldTicketIds: heldTicketIDs,
+	})
+}
+
+func toNullInt32(v *int32) sql.NullInt32 {
+	if v == nil {
+		return sql.NullInt32{}
+	}
+	return sql.NullInt32{Int32: *v, Valid: true}
+}
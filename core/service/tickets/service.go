@@ -3,12 +3,28 @@ package tickets
 import (
 	"context"
 	"database/sql"
+	"errors"
+	"fmt"
+	"log"
+	"strings"
 
 	"github.com/google/uuid"
+	"github.com/lib/pq"
 
 	"github.com/ignisrex/tix/core/types"
 )
 
+// uniqueViolation is the Postgres error code for a duplicate key
+const uniqueViolation = "23505"
+
+var (
+	ErrTicketTypeNotFound = errors.New("ticket type not found")
+	ErrInvalidTicketType  = errors.New("invalid ticket type")
+	ErrTicketTypeExists   = errors.New("ticket type already exists for event")
+	ErrTicketTypeInUse    = errors.New("ticket type has sold tickets")
+	ErrSeatTaken          = errors.New("seat already has a ticket for event")
+)
+
 type Service struct {
 	repo *Repo
 }
@@ -24,36 +40,272 @@ func (s *Service) GetTicketsForEvent(ctx context.Context, eventID uuid.UUID) ([]
 	return s.repo.GetTicketsForEvent(ctx, eventID)
 }
 
-func (s *Service) CreateTicketsForEvent(ctx context.Context, eventID uuid.UUID, ticketAllocation types.TicketAllocation, tx *sql.Tx) ([]types.Ticket, error) {
+// CreateTicketsForEvent creates the event's ticket types and issues their
+// tickets within tx. Ticket types with sections get one ticket per seat in
+// those sections of the venue's seat map.
+func (s *Service) CreateTicketsForEvent(ctx context.Context, eventID uuid.UUID, venueID uuid.UUID, ticketTypes []types.CreateTicketTypeRequest, tx *sql.Tx) ([]types.TicketType, error) {
+	created := make([]types.TicketType, 0, len(ticketTypes))
+	for _, req := range ticketTypes {
+		ticketType, err := s.createTicketType(ctx, eventID, venueID, req, tx)
+		if err != nil {
+			return nil, err
+		}
+		created = append(created, ticketType)
+	}
+	return created, nil
+}
+
+func (s *Service) GetTicket(ctx context.Context, eventID uuid.UUID, ticketID uuid.UUID) (types.Ticket, error) {
+	return s.repo.GetTicket(ctx, eventID, ticketID)
+}
 
-	ticketTypeIDs := []uuid.UUID{}
-	vipTicketTypeID, err := s.repo.getTicketTypeID(ctx, "vip")
+func (s *Service) ListTicketTypes(ctx context.Context, eventID uuid.UUID) ([]types.TicketType, error) {
+	return s.repo.ListTicketTypes(ctx, eventID)
+}
+
+// GetAvailability summarizes the availability of each of the events, in the
+// order given. Events without tickets get an empty summary.
+func (s *Service) GetAvailability(ctx context.Context, eventIDs []uuid.UUID, heldTicketIDs []uuid.UUID) ([]types.EventAvailability, error) {
+	if heldTicketIDs == nil {
+		// A NULL array would match no ticket at all, not even as available
+		heldTicketIDs = []uuid.UUID{}
+	}
+	return s.repo.GetAvailability(ctx, eventIDs, heldTicketIDs)
+}
+
+func (s *Service) GetTicketType(ctx context.Context, eventID uuid.UUID, id uuid.UUID) (types.TicketType, error) {
+	ticketType, err := s.repo.GetTicketType(ctx, eventID, id, nil)
 	if err != nil {
-		return nil, err
+		if errors.Is(err, sql.ErrNoRows) {
+			return types.TicketType{}, fmt.Errorf("%w: %s", ErrTicketTypeNotFound, id)
+		}
+		return types.TicketType{}, err
 	}
-	for i := 0; i < ticketAllocation.VIP; i++ {
-		ticketTypeIDs = append(ticketTypeIDs, vipTicketTypeID)
+	return ticketType, nil
+}
+
+// CreateTicketType adds a ticket type to an existing event held at venueID
+// and issues its tickets
+func (s *Service) CreateTicketType(ctx context.Context, eventID uuid.UUID, venueID uuid.UUID, req types.CreateTicketTypeRequest) (types.TicketType, error) {
+	tx, err := s.repo.db.BeginTx(ctx, nil)
+	if err != nil {
+		return types.TicketType{}, err
 	}
+	defer tx.Rollback()
 
-	gaTicketTypeID, err := s.repo.getTicketTypeID(ctx, "ga")
+	ticketType, err := s.createTicketType(ctx, eventID, venueID, req, tx)
 	if err != nil {
-		return nil, err
+		return types.TicketType{}, err
 	}
-	for i := 0; i < ticketAllocation.GA; i++ {
-		ticketTypeIDs = append(ticketTypeIDs, gaTicketTypeID)
+	// Search documents list the event's ticket types and prices
+	if err := s.repo.EnqueueEventIndexing(ctx, eventID, tx); err != nil {
+		return types.TicketType{}, err
 	}
 
-	frontRowTicketTypeID, err := s.repo.getTicketTypeID(ctx, "front_row")
+	if err := tx.Commit(); err != nil {
+		return types.TicketType{}, err
+	}
+	return ticketType, nil
+}
+
+// UpdateTicketType replaces a ticket type. Raising the quantity issues new
+// tickets; lowering it deletes unsold tickets that are not in heldTicketIDs
+// and fails with ErrTicketTypeInUse when too few of them are left. The
+// quantity of seated ticket types is fixed by their seats, and the price of
+// types with sold tickets cannot change.
+func (s *Service) UpdateTicketType(ctx context.Context, eventID uuid.UUID, id uuid.UUID, req types.UpdateTicketTypeRequest, heldTicketIDs []uuid.UUID) (types.TicketType, error) {
+	normalized, err := normalizeTicketType(types.CreateTicketTypeRequest{
+		Name:        req.Name,
+		DisplayName: req.DisplayName,
+		PriceCents:  req.PriceCents,
+		Quantity:    req.Quantity,
+		MaxPerOrder: req.MaxPerOrder,
+	})
+	if err != nil {
+		return types.TicketType{}, err
+	}
+	req.Name, req.DisplayName = normalized.Name, normalized.DisplayName
+
+	tx, err := s.repo.db.BeginTx(ctx, nil)
+	if err != nil {
+		return types.TicketType{}, err
+	}
+	defer tx.Rollback()
+
+	current, err := s.repo.GetTicketType(ctx, eventID, id, tx)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return types.TicketType{}, fmt.Errorf("%w: %s", ErrTicketTypeNotFound, id)
+		}
+		return types.TicketType{}, err
+	}
+
+	if req.Quantity != current.Quantity || req.PriceCents != current.PriceCents {
+		inventory, err := s.repo.GetTicketTypeInventory(ctx, id, tx)
+		if err != nil {
+			return types.TicketType{}, err
+		}
+		if req.Quantity != current.Quantity && inventory.Seated > 0 {
+			return types.TicketType{}, fmt.Errorf("%w: the quantity of a seated ticket type is set by its seats", ErrInvalidTicketType)
+		}
+		// Refunds are priced from the ticket type, so the price is fixed once
+		// any ticket was sold
+		if req.PriceCents != current.PriceCents && inventory.Unsold != inventory.Total {
+			return types.TicketType{}, fmt.Errorf("%w: the price cannot change once tickets were sold", ErrTicketTypeInUse)
+		}
+	}
+
+	switch delta := req.Quantity - current.Quantity; {
+	case delta > 0:
+		if _, err := s.repo.CreateTicketsForEvent(ctx, eventID, repeatID(id, delta), tx); err != nil {
+			log.Printf("UpdateTicketType: failed to issue tickets for ticket type %s: %v", id, err)
+			return types.TicketType{}, err
+		}
+	case delta < 0:
+		if heldTicketIDs == nil {
+			// A NULL array would match no ticket, so nothing would be deleted
+			heldTicketIDs = []uuid.UUID{}
+		}
+		deleted, err := s.repo.DeleteUnsoldTickets(ctx, id, -delta, heldTicketIDs, tx)
+		if err != nil {
+			log.Printf("UpdateTicketType: failed to delete tickets for ticket type %s: %v", id, err)
+			return types.TicketType{}, err
+		}
+		if deleted < int64(-delta) {
+			return types.TicketType{}, fmt.Errorf("%w: only %d unsold tickets that are not held can be removed", ErrTicketTypeInUse, deleted)
+		}
+	}
+
+	ticketType, err := s.repo.UpdateTicketType(ctx, eventID, id, req, tx)
 	if err != nil {
-		return nil, err
+		return types.TicketType{}, ticketTypeError(err, req.Name)
 	}
-	for i := 0; i < ticketAllocation.FrontRow; i++ {
-		ticketTypeIDs = append(ticketTypeIDs, frontRowTicketTypeID)
+	if err := s.repo.EnqueueEventIndexing(ctx, eventID, tx); err != nil {
+		return types.TicketType{}, err
 	}
 
-	return s.repo.CreateTicketsForEvent(ctx, eventID, ticketTypeIDs, tx)
+	if err := tx.Commit(); err != nil {
+		return types.TicketType{}, err
+	}
+	return ticketType, nil
 }
 
-func (s *Service) GetTicket(ctx context.Context, eventID uuid.UUID, ticketID uuid.UUID) (types.Ticket, error) {
-	return s.repo.GetTicket(ctx, eventID, ticketID)
+// DeleteTicketType deletes a ticket type and its tickets. Types with tickets
+// that were ever sold cannot be deleted.
+func (s *Service) DeleteTicketType(ctx context.Context, eventID uuid.UUID, id uuid.UUID) error {
+	tx, err := s.repo.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if _, err := s.repo.GetTicketType(ctx, eventID, id, tx); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return fmt.Errorf("%w: %s", ErrTicketTypeNotFound, id)
+		}
+		return err
+	}
+
+	inventory, err := s.repo.GetTicketTypeInventory(ctx, id, tx)
+	if err != nil {
+		return err
+	}
+	if inventory.Unsold != inventory.Total {
+		return fmt.Errorf("%w: %d of %d tickets were sold", ErrTicketTypeInUse, inventory.Total-inventory.Unsold, inventory.Total)
+	}
+
+	if err := s.repo.DeleteTicketType(ctx, eventID, id, tx); err != nil {
+		log.Printf("DeleteTicketType: failed to delete ticket type %s: %v", id, err)
+		return err
+	}
+	if err := s.repo.EnqueueEventIndexing(ctx, eventID, tx); err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+func (s *Service) createTicketType(ctx context.Context, eventID uuid.UUID, venueID uuid.UUID, req types.CreateTicketTypeRequest, tx *sql.Tx) (types.TicketType, error) {
+	req, err := normalizeTicketType(req)
+	if err != nil {
+		return types.TicketType{}, err
+	}
+
+	var seatIDs []uuid.UUID
+	if len(req.Sections) > 0 {
+		var found map[string]bool
+		seatIDs, found, err = s.repo.ListSeatsInSections(ctx, venueID, req.Sections, tx)
+		if err != nil {
+			return types.TicketType{}, err
+		}
+		for _, section := range req.Sections {
+			if !found[section] {
+				return types.TicketType{}, fmt.Errorf("%w: section %q is not in the venue's seat map", ErrInvalidTicketType, section)
+			}
+		}
+		req.Quantity = int32(len(seatIDs))
+	}
+
+	ticketType, err := s.repo.CreateTicketType(ctx, eventID, req, tx)
+	if err != nil {
+		return types.TicketType{}, ticketTypeError(err, req.Name)
+	}
+
+	if len(seatIDs) > 0 {
+		if _, err := s.repo.CreateSeatedTickets(ctx, eventID, ticketType.ID, seatIDs, tx); err != nil {
+			log.Printf("createTicketType: failed to issue seated tickets for ticket type %s: %v", ticketType.Name, err)
+			return types.TicketType{}, ticketTypeError(err, req.Name)
+		}
+	} else if req.Quantity > 0 {
+		if _, err := s.repo.CreateTicketsForEvent(ctx, eventID, repeatID(ticketType.ID, req.Quantity), tx); err != nil {
+			log.Printf("createTicketType: failed to issue tickets for ticket type %s: %v", ticketType.Name, err)
+			return types.TicketType{}, err
+		}
+	}
+	return ticketType, nil
+}
+
+// normalizeTicketType trims names, defaults the display name to the name and
+// rejects negative prices, quantities and per-order limits
+func normalizeTicketType(req types.CreateTicketTypeRequest) (types.CreateTicketTypeRequest, error) {
+	for i, section := range req.Sections {
+		req.Sections[i] = strings.TrimSpace(section)
+	}
+	req.Name = strings.TrimSpace(req.Name)
+	req.DisplayName = strings.TrimSpace(req.DisplayName)
+	if req.Name == "" {
+		return req, fmt.Errorf("%w: name is required", ErrInvalidTicketType)
+	}
+	if req.DisplayName == "" {
+		req.DisplayName = req.Name
+	}
+	if req.PriceCents < 0 {
+		return req, fmt.Errorf("%w: price_cents cannot be negative", ErrInvalidTicketType)
+	}
+	if req.Quantity < 0 {
+		return req, fmt.Errorf("%w: quantity cannot be negative", ErrInvalidTicketType)
+	}
+	if req.MaxPerOrder != nil && *req.MaxPerOrder <= 0 {
+		return req, fmt.Errorf("%w: max_per_order must be positive", ErrInvalidTicketType)
+	}
+	return req, nil
+}
+
+func ticketTypeError(err error, name string) error {
+	var pqErr *pq.Error
+	if errors.As(err, &pqErr) && pqErr.Code == uniqueViolation {
+		if pqErr.Constraint == "idx_tickets_event_id_seat_id" {
+			return fmt.Errorf("%w: sections of %s overlap another ticket type", ErrSeatTaken, name)
+		}
+		return fmt.Errorf("%w: %s", ErrTicketTypeExists, name)
+	}
+	return err
+}
+
+func repeatID(id uuid.UUID, n int32) []uuid.UUID {
+	ids := make([]uuid.UUID, n)
+	for i := range ids {
+		ids[i] = id
+	}
+	return ids
 }
@@ -1,11 +1,14 @@
 package venues
 
 import (
+	"database/sql"
+	"errors"
 	"fmt"
 	"net/http"
 
 	"github.com/go-chi/chi/v5"
 	"github.com/google/uuid"
+	"github.com/ignisrex/tix/auth"
 	"github.com/ignisrex/tix/core/internal/database"
 	"github.com/ignisrex/tix/core/internal/utils"
 	"github.com/ignisrex/tix/core/types"
@@ -15,8 +18,8 @@ type Handler struct {
 	service *Service
 }
 
-func NewHandler(queries *database.Queries) *Handler {
-	repo := NewRepo(queries)
+func NewHandler(queries *database.Queries, db *sql.DB) *Handler {
+	repo := NewRepo(queries, db)
 	service := NewService(repo)
 	return &Handler{
 		service: service,
@@ -26,10 +29,12 @@ func NewHandler(queries *database.Queries) *Handler {
 func (h *Handler) RegisterRoutes(r chi.Router) {
 	r.Route("/venues", func(r chi.Router) {
 		r.Get("/", h.GetVenues)
-		r.Post("/", h.CreateVenue)
+		r.With(auth.RequirePermission(auth.PermManageVenues)).Post("/", h.CreateVenue)
 		r.Get("/{id}", h.GetVenue)
-		r.Put("/{id}", h.UpdateVenue)
-		r.Delete("/{id}", h.DeleteVenue)
+		r.With(auth.RequirePermission(auth.PermManageVenues)).Put("/{id}", h.UpdateVenue)
+		r.With(auth.RequirePermission(auth.PermManageVenues)).Delete("/{id}", h.DeleteVenue)
+		r.Get("/{id}/seat-map", h.GetSeatMap)
+		r.With(auth.RequirePermission(auth.PermManageVenues)).Put("/{id}/seat-map", h.ReplaceSeatMap)
 	})
 }
 
@@ -51,7 +56,7 @@ func (h *Handler) CreateVenue(w http.ResponseWriter, r *http.Request) {
 
 	venue, err := h.service.CreateVenue(r.Context(), req)
 	if err != nil {
-		utils.WriteError(w, http.StatusInternalServerError, fmt.Errorf("failed to create venue: %w", err))
+		utils.WriteError(w, venueErrorStatus(err), fmt.Errorf("failed to create venue: %w", err))
 		return
 	}
 	utils.WriteJSON(w, http.StatusCreated, venue)
@@ -77,7 +82,7 @@ func (h *Handler) UpdateVenue(w http.ResponseWriter, r *http.Request) {
 
 	venue, err := h.service.UpdateVenue(r.Context(), uuid.MustParse(id), req)
 	if err != nil {
-		utils.WriteError(w, http.StatusInternalServerError, fmt.Errorf("failed to update venue: %w", err))
+		utils.WriteError(w, venueErrorStatus(err), fmt.Errorf("failed to update venue: %w", err))
 		return
 	}
 	utils.WriteJSON(w, http.StatusOK, venue)
@@ -86,9 +91,54 @@ func (h *Handler) UpdateVenue(w http.ResponseWriter, r *http.Request) {
 func (h *Handler) DeleteVenue(w http.ResponseWriter, r *http.Request) {
 	id := chi.URLParam(r, "id")
 	if err := h.service.DeleteVenue(r.Context(), uuid.MustParse(id)); err != nil {
-		utils.WriteError(w, http.StatusInternalServerError, fmt.Errorf("failed to delete venue: %w", err))
+		utils.WriteError(w, auth.ErrorStatus(err, http.StatusInternalServerError), fmt.Errorf("failed to delete venue: %w", err))
 		return
 	}
 	utils.WriteJSON(w, http.StatusOK, fmt.Sprintf("venue deleted successfully with id: %v", id))
 }
 
+func (h *Handler) GetSeatMap(w http.ResponseWriter, r *http.Request) {
+	id, err := uuid.Parse(chi.URLParam(r, "id"))
+	if err != nil {
+		utils.WriteError(w, http.StatusBadRequest, fmt.Errorf("invalid venue id: %w", err))
+		return
+	}
+	seatMap, err := h.service.GetSeatMap(r.Context(), id)
+	if err != nil {
+		utils.WriteError(w, venueErrorStatus(err), fmt.Errorf("failed to get seat map: %w", err))
+		return
+	}
+	utils.WriteJSON(w, http.StatusOK, seatMap)
+}
+
+func (h *Handler) ReplaceSeatMap(w http.ResponseWriter, r *http.Request) {
+	id, err := uuid.Parse(chi.URLParam(r, "id"))
+	if err != nil {
+		utils.WriteError(w, http.StatusBadRequest, fmt.Errorf("invalid venue id: %w", err))
+		return
+	}
+	var seatMap types.SeatMap
+	if err := utils.ParseJSON(r, &seatMap); err != nil {
+		utils.WriteError(w, http.StatusBadRequest, fmt.Errorf("failed to parse seat map request body: %w", err))
+		return
+	}
+
+	seatMap, err = h.service.ReplaceSeatMap(r.Context(), id, seatMap)
+	if err != nil {
+		utils.WriteError(w, venueErrorStatus(err), fmt.Errorf("failed to replace seat map: %w", err))
+		return
+	}
+	utils.WriteJSON(w, http.StatusOK, seatMap)
+}
+
+func venueErrorStatus(err error) int {
+	switch {
+	case errors.Is(err, ErrVenueNotFound):
+		return http.StatusNotFound
+	case errors.Is(err, ErrInvalidSeatMap):
+		return http.StatusBadRequest
+	case errors.Is(err, ErrSeatMapInUse):
+		return http.StatusConflict
+	}
+	return auth.ErrorStatus(err, http.StatusInternalServerError)
+}
@@ -2,6 +2,7 @@ package venues
 
 import (
 	"context"
+	"database/sql"
 
 	"github.com/google/uuid"
 
@@ -12,14 +13,24 @@ import (
 
 type Repo struct {
 	queries *database.Queries
+	db *sql.DB
 }
 
-func NewRepo(queries *database.Queries) *Repo {
+func NewRepo(queries *database.Queries, db *sql.DB) *Repo {
 	return &Repo{
 		queries: queries,
+		db: db,
 	}
 }
 
+// withTx returns queries bound to tx, or the default queries when tx is nil
+func (r *Repo) withTx(tx *sql.Tx) *database.Queries {
+	if tx != nil {
+		return r.queries.WithTx(tx)
+	}
+	return r.queries
+}
+
 func (r *Repo) GetVenues(ctx context.Context) ([]types.Venue, error) {
 	dbVenues, err := r.queries.GetVenues(ctx, database.GetVenuesParams{
 		Limit:  50, // TODO: make configurable
@@ -31,8 +42,8 @@ func (r *Repo) GetVenues(ctx context.Context) ([]types.Venue, error) {
 	return mappers.ToVenues(dbVenues), nil
 }
 
-func (r *Repo) CreateVenue(ctx context.Context, venue types.CreateVenueRequest) (types.Venue, error) {
-	dbVenue, err := r.queries.CreateVenue(ctx, database.CreateVenueParams{
+func (r *Repo) CreateVenue(ctx context.Context, venue types.CreateVenueRequest, tx *sql.Tx) (types.Venue, error) {
+	dbVenue, err := r.withTx(tx).CreateVenue(ctx, database.CreateVenueParams{
 		Name:     venue.Name,
 		Location: venue.Location,
 	})
@@ -50,8 +61,8 @@ func (r *Repo) GetVenue(ctx context.Context, id uuid.UUID) (types.Venue, error)
 	return mappers.ToVenue(dbVenue), nil
 }
 
-func (r *Repo) UpdateVenue(ctx context.Context, id uuid.UUID, venue types.UpdateVenueRequest) (types.Venue, error) {
-	dbVenue, err := r.queries.UpdateVenue(ctx, database.UpdateVenueParams{
+func (r *Repo) UpdateVenue(ctx context.Context, id uuid.UUID, venue types.UpdateVenueRequest, tx *sql.Tx) (types.Venue, error) {
+	dbVenue, err := r.withTx(tx).UpdateVenue(ctx, database.UpdateVenueParams{
 		ID:       id,
 		Name:     venue.Name,
 		Location: venue.Location,
@@ -62,7 +73,57 @@ func (r *Repo) UpdateVenue(ctx context.Context, id uuid.UUID, venue types.Update
 	return mappers.ToVenue(dbVenue), nil
 }
 
+// EnqueueEventIndexing records in the search outbox that the search
+// documents of the venue's events must be rewritten
+func (r *Repo) EnqueueEventIndexing(ctx context.Context, venueID uuid.UUID, tx *sql.Tx) error {
+	return r.withTx(tx).EnqueueVenueEventsIndexing(ctx, venueID)
+}
+
 func (r *Repo) DeleteVenue(ctx context.Context, id uuid.UUID) error {
 	return r.queries.DeleteVenue(ctx, id)
 }
 
+func (r *Repo) GetSeatMap(ctx context.Context, venueID uuid.UUID) (types.SeatMap, error) {
+	dbSeats, err := r.queries.ListVenueSeats(ctx, venueID)
+	if err != nil {
+		return types.SeatMap{}, err
+	}
+	return mappers.ToSeatMap(venueID, dbSeats), nil
+}
+
+// ReplaceSeatMap deletes the venue's seat map and stores seatMap in its
+// place. Rows are indexed in the order they are listed in their section.
+func (r *Repo) ReplaceSeatMap(ctx context.Context, venueID uuid.UUID, seatMap types.SeatMap, tx *sql.Tx) error {
+	queries := r.withTx(tx)
+	if err := queries.DeleteVenueSeatMap(ctx, venueID); err != nil {
+		return err
+	}
+
+	for position, section := range seatMap.Sections {
+		dbSection, err := queries.CreateVenueSection(ctx, database.CreateVenueSectionParams{
+			VenueID:  venueID,
+			Name:     section.Name,
+			Position: int32(position),
+		})
+		if err != nil {
+			return err
+		}
+
+		params := database.BatchCreateVenueSeatsParams{Column1: dbSection.ID}
+		for rowIndex, row := range section.Rows {
+			for _, seat := range row.Seats {
+				params.Column2 = append(params.Column2, row.Label)
+				params.Column3 = append(params.Column3, int32(rowIndex))
+				params.Column4 = append(params.Column4, seat.Number)
+				params.Column5 = append(params.Column5, seat.X)
+				params.Column6 = append(params.Column6, seat.Y)
+				params.Column7 = append(params.Column7, seat.Accessible)
+				params.Column8 = append(params.Column8, seat.Companion)
+			}
+		}
+		if err := queries.BatchCreateVenueSeats(ctx, params); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -2,12 +2,29 @@ package venues
 
 import (
 	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"log"
+	"strings"
 
 	"github.com/google/uuid"
+	"github.com/lib/pq"
 
+	"github.com/ignisrex/tix/auth"
 	"github.com/ignisrex/tix/core/types"
 )
 
+// foreignKeyViolation is the Postgres error code for a row that is still
+// referenced by another table
+const foreignKeyViolation = "23503"
+
+var (
+	ErrVenueNotFound  = errors.New("venue not found")
+	ErrInvalidSeatMap = errors.New("invalid seat map")
+	ErrSeatMapInUse   = errors.New("seat map has tickets issued for its seats")
+)
+
 type Service struct {
 	repo *Repo
 }
@@ -22,19 +39,187 @@ func (s *Service) GetVenues(ctx context.Context) ([]types.Venue, error) {
 	return s.repo.GetVenues(ctx)
 }
 
+// CreateVenue creates a venue together with its seat map, if one is given
 func (s *Service) CreateVenue(ctx context.Context, venue types.CreateVenueRequest) (types.Venue, error) {
-	return s.repo.CreateVenue(ctx, venue)
+	if _, err := auth.Authorize(ctx, auth.PermManageVenues); err != nil {
+		return types.Venue{}, err
+	}
+	if venue.SeatMap != nil {
+		if err := validateSeatMap(venue.SeatMap); err != nil {
+			return types.Venue{}, err
+		}
+	}
+
+	tx, err := s.repo.db.BeginTx(ctx, nil)
+	if err != nil {
+		return types.Venue{}, err
+	}
+	defer tx.Rollback()
+
+	created, err := s.repo.CreateVenue(ctx, venue, tx)
+	if err != nil {
+		return types.Venue{}, err
+	}
+	if venue.SeatMap != nil {
+		if err := s.repo.ReplaceSeatMap(ctx, created.ID, *venue.SeatMap, tx); err != nil {
+			log.Printf("CreateVenue: failed to store seat map: %v", err)
+			return types.Venue{}, err
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return types.Venue{}, err
+	}
+	return created, nil
 }
 
 func (s *Service) GetVenue(ctx context.Context, id uuid.UUID) (types.Venue, error) {
 	return s.repo.GetVenue(ctx, id)
 }
 
+// UpdateVenue updates a venue and replaces its seat map when one is given
 func (s *Service) UpdateVenue(ctx context.Context, id uuid.UUID, venue types.UpdateVenueRequest) (types.Venue, error) {
-	return s.repo.UpdateVenue(ctx, id, venue)
+	if _, err := auth.Authorize(ctx, auth.PermManageVenues); err != nil {
+		return types.Venue{}, err
+	}
+	if venue.SeatMap != nil {
+		if err := validateSeatMap(venue.SeatMap); err != nil {
+			return types.Venue{}, err
+		}
+	}
+
+	tx, err := s.repo.db.BeginTx(ctx, nil)
+	if err != nil {
+		return types.Venue{}, err
+	}
+	defer tx.Rollback()
+
+	updated, err := s.repo.UpdateVenue(ctx, id, venue, tx)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return types.Venue{}, fmt.Errorf("%w: %s", ErrVenueNotFound, id)
+		}
+		return types.Venue{}, err
+	}
+	if venue.SeatMap != nil {
+		if err := s.replaceSeatMap(ctx, id, *venue.SeatMap, tx); err != nil {
+			return types.Venue{}, err
+		}
+	}
+	// Indexed events carry the venue's name and location
+	if err := s.repo.EnqueueEventIndexing(ctx, id, tx); err != nil {
+		return types.Venue{}, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return types.Venue{}, err
+	}
+	return updated, nil
 }
 
 func (s *Service) DeleteVenue(ctx context.Context, id uuid.UUID) error {
+	if _, err := auth.Authorize(ctx, auth.PermManageVenues); err != nil {
+		return err
+	}
 	return s.repo.DeleteVenue(ctx, id)
 }
 
+// GetSeatMap returns the venue's seat map. Venues without seating have a
+// seat map with no sections.
+func (s *Service) GetSeatMap(ctx context.Context, venueID uuid.UUID) (types.SeatMap, error) {
+	if _, err := s.repo.GetVenue(ctx, venueID); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return types.SeatMap{}, fmt.Errorf("%w: %s", ErrVenueNotFound, venueID)
+		}
+		return types.SeatMap{}, err
+	}
+	return s.repo.GetSeatMap(ctx, venueID)
+}
+
+// ReplaceSeatMap replaces the venue's seat map. It fails with ErrSeatMapInUse
+// once tickets have been issued for any of the current seats.
+func (s *Service) ReplaceSeatMap(ctx context.Context, venueID uuid.UUID, seatMap types.SeatMap) (types.SeatMap, error) {
+	if _, err := auth.Authorize(ctx, auth.PermManageVenues); err != nil {
+		return types.SeatMap{}, err
+	}
+	if err := validateSeatMap(&seatMap); err != nil {
+		return types.SeatMap{}, err
+	}
+	if _, err := s.repo.GetVenue(ctx, venueID); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return types.SeatMap{}, fmt.Errorf("%w: %s", ErrVenueNotFound, venueID)
+		}
+		return types.SeatMap{}, err
+	}
+
+	tx, err := s.repo.db.BeginTx(ctx, nil)
+	if err != nil {
+		return types.SeatMap{}, err
+	}
+	defer tx.Rollback()
+
+	if err := s.replaceSeatMap(ctx, venueID, seatMap, tx); err != nil {
+		return types.SeatMap{}, err
+	}
+	if err := tx.Commit(); err != nil {
+		return types.SeatMap{}, err
+	}
+	return s.repo.GetSeatMap(ctx, venueID)
+}
+
+func (s *Service) replaceSeatMap(ctx context.Context, venueID uuid.UUID, seatMap types.SeatMap, tx *sql.Tx) error {
+	if err := s.repo.ReplaceSeatMap(ctx, venueID, seatMap, tx); err != nil {
+		var pqErr *pq.Error
+		if errors.As(err, &pqErr) && pqErr.Code == foreignKeyViolation {
+			return fmt.Errorf("%w: %s", ErrSeatMapInUse, venueID)
+		}
+		log.Printf("replaceSeatMap: failed to store seat map for venue %s: %v", venueID, err)
+		return err
+	}
+	return nil
+}
+
+// validateSeatMap trims names and labels and checks that sections, rows and
+// seats are non-empty and uniquely named within their parent
+func validateSeatMap(seatMap *types.SeatMap) error {
+	sectionNames := make(map[string]bool, len(seatMap.Sections))
+	for i := range seatMap.Sections {
+		section := &seatMap.Sections[i]
+		section.Name = strings.TrimSpace(section.Name)
+		if section.Name == "" {
+			return fmt.Errorf("%w: section %d has no name", ErrInvalidSeatMap, i+1)
+		}
+		if sectionNames[section.Name] {
+			return fmt.Errorf("%w: duplicate section %q", ErrInvalidSeatMap, section.Name)
+		}
+		sectionNames[section.Name] = true
+		if len(section.Rows) == 0 {
+			return fmt.Errorf("%w: section %q has no rows", ErrInvalidSeatMap, section.Name)
+		}
+
+		rowLabels := make(map[string]bool, len(section.Rows))
+		for j := range section.Rows {
+			row := &section.Rows[j]
+			row.Label = strings.TrimSpace(row.Label)
+			if row.Label == "" {
+				return fmt.Errorf("%w: row %d in section %q has no label", ErrInvalidSeatMap, j+1, section.Name)
+			}
+			if rowLabels[row.Label] {
+				return fmt.Errorf("%w: duplicate row %q in section %q", ErrInvalidSeatMap, row.Label, section.Name)
+			}
+			rowLabels[row.Label] = true
+			if len(row.Seats) == 0 {
+				return fmt.Errorf("%w: row %q in section %q has no seats", ErrInvalidSeatMap, row.Label, section.Name)
+			}
+
+			seatNumbers := make(map[int32]bool, len(row.Seats))
+			for _, seat := range row.Seats {
+				if seatNumbers[seat.Number] {
+					return fmt.Errorf("%w: duplicate seat %d in row %q of section %q", ErrInvalidSeatMap, seat.Number, row.Label, section.Name)
+				}
+				seatNumbers[seat.Number] = true
+			}
+		}
+	}
+	return nil
+}
@@ -0,0 +1,31 @@
+-- name: CreateCustomer :one
+INSERT INTO customers (id, email, name)
+VALUES ($1, $2, $3)
+RETURNING *;
+
+-- name: GetCustomer :one
+SELECT * FROM customers
+WHERE id = $1;
+
+-- name: GetCustomerByEmail :one
+SELECT * FROM customers
+WHERE email = $1;
+
+-- name: ListCustomerPurchases :many
+SELECT
+    p.id,
+    p.total_cents,
+    p.status,
+    p.created_at,
+    COUNT(t.id) AS ticket_count
+FROM purchases p
+LEFT JOIN tickets t ON t.purchase_id = p.id
+WHERE p.customer_id = $1
+GROUP BY p.id
+ORDER BY p.created_at DESC, p.id
+LIMIT $2
+OFFSET $3;
+
+-- name: CountCustomerPurchases :one
+SELECT COUNT(*) FROM purchases
+WHERE customer_id = $1;
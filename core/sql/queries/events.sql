@@ -1,6 +1,6 @@
 -- name: CreateEvent :one
-INSERT INTO events (title, description, start_date, venue_id)
-VALUES ($1, $2, $3, $4)
+INSERT INTO events (title, description, start_date, venue_id, organizer_id, category, performers)
+VALUES ($1, $2, $3, $4, $5, $6, $7)
 RETURNING *;
 
 -- name: GetEvent :one
@@ -14,9 +14,17 @@ LIMIT $1
 OFFSET $2;
 
 -- name: UpdateEvent :one
-UPDATE events SET title = $2, description = $3, start_date = $4, venue_id = $5
+UPDATE events SET title = $2, description = $3, start_date = $4, venue_id = $5, category = $6, performers = $7
 WHERE id = $1
 RETURNING *;
 
 -- name: DeleteEvent :exec
-DELETE FROM events WHERE id = $1;
\ No newline at end of file
+DELETE FROM events WHERE id = $1;
+-- Pages through every event with its venue for a full reindex, in ID order.
+-- name: ListEventsForIndexing :many
+SELECT e.*, v.name AS venue_name, v.location AS venue_location
+FROM events e
+JOIN venues v ON v.id = e.venue_id
+WHERE e.id > sqlc.arg(after_id)
+ORDER BY e.id
+LIMIT sqlc.arg(batch_size);
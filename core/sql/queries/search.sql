@@ -0,0 +1,105 @@
+-- Full-text search over events for when Elasticsearch is unavailable. Takes
+-- the search service's filters and sort orders; an empty query matches
+-- every event. Venue names and locations are matched too: events and venues
+-- are searched in two branches, each through its own index. The total is
+-- counted by CountSearchEvents, so it is right at any offset.
+-- name: SearchEvents :many
+SELECT e.id, e.title, e.description, e.start_date, e.venue_id, e.created_at, e.category, e.performers,
+    v.name AS venue_name, v.location AS venue_location,
+    tt.min_price_cents, tt.max_price_cents, tt.ticket_types
+FROM events e
+JOIN venues v ON v.id = e.venue_id
+LEFT JOIN LATERAL (
+    SELECT MIN(price_cents) AS min_price_cents, MAX(price_cents) AS max_price_cents,
+        array_agg(name::text ORDER BY price_cents DESC, name) AS ticket_types
+    FROM ticket_types
+    WHERE event_id = e.id
+) tt ON true
+WHERE (sqlc.arg(query)::text = '' OR e.id IN (
+        SELECT me.id FROM events me
+        WHERE event_search_document(me.title, me.description, me.performers) @@ websearch_to_tsquery('english', sqlc.arg(query)::text)
+        UNION
+        SELECT ve.id FROM venues mv
+        JOIN events ve ON ve.venue_id = mv.id
+        WHERE to_tsvector('simple', mv.name || ' ' || mv.location) @@ websearch_to_tsquery('simple', sqlc.arg(query)::text)
+    ))
+    AND e.start_date >= sqlc.arg(start_from)
+    AND (sqlc.narg(start_to)::timestamp IS NULL OR e.start_date <= sqlc.narg(start_to)::timestamp)
+    AND (cardinality(sqlc.arg(venue_ids)::uuid[]) = 0 OR e.venue_id = ANY(sqlc.arg(venue_ids)::uuid[]))
+    AND (sqlc.arg(location)::text = '' OR to_tsvector('simple', v.location) @@ plainto_tsquery('simple', sqlc.arg(location)::text))
+    AND (cardinality(sqlc.arg(categories)::text[]) = 0 OR e.category = ANY(sqlc.arg(categories)::text[]))
+    AND (cardinality(sqlc.arg(ticket_type_names)::text[]) = 0 OR tt.ticket_types && sqlc.arg(ticket_type_names)::text[])
+    AND ((sqlc.narg(min_price_cents)::int IS NULL AND sqlc.narg(max_price_cents)::int IS NULL)
+        OR EXISTS (
+            SELECT 1 FROM ticket_types p
+            WHERE p.event_id = e.id
+                AND (sqlc.narg(min_price_cents)::int IS NULL OR p.price_cents >= sqlc.narg(min_price_cents)::int)
+                AND (sqlc.narg(max_price_cents)::int IS NULL OR p.price_cents <= sqlc.narg(max_price_cents)::int)
+        ))
+ORDER BY
+    CASE WHEN sqlc.arg(sort)::text = 'relevance' THEN ts_rank(event_search_document(e.title, e.description, e.performers), websearch_to_tsquery('english', sqlc.arg(query)::text)) END DESC,
+    CASE WHEN sqlc.arg(sort)::text = 'price' THEN tt.min_price_cents END ASC NULLS LAST,
+    e.start_date, e.id
+LIMIT sqlc.arg(result_limit)
+OFFSET sqlc.arg(result_offset);
+
+-- Counts the events SearchEvents matches, with the same filters.
+-- name: CountSearchEvents :one
+SELECT COUNT(*) AS total
+FROM events e
+JOIN venues v ON v.id = e.venue_id
+LEFT JOIN LATERAL (
+    SELECT MIN(price_cents) AS min_price_cents, MAX(price_cents) AS max_price_cents,
+        array_agg(name::text ORDER BY price_cents DESC, name) AS ticket_types
+    FROM ticket_types
+    WHERE event_id = e.id
+) tt ON true
+WHERE (sqlc.arg(query)::text = '' OR e.id IN (
+        SELECT me.id FROM events me
+        WHERE event_search_document(me.title, me.description, me.performers) @@ websearch_to_tsquery('english', sqlc.arg(query)::text)
+        UNION
+        SELECT ve.id FROM venues mv
+        JOIN events ve ON ve.venue_id = mv.id
+        WHERE to_tsvector('simple', mv.name || ' ' || mv.location) @@ websearch_to_tsquery('simple', sqlc.arg(query)::text)
+    ))
+    AND e.start_date >= sqlc.arg(start_from)
+    AND (sqlc.narg(start_to)::timestamp IS NULL OR e.start_date <= sqlc.narg(start_to)::timestamp)
+    AND (cardinality(sqlc.arg(venue_ids)::uuid[]) = 0 OR e.venue_id = ANY(sqlc.arg(venue_ids)::uuid[]))
+    AND (sqlc.arg(location)::text = '' OR to_tsvector('simple', v.location) @@ plainto_tsquery('simple', sqlc.arg(location)::text))
+    AND (cardinality(sqlc.arg(categories)::text[]) = 0 OR e.category = ANY(sqlc.arg(categories)::text[]))
+    AND (cardinality(sqlc.arg(ticket_type_names)::text[]) = 0 OR tt.ticket_types && sqlc.arg(ticket_type_names)::text[])
+    AND ((sqlc.narg(min_price_cents)::int IS NULL AND sqlc.narg(max_price_cents)::int IS NULL)
+        OR EXISTS (
+            SELECT 1 FROM ticket_types p
+            WHERE p.event_id = e.id
+                AND (sqlc.narg(min_price_cents)::int IS NULL OR p.price_cents >= sqlc.narg(min_price_cents)::int)
+                AND (sqlc.narg(max_price_cents)::int IS NULL OR p.price_cents <= sqlc.narg(max_price_cents)::int)
+        ));
+
+-- Titles starting with a prefix, for suggestions when Elasticsearch is
+-- unavailable. The pattern is an escaped prefix followed by %. The
+-- suggestion queries are served by trigram indexes.
+-- name: SuggestEventTitles :many
+SELECT id, title, start_date FROM events
+WHERE title ILIKE sqlc.arg(pattern)
+ORDER BY title
+LIMIT sqlc.arg(result_limit);
+
+-- Events are first narrowed down through the index on all their performers,
+-- then each performer is matched.
+-- name: SuggestPerformers :many
+SELECT DISTINCT performer::text AS performer
+FROM events, unnest(performers) AS performer
+WHERE event_performers_text(performers) ILIKE '%' || sqlc.arg(pattern)::text
+    AND performer ILIKE sqlc.arg(pattern)::text
+ORDER BY performer
+LIMIT sqlc.arg(result_limit);
+
+-- Names of venues with events, like the venues suggested from the search
+-- index.
+-- name: SuggestVenueNames :many
+SELECT id, name FROM venues
+WHERE name ILIKE sqlc.arg(pattern)
+    AND EXISTS (SELECT 1 FROM events WHERE events.venue_id = venues.id)
+ORDER BY name
+LIMIT sqlc.arg(result_limit);
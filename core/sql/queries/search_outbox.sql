@@ -0,0 +1,43 @@
+-- name: EnqueueEventIndexing :exec
+INSERT INTO search_outbox (event_id)
+VALUES ($1);
+
+-- Queues every event at a venue, whose documents embed the venue's name and
+-- location.
+-- name: EnqueueVenueEventsIndexing :exec
+INSERT INTO search_outbox (event_id)
+SELECT id FROM events
+WHERE venue_id = $1;
+
+-- Claims due rows for lease_seconds, skipping rows other indexers are
+-- claiming, so several core instances can drain the outbox together.
+-- name: ClaimSearchOutbox :many
+UPDATE search_outbox
+SET available_at = NOW() + make_interval(secs => sqlc.arg(lease_seconds)::float8)
+WHERE id IN (
+    SELECT id FROM search_outbox
+    WHERE available_at <= NOW()
+    ORDER BY id
+    LIMIT sqlc.arg(batch_size)
+    FOR UPDATE SKIP LOCKED
+)
+RETURNING *;
+
+-- name: DeleteSearchOutboxEntries :exec
+DELETE FROM search_outbox
+WHERE id = ANY(sqlc.arg(ids)::bigint[]);
+
+-- name: RetrySearchOutboxEntries :exec
+UPDATE search_outbox
+SET attempts = attempts + 1,
+    last_error = sqlc.arg(last_error),
+    available_at = NOW() + make_interval(secs => sqlc.arg(backoff_seconds)::float8)
+WHERE id = ANY(sqlc.arg(ids)::bigint[]);
+
+-- The indexer applies each batch under a shared lock and a reindex takes the
+-- lock exclusively, so rows written during a reindex wait for the new index.
+-- name: TryLockSearchIndexShared :one
+SELECT pg_try_advisory_xact_lock_shared(hashtext('search_index'));
+
+-- name: LockSearchIndex :exec
+SELECT pg_advisory_xact_lock(hashtext('search_index'));
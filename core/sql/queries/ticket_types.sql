@@ -0,0 +1,28 @@
+-- name: CreateTicketType :one
+INSERT INTO ticket_types (event_id, name, display_name, price_cents, quantity, max_per_order)
+VALUES ($1, $2, $3, $4, $5, $6)
+RETURNING *;
+
+-- name: GetTicketType :one
+SELECT * FROM ticket_types
+WHERE event_id = $1 AND id = $2;
+
+-- name: ListEventTicketTypes :many
+SELECT * FROM ticket_types
+WHERE event_id = $1
+ORDER BY price_cents DESC, name;
+
+-- name: ListTicketTypesForEvents :many
+SELECT * FROM ticket_types
+WHERE event_id = ANY(sqlc.arg(event_ids)::uuid[])
+ORDER BY event_id, price_cents DESC, name;
+
+-- name: UpdateTicketType :one
+UPDATE ticket_types
+SET name = $3, display_name = $4, price_cents = $5, quantity = $6, max_per_order = $7
+WHERE event_id = $1 AND id = $2
+RETURNING *;
+
+-- name: DeleteTicketType :exec
+DELETE FROM ticket_types
+WHERE event_id = $1 AND id = $2;
@@ -6,6 +6,15 @@ SELECT
     'available'::ticket_status
 RETURNING id, event_id, ticket_type_id, status, created_at, updated_at;
 
+-- name: BatchCreateSeatedTickets :many
+INSERT INTO tickets (event_id, ticket_type_id, seat_id, status)
+SELECT
+    $1::uuid,
+    $2::uuid,
+    unnest($3::uuid[]),
+    'available'::ticket_status
+RETURNING id, event_id, ticket_type_id, status, created_at, updated_at;
+
 -- name: GetTicketsForEvent :many
 SELECT 
     id,
@@ -16,7 +25,12 @@ SELECT
     updated_at,
     ticket_type_name,
     ticket_type_display_name,
-    ticket_type_price_cents
+    ticket_type_price_cents,
+    seat_id,
+    section_name,
+    row_label,
+    seat_number,
+    seat_accessible
 FROM enriched_tickets
 WHERE event_id = $1
 ORDER BY ticket_type_id, id;
@@ -31,10 +45,65 @@ SELECT
     updated_at,
     ticket_type_name,
     ticket_type_display_name,
-    ticket_type_price_cents
+    ticket_type_price_cents,
+    seat_id,
+    section_name,
+    row_label,
+    seat_number,
+    seat_accessible
 FROM enriched_tickets
 WHERE event_id = $1 AND id = $2;
 
+-- Counts the tickets of every ticket type of the events by availability.
+-- Held tickets come from the booking service's hold index; only unsold
+-- tickets count as held.
+-- name: GetTicketAvailability :many
+SELECT
+    event_id,
+    ticket_type_id,
+    ticket_type_name,
+    ticket_type_display_name,
+    ticket_type_price_cents,
+    COUNT(*) FILTER (
+        WHERE status = 'available' AND NOT id = ANY(sqlc.arg(held_ticket_ids)::uuid[])
+    ) AS available,
+    COUNT(*) FILTER (
+        WHERE status = 'available' AND id = ANY(sqlc.arg(held_ticket_ids)::uuid[])
+    ) AS held,
+    COUNT(*) FILTER (WHERE status = 'sold') AS sold
+FROM enriched_tickets
+WHERE event_id = ANY(sqlc.arg(event_ids)::uuid[])
+GROUP BY event_id, ticket_type_id, ticket_type_name, ticket_type_display_name, ticket_type_price_cents
+ORDER BY event_id, ticket_type_price_cents, ticket_type_name;
+
+-- Deletes up to $2 tickets of a type that were never sold, newest first, when
+-- a ticket type's quantity is reduced. Tickets returned to inventory by a
+-- refund are kept because refund_tickets references them, and tickets held
+-- in the booking service are kept so their holds can still be purchased.
+-- name: DeleteAvailableTicketsForType :execrows
+DELETE FROM tickets
+WHERE id IN (
+    SELECT t.id FROM tickets t
+    WHERE t.ticket_type_id = $1
+      AND t.status = 'available'
+      AND NOT EXISTS (SELECT 1 FROM refund_tickets rt WHERE rt.ticket_id = t.id)
+      AND NOT t.id = ANY(sqlc.arg(held_ticket_ids)::uuid[])
+    ORDER BY t.created_at DESC
+    LIMIT $2
+    FOR UPDATE SKIP LOCKED
+);
+
+-- name: DeleteTicketsForType :exec
+DELETE FROM tickets
+WHERE ticket_type_id = $1;
 
--- name: GetTicketTypes :many
-SELECT * FROM ticket_types;
\ No newline at end of file
+-- name: GetTicketTypeInventory :one
+SELECT
+    COUNT(*) AS total,
+    COUNT(*) FILTER (
+        WHERE t.status = 'available'
+          AND NOT EXISTS (SELECT 1 FROM refund_tickets rt WHERE rt.ticket_id = t.id)
+    ) AS unsold,
+    COUNT(t.seat_id) AS seated
+FROM tickets t
+WHERE t.ticket_type_id = $1;
\ No newline at end of file
@@ -23,3 +23,52 @@ RETURNING *;
 -- name: DeleteVenue :exec
 DELETE FROM venues
 WHERE id = $1;
+
+-- name: CreateVenueSection :one
+INSERT INTO venue_sections (venue_id, name, position)
+VALUES ($1, $2, $3)
+RETURNING *;
+
+-- name: BatchCreateVenueSeats :exec
+INSERT INTO venue_seats (section_id, row_label, row_index, seat_number, x, y, accessible, companion)
+SELECT
+    $1::uuid,
+    unnest($2::text[]),
+    unnest($3::int[]),
+    unnest($4::int[]),
+    unnest($5::float8[]),
+    unnest($6::float8[]),
+    unnest($7::bool[]),
+    unnest($8::bool[]);
+
+-- Sections are deleted with their seats. Fails with a foreign key violation
+-- when tickets were issued for any of the seats.
+-- name: DeleteVenueSeatMap :exec
+DELETE FROM venue_sections
+WHERE venue_id = $1;
+
+-- name: ListVenueSeats :many
+SELECT
+    vs.id AS section_id,
+    vs.name AS section_name,
+    s.id,
+    s.row_label,
+    s.row_index,
+    s.seat_number,
+    s.x,
+    s.y,
+    s.accessible,
+    s.companion
+FROM venue_sections vs
+JOIN venue_seats s ON s.section_id = vs.id
+WHERE vs.venue_id = $1
+ORDER BY vs.position, s.row_index, s.seat_number;
+
+-- name: ListSeatsInSections :many
+SELECT
+    s.id,
+    vs.name AS section_name
+FROM venue_seats s
+JOIN venue_sections vs ON s.section_id = vs.id
+WHERE vs.venue_id = $1 AND vs.name = ANY($2::text[])
+ORDER BY vs.position, s.row_index, s.seat_number;
@@ -1,7 +1,6 @@
 package types
 
 import (
-	"encoding/json"
 	"time"
 
 	"github.com/google/uuid"
@@ -11,6 +10,7 @@ type TicketStatus string
 const (
 	TicketStatusAvailable TicketStatus = "available"
 	TicketStatusSold      TicketStatus = "sold"
+	TicketStatusRefunded  TicketStatus = "refunded"
 )
 
 type Event struct {
@@ -19,6 +19,9 @@ type Event struct {
 	Description string    `json:"description" validate:"required"`
 	StartDate   time.Time `json:"start_date"`
 	VenueID     uuid.UUID `json:"venue_id" validate:"required"`
+	OrganizerID *uuid.UUID `json:"organizer_id,omitempty"`
+	Category    string    `json:"category,omitempty"`
+	Performers  []string  `json:"performers,omitempty"`
 	CreatedAt   time.Time `json:"created_at"`
 }
 
@@ -36,26 +39,87 @@ type Ticket struct {
 	TicketTypeName        string `json:"ticket_type_name"`
 	TicketTypeDisplayName string `json:"ticket_type_display_name,omitempty"`
 	TicketTypePriceCents  int32  `json:"ticket_type_price_cents"`
+	Seat        *TicketSeat `json:"seat,omitempty"` // Unset for general admission tickets
 	IsReserved  bool     `json:"is_reserved,omitempty"`
 }
 
+type TicketSeat struct {
+	ID         uuid.UUID `json:"id"`
+	Section    string    `json:"section"`
+	Row        string    `json:"row"`
+	Number     int32     `json:"number"`
+	Accessible bool      `json:"accessible,omitempty"`
+}
+
 type CreateEventRequest struct {
 	Title       string    `json:"title" validate:"required"`
 	Description string    `json:"description" validate:"required"`
 	StartDate   time.Time `json:"start_date" validate:"required"`
 	VenueID     uuid.UUID `json:"venue_id" validate:"required"`
-	TicketAllocation TicketAllocation `json:"ticket_allocation" validate:"required"`
+	TicketTypes []CreateTicketTypeRequest `json:"ticket_types"`
+	OrganizerID uuid.UUID `json:"organizer_id"` // Optional: admins can create events for an organizer
+	Category    string    `json:"category"`     // Optional, e.g. music; stored lower-case
+	Performers  []string  `json:"performers"`   // Optional, in billing order
 }
 
 type CreateVenueRequest struct {
 	Name     string    `json:"name" validate:"required"`
 	Location string    `json:"location" validate:"required"`
+	SeatMap  *SeatMap  `json:"seat_map"` // Optional
+}
+
+// TicketType is a kind of ticket sold for one event. Quantity is the number
+// of tickets issued for the type; MaxPerOrder is unset when there is no limit.
+type TicketType struct {
+	ID          uuid.UUID `json:"id"`
+	EventID     uuid.UUID `json:"event_id"`
+	Name        string    `json:"name"`
+	DisplayName string    `json:"display_name"`
+	PriceCents  int32     `json:"price_cents"`
+	Quantity    int32     `json:"quantity"`
+	MaxPerOrder *int32    `json:"max_per_order,omitempty"`
+}
+
+// EventAvailability summarizes how many of an event's tickets are available,
+// held and sold. The price range only covers ticket types with available
+// tickets and is left out when the event is sold out.
+type EventAvailability struct {
+	EventID       uuid.UUID                `json:"event_id"`
+	Available     int64                    `json:"available"`
+	Held          int64                    `json:"held"`
+	Sold          int64                    `json:"sold"`
+	MinPriceCents *int32                   `json:"min_price_cents,omitempty"`
+	MaxPriceCents *int32                   `json:"max_price_cents,omitempty"`
+	TicketTypes   []TicketTypeAvailability `json:"ticket_types"`
 }
 
-type TicketAllocation struct {
-	VIP int `json:"vip" validate:"required"`
-	GA int `json:"ga" validate:"required"`
-	FrontRow int `json:"front_row" validate:"required"`
+type TicketTypeAvailability struct {
+	TicketTypeID uuid.UUID `json:"ticket_type_id"`
+	Name         string    `json:"name"`
+	DisplayName  string    `json:"display_name"`
+	PriceCents   int32     `json:"price_cents"`
+	Available    int64     `json:"available"`
+	Held         int64     `json:"held"`
+	Sold         int64     `json:"sold"`
+}
+
+type CreateTicketTypeRequest struct {
+	Name        string `json:"name"`
+	DisplayName string `json:"display_name"` // Defaults to name
+	PriceCents  int32  `json:"price_cents"`
+	Quantity    int32  `json:"quantity"`
+	MaxPerOrder *int32 `json:"max_per_order"` // Optional: tickets of this type allowed per order
+	Sections    []string `json:"sections"` // Optional: seat map sections to issue one ticket per seat for; Quantity is ignored when set
+}
+
+// UpdateTicketTypeRequest replaces a ticket type. Changing Quantity issues
+// new tickets or removes unsold ones.
+type UpdateTicketTypeRequest struct {
+	Name        string `json:"name"`
+	DisplayName string `json:"display_name"`
+	PriceCents  int32  `json:"price_cents"`
+	Quantity    int32  `json:"quantity"`
+	MaxPerOrder *int32 `json:"max_per_order"`
 }
 
 type UpdateEventRequest struct {
@@ -63,12 +127,42 @@ type UpdateEventRequest struct {
 	Description string    `json:"description" validate:"required"`
 	StartDate   time.Time `json:"start_date" validate:"required"`
 	VenueID     uuid.UUID `json:"venue_id" validate:"required"`
+	Category    string    `json:"category"` // Optional; an empty category clears it
+	Performers  []string  `json:"performers"` // Replaces the event's performers
 }
 
 type UpdateVenueRequest struct {
 	Name     string    `json:"name" validate:"required"`
 	Location string    `json:"location" validate:"required"`
-	SeatMap  json.RawMessage `json:"seat_map" validate:"required"`
+	SeatMap  *SeatMap  `json:"seat_map"` // Optional: replaces the venue's seat map when set
+}
+
+// SeatMap describes a venue's seating, with sections in display order and
+// rows front to back. IDs are assigned by the server and ignored on input.
+// Coordinates are in the seat map's own units and only used for rendering.
+type SeatMap struct {
+	VenueID  uuid.UUID        `json:"venue_id"`
+	Sections []SeatMapSection `json:"sections"`
+}
+
+type SeatMapSection struct {
+	ID   uuid.UUID    `json:"id"`
+	Name string       `json:"name"`
+	Rows []SeatMapRow `json:"rows"`
+}
+
+type SeatMapRow struct {
+	Label string `json:"label"`
+	Seats []Seat `json:"seats"`
+}
+
+type Seat struct {
+	ID         uuid.UUID `json:"id"`
+	Number     int32     `json:"number"`
+	X          float64   `json:"x"`
+	Y          float64   `json:"y"`
+	Accessible bool      `json:"accessible"` // Wheelchair accessible
+	Companion  bool      `json:"companion"`  // Companion seat next to an accessible one
 }	
 type SearchEventResult struct {
 	ID             string    `json:"id"`
@@ -79,9 +173,124 @@ type SearchEventResult struct {
 	VenueName      string    `json:"venue_name"`
 	VenueLocation  string    `json:"venue_location"`
 	CreatedAt      time.Time `json:"created_at"`
+	Category       string    `json:"category,omitempty"`
+	Performers     []string  `json:"performers,omitempty"`
+	MinPriceCents  *int32    `json:"min_price_cents,omitempty"` // Unset for events without ticket types
+	MaxPriceCents  *int32    `json:"max_price_cents,omitempty"`
+	TicketTypes    []string  `json:"ticket_types"`
+	Availability   *EventAvailability `json:"availability,omitempty"` // Only with include=availability
 }
 
+// Sort orders of an event search
+const (
+	SearchSortRelevance = "relevance"
+	SearchSortDate      = "date"
+	SearchSortPrice     = "price"
+)
+
+// SearchEventsParams are the query, filters and sort order of an event
+// search. Unset filters match every event; an empty query browses them.
+type SearchEventsParams struct {
+	Query  string
+	Limit  int
+	Offset int
+	Cursor string // next_cursor of the previous page; replaces Offset
+
+	StartFrom *time.Time // Defaults to now, i.e. upcoming events only
+	StartTo   *time.Time
+
+	VenueIDs    []uuid.UUID
+	Location    string   // Matches venue locations containing all its words
+	TicketTypes []string // Ticket type names; any of them matches
+	Categories  []string // Any of them matches
+
+	// Matches events with a ticket type priced within the range
+	MinPriceCents *int32
+	MaxPriceCents *int32
+
+	Sort string // Defaults to SearchSortDate
+
+	Facets bool // Count matching events per facet value alongside the results
+}
+
+// SearchEventResults is one page of an event search. NextOffset and
+// NextCursor are unset on the last page; NextOffset also once the next page
+// is deeper than offsets can reach.
 type SearchEventResults struct {
-	Results []SearchEventResult `json:"results"`
-	Total   int                 `json:"total"`
+	Results    []SearchEventResult `json:"results"`
+	Total      int                 `json:"total"`
+	NextOffset *int                `json:"next_offset,omitempty"`
+	NextCursor string              `json:"next_cursor,omitempty"`
+	Facets     *SearchFacets       `json:"facets,omitempty"` // Only with include=facets
+
+	// Degraded is set on results from the Postgres fallback, which has no
+	// facets or cursors
+	Degraded bool `json:"-"`
+}
+
+// EventSuggestion completes a search prefix. Titles carry their event,
+// venues their venue; performers are just names.
+type EventSuggestion struct {
+	Text      string     `json:"text"`
+	EventID   string     `json:"event_id,omitempty"`
+	VenueID   string     `json:"venue_id,omitempty"`
+	StartDate *time.Time `json:"start_date,omitempty"`
+}
+
+type EventSuggestions struct {
+	Titles     []EventSuggestion `json:"titles"`
+	Venues     []EventSuggestion `json:"venues"`
+	Performers []EventSuggestion `json:"performers"`
+
+	Degraded bool `json:"-"` // From the Postgres fallback
+}
+
+// FacetBucket is one value of a search facet and the number of matching
+// events with it
+type FacetBucket struct {
+	Value     string `json:"value"`
+	Label     string `json:"label,omitempty"`      // Venue name for venues
+	Count     int    `json:"count"`
+	FromCents *int32 `json:"from_cents,omitempty"` // Price ranges only
+	ToCents   *int32 `json:"to_cents,omitempty"`   // Unset on the open-ended price range
+}
+
+// SearchFacets count matching events per value of each filter. Each facet
+// respects every applied filter except its own, so its other values can
+// still be picked.
+type SearchFacets struct {
+	Venues      []FacetBucket `json:"venues"`
+	Locations   []FacetBucket `json:"locations"`
+	Months      []FacetBucket `json:"months"` // yyyy-MM, in UTC
+	PriceRanges []FacetBucket `json:"price_ranges"`
+	Categories  []FacetBucket `json:"categories"`
+}
+
+type Customer struct {
+	ID        uuid.UUID `json:"id"`
+	Email     string    `json:"email"`
+	Name      string    `json:"name"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+type CreateCustomerRequest struct {
+	Email string `json:"email"`
+	Name  string `json:"name"`
+}
+
+// CustomerPurchase summarises one purchase in a customer's history; the full
+// breakdown is available from /booking/purchases/{id}
+type CustomerPurchase struct {
+	ID          uuid.UUID `json:"id"`
+	TotalCents  int32     `json:"total_cents"`
+	Status      string    `json:"status"`
+	TicketCount int       `json:"ticket_count"`
+	CreatedAt   time.Time `json:"created_at"`
+}
+
+type CustomerPurchases struct {
+	Purchases []CustomerPurchase `json:"purchases"`
+	Total     int                `json:"total"`
+	Limit     int                `json:"limit"`
+	Offset    int                `json:"offset"`
 }
@@ -0,0 +1,22 @@
+-- +goose Up
+-- Stores the first response for each Idempotency-Key so retried requests are replayed
+-- instead of being executed twice. response_status is NULL while the request is in flight.
+CREATE TABLE idempotency_keys (
+    key VARCHAR(255) NOT NULL,
+    request_path VARCHAR(255) NOT NULL,
+    request_hash VARCHAR(64) NOT NULL,
+    response_status INTEGER,
+    response_body BYTEA,
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    PRIMARY KEY (key, request_path)
+);
+
+CREATE TRIGGER trigger_set_updated_at_idempotency_keys
+BEFORE UPDATE ON idempotency_keys
+FOR EACH ROW
+EXECUTE FUNCTION set_updated_at_column();
+
+-- +goose Down
+DROP TRIGGER trigger_set_updated_at_idempotency_keys ON idempotency_keys;
+DROP TABLE idempotency_keys;
@@ -0,0 +1,30 @@
+-- +goose Up
+CREATE TYPE payment_attempt_status AS ENUM ('pending', 'authorized', 'captured', 'voided', 'failed');
+
+-- One row per purchase attempt. The status tracks how far the
+-- authorize -> purchase -> capture flow got so that attempts left behind by a
+-- crashed process can be captured or voided later.
+CREATE TABLE payment_attempts (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    hold_id UUID NOT NULL,
+    amount_cents INTEGER NOT NULL,
+    status payment_attempt_status NOT NULL DEFAULT 'pending',
+    authorization_id VARCHAR(255),
+    purchase_id UUID REFERENCES purchases(id) ON DELETE SET NULL,
+    failure_reason TEXT,
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX idx_payment_attempts_unsettled ON payment_attempts (updated_at)
+WHERE status IN ('pending', 'authorized');
+
+CREATE TRIGGER trigger_set_updated_at_payment_attempts
+BEFORE UPDATE ON payment_attempts
+FOR EACH ROW
+EXECUTE FUNCTION set_updated_at_column();
+
+-- +goose Down
+DROP TRIGGER trigger_set_updated_at_payment_attempts ON payment_attempts;
+DROP TABLE payment_attempts;
+DROP TYPE payment_attempt_status;
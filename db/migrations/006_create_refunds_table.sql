@@ -0,0 +1,30 @@
+-- +goose Up
+ALTER TYPE ticket_status ADD VALUE IF NOT EXISTS 'refunded';
+
+CREATE TABLE refunds (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    purchase_id UUID NOT NULL REFERENCES purchases(id),
+    amount_cents INTEGER NOT NULL,
+    -- true when the tickets went back on sale instead of being marked refunded
+    returned_to_inventory BOOLEAN NOT NULL DEFAULT FALSE,
+    provider_refund_id VARCHAR(255) NOT NULL,
+    reason TEXT,
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX idx_refunds_purchase_id ON refunds (purchase_id);
+
+-- Tickets are kept here because tickets returned to inventory lose their
+-- purchase_id once they are back on sale
+CREATE TABLE refund_tickets (
+    refund_id UUID NOT NULL REFERENCES refunds(id) ON DELETE CASCADE,
+    ticket_id UUID NOT NULL REFERENCES tickets(id),
+    amount_cents INTEGER NOT NULL,
+    PRIMARY KEY (refund_id, ticket_id)
+);
+
+-- +goose Down
+DROP TABLE refund_tickets;
+DROP TABLE refunds;
+-- Postgres cannot drop enum values; move refunded tickets back to sold
+UPDATE tickets SET status = 'sold' WHERE status = 'refunded';
@@ -0,0 +1,42 @@
+-- +goose Up
+CREATE TYPE purchase_status AS ENUM (
+    'pending_payment',
+    'paid',
+    'failed',
+    'refunded',
+    'partially_refunded',
+    'cancelled'
+);
+
+-- Purchases created before this migration were only written once paid
+ALTER TABLE purchases ADD COLUMN status purchase_status NOT NULL DEFAULT 'paid';
+ALTER TABLE purchases ALTER COLUMN status SET DEFAULT 'pending_payment';
+
+UPDATE purchases p
+SET status = CASE
+    WHEN EXISTS (SELECT 1 FROM tickets t WHERE t.purchase_id = p.id AND t.status = 'sold')
+        THEN 'partially_refunded'::purchase_status
+    ELSE 'refunded'::purchase_status
+END
+WHERE EXISTS (SELECT 1 FROM refunds r WHERE r.purchase_id = p.id);
+
+-- One row per status change. created_at uses clock_timestamp() so that
+-- transitions written in the same transaction keep their order.
+CREATE TABLE purchase_status_transitions (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    purchase_id UUID NOT NULL REFERENCES purchases(id) ON DELETE CASCADE,
+    from_status purchase_status,
+    to_status purchase_status NOT NULL,
+    reason TEXT,
+    created_at TIMESTAMP NOT NULL DEFAULT clock_timestamp()
+);
+
+CREATE INDEX idx_purchase_status_transitions_purchase_id ON purchase_status_transitions (purchase_id);
+
+INSERT INTO purchase_status_transitions (purchase_id, from_status, to_status, created_at)
+SELECT id, NULL, status, created_at FROM purchases;
+
+-- +goose Down
+DROP TABLE purchase_status_transitions;
+ALTER TABLE purchases DROP COLUMN status;
+DROP TYPE purchase_status;
@@ -0,0 +1,25 @@
+-- +goose Up
+CREATE TABLE customers (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    email TEXT NOT NULL UNIQUE,
+    name TEXT NOT NULL,
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE TRIGGER trigger_set_updated_at_customers
+BEFORE UPDATE ON customers
+FOR EACH ROW
+EXECUTE FUNCTION set_updated_at_column();
+
+-- Purchases made before customer accounts existed stay anonymous
+ALTER TABLE purchases
+ADD COLUMN customer_id UUID REFERENCES customers(id) ON DELETE SET NULL;
+
+CREATE INDEX idx_purchases_customer_id_created_at ON purchases (customer_id, created_at DESC);
+
+-- +goose Down
+DROP INDEX idx_purchases_customer_id_created_at;
+ALTER TABLE purchases DROP COLUMN customer_id;
+DROP TRIGGER trigger_set_updated_at_customers ON customers;
+DROP TABLE customers;
@@ -0,0 +1,11 @@
+-- +goose Up
+-- Organizers are identified by their token subject; they have no table of
+-- their own. Events created before this migration have no organizer and can
+-- only be managed by admins.
+ALTER TABLE events ADD COLUMN organizer_id UUID;
+
+CREATE INDEX idx_events_organizer_id ON events (organizer_id);
+
+-- +goose Down
+DROP INDEX idx_events_organizer_id;
+ALTER TABLE events DROP COLUMN organizer_id;
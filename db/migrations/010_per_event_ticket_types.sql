@@ -0,0 +1,49 @@
+-- +goose Up
+-- Ticket types belong to a single event so each event can define its own
+-- names, prices and quotas. max_per_order is NULL when there is no limit.
+ALTER TABLE ticket_types
+ADD COLUMN event_id UUID REFERENCES events(id) ON DELETE CASCADE,
+ADD COLUMN quantity INTEGER NOT NULL DEFAULT 0 CHECK (quantity >= 0),
+ADD COLUMN max_per_order INTEGER CHECK (max_per_order > 0),
+ADD COLUMN created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+ADD COLUMN updated_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP;
+
+ALTER TABLE ticket_types DROP CONSTRAINT ticket_types_name_key;
+
+-- Give every existing event its own copy of the global types it uses
+INSERT INTO ticket_types (event_id, name, display_name, price_cents, quantity)
+SELECT t.event_id, tt.name, tt.display_name, tt.price_cents, COUNT(*)
+FROM tickets t
+JOIN ticket_types tt ON t.ticket_type_id = tt.id
+WHERE tt.event_id IS NULL
+GROUP BY t.event_id, tt.id, tt.name, tt.display_name, tt.price_cents;
+
+UPDATE tickets t
+SET ticket_type_id = ett.id
+FROM ticket_types gtt, ticket_types ett
+WHERE t.ticket_type_id = gtt.id
+  AND gtt.event_id IS NULL
+  AND ett.event_id = t.event_id
+  AND ett.name = gtt.name;
+
+DELETE FROM ticket_types WHERE event_id IS NULL;
+
+ALTER TABLE ticket_types ALTER COLUMN event_id SET NOT NULL;
+ALTER TABLE ticket_types ADD CONSTRAINT ticket_types_event_id_name_key UNIQUE (event_id, name);
+
+CREATE TRIGGER trigger_set_updated_at_ticket_types
+BEFORE UPDATE ON ticket_types
+FOR EACH ROW
+EXECUTE FUNCTION set_updated_at_column();
+
+-- +goose Down
+-- Per-event copies are kept as they are; names are only unique per event
+-- so the global unique constraint is not restored.
+DROP TRIGGER trigger_set_updated_at_ticket_types ON ticket_types;
+ALTER TABLE ticket_types DROP CONSTRAINT ticket_types_event_id_name_key;
+ALTER TABLE ticket_types
+DROP COLUMN updated_at,
+DROP COLUMN created_at,
+DROP COLUMN max_per_order,
+DROP COLUMN quantity,
+DROP COLUMN event_id;
@@ -0,0 +1,72 @@
+-- +goose Up
+-- A venue's seat map is made of sections holding rows of numbered seats.
+-- Coordinates are in the seat map's own units and are only used for rendering.
+CREATE TABLE venue_sections (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    venue_id UUID NOT NULL REFERENCES venues(id) ON DELETE CASCADE,
+    name VARCHAR(255) NOT NULL,
+    position INTEGER NOT NULL,
+    UNIQUE (venue_id, name)
+);
+
+CREATE TABLE venue_seats (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    section_id UUID NOT NULL REFERENCES venue_sections(id) ON DELETE CASCADE,
+    row_label VARCHAR(32) NOT NULL,
+    -- order of the row within its section, front to back
+    row_index INTEGER NOT NULL,
+    seat_number INTEGER NOT NULL,
+    x DOUBLE PRECISION NOT NULL DEFAULT 0,
+    y DOUBLE PRECISION NOT NULL DEFAULT 0,
+    accessible BOOLEAN NOT NULL DEFAULT FALSE,
+    companion BOOLEAN NOT NULL DEFAULT FALSE,
+    UNIQUE (section_id, row_label, seat_number)
+);
+
+-- Seated tickets point at the seat they admit to; general admission tickets
+-- have no seat. Seats with tickets cannot be removed from the seat map.
+ALTER TABLE tickets ADD COLUMN seat_id UUID REFERENCES venue_seats(id);
+
+CREATE UNIQUE INDEX idx_tickets_event_id_seat_id ON tickets (event_id, seat_id) WHERE seat_id IS NOT NULL;
+
+CREATE OR REPLACE VIEW enriched_tickets AS
+SELECT
+    t.id,
+    t.event_id,
+    t.ticket_type_id,
+    t.status,
+    t.created_at,
+    t.updated_at,
+    tt.name AS ticket_type_name,
+    tt.display_name AS ticket_type_display_name,
+    tt.price_cents AS ticket_type_price_cents,
+    t.seat_id,
+    vs.name AS section_name,
+    s.row_label,
+    s.seat_number,
+    s.accessible AS seat_accessible
+FROM tickets t
+JOIN ticket_types tt ON t.ticket_type_id = tt.id
+LEFT JOIN venue_seats s ON t.seat_id = s.id
+LEFT JOIN venue_sections vs ON s.section_id = vs.id;
+
+-- +goose Down
+DROP VIEW enriched_tickets;
+CREATE VIEW enriched_tickets AS
+SELECT
+    t.id,
+    t.event_id,
+    t.ticket_type_id,
+    t.status,
+    t.created_at,
+    t.updated_at,
+    tt.name AS ticket_type_name,
+    tt.display_name AS ticket_type_display_name,
+    tt.price_cents AS ticket_type_price_cents
+FROM tickets t
+JOIN ticket_types tt ON t.ticket_type_id = tt.id;
+
+DROP INDEX idx_tickets_event_id_seat_id;
+ALTER TABLE tickets DROP COLUMN seat_id;
+DROP TABLE venue_seats;
+DROP TABLE venue_sections;
@@ -0,0 +1,21 @@
+-- +goose Up
+-- Events whose search document must be rewritten, written in the same
+-- transaction as the change so the index catches up even when Elasticsearch
+-- is down at write time. Rows only name the event: the indexer reads its
+-- current state and indexes it, or deletes the document if the event is gone.
+CREATE TABLE search_outbox (
+    id BIGSERIAL PRIMARY KEY,
+    event_id UUID NOT NULL,
+    attempts INTEGER NOT NULL DEFAULT 0,
+    last_error TEXT,
+    -- when the row may next be claimed; pushed back while a claim is held
+    -- and after every failed attempt
+    available_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX idx_search_outbox_available_at ON search_outbox (available_at);
+
+-- +goose Down
+DROP INDEX idx_search_outbox_available_at;
+DROP TABLE search_outbox;
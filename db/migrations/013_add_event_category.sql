@@ -0,0 +1,7 @@
+-- +goose Up
+-- Free-form category such as music or sports, used to browse and facet
+-- search results. Stored lower-case; NULL when the event has none.
+ALTER TABLE events ADD COLUMN category VARCHAR(64);
+
+-- +goose Down
+ALTER TABLE events DROP COLUMN category;
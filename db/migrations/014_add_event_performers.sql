@@ -0,0 +1,7 @@
+-- +goose Up
+-- Names of the artists, speakers or teams appearing at the event, in billing
+-- order. Searched and suggested alongside titles and venues.
+ALTER TABLE events ADD COLUMN performers TEXT[] NOT NULL DEFAULT '{}';
+
+-- +goose Down
+ALTER TABLE events DROP COLUMN performers;
@@ -0,0 +1,22 @@
+-- +goose Up
+-- Full-text document of an event, searched in Postgres while Elasticsearch
+-- is unavailable. Titles weigh most, then performers, then descriptions.
+-- Declared IMMUTABLE so it can be indexed; array_to_string is only STABLE
+-- in general, but is immutable for text arrays.
+-- +goose StatementBegin
+CREATE OR REPLACE FUNCTION event_search_document(title TEXT, description TEXT, performers TEXT[])
+RETURNS tsvector AS $$
+    SELECT setweight(to_tsvector('english', title), 'A') ||
+        setweight(to_tsvector('english', array_to_string(performers, ' ')), 'B') ||
+        setweight(to_tsvector('english', description), 'C');
+$$ LANGUAGE sql IMMUTABLE;
+-- +goose StatementEnd
+
+-- Queries must call event_search_document with the same arguments for the
+-- index to be used
+CREATE INDEX idx_events_search_document ON events
+USING GIN (event_search_document(title, description, performers));
+
+-- +goose Down
+DROP INDEX idx_events_search_document;
+DROP FUNCTION event_search_document(TEXT, TEXT, TEXT[]);
@@ -0,0 +1,31 @@
+-- +goose Up
+-- Refunds are recorded as pending before the payment provider is called and
+-- settled once it answers, so a refund interrupted in between is found and
+-- finished by the reconciler instead of being lost. Existing refunds were
+-- all confirmed by the provider.
+CREATE TYPE refund_status AS ENUM ('pending', 'succeeded', 'failed');
+
+ALTER TABLE refunds ADD COLUMN status refund_status NOT NULL DEFAULT 'succeeded';
+ALTER TABLE refunds ALTER COLUMN status SET DEFAULT 'pending';
+ALTER TABLE refunds ADD COLUMN failure_reason TEXT;
+ALTER TABLE refunds ADD COLUMN updated_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP;
+-- Only known once the provider has made the refund
+ALTER TABLE refunds ALTER COLUMN provider_refund_id DROP NOT NULL;
+
+CREATE INDEX idx_refunds_pending ON refunds (updated_at)
+WHERE status = 'pending';
+
+CREATE TRIGGER trigger_set_updated_at_refunds
+BEFORE UPDATE ON refunds
+FOR EACH ROW
+EXECUTE FUNCTION set_updated_at_column();
+
+-- +goose Down
+DROP TRIGGER trigger_set_updated_at_refunds ON refunds;
+DROP INDEX idx_refunds_pending;
+DELETE FROM refunds WHERE status <> 'succeeded';
+ALTER TABLE refunds ALTER COLUMN provider_refund_id SET NOT NULL;
+ALTER TABLE refunds DROP COLUMN updated_at;
+ALTER TABLE refunds DROP COLUMN failure_reason;
+ALTER TABLE refunds DROP COLUMN status;
+DROP TYPE refund_status;
@@ -0,0 +1,39 @@
+-- +goose Up
+-- Indexes for the Postgres search fallback. Venue names and locations are
+-- searched through their own document, which SearchEvents matches in a
+-- separate branch so that each branch can use its index.
+CREATE INDEX idx_venues_search_document ON venues
+USING GIN (to_tsvector('simple', name || ' ' || location));
+
+CREATE INDEX idx_venues_location_search ON venues
+USING GIN (to_tsvector('simple', location));
+
+CREATE INDEX idx_events_venue_id ON events (venue_id);
+
+-- Suggestions match case-insensitive prefixes with ILIKE, which btree
+-- indexes can't serve; trigram indexes can
+CREATE EXTENSION IF NOT EXISTS pg_trgm;
+
+-- Performers joined into one string so that they can be trigram indexed.
+-- Declared IMMUTABLE like event_search_document; array_to_string is
+-- immutable for text arrays.
+-- +goose StatementBegin
+CREATE OR REPLACE FUNCTION event_performers_text(performers TEXT[])
+RETURNS text AS $$
+    SELECT array_to_string(performers, ' ');
+$$ LANGUAGE sql IMMUTABLE;
+-- +goose StatementEnd
+
+CREATE INDEX idx_events_title_trgm ON events USING GIN (title gin_trgm_ops);
+CREATE INDEX idx_events_performers_trgm ON events USING GIN (event_performers_text(performers) gin_trgm_ops);
+CREATE INDEX idx_venues_name_trgm ON venues USING GIN (name gin_trgm_ops);
+
+-- +goose Down
+DROP INDEX idx_venues_name_trgm;
+DROP INDEX idx_events_performers_trgm;
+DROP INDEX idx_events_title_trgm;
+DROP FUNCTION event_performers_text(TEXT[]);
+DROP EXTENSION IF EXISTS pg_trgm;
+DROP INDEX idx_events_venue_id;
+DROP INDEX idx_venues_location_search;
+DROP INDEX idx_venues_search_document;
@@ -2,6 +2,7 @@ package api
 
 import (
 	"database/sql"
+	"fmt"
 	"net/http"
 
 	"github.com/go-chi/chi/v5"
@@ -9,7 +10,9 @@ import (
 	"github.com/go-chi/cors"
 	"github.com/ignisrex/tix/search/internal/elasticsearch"
 
+	"github.com/ignisrex/tix/auth"
 	"github.com/ignisrex/tix/search/internal/config"
+	"github.com/ignisrex/tix/search/internal/utils"
 	"github.com/ignisrex/tix/search/service/events"
 )
 
@@ -17,13 +20,15 @@ type APIServer struct {
 	addr    string
 	db *sql.DB
 	esClient *elasticsearch.Client
+	verifier *auth.Verifier
 }
 
-func NewAPIServer(addr string, db *sql.DB, esClient *elasticsearch.Client) *APIServer {
+func NewAPIServer(addr string, db *sql.DB, esClient *elasticsearch.Client, verifier *auth.Verifier) *APIServer {
 	return &APIServer{
 		addr:    addr,
 		db: db,
 		esClient: esClient,
+		verifier: verifier,
 	}
 }
 
@@ -40,6 +45,7 @@ func (s *APIServer) Run() error {
 	}))
 
 	r.Use(middleware.Logger)
+	r.Use(s.verifier.Authenticate)
 
 	r.Get("/", func(w http.ResponseWriter, r *http.Request) {
 		w.Write([]byte("search service"))
@@ -47,7 +53,7 @@ func (s *APIServer) Run() error {
 
 	// search endpoints under /api/v1
 	v1 := chi.NewRouter()
-	v1.Get("/healthz", nil)
+	v1.Get("/healthz", s.healthz)
 	
 	eventsHandler := events.NewHandler(s.esClient)
 	eventsHandler.RegisterRoutes(v1)
@@ -57,6 +63,20 @@ func (s *APIServer) Run() error {
 	return http.ListenAndServe(s.addr, r)
 }
 
+// healthz reports whether searches can be served, which core uses to decide
+// when to fall back to searching Postgres
+func (s *APIServer) healthz(w http.ResponseWriter, r *http.Request) {
+	if s.esClient == nil {
+		utils.WriteError(w, http.StatusServiceUnavailable, fmt.Errorf("elasticsearch is not configured"))
+		return
+	}
+	if err := s.esClient.Health(r.Context()); err != nil {
+		utils.WriteError(w, http.StatusServiceUnavailable, err)
+		return
+	}
+	utils.WriteJSON(w, http.StatusOK, map[string]string{"status": "ok"})
+}
+
 func AddrFromConfig() string {
 	port := config.Envs.Port
 	if port == "" {
@@ -4,6 +4,7 @@ import (
 	"database/sql"
 	"log"
 
+	"github.com/ignisrex/tix/auth"
 	"github.com/ignisrex/tix/search/cmd/api"
 	"github.com/ignisrex/tix/search/internal/config"
 	"github.com/ignisrex/tix/search/internal/elasticsearch"
@@ -35,7 +36,20 @@ func main() {
 		log.Printf("Successfully connected to Elasticsearch")
 	}
 
-	server := api.NewAPIServer(addr, db, esClient)
+	verifier, err := auth.NewVerifier(auth.KeyConfig{
+		Algorithm:      config.Envs.JWTAlgorithm,
+		Secret:         config.Envs.JWTSecret,
+		SecretFile:     config.Envs.JWTSecretFile,
+		PublicKeyFile:  config.Envs.JWTPublicKeyFile,
+		Issuer:         config.Envs.JWTIssuer,
+		Audience:       config.Envs.JWTAudience,
+		AllowDevSecret: config.Envs.AppEnv == "development",
+	})
+	if err != nil {
+		log.Fatal("failed to create jwt verifier: ", err)
+	}
+
+	server := api.NewAPIServer(addr, db, esClient, verifier)
 	if err := server.Run(); err != nil {
 		log.Fatal("search service failed: ", err)
 	}
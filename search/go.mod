@@ -11,6 +11,11 @@ require (
 	github.com/lib/pq v1.10.9
 )
 
+require (
+	github.com/golang-jwt/jwt/v5 v5.3.0 // indirect
+	github.com/google/uuid v1.6.0 // indirect
+)
+
 require (
 	github.com/elastic/elastic-transport-go/v8 v8.7.0 // indirect
 	github.com/gabriel-vasile/mimetype v1.4.10 // indirect
@@ -18,6 +23,8 @@ require (
 	github.com/go-logr/stdr v1.2.2 // indirect
 	github.com/go-playground/locales v0.14.1 // indirect
 	github.com/go-playground/universal-translator v0.18.1 // indirect
+	github.com/ignisrex/tix/auth v0.0.0
+	github.com/ignisrex/tix/searchquery v0.0.0
 	github.com/leodido/go-urn v1.4.0 // indirect
 	go.opentelemetry.io/otel v1.28.0 // indirect
 	go.opentelemetry.io/otel/metric v1.28.0 // indirect
@@ -26,3 +33,7 @@ require (
 	golang.org/x/sys v0.36.0 // indirect
 	golang.org/x/text v0.29.0 // indirect
 )
+
+replace github.com/ignisrex/tix/auth => ../auth
+
+replace github.com/ignisrex/tix/searchquery => ../searchquery
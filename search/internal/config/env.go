@@ -17,6 +17,17 @@ type Config struct {
 
 	ESHost string
 	ESPort string
+
+	// AppEnv is "development" for local setups, which may use the
+	// placeholder JWT secret
+	AppEnv string
+
+	JWTAlgorithm     string
+	JWTSecret        string
+	JWTSecretFile    string
+	JWTPublicKeyFile string
+	JWTIssuer        string
+	JWTAudience      string
 }
 
 var Envs Config = initConfig()
@@ -33,6 +44,13 @@ func initConfig() Config {
 		DBName:    getEnv("DB_NAME", "tix_db"),
 		ESHost:    getEnv("ES_HOST", "localhost"),
 		ESPort:    getEnv("ES_PORT", "9200"),
+		AppEnv:           getEnv("APP_ENV", "production"),
+		JWTAlgorithm:     getEnv("JWT_ALGORITHM", "HS256"),
+		JWTSecret:        getEnv("JWT_SECRET", ""),
+		JWTSecretFile:    getEnv("JWT_SECRET_FILE", ""),
+		JWTPublicKeyFile: getEnv("JWT_PUBLIC_KEY_FILE", ""),
+		JWTIssuer:        getEnv("JWT_ISSUER", ""),
+		JWTAudience:      getEnv("JWT_AUDIENCE", ""),
 	}
 }
 
@@ -5,12 +5,17 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
+	"io"
 	"time"
 
 	"github.com/elastic/go-elasticsearch/v8"
 	"github.com/elastic/go-elasticsearch/v8/esapi"
 )
 
+// eventsAlias is the alias core keeps pointed at the current versioned
+// events index; searching through it keeps working across reindexes
+const eventsAlias = "events"
+
 type Client struct {
 	es *elasticsearch.Client
 }
@@ -28,6 +33,35 @@ func NewClient(addresses []string) (*Client, error) {
 	return &Client{es: es}, nil
 }
 
+// Health checks that the events index can serve searches: the cluster is
+// reachable and none of the index's primary shards are unassigned
+func (c *Client) Health(ctx context.Context) error {
+	req := esapi.ClusterHealthRequest{
+		Index:   []string{eventsAlias},
+		Timeout: time.Second,
+	}
+	res, err := req.Do(ctx, c.es)
+	if err != nil {
+		return fmt.Errorf("failed to check cluster health: %w", err)
+	}
+	defer res.Body.Close()
+
+	if res.IsError() {
+		return fmt.Errorf("cluster health error: %s", res.String())
+	}
+
+	var health struct {
+		Status string `json:"status"`
+	}
+	if err := json.NewDecoder(res.Body).Decode(&health); err != nil {
+		return fmt.Errorf("failed to decode cluster health: %w", err)
+	}
+	if health.Status == "red" {
+		return fmt.Errorf("events index is red")
+	}
+	return nil
+}
+
 type SearchResult struct {
 	ID             string    `json:"id"`
 	Title           string    `json:"title"`
@@ -37,52 +71,110 @@ type SearchResult struct {
 	VenueName       string    `json:"venue_name"`
 	VenueLocation   string    `json:"venue_location"`
 	CreatedAt       time.Time `json:"created_at"`
+	MinPriceCents   *int      `json:"min_price_cents,omitempty"`
+	MaxPriceCents   *int      `json:"max_price_cents,omitempty"`
+	TicketTypes     []string  `json:"ticket_types"`
+	Category        string    `json:"category,omitempty"`
+	Performers      []string  `json:"performers"`
+}
+
+// Sort orders of a search
+const (
+	SortRelevance = "relevance" // best match first, then soonest
+	SortDate      = "date"      // soonest first
+	SortPrice     = "price"     // cheapest ticket first, then soonest
+)
+
+// SearchParams are the query, filters and sort order of an event search.
+// Unset filters match every event, and an empty query browses them all.
+type SearchParams struct {
+	Query  string
+	Limit  int
+	Offset int
+
+	// After continues from a cursor instead of an offset; see DecodeCursor
+	After []json.RawMessage
+
+	// StartFrom defaults to now, so only upcoming events are found unless
+	// an earlier date is asked for
+	StartFrom *time.Time
+	StartTo   *time.Time
+
+	VenueIDs    []string
+	Location    string   // matches venue locations containing all its words
+	TicketTypes []string // ticket type names; any of them matches
+	Categories  []string // any of them matches
+
+	// Matches events with at least one ticket type priced within the range
+	MinPriceCents *int32
+	MaxPriceCents *int32
+
+	Sort string
+
+	// Facets asks for facet counts alongside the hits
+	Facets bool
 }
 
+// SearchResponse is one page of results. The next page is fetched with
+// next_cursor, or with next_offset while it stays within MaxResultWindow;
+// both are left out on the last page.
 type SearchResponse struct {
-	Results []SearchResult `json:"results"`
-	Total   int            `json:"total"`
+	Results    []SearchResult `json:"results"`
+	Total      int            `json:"total"`
+	NextOffset *int           `json:"next_offset,omitempty"`
+	NextCursor string         `json:"next_cursor,omitempty"`
+	Facets     *Facets        `json:"facets,omitempty"`
 }
 
-// Only returns future events (start_date >= now)
-func (c *Client) SearchEvents(ctx context.Context, query string, limit, offset int) (*SearchResponse, error) {
-	indexName := "events"
-	
-	now := time.Now().Format("2006-01-02T15:04:05Z07:00")
-	
+func (c *Client) SearchEvents(ctx context.Context, params SearchParams) (*SearchResponse, error) {
+	indexName := eventsAlias
+
+	filters := searchFilters(params)
+	facetFilters := facetFilters(params)
+	if !params.Facets {
+		for _, filter := range facetFilters {
+			filters = append(filters, filter)
+		}
+	}
+
+	// Without a query every event matches, so browsing lists upcoming
+	// events in sort order
+	must := map[string]interface{}{"match_all": map[string]interface{}{}}
+	if params.Query != "" {
+		must = map[string]interface{}{
+			"multi_match": map[string]interface{}{
+				"query":  params.Query,
+				"fields": []string{"title^2", "description", "venue_name", "venue_location", "performers"},
+				"type":   "best_fields",
+				"fuzziness": "AUTO",
+			},
+		}
+	}
+
 	searchQuery := map[string]interface{}{
-		"size": limit,
-		"from": offset,
+		// One extra hit tells whether there is a next page
+		"size": params.Limit + 1,
 		"query": map[string]interface{}{
 			"bool": map[string]interface{}{
-				"must": []map[string]interface{}{
-					{
-						"multi_match": map[string]interface{}{
-							"query":  query,
-							"fields": []string{"title^2", "description", "venue_name", "venue_location"},
-							"type":   "best_fields",
-							"fuzziness": "AUTO",
-						},
-					},
-				},
-				"filter": []map[string]interface{}{
-					{
-						"range": map[string]interface{}{
-							"start_date": map[string]interface{}{
-								"gte": now,
-							},
-						},
-					},
-				},
-			},
-		},
-		"sort": []map[string]interface{}{
-			{
-				"start_date": map[string]interface{}{
-					"order": "asc",
-				},
+				"must":   []map[string]interface{}{must},
+				"filter": filters,
 			},
 		},
+		"sort":             searchSort(params.Sort),
+		"track_total_hits": true,
+	}
+	if params.After != nil {
+		searchQuery["search_after"] = params.After
+	} else {
+		searchQuery["from"] = params.Offset
+	}
+
+	if params.Facets {
+		// Filtering the hits after aggregating lets each facet count events
+		// as if its own filter were not applied, so the other values of a
+		// filtered facet stay visible with their counts
+		searchQuery["post_filter"] = allFilters(facetFilters)
+		searchQuery["aggs"] = facetAggs(facetFilters)
 	}
 
 	queryJSON, err := json.Marshal(searchQuery)
@@ -105,8 +197,24 @@ func (c *Client) SearchEvents(ctx context.Context, query string, limit, offset i
 		return nil, fmt.Errorf("search error: %s", res.String())
 	}
 
+	body, err := io.ReadAll(res.Body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read search response: %w", err)
+	}
 	var result map[string]interface{}
-	if err := json.NewDecoder(res.Body).Decode(&result); err != nil {
+	if err := json.Unmarshal(body, &result); err != nil {
+		return nil, fmt.Errorf("failed to decode search response: %w", err)
+	}
+	// Sort values are kept raw for cursors, as decoding them into float64
+	// would round large longs
+	var sorts struct {
+		Hits struct {
+			Hits []struct {
+				Sort []json.RawMessage `json:"sort"`
+			} `json:"hits"`
+		} `json:"hits"`
+	}
+	if err := json.Unmarshal(body, &sorts); err != nil {
 		return nil, fmt.Errorf("failed to decode search response: %w", err)
 	}
 
@@ -116,6 +224,12 @@ func (c *Client) SearchEvents(ctx context.Context, query string, limit, offset i
 		return &SearchResponse{Results: []SearchResult{}, Total: 0}, nil
 	}
 
+	var facets *Facets
+	if params.Facets {
+		aggs, _ := result["aggregations"].(map[string]interface{})
+		facets = parseFacets(aggs)
+	}
+
 	total, _ := hits["total"].(map[string]interface{})
 	totalValue := 0
 	if total != nil {
@@ -126,7 +240,20 @@ func (c *Client) SearchEvents(ctx context.Context, query string, limit, offset i
 
 	hitsArray, ok := hits["hits"].([]interface{})
 	if !ok {
-		return &SearchResponse{Results: []SearchResult{}, Total: totalValue}, nil
+		return &SearchResponse{Results: []SearchResult{}, Total: totalValue, Facets: facets}, nil
+	}
+
+	var nextOffset *int
+	var nextCursor string
+	if len(hitsArray) > params.Limit {
+		hitsArray = hitsArray[:params.Limit]
+		if len(sorts.Hits.Hits) >= params.Limit {
+			nextCursor = encodeCursor(params.Sort, sorts.Hits.Hits[params.Limit-1].Sort)
+		}
+		if params.After == nil && params.Offset+2*params.Limit+1 <= MaxResultWindow {
+			offset := params.Offset + params.Limit
+			nextOffset = &offset
+		}
 	}
 
 	results := make([]SearchResult, 0, len(hitsArray))
@@ -148,6 +275,11 @@ func (c *Client) SearchEvents(ctx context.Context, query string, limit, offset i
 			VenueID:      getString(source, "venue_id"),
 			VenueName:    getString(source, "venue_name"),
 			VenueLocation: getString(source, "venue_location"),
+			MinPriceCents: getInt(source, "min_price_cents"),
+			MaxPriceCents: getInt(source, "max_price_cents"),
+			TicketTypes:   getStrings(source, "ticket_types"),
+			Category:      getString(source, "category"),
+			Performers:    getStrings(source, "performers"),
 		}
 
 		// Parse dates
@@ -166,11 +298,126 @@ func (c *Client) SearchEvents(ctx context.Context, query string, limit, offset i
 	}
 
 	return &SearchResponse{
-		Results: results,
-		Total:   totalValue,
+		Results:    results,
+		Total:      totalValue,
+		NextOffset: nextOffset,
+		NextCursor: nextCursor,
+		Facets:     facets,
 	}, nil
 }
 
+// searchFilters turns the params' filters that have no facet into bool
+// filter clauses
+func searchFilters(params SearchParams) []map[string]interface{} {
+	startFrom := time.Now()
+	if params.StartFrom != nil {
+		startFrom = *params.StartFrom
+	}
+	startDate := map[string]interface{}{
+		"gte": startFrom.Format(time.RFC3339Nano),
+	}
+	if params.StartTo != nil {
+		startDate["lte"] = params.StartTo.Format(time.RFC3339Nano)
+	}
+
+	filters := []map[string]interface{}{
+		{"range": map[string]interface{}{"start_date": startDate}},
+	}
+
+	if len(params.TicketTypes) > 0 {
+		filters = append(filters, map[string]interface{}{
+			"terms": map[string]interface{}{"ticket_types": params.TicketTypes},
+		})
+	}
+
+	return filters
+}
+
+// facetFilters turns the params' filters that have a facet into filter
+// clauses, keyed by facet. The month facet has none, as dates are filtered
+// by the date range.
+func facetFilters(params SearchParams) map[string]map[string]interface{} {
+	filters := make(map[string]map[string]interface{})
+
+	if len(params.VenueIDs) > 0 {
+		filters[facetVenues] = map[string]interface{}{
+			"terms": map[string]interface{}{"venue_id": params.VenueIDs},
+		}
+	}
+	if params.Location != "" {
+		filters[facetLocations] = map[string]interface{}{
+			"match": map[string]interface{}{
+				"venue_location": map[string]interface{}{
+					"query":    params.Location,
+					"operator": "and",
+				},
+			},
+		}
+	}
+	if params.MinPriceCents != nil || params.MaxPriceCents != nil {
+		price := map[string]interface{}{}
+		if params.MinPriceCents != nil {
+			price["gte"] = *params.MinPriceCents
+		}
+		if params.MaxPriceCents != nil {
+			price["lte"] = *params.MaxPriceCents
+		}
+		filters[facetPriceRanges] = map[string]interface{}{
+			"range": map[string]interface{}{"ticket_prices_cents": price},
+		}
+	}
+	if len(params.Categories) > 0 {
+		filters[facetCategories] = map[string]interface{}{
+			"terms": map[string]interface{}{"category": params.Categories},
+		}
+	}
+
+	return filters
+}
+
+// searchSort returns the sort clauses of an order. Each ends with the event
+// ID, so hits never tie and a cursor resumes at exactly one position.
+func searchSort(order string) []interface{} {
+	byDate := map[string]interface{}{
+		"start_date": map[string]interface{}{"order": "asc"},
+	}
+	byID := map[string]interface{}{
+		"id": map[string]interface{}{"order": "asc"},
+	}
+	switch order {
+	case SortRelevance:
+		return []interface{}{"_score", byDate, byID}
+	case SortPrice:
+		return []interface{}{
+			map[string]interface{}{
+				"min_price_cents": map[string]interface{}{"order": "asc", "missing": "_last"},
+			},
+			byDate,
+			byID,
+		}
+	}
+	return []interface{}{byDate, byID}
+}
+
+func getInt(m map[string]interface{}, key string) *int {
+	if val, ok := m[key].(float64); ok {
+		i := int(val)
+		return &i
+	}
+	return nil
+}
+
+func getStrings(m map[string]interface{}, key string) []string {
+	vals, _ := m[key].([]interface{})
+	strs := make([]string, 0, len(vals))
+	for _, val := range vals {
+		if str, ok := val.(string); ok {
+			strs = append(strs, str)
+		}
+	}
+	return strs
+}
+
 func getString(m map[string]interface{}, key string) string {
 	if val, ok := m[key].(string); ok {
 		return val
@@ -0,0 +1,52 @@
+package elasticsearch
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+)
+
+// MaxResultWindow is how deep offset paging can go: Elasticsearch rejects
+// searches whose from + size exceeds the index's max_result_window, which
+// defaults to 10000. Cursors page past it.
+const MaxResultWindow = 10000
+
+// ErrInvalidCursor is returned for cursors that can't be decoded or were
+// taken under a different sort order
+var ErrInvalidCursor = errors.New("invalid cursor")
+
+// cursor marks the end of a page: the sort values of its last hit, which
+// the next page searches after. The values are kept as Elasticsearch sent
+// them, so large longs survive the round trip exactly.
+type cursor struct {
+	Sort  string            `json:"s"`
+	After []json.RawMessage `json:"a"`
+}
+
+func encodeCursor(sort string, after []json.RawMessage) string {
+	data, err := json.Marshal(cursor{Sort: sort, After: after})
+	if err != nil {
+		// Raw messages from a decoded response always marshal
+		return ""
+	}
+	return base64.RawURLEncoding.EncodeToString(data)
+}
+
+// DecodeCursor returns the sort values a cursor continues after. The cursor
+// must come from a search with the same sort order; other filters are not
+// checked, and changing them mid-way skips or repeats results.
+func DecodeCursor(value string, sort string) ([]json.RawMessage, error) {
+	data, err := base64.RawURLEncoding.DecodeString(value)
+	if err != nil {
+		return nil, ErrInvalidCursor
+	}
+	var c cursor
+	if err := json.Unmarshal(data, &c); err != nil || len(c.After) == 0 {
+		return nil, ErrInvalidCursor
+	}
+	if c.Sort != sort {
+		return nil, fmt.Errorf("%w: it was taken with sort %q", ErrInvalidCursor, c.Sort)
+	}
+	return c.After, nil
+}
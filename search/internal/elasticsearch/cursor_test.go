@@ -0,0 +1,52 @@
+package elasticsearch
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"testing"
+)
+
+func TestCursorRoundTripKeepsSortValuesExact(t *testing.T) {
+	// A long beyond float64's exact range, a date in millis and a keyword
+	after := []json.RawMessage{
+		json.RawMessage(`9007199254740993`),
+		json.RawMessage(`1767225600000`),
+		json.RawMessage(`"5f1c2a4e-0000-4000-8000-000000000001"`),
+	}
+
+	got, err := DecodeCursor(encodeCursor(SortPrice, after), SortPrice)
+	if err != nil {
+		t.Fatalf("DecodeCursor: %v", err)
+	}
+	if len(got) != len(after) {
+		t.Fatalf("got %d sort values, want %d", len(got), len(after))
+	}
+	for i := range after {
+		if string(got[i]) != string(after[i]) {
+			t.Errorf("sort value %d = %s, want %s", i, got[i], after[i])
+		}
+	}
+}
+
+func TestDecodeCursorRejectsInvalidCursors(t *testing.T) {
+	valid := encodeCursor(SortDate, []json.RawMessage{json.RawMessage(`1767225600000`)})
+
+	tests := []struct {
+		name   string
+		cursor string
+		sort   string
+	}{
+		{name: "other sort order", cursor: valid, sort: SortRelevance},
+		{name: "not base64", cursor: "not a cursor!", sort: SortDate},
+		{name: "not json", cursor: base64.RawURLEncoding.EncodeToString([]byte("{")), sort: SortDate},
+		{name: "no sort values", cursor: base64.RawURLEncoding.EncodeToString([]byte(`{"s":"date","a":[]}`)), sort: SortDate},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := DecodeCursor(tt.cursor, tt.sort); !errors.Is(err, ErrInvalidCursor) {
+				t.Errorf("DecodeCursor = %v, want ErrInvalidCursor", err)
+			}
+		})
+	}
+}
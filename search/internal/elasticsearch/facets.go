@@ -0,0 +1,176 @@
+package elasticsearch
+
+import (
+	"fmt"
+)
+
+// Facets of a search, named by the filter they narrow
+const (
+	facetVenues      = "venues"
+	facetLocations   = "locations"
+	facetMonths      = "months"
+	facetPriceRanges = "price_ranges"
+	facetCategories  = "categories"
+)
+
+// facetSize caps the number of values returned for the term facets
+const facetSize = 50
+
+// priceRanges are the price facet's buckets in cents; a zero upper bound
+// leaves the last one open
+var priceRanges = []struct{ from, to int }{
+	{0, 2500},
+	{2500, 5000},
+	{5000, 10000},
+	{10000, 20000},
+	{20000, 0},
+}
+
+// FacetBucket is one value of a facet and the number of matching events
+// with it. Price ranges also carry their bounds; ToCents is unset on the
+// open-ended one.
+type FacetBucket struct {
+	Value     string `json:"value"`
+	Label     string `json:"label,omitempty"` // venue name for venues
+	Count     int    `json:"count"`
+	FromCents *int   `json:"from_cents,omitempty"`
+	ToCents   *int   `json:"to_cents,omitempty"`
+}
+
+// Facets are the counts of matching events per value of each filter. Each
+// facet respects every applied filter except its own.
+type Facets struct {
+	Venues      []FacetBucket `json:"venues"`
+	Locations   []FacetBucket `json:"locations"`
+	Months      []FacetBucket `json:"months"` // yyyy-MM, in UTC
+	PriceRanges []FacetBucket `json:"price_ranges"`
+	Categories  []FacetBucket `json:"categories"`
+}
+
+// allFilters combines facet filters into one clause
+func allFilters(filters map[string]map[string]interface{}) map[string]interface{} {
+	return otherFilters(filters, "")
+}
+
+// otherFilters combines the facet filters other than the named one into one
+// clause
+func otherFilters(filters map[string]map[string]interface{}, facet string) map[string]interface{} {
+	clauses := []map[string]interface{}{}
+	for name, filter := range filters {
+		if name != facet {
+			clauses = append(clauses, filter)
+		}
+	}
+	return map[string]interface{}{
+		"bool": map[string]interface{}{"filter": clauses},
+	}
+}
+
+// facetAggs builds an aggregation per facet. Each one is scoped to the other
+// facets' filters and buckets its values under "values".
+func facetAggs(filters map[string]map[string]interface{}) map[string]interface{} {
+	ranges := make([]map[string]interface{}, 0, len(priceRanges))
+	for _, r := range priceRanges {
+		bucket := map[string]interface{}{
+			"key":  priceRangeKey(r.from, r.to),
+			"from": r.from,
+		}
+		if r.to > 0 {
+			bucket["to"] = r.to
+		}
+		ranges = append(ranges, bucket)
+	}
+
+	values := map[string]map[string]interface{}{
+		facetVenues: {
+			"terms": map[string]interface{}{"field": "venue_id", "size": facetSize},
+			"aggs": map[string]interface{}{
+				"name": map[string]interface{}{
+					"terms": map[string]interface{}{"field": "venue_name.keyword", "size": 1},
+				},
+			},
+		},
+		facetLocations: {
+			"terms": map[string]interface{}{"field": "venue_location.keyword", "size": facetSize},
+		},
+		facetMonths: {
+			"date_histogram": map[string]interface{}{
+				"field":             "start_date",
+				"calendar_interval": "month",
+				"format":            "yyyy-MM",
+				"min_doc_count":     1,
+			},
+		},
+		facetPriceRanges: {
+			"range": map[string]interface{}{"field": "ticket_prices_cents", "ranges": ranges},
+		},
+		facetCategories: {
+			"terms": map[string]interface{}{"field": "category", "size": facetSize},
+		},
+	}
+
+	aggs := make(map[string]interface{}, len(values))
+	for facet, agg := range values {
+		aggs[facet] = map[string]interface{}{
+			"filter": otherFilters(filters, facet),
+			"aggs":   map[string]interface{}{"values": agg},
+		}
+	}
+	return aggs
+}
+
+func priceRangeKey(from, to int) string {
+	if to == 0 {
+		return fmt.Sprintf("%d-", from)
+	}
+	return fmt.Sprintf("%d-%d", from, to)
+}
+
+// parseFacets reads the facet aggregations of a search response. Values
+// without matching events are left out.
+func parseFacets(aggs map[string]interface{}) *Facets {
+	return &Facets{
+		Venues:      facetBuckets(aggs, facetVenues),
+		Locations:   facetBuckets(aggs, facetLocations),
+		Months:      facetBuckets(aggs, facetMonths),
+		PriceRanges: facetBuckets(aggs, facetPriceRanges),
+		Categories:  facetBuckets(aggs, facetCategories),
+	}
+}
+
+func facetBuckets(aggs map[string]interface{}, facet string) []FacetBucket {
+	agg, _ := aggs[facet].(map[string]interface{})
+	values, _ := agg["values"].(map[string]interface{})
+	raw, _ := values["buckets"].([]interface{})
+
+	buckets := make([]FacetBucket, 0, len(raw))
+	for _, item := range raw {
+		b, ok := item.(map[string]interface{})
+		if !ok {
+			continue
+		}
+		count := getInt(b, "doc_count")
+		if count == nil || *count == 0 {
+			continue
+		}
+
+		bucket := FacetBucket{Value: getString(b, "key"), Count: *count}
+		switch facet {
+		case facetVenues:
+			if name, ok := b["name"].(map[string]interface{}); ok {
+				if names, _ := name["buckets"].([]interface{}); len(names) > 0 {
+					if top, ok := names[0].(map[string]interface{}); ok {
+						bucket.Label = getString(top, "key")
+					}
+				}
+			}
+		case facetMonths:
+			bucket.Value = getString(b, "key_as_string")
+		case facetPriceRanges:
+			bucket.FromCents = getInt(b, "from")
+			bucket.ToCents = getInt(b, "to")
+		}
+		buckets = append(buckets, bucket)
+	}
+	return buckets
+}
@@ -0,0 +1,129 @@
+package elasticsearch
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/elastic/go-elasticsearch/v8/esapi"
+)
+
+// Suggestion groups, each backed by a completion field of the events index
+const (
+	suggestTitles     = "titles"
+	suggestVenues     = "venues"
+	suggestPerformers = "performers"
+)
+
+var suggestFields = map[string]string{
+	suggestTitles:     "title.suggest",
+	suggestVenues:     "venue_name.suggest",
+	suggestPerformers: "performers.suggest",
+}
+
+// Suggestion is one completion of a prefix. Titles link to their event and
+// venues to their venue; performers only carry the name.
+type Suggestion struct {
+	Text      string     `json:"text"`
+	EventID   string     `json:"event_id,omitempty"`
+	VenueID   string     `json:"venue_id,omitempty"`
+	StartDate *time.Time `json:"start_date,omitempty"`
+}
+
+// SuggestResponse holds the completions of a prefix grouped by type
+type SuggestResponse struct {
+	Titles     []Suggestion `json:"titles"`
+	Venues     []Suggestion `json:"venues"`
+	Performers []Suggestion `json:"performers"`
+}
+
+// Suggest completes a prefix against event titles, venue names and
+// performers. It runs on the completion suggester's in-memory structures
+// rather than a full query, so it is fast enough to call on each keystroke.
+// Duplicate texts, such as a venue hosting many events, are suggested once.
+func (c *Client) Suggest(ctx context.Context, prefix string, size int) (*SuggestResponse, error) {
+	suggest := make(map[string]interface{}, len(suggestFields))
+	for group, field := range suggestFields {
+		suggest[group] = map[string]interface{}{
+			"prefix": prefix,
+			"completion": map[string]interface{}{
+				"field":           field,
+				"size":            size,
+				"skip_duplicates": true,
+			},
+		}
+	}
+	suggestQuery := map[string]interface{}{
+		"size":    0,
+		"_source": []string{"id", "venue_id", "start_date"},
+		"suggest": suggest,
+	}
+
+	queryJSON, err := json.Marshal(suggestQuery)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal suggest query: %w", err)
+	}
+
+	req := esapi.SearchRequest{
+		Index: []string{eventsAlias},
+		Body:  bytes.NewReader(queryJSON),
+	}
+
+	res, err := req.Do(ctx, c.es)
+	if err != nil {
+		return nil, fmt.Errorf("failed to execute suggest: %w", err)
+	}
+	defer res.Body.Close()
+
+	if res.IsError() {
+		return nil, fmt.Errorf("suggest error: %s", res.String())
+	}
+
+	var result map[string]interface{}
+	if err := json.NewDecoder(res.Body).Decode(&result); err != nil {
+		return nil, fmt.Errorf("failed to decode suggest response: %w", err)
+	}
+
+	groups, _ := result["suggest"].(map[string]interface{})
+	return &SuggestResponse{
+		Titles:     suggestions(groups, suggestTitles),
+		Venues:     suggestions(groups, suggestVenues),
+		Performers: suggestions(groups, suggestPerformers),
+	}, nil
+}
+
+// suggestions reads the options of one suggestion group
+func suggestions(groups map[string]interface{}, group string) []Suggestion {
+	suggestions := []Suggestion{}
+
+	// A group holds one entry per suggested prefix, and there is one prefix
+	entries, _ := groups[group].([]interface{})
+	if len(entries) == 0 {
+		return suggestions
+	}
+	entry, _ := entries[0].(map[string]interface{})
+	options, _ := entry["options"].([]interface{})
+
+	for _, option := range options {
+		optionMap, ok := option.(map[string]interface{})
+		if !ok {
+			continue
+		}
+		source, _ := optionMap["_source"].(map[string]interface{})
+
+		suggestion := Suggestion{Text: getString(optionMap, "text")}
+		switch group {
+		case suggestTitles:
+			suggestion.EventID = getString(source, "id")
+			if t, err := time.Parse(time.RFC3339, getString(source, "start_date")); err == nil {
+				suggestion.StartDate = &t
+			}
+		case suggestVenues:
+			suggestion.VenueID = getString(source, "venue_id")
+		}
+		suggestions = append(suggestions, suggestion)
+	}
+	return suggestions
+}
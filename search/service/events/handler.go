@@ -3,12 +3,15 @@ package events
 import (
 	"fmt"
 	"net/http"
+	"net/url"
 	"strconv"
+	"strings"
 
 	"github.com/go-chi/chi/v5"
 	"github.com/ignisrex/tix/search/internal/elasticsearch"
 
 	"github.com/ignisrex/tix/search/internal/utils"
+	"github.com/ignisrex/tix/searchquery"
 )
 
 type Handler struct {
@@ -24,35 +27,92 @@ func NewHandler(esClient *elasticsearch.Client) *Handler {
 func (h *Handler) RegisterRoutes(r chi.Router) {
 	r.Route("/search", func(r chi.Router) {
 		r.Get("/events", h.SearchEvents)
+		r.Get("/suggest", h.Suggest)
 	})
 }
 
+// SearchEvents searches upcoming events, or browses them all when q is
+// empty
 func (h *Handler) SearchEvents(w http.ResponseWriter, r *http.Request) {
-	query := r.URL.Query().Get("q")
-	if query == "" {
+	params, err := parseSearchParams(r.URL.Query())
+	if err != nil {
+		utils.WriteError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	results, err := h.service.SearchEvents(r.Context(), params)
+	if err != nil {
+		utils.WriteError(w, http.StatusInternalServerError, fmt.Errorf("failed to search events: %w", err))
+		return
+	}
+
+	utils.WriteJSON(w, http.StatusOK, results)
+}
+
+// Suggest returns completions of q for event titles, venue names and
+// performers, grouped by type. limit caps each group (default 5, at most 20).
+func (h *Handler) Suggest(w http.ResponseWriter, r *http.Request) {
+	prefix := strings.TrimSpace(r.URL.Query().Get("q"))
+	if prefix == "" {
 		utils.WriteError(w, http.StatusBadRequest, fmt.Errorf("query parameter 'q' is required"))
 		return
 	}
 
-	// Parse pagination parameters
-	limit := 10
-	offset := 0
+	size := 5
 	if limitStr := r.URL.Query().Get("limit"); limitStr != "" {
-		if l, err := strconv.Atoi(limitStr); err == nil && l > 0 && l <= 100 {
-			limit = l
-		}
-	}
-	if offsetStr := r.URL.Query().Get("offset"); offsetStr != "" {
-		if o, err := strconv.Atoi(offsetStr); err == nil && o >= 0 {
-			offset = o
+		if l, err := strconv.Atoi(limitStr); err == nil && l > 0 && l <= 20 {
+			size = l
 		}
 	}
 
-	results, err := h.service.SearchEvents(r.Context(), query, limit, offset)
+	suggestions, err := h.service.Suggest(r.Context(), prefix, size)
 	if err != nil {
-		utils.WriteError(w, http.StatusInternalServerError, fmt.Errorf("failed to search events: %w", err))
+		utils.WriteError(w, http.StatusInternalServerError, fmt.Errorf("failed to get suggestions: %w", err))
 		return
 	}
 
-	utils.WriteJSON(w, http.StatusOK, results)
-}
\ No newline at end of file
+	utils.WriteJSON(w, http.StatusOK, suggestions)
+}
+
+// parseSearchParams reads pagination, filters and sort order from the query
+// string like core does, plus the cursor and facets only the search service
+// handles
+func parseSearchParams(values url.Values) (elasticsearch.SearchParams, error) {
+	parsed, err := searchquery.Parse(values)
+	if err != nil {
+		return elasticsearch.SearchParams{}, err
+	}
+	params := elasticsearch.SearchParams{
+		Query:         parsed.Query,
+		Limit:         parsed.Limit,
+		Offset:        parsed.Offset,
+		StartFrom:     parsed.StartFrom,
+		StartTo:       parsed.StartTo,
+		VenueIDs:      parsed.VenueIDs,
+		Location:      parsed.Location,
+		TicketTypes:   parsed.TicketTypes,
+		Categories:    parsed.Categories,
+		MinPriceCents: parsed.MinPriceCents,
+		MaxPriceCents: parsed.MaxPriceCents,
+		Sort:          parsed.Sort,
+	}
+
+	if cursor := values.Get("cursor"); cursor != "" {
+		if params.Offset > 0 {
+			return params, fmt.Errorf("offset and cursor can't be combined")
+		}
+		if params.After, err = elasticsearch.DecodeCursor(cursor, params.Sort); err != nil {
+			return params, err
+		}
+	} else if params.Offset+params.Limit+1 > elasticsearch.MaxResultWindow {
+		return params, fmt.Errorf("offset is too deep, page with cursor instead")
+	}
+
+	if facets := values.Get("facets"); facets != "" {
+		if params.Facets, err = strconv.ParseBool(facets); err != nil {
+			return params, fmt.Errorf("invalid facets %q: must be true or false", facets)
+		}
+	}
+
+	return params, nil
+}
@@ -14,9 +14,20 @@ func NewRepo(esClient *elasticsearch.Client) *Repo {
 	return &Repo{esClient: esClient}
 }
 
-func (r *Repo) SearchEvents(ctx context.Context, query string, limit, offset int) (*elasticsearch.SearchResponse, error) {
+func (r *Repo) SearchEvents(ctx context.Context, params elasticsearch.SearchParams) (*elasticsearch.SearchResponse, error) {
 	if r.esClient == nil {
 		return &elasticsearch.SearchResponse{Results: []elasticsearch.SearchResult{}, Total: 0}, nil
 	}
-	return r.esClient.SearchEvents(ctx, query, limit, offset)
+	return r.esClient.SearchEvents(ctx, params)
+}
+
+func (r *Repo) Suggest(ctx context.Context, prefix string, size int) (*elasticsearch.SuggestResponse, error) {
+	if r.esClient == nil {
+		return &elasticsearch.SuggestResponse{
+			Titles:     []elasticsearch.Suggestion{},
+			Venues:     []elasticsearch.Suggestion{},
+			Performers: []elasticsearch.Suggestion{},
+		}, nil
+	}
+	return r.esClient.Suggest(ctx, prefix, size)
 }
\ No newline at end of file
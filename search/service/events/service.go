@@ -14,6 +14,10 @@ func NewService(repo *Repo) *Service {
 	return &Service{repo: repo}
 }
 
-func (s *Service) SearchEvents(ctx context.Context, query string, limit, offset int) (*elasticsearch.SearchResponse, error) {
-	return s.repo.SearchEvents(ctx, query, limit, offset)
-}
\ No newline at end of file
+func (s *Service) SearchEvents(ctx context.Context, params elasticsearch.SearchParams) (*elasticsearch.SearchResponse, error) {
+	return s.repo.SearchEvents(ctx, params)
+}
+
+func (s *Service) Suggest(ctx context.Context, prefix string, size int) (*elasticsearch.SuggestResponse, error) {
+	return s.repo.Suggest(ctx, prefix, size)
+}
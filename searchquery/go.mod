@@ -0,0 +1,3 @@
+module github.com/ignisrex/tix/searchquery
+
+go 1.25.4
@@ -0,0 +1,140 @@
+// Package searchquery reads event searches from the query string. Core and
+// the search service share it so that both accept exactly the same searches.
+package searchquery
+
+import (
+	"fmt"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Sort orders
+const (
+	SortRelevance = "relevance"
+	SortDate      = "date"
+	SortPrice     = "price"
+)
+
+// Params are the search, filters and pagination common to both services.
+// Cursors and facets are left to each of them.
+type Params struct {
+	Query  string
+	Limit  int
+	Offset int
+
+	StartFrom *time.Time
+	StartTo   *time.Time
+
+	VenueIDs    []string
+	Location    string   // matches venue locations containing all its words
+	TicketTypes []string // ticket type names; any of them matches
+	Categories  []string // lower-cased; any of them matches
+
+	// Matches events with a ticket type priced within the range
+	MinPriceCents *int32
+	MaxPriceCents *int32
+
+	Sort string // defaults to SortDate
+}
+
+// Parse reads an event search from the query string. Out of range
+// pagination falls back to the defaults; invalid filters are an error.
+func Parse(values url.Values) (Params, error) {
+	params := Params{
+		Query: strings.TrimSpace(values.Get("q")),
+		Limit: 10,
+		Sort:  SortDate,
+	}
+	if limitStr := values.Get("limit"); limitStr != "" {
+		if l, err := strconv.Atoi(limitStr); err == nil && l > 0 && l <= 100 {
+			params.Limit = l
+		}
+	}
+	if offsetStr := values.Get("offset"); offsetStr != "" {
+		if o, err := strconv.Atoi(offsetStr); err == nil && o >= 0 {
+			params.Offset = o
+		}
+	}
+
+	var err error
+	if params.StartFrom, err = parseDate(values.Get("start_from"), false); err != nil {
+		return params, fmt.Errorf("invalid start_from: %w", err)
+	}
+	if params.StartTo, err = parseDate(values.Get("start_to"), true); err != nil {
+		return params, fmt.Errorf("invalid start_to: %w", err)
+	}
+
+	params.VenueIDs = ListParam(values, "venue_id")
+	params.Location = strings.TrimSpace(values.Get("location"))
+	params.TicketTypes = ListParam(values, "ticket_type")
+	for _, category := range ListParam(values, "category") {
+		params.Categories = append(params.Categories, strings.ToLower(category))
+	}
+
+	if params.MinPriceCents, err = parseCents(values.Get("min_price_cents")); err != nil {
+		return params, fmt.Errorf("invalid min_price_cents: %w", err)
+	}
+	if params.MaxPriceCents, err = parseCents(values.Get("max_price_cents")); err != nil {
+		return params, fmt.Errorf("invalid max_price_cents: %w", err)
+	}
+	if params.MinPriceCents != nil && params.MaxPriceCents != nil && *params.MinPriceCents > *params.MaxPriceCents {
+		return params, fmt.Errorf("min_price_cents is above max_price_cents")
+	}
+
+	switch sort := values.Get("sort"); sort {
+	case "":
+	case SortRelevance, SortDate, SortPrice:
+		params.Sort = sort
+	default:
+		return params, fmt.Errorf("invalid sort %q: must be relevance, date or price", sort)
+	}
+
+	return params, nil
+}
+
+// parseDate accepts RFC 3339 timestamps and plain dates. A plain date that
+// ends a range covers the whole day.
+func parseDate(value string, endOfRange bool) (*time.Time, error) {
+	if value == "" {
+		return nil, nil
+	}
+	if t, err := time.Parse(time.RFC3339, value); err == nil {
+		return &t, nil
+	}
+	t, err := time.Parse(time.DateOnly, value)
+	if err != nil {
+		return nil, fmt.Errorf("expected a date (2006-01-02) or RFC 3339 timestamp, got %q", value)
+	}
+	if endOfRange {
+		t = t.Add(24*time.Hour - time.Nanosecond)
+	}
+	return &t, nil
+}
+
+func parseCents(value string) (*int32, error) {
+	if value == "" {
+		return nil, nil
+	}
+	cents, err := strconv.ParseInt(value, 10, 32)
+	if err != nil || cents < 0 {
+		return nil, fmt.Errorf("expected a non-negative number of cents, got %q", value)
+	}
+	c := int32(cents)
+	return &c, nil
+}
+
+// ListParam collects a parameter given several times or as a comma-separated
+// list
+func ListParam(values url.Values, key string) []string {
+	var list []string
+	for _, value := range values[key] {
+		for _, item := range strings.Split(value, ",") {
+			if item = strings.TrimSpace(item); item != "" {
+				list = append(list, item)
+			}
+		}
+	}
+	return list
+}
@@ -0,0 +1,50 @@
+package searchquery
+
+import (
+	"net/url"
+	"slices"
+	"testing"
+	"time"
+)
+
+func TestParse(t *testing.T) {
+	values, _ := url.ParseQuery("q=+jazz+&limit=500&offset=20&start_to=2026-07-01&category=Jazz,Blues&category=&ticket_type=VIP&min_price_cents=1000&sort=price")
+	params, err := Parse(values)
+	if err != nil {
+		t.Fatalf("Parse: %v", err)
+	}
+
+	if params.Query != "jazz" {
+		t.Errorf("Query = %q, want jazz", params.Query)
+	}
+	if params.Limit != 10 || params.Offset != 20 {
+		t.Errorf("Limit, Offset = %d, %d, want the default limit 10 and 20", params.Limit, params.Offset)
+	}
+	if want := time.Date(2026, 7, 1, 23, 59, 59, int(time.Second-time.Nanosecond), time.UTC); params.StartTo == nil || !params.StartTo.Equal(want) {
+		t.Errorf("StartTo = %v, want the end of the day %v", params.StartTo, want)
+	}
+	if !slices.Equal(params.Categories, []string{"jazz", "blues"}) {
+		t.Errorf("Categories = %v, want [jazz blues]", params.Categories)
+	}
+	if params.MinPriceCents == nil || *params.MinPriceCents != 1000 || params.MaxPriceCents != nil {
+		t.Errorf("price range = %v-%v, want 1000 and no maximum", params.MinPriceCents, params.MaxPriceCents)
+	}
+	if params.Sort != SortPrice {
+		t.Errorf("Sort = %q, want price", params.Sort)
+	}
+}
+
+func TestParseRejectsInvalidFilters(t *testing.T) {
+	for _, query := range []string{
+		"start_from=tomorrow",
+		"min_price_cents=-1",
+		"max_price_cents=99999999999",
+		"min_price_cents=500&max_price_cents=100",
+		"sort=popularity",
+	} {
+		values, _ := url.ParseQuery(query)
+		if _, err := Parse(values); err == nil {
+			t.Errorf("Parse(%q) succeeded, want an error", query)
+		}
+	}
+}
@@ -6,6 +6,7 @@ import { getEvent, getEventTickets, purchaseTickets } from "@/lib/api";
 import type { Event, Ticket } from "@/types/events";
 import type { ReservationData } from "@/types/booking";
 import { Button } from "@/components/ui/button";
+import { Input } from "@/components/ui/input";
 import { Card, CardContent, CardHeader, CardTitle } from "@/components/ui/card";
 import { Skeleton } from "@/components/ui/skeleton";
 import { ArrowLeft } from "lucide-react";
@@ -26,6 +27,7 @@ export default function CheckoutPage() {
   const [tickets, setTickets] = useState<Ticket[]>([]);
   const [loading, setLoading] = useState(true);
   const [purchasing, setPurchasing] = useState(false);
+  const [paymentToken, setPaymentToken] = useState("tok_success");
   const [error, setError] = useState<string | null>(null);
   const [reservationValid, setReservationValid] = useState<boolean | null>(null);
 
@@ -115,8 +117,15 @@ export default function CheckoutPage() {
       const reservation: ReservationData = JSON.parse(reservationStr);
       const ticketIds = reservation.ticketIds;
 
+      // One key per checkout attempt, kept with the reservation so a reload
+      // or a second click resends the same purchase instead of a new one
+      if (!reservation.checkoutKey) {
+        reservation.checkoutKey = crypto.randomUUID();
+        localStorage.setItem("tix_reservation", JSON.stringify(reservation));
+      }
+
       setPurchasing(true);
-      const response = await purchaseTickets(ticketIds);
+      const response = await purchaseTickets(ticketIds, reservation.holdId, paymentToken, reservation.checkoutKey);
       
       if (response.success && response.purchase_id) {
         // Clear reservation
@@ -232,6 +241,16 @@ export default function CheckoutPage() {
               </div>
             </div>
 
+            <div className="border-t pt-4">
+              <label htmlFor="payment-token" className="font-semibold block mb-2">Payment Method</label>
+              <Input
+                id="payment-token"
+                value={paymentToken}
+                onChange={(e) => setPaymentToken(e.target.value)}
+                placeholder="Payment method token"
+              />
+            </div>
+
             <div className="border-t pt-4">
               <div className="flex justify-between items-center mb-4">
                 <span className="text-lg font-semibold">Total:</span>
@@ -239,7 +258,7 @@ export default function CheckoutPage() {
               </div>
               <Button
                 onClick={handlePurchase}
-                disabled={purchasing}
+                disabled={purchasing || !paymentToken}
                 className="w-full bg-indigo-500 hover:bg-indigo-600 text-white"
                 size="lg"
               >
@@ -128,6 +128,7 @@ export default function EventDetailPage() {
               // Check for existing reservation
               const existingReservationStr = localStorage.getItem("tix_reservation");
               let existingTicketIds: string[] = [];
+              let existingHoldId: string | undefined;
               
               if (existingReservationStr) {
                 try {
@@ -135,8 +136,9 @@ export default function EventDetailPage() {
                   
                   // Check if existing reservation is for the same event
                   if (existingReservation.eventId === eventId) {
-                    // Get existing ticket IDs
-                    existingTicketIds = existingReservation.ticketIds || [];
+                    // Get existing ticket IDs; without a hold ID they cannot be purchased, so start over
+                    existingHoldId = existingReservation.holdId;
+                    existingTicketIds = existingHoldId ? existingReservation.ticketIds || [] : [];
                   } else {
                     // Different event - user should clear existing reservation first
                     alert("You already have tickets reserved for a different event. Please complete or cancel that reservation first.");
@@ -154,11 +156,12 @@ export default function EventDetailPage() {
               const ticketsToReserve = newTicketIds.filter((id) => !existingTicketIds.includes(id));
 
               // If all tickets are already reserved, just update localStorage and go to checkout
-              if (ticketsToReserve.length === 0) {
+              if (ticketsToReserve.length === 0 && existingHoldId) {
                 // All tickets already reserved, just update the reservation data
                 const reservationData: ReservationData = {
                   ticketIds: [...new Set([...existingTicketIds, ...newTicketIds])],
                   eventId: eventId,
+                  holdId: existingHoldId,
                   reservedAt: Date.now(),
                 };
                 localStorage.setItem("tix_reservation", JSON.stringify(reservationData));
@@ -167,8 +170,8 @@ export default function EventDetailPage() {
                 return;
               }
 
-              // Reserve only the new tickets
-              const response = await reserveTickets(ticketsToReserve);
+              // Reserve only the new tickets, adding them to the existing hold if there is one
+              const response = await reserveTickets(ticketsToReserve, existingHoldId);
 
               if (response.success) {
                 // Merge existing and newly reserved tickets
@@ -178,6 +181,7 @@ export default function EventDetailPage() {
                 const reservationData: ReservationData = {
                   ticketIds: allReservedTicketIds,
                   eventId: eventId,
+                  holdId: response.hold_id,
                   reservedAt: Date.now(),
                 };
                 localStorage.setItem("tix_reservation", JSON.stringify(reservationData));
@@ -28,7 +28,7 @@ export default function Home() {
     setShowAnimation(false);
 
     try {
-      const events = await searchEvents(searchQuery);
+      const { results: events } = await searchEvents(searchQuery);
       
       // Map SearchEventResult to UI SearchResult format
       const mappedResults: SearchResult[] = events.map((event: SearchEventResult) => ({
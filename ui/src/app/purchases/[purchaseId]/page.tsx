@@ -129,6 +129,10 @@ export default function PurchaseSuccessPage() {
                 <span className="text-muted-foreground">Purchase ID:</span>
                 <span className="font-mono text-sm">{purchase.purchase_id}</span>
               </div>
+              <div className="flex justify-between">
+                <span className="text-muted-foreground">Status:</span>
+                <span className="font-medium capitalize">{purchase.status.replace("_", " ")}</span>
+              </div>
               <div className="flex justify-between">
                 <span className="text-muted-foreground">Purchase Date:</span>
                 <span className="font-medium">
@@ -168,6 +172,36 @@ export default function PurchaseSuccessPage() {
                 ))}
               </div>
             </div>
+
+            {purchase.refunds && purchase.refunds.length > 0 && (
+              <div className="border-t pt-4">
+                <h3 className="font-semibold mb-4">
+                  Refunds ({formatPrice(purchase.refunded_cents)})
+                </h3>
+                <div className="space-y-3">
+                  {purchase.refunds.map((refund) => (
+                    <div
+                      key={refund.id}
+                      className="flex items-center justify-between p-3 rounded-lg bg-muted/50"
+                    >
+                      <div className="flex-1">
+                        <p className="font-medium text-sm">
+                          {refund.ticket_ids.length} {refund.ticket_ids.length === 1 ? "ticket" : "tickets"}
+                          {refund.reason ? ` · ${refund.reason}` : ""}
+                          {refund.status !== "succeeded" ? ` · ${refund.status}` : ""}
+                        </p>
+                        <p className="text-xs text-muted-foreground mt-1">
+                          {new Date(refund.created_at).toLocaleString()}
+                        </p>
+                      </div>
+                      <p className="font-semibold text-sm">
+                        -{formatPrice(refund.amount_cents)}
+                      </p>
+                    </div>
+                  ))}
+                </div>
+              </div>
+            )}
           </CardContent>
         </Card>
 
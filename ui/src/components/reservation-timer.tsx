@@ -4,18 +4,44 @@ import { useEffect, useState } from "react";
 import { useRouter, usePathname } from "next/navigation";
 import { Button } from "@/components/ui/button";
 import { Clock } from "lucide-react";
-import type { ReservationData } from "@/types/booking";
+import type { ReservationData, HoldResponse } from "@/types/booking";
+import { getHold, extendHold } from "@/lib/api/booking";
+import { ApiException } from "@/types/api";
 
 const RESERVATION_TTL_SECONDS = parseInt(
   process.env.NEXT_PUBLIC_RESERVATION_TTL_SECONDS || "180",
   10
 );
 
+// How often the countdown is re-synced with the booking service
+const HOLD_SYNC_INTERVAL_MS = 15000;
+
+/**
+ * Rewrite the stored reservation so the local countdown matches the server's hold TTL
+ */
+function applyHold(hold: HoldResponse) {
+  const reservationStr = localStorage.getItem("tix_reservation");
+  if (!reservationStr) return;
+
+  const reservation: ReservationData = JSON.parse(reservationStr);
+  if (reservation.holdId !== hold.hold_id) return;
+
+  const heldIds = new Set(hold.tickets.map((t) => t.ticket_id));
+  const updated: ReservationData = {
+    ...reservation,
+    ticketIds: reservation.ticketIds.filter((id) => heldIds.has(id)),
+    reservedAt: Date.now() - (RESERVATION_TTL_SECONDS - hold.expires_in_seconds) * 1000,
+  };
+  localStorage.setItem("tix_reservation", JSON.stringify(updated));
+}
+
 export function ReservationTimer() {
   const router = useRouter();
   const pathname = usePathname();
   const [remainingSeconds, setRemainingSeconds] = useState<number | null>(null);
   const [ticketIds, setTicketIds] = useState<string[]>([]);
+  const [extensionsLeft, setExtensionsLeft] = useState(0);
+  const [isExtending, setIsExtending] = useState(false);
   
   const isOnCheckoutPage = pathname?.startsWith("/checkout");
 
@@ -64,6 +90,50 @@ export function ReservationTimer() {
     return () => clearInterval(interval);
   }, []);
 
+  useEffect(() => {
+    // Periodically pull the real hold TTL from the server
+    const syncHold = async () => {
+      const reservationStr = localStorage.getItem("tix_reservation");
+      if (!reservationStr) return;
+
+      try {
+        const reservation: ReservationData = JSON.parse(reservationStr);
+        if (!reservation.holdId) return;
+
+        const hold = await getHold(reservation.holdId);
+        applyHold(hold);
+        setExtensionsLeft(hold.max_extensions - hold.extensions);
+      } catch (err) {
+        if (err instanceof ApiException && err.status === 404) {
+          // Hold expired or was released server-side
+          localStorage.removeItem("tix_reservation");
+        }
+      }
+    };
+
+    syncHold();
+    const interval = setInterval(syncHold, HOLD_SYNC_INTERVAL_MS);
+
+    return () => clearInterval(interval);
+  }, []);
+
+  const handleExtend = async () => {
+    const reservationStr = localStorage.getItem("tix_reservation");
+    if (!reservationStr) return;
+
+    setIsExtending(true);
+    try {
+      const reservation: ReservationData = JSON.parse(reservationStr);
+      const hold = await extendHold(reservation.holdId);
+      applyHold(hold);
+      setExtensionsLeft(hold.max_extensions - hold.extensions);
+    } catch (err) {
+      alert(err instanceof Error ? err.message : "Failed to extend reservation");
+    } finally {
+      setIsExtending(false);
+    }
+  };
+
   const handleGoToCheckout = () => {
     if (ticketIds.length > 0 && !isOnCheckoutPage) {
       router.push("/checkout");
@@ -102,6 +172,17 @@ export function ReservationTimer() {
             </div>
           </div>
 
+          {isUrgent && extensionsLeft > 0 && (
+            <Button
+              variant="outline"
+              onClick={handleExtend}
+              disabled={isExtending}
+              className="w-full rounded-lg font-medium"
+            >
+              {isExtending ? "Extending..." : "Need more time?"}
+            </Button>
+          )}
+
           {/* Button */}
           <Button 
             onClick={handleGoToCheckout} 
@@ -3,7 +3,7 @@
 import { useMemo } from "react";
 import type { Ticket } from "@/types/events";
 import type { SeatViewProps, TicketTypeSection } from "./seat-view/types";
-import { TYPE_COLORS, SECTION_ORDER } from "./seat-view/constants";
+import { SECTION_COLORS } from "./seat-view/constants";
 import { SectionSeats } from "./seat-view/section-seats";
 
 export function SeatView({ tickets, selectedTicketIds, onSeatSelect }: SeatViewProps) {
@@ -18,44 +18,36 @@ export function SeatView({ tickets, selectedTicketIds, onSeatSelect }: SeatViewP
       return acc;
     }, {} as Record<string, Ticket[]>);
 
-    // Sort tickets by ID within each type for consistent ordering
+    // Sort seated tickets by seat and the rest by ID for consistent ordering
     Object.keys(grouped).forEach((typeId) => {
-      grouped[typeId].sort((a, b) => a.id.localeCompare(b.id));
+      grouped[typeId].sort((a, b) => {
+        if (a.seat && b.seat) {
+          return (
+            a.seat.section.localeCompare(b.seat.section) ||
+            a.seat.row.localeCompare(b.seat.row, undefined, { numeric: true }) ||
+            a.seat.number - b.seat.number
+          );
+        }
+        return a.id.localeCompare(b.id);
+      });
     });
 
     return grouped;
   }, [tickets]);
 
-  // Create sections with color mapping
+  // Create sections ordered by price, most expensive first, with colors
+  // assigned in that order
   const sections: TicketTypeSection[] = useMemo(() => {
-    const sectionsWithSortKey = Object.entries(ticketsByType).map(([typeId, tickets]) => {
-      const firstTicket = tickets[0];
-      const key = firstTicket.ticket_type_name as "vip" | "front_row" | "ga";
+    const entries = Object.entries(ticketsByType).sort(
+      ([, a], [, b]) => b[0].ticket_type_price_cents - a[0].ticket_type_price_cents
+    );
 
-      const colorConfig = TYPE_COLORS[key] ?? TYPE_COLORS.ga;
-      const displayName = firstTicket.ticket_type_display_name;
-
-      return {
-        section: {
-          typeId,
-          typeName: displayName,
-          tickets,
-          colorConfig,
-        },
-        sortKey: key,
-      };
-    });
-
-    // Sort sections so that VIP comes first, then Front Row, then GA.
-    sectionsWithSortKey.sort((a, b) => {
-      const aIndex = SECTION_ORDER.indexOf(a.sortKey);
-      const bIndex = SECTION_ORDER.indexOf(b.sortKey);
-      const safeA = aIndex === -1 ? SECTION_ORDER.length : aIndex;
-      const safeB = bIndex === -1 ? SECTION_ORDER.length : bIndex;
-      return safeA - safeB;
-    });
-
-    return sectionsWithSortKey.map((item) => item.section);
+    return entries.map(([typeId, tickets], index) => ({
+      typeId,
+      typeName: tickets[0].ticket_type_display_name || tickets[0].ticket_type_name,
+      tickets,
+      colorConfig: SECTION_COLORS[index % SECTION_COLORS.length],
+    }));
   }, [ticketsByType]);
 
   return (
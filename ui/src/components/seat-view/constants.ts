@@ -1,24 +1,31 @@
 import type { ColorConfig } from "./types";
 
-// Color scheme for each ticket type section.
-// We assume only these three ticket types exist for now: VIP, Front Row, GA.
-export const TYPE_COLORS: Record<string, ColorConfig> = {
-  vip: {
+// Colors assigned to ticket type sections in order, most expensive first.
+// Events with more ticket types than colors reuse the palette.
+export const SECTION_COLORS: ColorConfig[] = [
+  {
     color: "text-purple-700",
     bgColor: "bg-purple-100",
     borderColor: "border-purple-300",
   },
-  front_row: {
+  {
     color: "text-blue-700",
     bgColor: "bg-blue-100",
     borderColor: "border-blue-300",
   },
-  ga: {
+  {
     color: "text-green-700",
     bgColor: "bg-green-100",
     borderColor: "border-green-300",
   },
-};
-
-export const SECTION_ORDER: Array<"vip" | "front_row" | "ga"> = ["vip", "front_row", "ga"];
-
+  {
+    color: "text-amber-700",
+    bgColor: "bg-amber-100",
+    borderColor: "border-amber-300",
+  },
+  {
+    color: "text-rose-700",
+    bgColor: "bg-rose-100",
+    borderColor: "border-rose-300",
+  },
+];
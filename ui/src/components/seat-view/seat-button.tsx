@@ -8,6 +8,10 @@ export function SeatButton({ ticket, isAvailable, isSelected, colorConfig, onCli
   const buttonRef = useRef<HTMLButtonElement>(null);
   const rippleIdRef = useRef(0);
 
+  const seatName = ticket.seat
+    ? `${ticket.seat.section}, Row ${ticket.seat.row}, Seat ${ticket.seat.number}${ticket.seat.accessible ? " (accessible)" : ""}`
+    : `Seat ${ticket.id.slice(0, 8)}`;
+
   const handleClick = (e: React.MouseEvent<HTMLButtonElement>) => {
     if (!isAvailable || !buttonRef.current) return;
 
@@ -50,13 +54,13 @@ export function SeatButton({ ticket, isAvailable, isSelected, colorConfig, onCli
       `}
       title={
         isAvailable 
-          ? `Seat ${ticket.id.slice(0, 8)} - Available` 
+          ? `${seatName} - Available` 
           : ticket.is_reserved 
-            ? `Seat ${ticket.id.slice(0, 8)} - Reserved` 
-            : `Seat ${ticket.id.slice(0, 8)} - Sold`
+            ? `${seatName} - Reserved` 
+            : `${seatName} - Sold`
       }
     >
-      {ticket.id.slice(0, 4)}
+      {ticket.seat ? `${ticket.seat.row}${ticket.seat.number}` : ticket.id.slice(0, 4)}
       
       {/* Ripple effects */}
       {ripples.map((ripple) => (
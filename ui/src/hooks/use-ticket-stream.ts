@@ -1,6 +1,6 @@
 import { useEffect, useState, useRef } from 'react';
 import { createTicketStream } from '@/lib/api/events';
-import type { Ticket } from '@/types/events';
+import type { Ticket, TicketStreamMessage } from '@/types/events';
 
 interface UseTicketStreamResult {
   tickets: Ticket[];
@@ -54,11 +54,12 @@ export function useTicketStream(eventId: string | null): UseTicketStreamResult {
           }
         };
 
-        eventSource.onmessage = (event) => {
+        // The stream starts with a snapshot of every ticket, then sends
+        // deltas with only the tickets that changed
+        const handleMessage = (apply: (message: TicketStreamMessage) => void) => (event: MessageEvent) => {
           if (isMounted) {
             try {
-              const data = JSON.parse(event.data) as Ticket[];
-              setTickets(data);
+              apply(JSON.parse(event.data) as TicketStreamMessage);
               setError(null);
             } catch (err) {
               console.error('Error parsing SSE data:', err);
@@ -67,6 +68,14 @@ export function useTicketStream(eventId: string | null): UseTicketStreamResult {
           }
         };
 
+        eventSource.addEventListener('snapshot', handleMessage((message) => {
+          setTickets(message.tickets);
+        }));
+
+        eventSource.addEventListener('delta', handleMessage((message) => {
+          setTickets((current) => applyTicketDelta(current, message));
+        }));
+
         eventSource.onerror = (err) => {
           if (isMounted) {
             setConnected(false);
@@ -121,3 +130,19 @@ export function useTicketStream(eventId: string | null): UseTicketStreamResult {
   return { tickets, connected, error };
 }
 
+/**
+ * Merge a delta into the current tickets, keeping their order
+ */
+function applyTicketDelta(tickets: Ticket[], delta: TicketStreamMessage): Ticket[] {
+  const changed = new Map(delta.tickets.map((ticket) => [ticket.id, ticket]));
+  const removed = new Set(delta.removed ?? []);
+
+  const merged = tickets
+    .filter((ticket) => !removed.has(ticket.id))
+    .map((ticket) => {
+      const update = changed.get(ticket.id);
+      changed.delete(ticket.id);
+      return update ?? ticket;
+    });
+  return [...merged, ...changed.values()];
+}
@@ -3,18 +3,20 @@
  */
 
 import { ApiException } from '@/types/api';
-import type { ReserveResponse, PurchaseResponse, PurchaseDetailsResponse } from '@/types/booking';
+import { authHeaders } from './client';
+import { admissionHeaders } from './queue';
+import type { ReserveResponse, PurchaseResponse, PurchaseDetailsResponse, HoldResponse, Refund } from '@/types/booking';
 
 const BASE_URL = process.env.NEXT_PUBLIC_CORE_API_URL || 'http://localhost:8080/api/v1';
 
-import type { ReserveRequest, PurchaseRequest } from '@/types/booking';
+import type { ReserveRequest, BestAvailableRequest, PurchaseRequest, RefundRequest } from '@/types/booking';
 
 /**
  * Reserve tickets (supports single or multiple)
  * Note: The booking service returns response body even on error status codes (409, 410, 404)
  * so we need to parse the response body even when status is not ok
  */
-export async function reserveTickets(ticketIds: string[]): Promise<ReserveResponse> {
+export async function reserveTickets(ticketIds: string[], holdId?: string): Promise<ReserveResponse> {
   const url = `${BASE_URL}/booking/reserve`;
   
   try {
@@ -22,8 +24,10 @@ export async function reserveTickets(ticketIds: string[]): Promise<ReserveRespon
       method: 'POST',
       headers: {
         'Content-Type': 'application/json',
+        ...authHeaders(),
+        ...admissionHeaders(),
       },
-      body: JSON.stringify({ ticket_ids: ticketIds } as ReserveRequest),
+      body: JSON.stringify({ ticket_ids: ticketIds, hold_id: holdId } as ReserveRequest),
     });
 
     const data = await response.json().catch(() => null);
@@ -54,37 +58,29 @@ export async function reserveTickets(ticketIds: string[]): Promise<ReserveRespon
 }
 
 /**
- * Reserve a single ticket (backward compatibility)
+ * Reserve the best available tickets of a ticket type; the server picks the
+ * tickets, preferring adjacent seats
  */
-export async function reserveTicket(ticketId: string): Promise<ReserveResponse> {
-  return reserveTickets([ticketId]);
-}
+export async function reserveBestAvailable(request: BestAvailableRequest): Promise<ReserveResponse> {
+  const url = `${BASE_URL}/booking/reserve/best-available`;
 
-/**
- * Purchase tickets (supports single or multiple)
- * Note: The booking service returns response body even on error status codes
- */
-export async function purchaseTickets(ticketIds: string[]): Promise<PurchaseResponse> {
-  const url = `${BASE_URL}/booking/purchase`;
-  
   try {
     const response = await fetch(url, {
       method: 'POST',
       headers: {
         'Content-Type': 'application/json',
+        ...authHeaders(),
+        ...admissionHeaders(),
       },
-      body: JSON.stringify({ ticket_ids: ticketIds } as PurchaseRequest),
+      body: JSON.stringify(request),
     });
 
     const data = await response.json().catch(() => null);
 
-    // Booking service returns response body even on error status codes
-    // Check if response has success field (booking response format)
     if (data && typeof data.success === 'boolean') {
-      return data as PurchaseResponse;
+      return data as ReserveResponse;
     }
 
-    // If not a booking response format, throw error
     if (!response.ok) {
       throw new ApiException(
         data?.error || data?.message || `Request failed with status ${response.status}`,
@@ -92,7 +88,76 @@ export async function purchaseTickets(ticketIds: string[]): Promise<PurchaseResp
       );
     }
 
-    return data as PurchaseResponse;
+    return data as ReserveResponse;
+  } catch (error) {
+    if (error instanceof ApiException) {
+      throw error;
+    }
+    throw new ApiException(
+      error instanceof Error ? error.message : 'An unexpected error occurred'
+    );
+  }
+}
+
+/**
+ * Reserve a single ticket (backward compatibility)
+ */
+export async function reserveTicket(ticketId: string): Promise<ReserveResponse> {
+  return reserveTickets([ticketId]);
+}
+
+// How many times a purchase is resent while the server is still processing
+// an earlier request with the same key
+const MAX_IN_PROGRESS_RETRIES = 10;
+
+/**
+ * Purchase tickets (supports single or multiple)
+ * Note: The booking service returns response body even on error status codes
+ *
+ * idempotencyKey must stay the same for every attempt of one checkout, so a
+ * double click or a resend after a timeout can never charge twice. While an
+ * earlier request with the key is still being processed the server answers
+ * 409 with Retry-After, and the purchase is resent after waiting.
+ */
+export async function purchaseTickets(ticketIds: string[], holdId: string, paymentToken: string, idempotencyKey: string, customerId?: string): Promise<PurchaseResponse> {
+  const url = `${BASE_URL}/booking/purchase`;
+  
+  try {
+    for (let attempt = 0; ; attempt++) {
+      const response = await fetch(url, {
+        method: 'POST',
+        headers: {
+          'Content-Type': 'application/json',
+          'Idempotency-Key': idempotencyKey,
+          ...authHeaders(),
+        },
+        body: JSON.stringify({ ticket_ids: ticketIds, hold_id: holdId, payment_token: paymentToken, customer_id: customerId } as PurchaseRequest),
+      });
+
+      const retryAfter = parseInt(response.headers.get('Retry-After') || '', 10);
+      if (response.status === 409 && retryAfter > 0 && attempt < MAX_IN_PROGRESS_RETRIES) {
+        await new Promise((resolve) => setTimeout(resolve, retryAfter * 1000));
+        continue;
+      }
+
+      const data = await response.json().catch(() => null);
+
+      // Booking service returns response body even on error status codes
+      // Check if response has success field (booking response format)
+      if (data && typeof data.success === 'boolean') {
+        return data as PurchaseResponse;
+      }
+
+      // If not a booking response format, throw error
+      if (!response.ok) {
+        throw new ApiException(
+          data?.error || data?.message || `Request failed with status ${response.status}`,
+          response.status
+        );
+      }
+
+      return data as PurchaseResponse;
+    }
   } catch (error) {
     if (error instanceof ApiException) {
       throw error;
@@ -106,8 +171,8 @@ export async function purchaseTickets(ticketIds: string[]): Promise<PurchaseResp
 /**
  * Purchase a single ticket (backward compatibility)
  */
-export async function purchaseTicket(ticketId: string): Promise<PurchaseResponse> {
-  return purchaseTickets([ticketId]);
+export async function purchaseTicket(ticketId: string, holdId: string, paymentToken: string, idempotencyKey: string): Promise<PurchaseResponse> {
+  return purchaseTickets([ticketId], holdId, paymentToken, idempotencyKey);
 }
 
 /**
@@ -121,6 +186,7 @@ export async function getPurchaseDetails(purchaseId: string): Promise<PurchaseDe
       method: 'GET',
       headers: {
         'Content-Type': 'application/json',
+        ...authHeaders(),
       },
     });
 
@@ -143,3 +209,93 @@ export async function getPurchaseDetails(purchaseId: string): Promise<PurchaseDe
   }
 }
 
+/**
+ * Refund a purchase, in full or for the given tickets only
+ */
+export async function refundPurchase(purchaseId: string, request: RefundRequest = {}): Promise<Refund> {
+  const url = `${BASE_URL}/booking/purchases/${purchaseId}/refund`;
+
+  try {
+    const response = await fetch(url, {
+      method: 'POST',
+      headers: {
+        'Content-Type': 'application/json',
+        ...authHeaders(),
+        ...admissionHeaders(),
+      },
+      body: JSON.stringify(request),
+    });
+
+    if (!response.ok) {
+      const data = await response.json().catch(() => null);
+      throw new ApiException(
+        data?.error || data?.message || `Request failed with status ${response.status}`,
+        response.status
+      );
+    }
+
+    return await response.json() as Refund;
+  } catch (error) {
+    if (error instanceof ApiException) {
+      throw error;
+    }
+    throw new ApiException(
+      error instanceof Error ? error.message : 'An unexpected error occurred'
+    );
+  }
+}
+
+/**
+ * Send a hold request and throw on any non-2xx status
+ */
+async function holdRequest(url: string, method: string): Promise<Response> {
+  try {
+    const response = await fetch(url, {
+      method,
+      headers: {
+        'Content-Type': 'application/json',
+        ...authHeaders(),
+      },
+    });
+
+    if (!response.ok) {
+      const data = await response.json().catch(() => null);
+      throw new ApiException(
+        data?.error || data?.message || `Request failed with status ${response.status}`,
+        response.status
+      );
+    }
+
+    return response;
+  } catch (error) {
+    if (error instanceof ApiException) {
+      throw error;
+    }
+    throw new ApiException(
+      error instanceof Error ? error.message : 'An unexpected error occurred'
+    );
+  }
+}
+
+/**
+ * Get the tickets still held under a hold ID and their remaining TTL
+ */
+export async function getHold(holdId: string): Promise<HoldResponse> {
+  const response = await holdRequest(`${BASE_URL}/booking/holds/${holdId}`, 'GET');
+  return await response.json() as HoldResponse;
+}
+
+/**
+ * Reset a hold to the full reservation TTL (limited number of times)
+ */
+export async function extendHold(holdId: string): Promise<HoldResponse> {
+  const response = await holdRequest(`${BASE_URL}/booking/holds/${holdId}/extend`, 'POST');
+  return await response.json() as HoldResponse;
+}
+
+/**
+ * Release all tickets in a hold
+ */
+export async function releaseHold(holdId: string): Promise<void> {
+  await holdRequest(`${BASE_URL}/booking/holds/${holdId}`, 'DELETE');
+}
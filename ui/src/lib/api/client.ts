@@ -6,6 +6,19 @@ import { ApiException } from '@/types/api';
 
 const BASE_URL = process.env.NEXT_PUBLIC_CORE_API_URL || 'http://localhost:8080/api/v1';
 
+const AUTH_TOKEN_KEY = 'tix_token';
+
+/**
+ * Authorization header for the signed-in user's token, if there is one
+ */
+export function authHeaders(): Record<string, string> {
+  if (typeof window === 'undefined') {
+    return {};
+  }
+  const token = window.localStorage.getItem(AUTH_TOKEN_KEY);
+  return token ? { Authorization: `Bearer ${token}` } : {};
+}
+
 interface RequestConfig extends RequestInit {
   params?: Record<string, string | number>;
 }
@@ -31,6 +44,7 @@ export async function request<T>(path: string, config?: RequestConfig): Promise<
       ...fetchOptions,
       headers: {
         'Content-Type': 'application/json',
+        ...authHeaders(),
         ...(fetchOptions.headers || {}),
       },
     });
@@ -0,0 +1,30 @@
+/**
+ * Customer API endpoints
+ */
+
+import { request } from './client';
+import type { Customer, CreateCustomerRequest, CustomerPurchases } from '@/types/customers';
+
+export async function createCustomer(customer: CreateCustomerRequest): Promise<Customer> {
+  return request<Customer>('/customers', {
+    method: 'POST',
+    body: JSON.stringify(customer),
+  });
+}
+
+export async function getCustomer(id: string): Promise<Customer> {
+  return request<Customer>(`/customers/${id}`);
+}
+
+/**
+ * Get a page of a customer's purchases, newest first
+ */
+export async function getCustomerPurchases(
+  customerId: string,
+  limit: number = 20,
+  offset: number = 0
+): Promise<CustomerPurchases> {
+  return request<CustomerPurchases>(`/customers/${customerId}/purchases`, {
+    params: { limit, offset },
+  });
+}
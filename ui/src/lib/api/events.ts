@@ -1,27 +1,78 @@
 import { request } from './client';
-import type { Event, SearchEventResult, Ticket } from '@/types/events';
+import type { Event, EventAvailability, EventSuggestions, SearchEventResults, SearchFilters, SearchInclude, Ticket, TicketType } from '@/types/events';
 
 export async function searchEvents(
   query?: string,
   limit: number = 20,
-  offset: number = 0
-): Promise<SearchEventResult[]> {
+  offset: number = 0,
+  include: SearchInclude[] = [],
+  filters: SearchFilters = {}
+): Promise<SearchEventResults> {
   const params: Record<string, string | number> = {
     limit,
-    offset,
   };
 
   if (query) {
     params.q = query;
   }
+  if (include.length) {
+    params.include = include.join(',');
+  }
+  if (filters.start_from) {
+    params.start_from = filters.start_from;
+  }
+  if (filters.start_to) {
+    params.start_to = filters.start_to;
+  }
+  if (filters.venue_ids?.length) {
+    params.venue_id = filters.venue_ids.join(',');
+  }
+  if (filters.location) {
+    params.location = filters.location;
+  }
+  if (filters.ticket_types?.length) {
+    params.ticket_type = filters.ticket_types.join(',');
+  }
+  if (filters.categories?.length) {
+    params.category = filters.categories.join(',');
+  }
+  if (filters.min_price_cents !== undefined) {
+    params.min_price_cents = filters.min_price_cents;
+  }
+  if (filters.max_price_cents !== undefined) {
+    params.max_price_cents = filters.max_price_cents;
+  }
+  if (filters.sort) {
+    params.sort = filters.sort;
+  }
+  if (filters.cursor) {
+    params.cursor = filters.cursor;
+  } else {
+    params.offset = offset;
+  }
 
-  return request<SearchEventResult[]>('/events', { params });
+  return request<SearchEventResults>('/events', { params });
+}
+
+/**
+ * Get completions of a search box prefix, grouped into event titles, venue
+ * names and performers
+ */
+export async function suggestEvents(query: string, limit: number = 5): Promise<EventSuggestions> {
+  return request<EventSuggestions>('/events/suggest', { params: { q: query, limit } });
 }
 
 export async function getEvent(id: string): Promise<Event> {
   return request<Event>(`/events/${id}`);
 }
 
+/**
+ * Get how many of an event's tickets are available, held and sold
+ */
+export async function getEventAvailability(eventId: string): Promise<EventAvailability> {
+  return request<EventAvailability>(`/events/${eventId}/availability`);
+}
+
 /**
  * Get tickets for an event
  */
@@ -36,6 +87,13 @@ export async function getTicket(eventId: string, ticketId: string): Promise<Tick
   return request<Ticket>(`/events/${eventId}/tickets/${ticketId}`);
 }
 
+/**
+ * Get the ticket types defined for an event
+ */
+export async function getEventTicketTypes(eventId: string): Promise<TicketType[]> {
+  return request<TicketType[]>(`/events/${eventId}/ticket-types`);
+}
+
 /**
  * Create an EventSource for streaming ticket updates via SSE
  */
@@ -1,8 +1,14 @@
 
 export { request } from './client';
-export { searchEvents, getEvent, getEventTickets, getTicket } from './events';
-export { reserveTicket, reserveTickets, purchaseTicket, purchaseTickets, getPurchaseDetails } from './booking';
+export { searchEvents, suggestEvents, getEvent, getEventAvailability, getEventTickets, getEventTicketTypes, getTicket } from './events';
+export { getVenue, getVenueSeatMap } from './venues';
+export { joinQueue, getQueueStatus } from './queue';
+export { createCustomer, getCustomer, getCustomerPurchases } from './customers';
+export { reserveTicket, reserveTickets, reserveBestAvailable, purchaseTicket, purchaseTickets, getPurchaseDetails, refundPurchase, getHold, extendHold, releaseHold } from './booking';
 
 export type { ApiException, ApiError, RequestOptions } from '@/types/api';
-export type { Event, SearchEventResult, SearchResult, Ticket, TicketType, TicketWithType, TicketStatus } from '@/types/events';
+export type { Customer, CreateCustomerRequest, CustomerPurchase, CustomerPurchases } from '@/types/customers';
+export type { Event, EventAvailability, EventSuggestion, EventSuggestions, FacetBucket, SearchEventResult, SearchEventResults, SearchFacets, SearchFilters, SearchInclude, SearchResult, SearchSort, Ticket, TicketSeat, TicketStreamMessage, TicketType, TicketTypeAvailability, TicketWithType, TicketStatus } from '@/types/events';
+export type { Venue, SeatMap, SeatMapSection, SeatMapRow, Seat } from '@/types/venues';
+export type { QueueStatus, QueueStatusResponse } from '@/types/queue';
 
@@ -0,0 +1,56 @@
+/**
+ * Waiting room endpoints. Admission tokens are kept in session storage and
+ * sent with every reserve call.
+ */
+
+import { request } from './client';
+import type { QueueStatusResponse } from '@/types/queue';
+
+const ADMISSION_TOKEN_KEY = 'tix_admission_token';
+const QUEUE_TOKEN_KEY = 'tix_queue_token';
+
+/**
+ * Join an event's waiting room. Events without one report the open status.
+ * Joining again with the remembered queue token keeps the visitor's place.
+ */
+export async function joinQueue(eventId: string): Promise<QueueStatusResponse> {
+  const queueToken =
+    typeof window !== 'undefined' ? window.sessionStorage.getItem(`${QUEUE_TOKEN_KEY}:${eventId}`) : null;
+  const status = await request<QueueStatusResponse>(`/booking/queue/${eventId}/join`, {
+    method: 'POST',
+    headers: queueToken ? { 'X-Queue-Token': queueToken } : undefined,
+  });
+  if (typeof window !== 'undefined' && status.queue_token) {
+    window.sessionStorage.setItem(`${QUEUE_TOKEN_KEY}:${eventId}`, status.queue_token);
+  }
+  rememberAdmission(status);
+  return status;
+}
+
+/**
+ * Poll the position in an event's waiting room with the token from joinQueue
+ */
+export async function getQueueStatus(eventId: string, queueToken: string): Promise<QueueStatusResponse> {
+  const status = await request<QueueStatusResponse>(`/booking/queue/${eventId}/status`, {
+    headers: { 'X-Queue-Token': queueToken },
+  });
+  rememberAdmission(status);
+  return status;
+}
+
+/**
+ * Admission token header for reserve calls, if the visitor was admitted
+ */
+export function admissionHeaders(): Record<string, string> {
+  if (typeof window === 'undefined') {
+    return {};
+  }
+  const token = window.sessionStorage.getItem(ADMISSION_TOKEN_KEY);
+  return token ? { 'X-Admission-Token': token } : {};
+}
+
+function rememberAdmission(status: QueueStatusResponse) {
+  if (typeof window !== 'undefined' && status.admission_token) {
+    window.sessionStorage.setItem(ADMISSION_TOKEN_KEY, status.admission_token);
+  }
+}
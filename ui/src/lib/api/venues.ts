@@ -0,0 +1,13 @@
+import { request } from './client';
+import type { SeatMap, Venue } from '@/types/venues';
+
+export async function getVenue(id: string): Promise<Venue> {
+  return request<Venue>(`/venues/${id}`);
+}
+
+/**
+ * Get a venue's seat map; venues without seating have no sections
+ */
+export async function getVenueSeatMap(id: string): Promise<SeatMap> {
+  return request<SeatMap>(`/venues/${id}/seat-map`);
+}
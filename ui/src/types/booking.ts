@@ -1,22 +1,49 @@
 // ui/src/types/booking.ts
 export interface ReserveRequest {
   ticket_ids: string[];
+  hold_id?: string; // add tickets to an existing hold
+}
+
+export interface BestAvailableRequest {
+  event_id: string;
+  ticket_type_id: string;
+  quantity: number;
+  hold_id?: string; // Optional: add tickets to an existing hold
 }
 
 export interface ReserveResponse {
   success: boolean;
   message: string;
   ticket_ids: string[];
+  hold_id: string;
 }
 
 export interface ReservationData {
   ticketIds: string[];
   eventId: string;
+  holdId: string; // owner token required to purchase the held tickets
   reservedAt: number; // timestamp in milliseconds
+  checkoutKey?: string; // Idempotency-Key reused by every purchase attempt of this reservation
+}
+
+export interface HeldTicket {
+  ticket_id: string;
+  expires_in_seconds: number;
+}
+
+export interface HoldResponse {
+  hold_id: string;
+  tickets: HeldTicket[];
+  expires_in_seconds: number; // time until the first ticket in the hold expires
+  extensions: number;
+  max_extensions: number;
 }
 
 export interface PurchaseRequest {
   ticket_ids: string[];
+  hold_id: string;
+  payment_token: string;
+  customer_id?: string;
 }
 
 export interface PurchaseResponse {
@@ -25,6 +52,7 @@ export interface PurchaseResponse {
   ticket_ids: string[];
   total: number;
   purchase_id: string;
+  unavailable_ticket_ids?: string[];
 }
 
 export interface PurchaseTicketDetail {
@@ -37,9 +65,55 @@ export interface PurchaseTicketDetail {
   ticket_type_price_cents: number;
 }
 
+export type PurchaseStatus =
+  | "pending_payment"
+  | "paid"
+  | "failed"
+  | "refunded"
+  | "partially_refunded"
+  | "cancelled";
+
 export interface PurchaseDetailsResponse {
   purchase_id: string;
   total_cents: number;
+  status: PurchaseStatus;
+  customer_id?: string;
   purchase_created_at: string;
   tickets: PurchaseTicketDetail[];
+  refunds: Refund[];
+  refunded_cents: number;
+  status_history: StatusTransition[];
+  payment_attempts: PaymentAttempt[];
+}
+
+export interface StatusTransition {
+  from?: PurchaseStatus;
+  to: PurchaseStatus;
+  reason?: string;
+  at: string;
+}
+
+export interface PaymentAttempt {
+  id: string;
+  status: "pending" | "authorized" | "captured" | "voided" | "failed";
+  amount_cents: number;
+  failure_reason?: string;
+  created_at: string;
+  updated_at: string;
+}
+
+export interface RefundRequest {
+  ticket_ids?: string[];
+  return_to_inventory?: boolean;
+  reason?: string;
+}
+
+export interface Refund {
+  id: string;
+  ticket_ids: string[];
+  amount_cents: number;
+  returned_to_inventory: boolean;
+  reason?: string;
+  status: "pending" | "succeeded" | "failed";
+  created_at: string;
 }
\ No newline at end of file
@@ -0,0 +1,32 @@
+/**
+ * Customer types
+ */
+
+import type { PurchaseStatus } from './booking';
+
+export interface Customer {
+  id: string;
+  email: string;
+  name: string;
+  created_at: string;
+}
+
+export interface CreateCustomerRequest {
+  email: string;
+  name: string;
+}
+
+export interface CustomerPurchase {
+  id: string;
+  total_cents: number;
+  status: PurchaseStatus;
+  ticket_count: number;
+  created_at: string;
+}
+
+export interface CustomerPurchases {
+  purchases: CustomerPurchase[];
+  total: number;
+  limit: number;
+  offset: number;
+}
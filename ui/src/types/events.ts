@@ -9,6 +9,9 @@ export interface Event {
   description: string;
   start_date: string; // ISO date string
   venue_id: string;
+  organizer_id?: string;
+  category?: string;
+  performers?: string[];
   created_at: string; // ISO date string
 }
 
@@ -21,14 +24,75 @@ export interface SearchEventResult {
   venue_name: string;
   venue_location: string;
   created_at: string;
+  category?: string;
+  performers?: string[];
+  min_price_cents?: number; // Unset for events without ticket types
+  max_price_cents?: number;
+  ticket_types: string[];
+  availability?: EventAvailability; // Only when searched with include=availability
 }
 
 
+export type SearchSort = 'date' | 'relevance' | 'price';
+
+export interface SearchFilters {
+  start_from?: string; // 2025-06-01 or an RFC 3339 timestamp
+  start_to?: string;
+  venue_ids?: string[];
+  location?: string;
+  ticket_types?: string[];
+  categories?: string[];
+  min_price_cents?: number;
+  max_price_cents?: number;
+  sort?: SearchSort;
+  cursor?: string; // next_cursor of the previous page; replaces offset
+}
+
+/** Optional extras of a search */
+export type SearchInclude = 'availability' | 'facets';
+
+export interface FacetBucket {
+  value: string;
+  label?: string; // Venue name for venues
+  count: number;
+  from_cents?: number; // Price ranges only
+  to_cents?: number; // Unset on the open-ended price range
+}
+
+/**
+ * Matching events per value of each filter; each facet ignores its own
+ * filter so its other values can still be picked
+ */
+export interface SearchFacets {
+  venues: FacetBucket[];
+  locations: FacetBucket[];
+  months: FacetBucket[]; // yyyy-MM
+  price_ranges: FacetBucket[];
+  categories: FacetBucket[];
+}
+
 export interface SearchEventResults {
   results: SearchEventResult[];
   total: number;
+  next_offset?: number; // Unset on the last page
+  next_cursor?: string;
+  facets?: SearchFacets; // Only when searched with include=facets
+}
+
+
+/** A completion of a search prefix; titles link to their event, venues to their venue */
+export interface EventSuggestion {
+  text: string;
+  event_id?: string;
+  venue_id?: string;
+  start_date?: string;
 }
 
+export interface EventSuggestions {
+  titles: EventSuggestion[];
+  venues: EventSuggestion[];
+  performers: EventSuggestion[];
+}
 
 export interface SearchResult {
   id: string;
@@ -38,7 +102,7 @@ export interface SearchResult {
 }
 
 
-export type TicketStatus = "available" | "sold";
+export type TicketStatus = "available" | "sold" | "refunded";
 
 export interface Ticket {
   id: string;
@@ -48,14 +112,56 @@ export interface Ticket {
   ticket_type_name: string;
   ticket_type_display_name: string;
   ticket_type_price_cents: number;
+  seat?: TicketSeat; // Unset for general admission tickets
   is_reserved?: boolean;
 }
 
+export interface TicketSeat {
+  id: string;
+  section: string;
+  row: string;
+  number: number;
+  accessible?: boolean;
+}
+
+/**
+ * A message of the ticket stream: a snapshot carries every ticket of the
+ * event, a delta only the tickets that changed
+ */
+export interface TicketStreamMessage {
+  event_id: string;
+  tickets: Ticket[];
+  removed?: string[];
+}
+
 export interface TicketType {
   id: string;
+  event_id: string;
+  name: string;
+  display_name: string;
+  price_cents: number;
+  quantity: number;
+  max_per_order?: number; // Unset when there is no per-order limit
+}
+
+export interface TicketTypeAvailability {
+  ticket_type_id: string;
   name: string;
   display_name: string;
   price_cents: number;
+  available: number;
+  held: number;
+  sold: number;
+}
+
+export interface EventAvailability {
+  event_id: string;
+  available: number;
+  held: number;
+  sold: number;
+  min_price_cents?: number; // Unset when the event is sold out
+  max_price_cents?: number;
+  ticket_types: TicketTypeAvailability[];
 }
 
 export interface TicketWithType extends Ticket {
@@ -0,0 +1,15 @@
+/**
+ * Waiting room types matching backend API responses
+ */
+
+export type QueueStatus = 'open' | 'waiting' | 'admitted';
+
+export interface QueueStatusResponse {
+  event_id: string;
+  status: QueueStatus; // open when the event has no waiting room
+  queue_token?: string; // send as X-Queue-Token to poll the status
+  position?: number; // 1-based, while waiting
+  estimated_wait_seconds?: number;
+  admission_token?: string; // once admitted; sent with reserve calls
+  admission_expires_at?: string; // ISO timestamp
+}
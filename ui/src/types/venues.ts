@@ -0,0 +1,38 @@
+/**
+ * Venue types matching backend API responses
+ */
+
+export interface Venue {
+  id: string;
+  name: string;
+  location: string;
+}
+
+/**
+ * Seat map of a venue: sections in display order, rows front to back.
+ * Coordinates are in the seat map's own units.
+ */
+export interface SeatMap {
+  venue_id: string;
+  sections: SeatMapSection[];
+}
+
+export interface SeatMapSection {
+  id: string;
+  name: string;
+  rows: SeatMapRow[];
+}
+
+export interface SeatMapRow {
+  label: string;
+  seats: Seat[];
+}
+
+export interface Seat {
+  id: string;
+  number: number;
+  x: number;
+  y: number;
+  accessible: boolean;
+  companion: boolean;
+}